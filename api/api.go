@@ -0,0 +1,8 @@
+package api
+
+import (
+	_ "embed"
+)
+
+//go:embed gophermart.yml
+var Spec []byte
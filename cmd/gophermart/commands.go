@@ -0,0 +1,209 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/Stern-Ritter/gophermart/internal/app"
+	"github.com/Stern-Ritter/gophermart/internal/config"
+	"github.com/Stern-Ritter/gophermart/internal/logger"
+	"github.com/Stern-Ritter/gophermart/internal/model"
+)
+
+const usage = `usage: gophermart [command] [flags]
+
+commands:
+  serve                          run the servers and the accruals scheduler (default)
+  migrate up|down|status|redo    manage the database schema
+  user create <login> [role]     create a user with role USER or ADMIN, the password is read from stdin
+  user disable <login>           prevent the user from signing in
+  order requeue <number>         fetch the accrual of the order from the accrual system again
+  balance recompute <login>      compute and print the balance of the user
+  accrual sync-now               process all pending accruals without waiting for the scheduler
+  audit verify                   verify the audit trail, exits with an error if it is broken
+
+all commands accept the server flags, run "gophermart -h" to list them`
+
+var errAuditChainBroken = errors.New("audit chain is broken")
+
+type command struct {
+	name string
+	args []string
+}
+
+// parseCommand splits args into the command with its positional arguments and the flags, which start at the
+// first argument beginning with a dash.
+func parseCommand(args []string) (command, []string, error) {
+	positional := args
+	for i, arg := range args {
+		if strings.HasPrefix(arg, "-") {
+			positional = args[:i]
+			break
+		}
+	}
+	flags := args[len(positional):]
+
+	if len(positional) == 0 {
+		return command{name: "serve"}, flags, nil
+	}
+
+	name := positional[0]
+	rest := positional[1:]
+	if len(rest) > 0 && name != "serve" {
+		name = name + " " + rest[0]
+		rest = rest[1:]
+	}
+
+	var minArgs, maxArgs int
+	switch name {
+	case "serve", "migrate up", "migrate down", "migrate status", "migrate redo", "accrual sync-now", "audit verify":
+	case "user create":
+		minArgs, maxArgs = 1, 2
+	case "user disable", "order requeue", "balance recompute":
+		minArgs, maxArgs = 1, 1
+	default:
+		return command{}, nil, fmt.Errorf("unknown command %q\n\n%s", strings.Join(positional, " "), usage)
+	}
+	if len(rest) < minArgs || len(rest) > maxArgs {
+		return command{}, nil, fmt.Errorf("command %q expects %d to %d arguments, got %d\n\n%s",
+			name, minArgs, maxArgs, len(rest), usage)
+	}
+
+	return command{name: name, args: rest}, flags, nil
+}
+
+func run(args []string, stdin io.Reader, stdout io.Writer) error {
+	cmd, flags, err := parseCommand(args)
+	if err != nil {
+		return err
+	}
+
+	// The admin commands print their results to stdout, so only errors are logged unless configured otherwise.
+	defaultLoggerLvl := "error"
+	if cmd.name == "serve" {
+		defaultLoggerLvl = "debug"
+	}
+	loadConfig := func() (config.ServerConfig, error) {
+		return app.GetConfig(config.ServerConfig{
+			LoggerLvl: defaultLoggerLvl,
+		}, flags)
+	}
+
+	cfg, err := loadConfig()
+	if errors.Is(err, flag.ErrHelp) {
+		fmt.Fprintln(stdout, usage)
+		return err
+	}
+	if errors.Is(err, app.ErrUnexpectedArgument) {
+		return fmt.Errorf("%w\n\n%s", err, usage)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid config:\n%w", err)
+	}
+
+	if cfg.PrintConfig {
+		return app.PrintConfig(stdout, cfg)
+	}
+
+	l, err := logger.Initialize(cfg.LoggerLvl)
+	if err != nil {
+		return err
+	}
+
+	switch cmd.name {
+	case "serve":
+		err = app.Run(&cfg, loadConfig, l)
+		if err != nil {
+			l.Fatal("Error starting server", zap.String("event", "start server"), zap.Error(err))
+		}
+		return err
+	case "migrate up", "migrate down", "migrate status", "migrate redo":
+		return app.MigrateDatabase(&cfg, strings.TrimPrefix(cmd.name, "migrate "))
+	case "user create":
+		return createUser(&cfg, cmd.args, stdin, stdout, l)
+	case "user disable":
+		if err = app.DisableUser(&cfg, cmd.args[0], l); err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "User %s disabled\n", cmd.args[0])
+	case "order requeue":
+		if err = app.RequeueOrder(&cfg, cmd.args[0], l); err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "Order %s requeued\n", cmd.args[0])
+	case "balance recompute":
+		balance, err := app.RecomputeBalance(&cfg, cmd.args[0], l)
+		if err != nil {
+			return err
+		}
+		return writeJSON(stdout, model.ToBalanceDto(balance))
+	case "accrual sync-now":
+		synced, err := app.SyncAccrualsNow(&cfg, l)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "Synced %d accruals\n", synced)
+	case "audit verify":
+		verification, err := app.VerifyAuditChain(&cfg, l)
+		if err != nil {
+			return err
+		}
+		if err = writeJSON(stdout, verification); err != nil {
+			return err
+		}
+		if !verification.Valid {
+			return errAuditChainBroken
+		}
+	}
+
+	return nil
+}
+
+func createUser(cfg *config.ServerConfig, args []string, stdin io.Reader, stdout io.Writer,
+	logger *logger.ServerLogger) error {
+	role := model.UserRoleUser
+	if len(args) > 1 {
+		role = model.UserRole(strings.ToUpper(args[1]))
+	}
+	if role != model.UserRoleUser && role != model.UserRoleAdmin {
+		return fmt.Errorf("unknown role %q, should be USER or ADMIN", args[1])
+	}
+
+	password, err := readPassword(stdin)
+	if err != nil {
+		return err
+	}
+
+	err = app.CreateUser(cfg, model.SignUpRequest{Login: args[0], Password: password}, role, logger)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "User %s created with role %s\n", args[0], role)
+	return nil
+}
+
+// readPassword reads the first line of stdin, so that the password doesn't end up in the shell history.
+func readPassword(stdin io.Reader) (string, error) {
+	line, err := bufio.NewReader(stdin).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", fmt.Errorf("failed to read password from stdin: %w", err)
+	}
+	password := strings.TrimRight(line, "\r\n")
+	if password == "" {
+		return "", errors.New("password should be passed on stdin")
+	}
+	return password, nil
+}
+
+func writeJSON(w io.Writer, v interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCommand(t *testing.T) {
+	tests := []struct {
+		name                  string
+		args                  []string
+		expectedCommand       command
+		expectedFlags         []string
+		expectedErrorContains string
+	}{
+		{
+			name:            "should run server when there are no arguments",
+			expectedCommand: command{name: "serve"},
+			expectedFlags:   []string{},
+		},
+		{
+			name:            "should run server when there are only flags",
+			args:            []string{"-a", ":8081", "-d", "postgres://localhost/gophermart"},
+			expectedCommand: command{name: "serve"},
+			expectedFlags:   []string{"-a", ":8081", "-d", "postgres://localhost/gophermart"},
+		},
+		{
+			name:            "should split migrate command and flags",
+			args:            []string{"migrate", "status", "-d", "postgres://localhost/gophermart"},
+			expectedCommand: command{name: "migrate status", args: []string{}},
+			expectedFlags:   []string{"-d", "postgres://localhost/gophermart"},
+		},
+		{
+			name:            "should parse user create command with role",
+			args:            []string{"user", "create", "admin", "ADMIN", "-config", "gophermart.yaml"},
+			expectedCommand: command{name: "user create", args: []string{"admin", "ADMIN"}},
+			expectedFlags:   []string{"-config", "gophermart.yaml"},
+		},
+		{
+			name:            "should parse order requeue command",
+			args:            []string{"order", "requeue", "12345678903"},
+			expectedCommand: command{name: "order requeue", args: []string{"12345678903"}},
+			expectedFlags:   []string{},
+		},
+		{
+			name:                  "should return error when command is unknown",
+			args:                  []string{"user", "delete", "admin"},
+			expectedErrorContains: `unknown command "user delete admin"`,
+		},
+		{
+			name:                  "should return error when migrate direction is missing",
+			args:                  []string{"migrate", "-d", "postgres://localhost/gophermart"},
+			expectedErrorContains: `unknown command "migrate"`,
+		},
+		{
+			name:                  "should return error when argument is missing",
+			args:                  []string{"balance", "recompute"},
+			expectedErrorContains: `command "balance recompute" expects 1 to 1 arguments, got 0`,
+		},
+		{
+			name:                  "should return error when there are extra arguments",
+			args:                  []string{"accrual", "sync-now", "now"},
+			expectedErrorContains: `command "accrual sync-now" expects 0 to 0 arguments, got 1`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, flags, err := parseCommand(tt.args)
+
+			if tt.expectedErrorContains != "" {
+				require.Error(t, err, "parseCommand should return error")
+				assert.Contains(t, err.Error(), tt.expectedErrorContains, "Error does not match expected error")
+				return
+			}
+			require.NoError(t, err, "Error parsing command")
+			assert.Equal(t, tt.expectedCommand.name, cmd.name, "Command does not match expected command")
+			assert.ElementsMatch(t, tt.expectedCommand.args, cmd.args, "Command args do not match expected args")
+			assert.ElementsMatch(t, tt.expectedFlags, flags, "Flags do not match expected flags")
+		})
+	}
+}
+
+func TestRunUserCreate(t *testing.T) {
+	tests := []struct {
+		name                  string
+		args                  []string
+		stdin                 string
+		expectedErrorContains string
+	}{
+		{
+			name:                  "should return error when role is unknown",
+			args:                  []string{"user", "create", "operator", "ROOT", "-d", "postgres://localhost/gophermart"},
+			stdin:                 "Pa55word!\n",
+			expectedErrorContains: `unknown role "ROOT", should be USER or ADMIN`,
+		},
+		{
+			name:                  "should return error when password is not passed on stdin",
+			args:                  []string{"user", "create", "operator", "-d", "postgres://localhost/gophermart"},
+			expectedErrorContains: "password should be passed on stdin",
+		},
+		{
+			name:                  "should return error when password violates password policy",
+			args:                  []string{"user", "create", "operator", "-d", "postgres://localhost/gophermart"},
+			stdin:                 "password\n",
+			expectedErrorContains: "Password length should be between 8 and 256 characters",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := bytes.Buffer{}
+			err := run(tt.args, strings.NewReader(tt.stdin), &out)
+
+			require.Error(t, err, "run should return error")
+			assert.Contains(t, err.Error(), tt.expectedErrorContains, "Error does not match expected error")
+			assert.Empty(t, out.String(), "Nothing should be printed when user is not created")
+		})
+	}
+}
+
+func TestRunRejectsCommandAfterFlags(t *testing.T) {
+	out := bytes.Buffer{}
+	err := run([]string{"-d", "postgres://localhost/gophermart", "migrate", "up"}, strings.NewReader(""), &out)
+
+	require.Error(t, err, "run should return error")
+	assert.Contains(t, err.Error(), `unexpected argument "migrate", the command should precede the flags`,
+		"Error does not match expected error")
+	assert.Contains(t, err.Error(), usage, "Error should contain usage")
+}
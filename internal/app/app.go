@@ -2,122 +2,330 @@ package app
 
 import (
 	"context"
+	"fmt"
+	"net"
 	"net/http"
+	"time"
 
 	"github.com/go-chi/chi"
-	"github.com/jackc/pgx/v5/pgxpool"
 	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
 
+	"github.com/Stern-Ritter/gophermart/api"
+	"github.com/Stern-Ritter/gophermart/internal/audit"
 	"github.com/Stern-Ritter/gophermart/internal/auth"
 	"github.com/Stern-Ritter/gophermart/internal/compress"
 	"github.com/Stern-Ritter/gophermart/internal/config"
 	"github.com/Stern-Ritter/gophermart/internal/logger"
+	"github.com/Stern-Ritter/gophermart/internal/metrics"
+	"github.com/Stern-Ritter/gophermart/internal/model"
+	"github.com/Stern-Ritter/gophermart/internal/openapi"
+	"github.com/Stern-Ritter/gophermart/internal/rpc"
 	"github.com/Stern-Ritter/gophermart/internal/scheduler"
 	"github.com/Stern-Ritter/gophermart/internal/server"
 	"github.com/Stern-Ritter/gophermart/internal/service"
-	"github.com/Stern-Ritter/gophermart/internal/storage"
+	"github.com/Stern-Ritter/gophermart/internal/tracing"
 	"github.com/Stern-Ritter/gophermart/internal/validator"
 	"github.com/Stern-Ritter/gophermart/migrations"
 
 	_ "github.com/jackc/pgx/v5/stdlib"
 )
 
-func Run(config *config.ServerConfig, logger *logger.ServerLogger) error {
+// Run starts the servers and the accruals scheduler. On SIGHUP the config is loaded again with reloadConfig and
+// the settings that are safe to change at runtime are applied.
+func Run(config *config.ServerConfig, reloadConfig func() (config.ServerConfig, error), logger *logger.ServerLogger) error {
 	ctx := context.Background()
-	db, err := pgxpool.New(ctx, config.DatabaseURL)
+	shutdownTracing, err := tracing.Init(ctx, config.TracingConfig)
 	if err != nil {
-		logger.Fatal("Failed to connect to database", zap.String("event", "connect database"),
-			zap.String("database url", config.DatabaseURL), zap.Error(err))
+		logger.Fatal("Failed to init tracing", zap.String("event", "init tracing"), zap.Error(err))
 		return err
 	}
-	defer db.Close()
+	defer shutdownTracing(context.Background())
 
-	err = migrations.Migrate(config.DatabaseURL, "postgres", "pgx")
+	storages, closeStorages, err := openStorages(ctx, config, logger)
 	if err != nil {
-		logger.Fatal("Failed to migrate database", zap.String("event", "migrate database"),
-			zap.String("database url", config.DatabaseURL), zap.Error(err))
+		return err
 	}
+	defer closeStorages()
 
 	validate, err := validator.GetValidator()
 	if err != nil {
 		logger.Fatal("Failed to init validator", zap.String("event", "init validator"), zap.Error(err))
 	}
+	passwordPolicy, err := getPasswordPolicy(config.PasswordConfig)
+	if err != nil {
+		logger.Fatal("Failed to load password policy", zap.String("event", "load password policy"),
+			zap.String("breached passwords file", config.PasswordConfig.BreachedListFile), zap.Error(err))
+	}
+	err = validator.RegisterPasswordPolicy(validate, passwordPolicy)
+	if err != nil {
+		logger.Fatal("Failed to init validator", zap.String("event", "init validator"), zap.Error(err))
+	}
+	deprecationPolicy, err := getDeprecationPolicy(config.ApiVersionConfig)
+	if err != nil {
+		logger.Fatal("Failed to load api v1 deprecation policy", zap.String("event", "load deprecation policy"),
+			zap.Error(err))
+	}
+	specValidator, err := openapi.NewValidator(api.Spec)
+	if err != nil {
+		logger.Fatal("Failed to load openapi spec", zap.String("event", "load openapi spec"), zap.Error(err))
+	}
 	authToken := auth.GenerateAuthToken(config.JwtSecretKey)
+	passwordHasher := auth.NewPasswordHasher(auth.NewArgon2idHasher(getArgon2idParams(config.PasswordConfig)),
+		auth.NewBcryptHasher(bcrypt.DefaultCost))
+
+	userService := service.NewUserService(storages.users, logger)
+	totpService := service.NewTotpService(storages.totps, logger)
+	auditService := service.NewAuditService(storages.auditEvents, logger)
+	authService := service.NewAuthService(userService, totpService, auditService, passwordHasher, authToken, logger)
+	accrualService := service.NewAccrualService(storages.accruals, logger)
+	withdrawnService := service.NewWithdrawnService(storages.withdrawals, auditService, logger)
+	balanceService := service.NewBalanceService(storages.balances, logger)
+	adjustmentService := service.NewAdjustmentService(storages.adjustments, logger)
+	apiKeyService := service.NewApiKeyService(storages.apiKeys, userService, logger)
+	accrualEventService := service.NewAccrualEventService(storages.accrualEvents, storages.accrualEventListener, logger)
 
-	userStorage := storage.NewUserStorage(db, logger)
-	accrualStorage := storage.NewAccrualStorage(db, logger)
-	withdrawnStorage := storage.NewWithdrawnStorage(db, logger)
-	balanceStorage := storage.NewBalanceStorage(db, logger)
+	accrualsScheduler := newAccrualsScheduler(config, accrualService, logger)
+
+	migrationVersion, err := migrations.LatestVersion(storages.dialect)
+	if err != nil {
+		return fmt.Errorf("failed to get latest migration version: %w", err)
+	}
+	readinessAccrualSystemURL := ""
+	if config.ReadinessConfig.CheckAccrualSystem {
+		readinessAccrualSystemURL = config.AccrualSystemURL
+	}
+	healthService := service.NewHealthService(storages.health, accrualsScheduler,
+		config.ReadinessConfig.SchedulerHeartbeatMaxAge, migrationVersion,
+		readinessAccrualSystemURL, logger)
 
-	userService := service.NewUserService(userStorage, logger)
-	authService := service.NewAuthService(userService, authToken, logger)
-	accrualService := service.NewAccrualService(accrualStorage, logger)
-	withdrawnService := service.NewWithdrawnService(withdrawnStorage, logger)
-	balanceService := service.NewBalanceService(balanceStorage, logger)
+	// Serving with an outdated schema fails on the first query that uses a new column, so the server doesn't start.
+	err = healthService.CheckSchema(ctx)
+	if err != nil {
+		return fmt.Errorf("%w, run \"gophermart migrate up\" or start with -migrate-on-start", err)
+	}
 
-	accrualsScheduler := scheduler.NewAccrualsScheduler(accrualService, config.AccrualSystemURL,
-		config.ProcessAccrualsConfig.ProcessAccrualsBatchMaxSize, config.ProcessAccrualsConfig.ProcessAccrualsBufferSize,
-		config.ProcessAccrualsConfig.ProcessAccrualsWorkerPoolSize, config.ProcessAccrualsConfig.GetNewAccrualsInterval, logger)
 	accrualsScheduler.RunTasks()
 	defer accrualsScheduler.StopTasks()
 
+	reloadCtx, stopReload := context.WithCancel(ctx)
+	defer stopReload()
+	go watchReload(reloadCtx, *config, reloadConfig, accrualsScheduler, logger)
+
+	listenCtx, stopListen := context.WithCancel(ctx)
+	defer stopListen()
+	go accrualEventService.Listen(listenCtx)
+
 	server := server.NewServer(
 		authService,
 		userService,
 		accrualService,
 		withdrawnService,
 		balanceService,
+		totpService,
+		adjustmentService,
+		apiKeyService,
+		accrualEventService,
+		healthService,
+		auditService,
 		validate,
 		authToken,
 		config,
 		logger,
 	)
 
-	r := addRoutes(server)
+	r := addRoutes(server, specValidator, deprecationPolicy)
 
-	err = http.ListenAndServe(server.Config.URL, r)
-	if err != nil {
-		logger.Fatal("Failed to start server", zap.String("event", "start server"),
-			zap.String("url", server.Config.URL), zap.Error(err), zap.Error(err))
+	serveErrCh := make(chan error, 3)
+
+	if config.GrpcURL != "" {
+		rpcServer := rpc.NewServer(authService, userService, accrualService, withdrawnService, balanceService, validate,
+			authToken, logger)
+		grpcServer := rpcServer.NewGrpcServer()
+		defer grpcServer.GracefulStop()
+
+		listener, err := net.Listen("tcp", config.GrpcURL)
+		if err != nil {
+			return fmt.Errorf("failed to listen grpc address %s: %w", config.GrpcURL, err)
+		}
+		go func() {
+			if err := grpcServer.Serve(listener); err != nil {
+				serveErrCh <- fmt.Errorf("failed to serve grpc: %w", err)
+			}
+		}()
+	}
+
+	if config.MetricsURL != "" {
+		go func() {
+			if err := http.ListenAndServe(config.MetricsURL, metricsRoutes()); err != nil {
+				serveErrCh <- fmt.Errorf("failed to start metrics server on %s: %w", config.MetricsURL, err)
+			}
+		}()
+	}
+
+	go func() {
+		if err := http.ListenAndServe(server.Config.URL, r); err != nil {
+			serveErrCh <- fmt.Errorf("failed to start server on %s: %w", server.Config.URL, err)
+		}
+	}()
+
+	return <-serveErrCh
+}
+
+func newAccrualsScheduler(config *config.ServerConfig, accrualService service.AccrualService,
+	logger *logger.ServerLogger) scheduler.Scheduler {
+	return scheduler.NewAccrualsScheduler(accrualService, config.AccrualSystemURL,
+		config.ProcessAccrualsConfig.ProcessAccrualsBufferSize, config.ProcessAccrualsConfig.ProcessAccrualsBatchMaxSize,
+		config.ProcessAccrualsConfig.ProcessAccrualsWorkerPoolSize, config.ProcessAccrualsConfig.GetNewAccrualsInterval,
+		logger)
+}
+
+func getPasswordPolicy(c config.PasswordConfig) (validator.PasswordPolicy, error) {
+	var breachedPasswords []string
+	if c.BreachedListFile != "" {
+		passwords, err := validator.LoadBreachedPasswords(c.BreachedListFile)
+		if err != nil {
+			return validator.PasswordPolicy{}, err
+		}
+		breachedPasswords = passwords
+	}
+
+	return validator.NewPasswordPolicy(c.MinCharClasses, breachedPasswords), nil
+}
+
+func getDeprecationPolicy(c config.ApiVersionConfig) (server.DeprecationPolicy, error) {
+	policy := server.DeprecationPolicy{}
+	if c.V1DeprecatedAt != "" {
+		deprecatedAt, err := time.Parse(time.RFC3339, c.V1DeprecatedAt)
+		if err != nil {
+			return policy, fmt.Errorf("api v1 deprecation date should be in RFC3339 format: %w", err)
+		}
+		policy.DeprecatedAt = deprecatedAt
+	}
+	if c.V1SunsetAt != "" {
+		sunsetAt, err := time.Parse(time.RFC3339, c.V1SunsetAt)
+		if err != nil {
+			return policy, fmt.Errorf("api v1 sunset date should be in RFC3339 format: %w", err)
+		}
+		policy.SunsetAt = sunsetAt
+	}
+	if !policy.DeprecatedAt.IsZero() && !policy.SunsetAt.IsZero() && policy.SunsetAt.Before(policy.DeprecatedAt) {
+		return policy, fmt.Errorf("api v1 sunset date should not be earlier than deprecation date")
 	}
 
-	return err
+	return policy, nil
+}
+
+func getArgon2idParams(c config.PasswordConfig) auth.Argon2idParams {
+	params := auth.DefaultArgon2idParams
+	params.Memory = uint32(c.Argon2Memory)
+	params.Iterations = uint32(c.Argon2Iterations)
+	params.Parallelism = uint8(c.Argon2Parallelism)
+	return params
 }
 
-func addRoutes(s *server.Server) *chi.Mux {
+func addRoutes(s *server.Server, specValidator *openapi.Validator, deprecationPolicy server.DeprecationPolicy) *chi.Mux {
 	r := chi.NewRouter()
+	r.Use(tracing.Middleware)
+	r.Use(logger.RequestIDMiddleware)
+	r.Use(audit.Middleware)
+	r.Use(metrics.Middleware)
 	r.Use(s.Logger.LoggerMiddleware)
 	r.Use(compress.GzipMiddleware)
 
-	r.Get("/healthcheck", s.HealthcheckHandler)
+	r.Get("/livez", s.LivenessHandler)
+	r.Get("/readyz", s.ReadinessHandler)
+	r.Get("/healthcheck", s.LivenessHandler)
 
 	r.Route("/api", func(r chi.Router) {
-		r.Route("/user", func(r chi.Router) {
-			r.Group(func(r chi.Router) {
-				r.Post("/register", s.SignUpHandler)
-				r.Post("/login", s.SignInHandler)
-			})
+		r.Use(specValidator.Middleware)
 
-			r.Group(func(r chi.Router) {
-				r.Use(auth.Verifier(s.AuthToken))
-				r.Use(auth.Authenticator(s.AuthToken))
+		r.Group(func(r chi.Router) {
+			r.Use(server.WithAPIVersion(server.APIVersion1))
+			r.Use(server.Deprecated(deprecationPolicy, "/api", "/api/v2"))
 
-				r.Route("/orders", func(r chi.Router) {
-					r.Post("/", s.LoadOrderHandler)
-					r.Get("/", s.FindAllOrdersLoadedByUserHandler)
-				})
+			r.Route("/user", addUserRoutes(s))
+		})
 
-				r.Route("/balance", func(r chi.Router) {
-					r.Get("/", s.GetLoyaltyPointsBalanceHandler)
-					r.Post("/withdraw", s.WithdrawLoyaltyPointsHandler)
-				})
+		r.Route("/v2", func(r chi.Router) {
+			r.Use(server.WithAPIVersion(server.APIVersion2))
 
-				r.Route("/withdrawals", func(r chi.Router) {
-					r.Get("/", s.FindAllWithdrawalsByUserHandler)
-				})
-			})
+			r.Route("/user", addUserRoutes(s))
+		})
+
+		r.Route("/admin", func(r chi.Router) {
+			r.Use(auth.Verifier(s.AuthToken, s.ApiKeyService))
+			r.Use(auth.Authenticator(s.AuthToken))
+			r.Use(auth.SessionAuthorizer)
+			r.Use(s.RoleAuthorizer(model.UserRoleAdmin))
+			r.Use(s.AuditAdminActions)
+
+			r.Get("/users", s.SearchUsersHandler)
+			r.Get("/users/{userID}/balance", s.GetUserBalanceHandler)
+			r.Get("/users/{userID}/adjustments", s.FindAllAdjustmentsByUserHandler)
+			r.Post("/users/{userID}/adjustments", s.CreateAdjustmentHandler)
+			r.Get("/orders/{number}", s.GetOrderByNumberHandler)
+			r.Get("/audit-events", s.FindAllAuditEventsHandler)
+			r.Get("/audit-events/verify", s.VerifyAuditChainHandler)
 		})
 	})
 
 	return r
 }
+
+// metricsRoutes serves metrics on a separate listener, so that they are not exposed with the public API.
+func metricsRoutes() *chi.Mux {
+	r := chi.NewRouter()
+	r.Handle("/metrics", metrics.Handler())
+	return r
+}
+
+func addUserRoutes(s *server.Server) func(r chi.Router) {
+	return func(r chi.Router) {
+		r.Group(func(r chi.Router) {
+			r.Post("/register", s.SignUpHandler)
+			r.Post("/login", s.SignInHandler)
+			r.Post("/login/2fa", s.SignInSecondFactorHandler)
+		})
+
+		r.Group(func(r chi.Router) {
+			r.Use(auth.Verifier(s.AuthToken, s.ApiKeyService))
+			r.Use(auth.Authenticator(s.AuthToken))
+
+			r.Route("/orders", func(r chi.Router) {
+				r.With(auth.ScopeAuthorizer(model.ApiKeyScopeOrdersWrite)).Post("/", s.LoadOrderHandler)
+				r.With(auth.ScopeAuthorizer(model.ApiKeyScopeOrdersWrite)).Post("/batch", s.LoadOrdersBatchHandler)
+				r.With(auth.ScopeAuthorizer(model.ApiKeyScopeOrdersRead)).Get("/", s.FindAllOrdersLoadedByUserHandler)
+				r.With(auth.ScopeAuthorizer(model.ApiKeyScopeOrdersRead)).Get("/stream", s.StreamOrdersHandler)
+				r.With(auth.ScopeAuthorizer(model.ApiKeyScopeOrdersRead)).Get("/{number}", s.GetOrderLoadedByUserHandler)
+			})
+
+			r.Route("/balance", func(r chi.Router) {
+				r.With(auth.ScopeAuthorizer(model.ApiKeyScopeBalanceRead)).Get("/", s.GetLoyaltyPointsBalanceHandler)
+				r.With(auth.ScopeAuthorizer(model.ApiKeyScopeBalanceWrite)).Post("/withdraw", s.WithdrawLoyaltyPointsHandler)
+			})
+
+			r.Route("/withdrawals", func(r chi.Router) {
+				r.With(auth.ScopeAuthorizer(model.ApiKeyScopeBalanceRead)).Get("/", s.FindAllWithdrawalsByUserHandler)
+			})
+
+			r.With(auth.SessionAuthorizer).Get("/export", s.ExportUserHistoryHandler)
+
+			r.Route("/2fa", func(r chi.Router) {
+				r.Use(auth.SessionAuthorizer)
+
+				r.Post("/enroll", s.EnrollTotpHandler)
+				r.Post("/confirm", s.ConfirmTotpHandler)
+				r.Post("/disable", s.DisableTotpHandler)
+			})
+
+			r.Route("/api-keys", func(r chi.Router) {
+				r.Use(auth.SessionAuthorizer)
+
+				r.Post("/", s.CreateApiKeyHandler)
+				r.Get("/", s.FindAllApiKeysByUserHandler)
+				r.Delete("/{keyID}", s.RevokeApiKeyHandler)
+			})
+		})
+	}
+}
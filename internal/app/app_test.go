@@ -0,0 +1,83 @@
+package app
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Stern-Ritter/gophermart/api"
+	"github.com/Stern-Ritter/gophermart/internal/auth"
+	"github.com/Stern-Ritter/gophermart/internal/config"
+	"github.com/Stern-Ritter/gophermart/internal/logger"
+	"github.com/Stern-Ritter/gophermart/internal/openapi"
+	"github.com/Stern-Ritter/gophermart/internal/server"
+)
+
+func TestRoutesAreDescribedInSpec(t *testing.T) {
+	l, err := logger.Initialize("error")
+	require.NoError(t, err, "Error init logger")
+	specValidator, err := openapi.NewValidator(api.Spec)
+	require.NoError(t, err, "Error loading openapi spec")
+
+	s := server.NewServer(nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, auth.GenerateAuthToken("secret"),
+		&config.ServerConfig{}, l)
+	r := addRoutes(s, specValidator, server.DeprecationPolicy{})
+
+	routes := 0
+	err = chi.Walk(r, func(method string, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		if !strings.HasPrefix(route, openapi.BasePath+"/") {
+			return nil
+		}
+
+		routes++
+		path := strings.TrimSuffix(route, "/")
+		assert.True(t, specValidator.HasOperation(method, path), "Route %s %s is not described in openapi spec", method, route)
+		return nil
+	})
+	require.NoError(t, err, "Error walking routes")
+	assert.NotZero(t, routes, "Router should have api routes")
+}
+
+func TestMetricsAreServedOnlyOnMetricsRoutes(t *testing.T) {
+	l, err := logger.Initialize("error")
+	require.NoError(t, err, "Error init logger")
+	specValidator, err := openapi.NewValidator(api.Spec)
+	require.NoError(t, err, "Error loading openapi spec")
+
+	s := server.NewServer(nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, auth.GenerateAuthToken("secret"),
+		&config.ServerConfig{}, l)
+
+	tests := []struct {
+		name               string
+		handler            http.Handler
+		expectedStatusCode int
+	}{
+		{
+			name:               "should not serve metrics with public api",
+			handler:            addRoutes(s, specValidator, server.DeprecationPolicy{}),
+			expectedStatusCode: http.StatusNotFound,
+		},
+		{
+			name:               "should serve metrics on metrics address",
+			handler:            metricsRoutes(),
+			expectedStatusCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			tt.handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+			resp := w.Result()
+			defer resp.Body.Close()
+
+			assert.Equal(t, tt.expectedStatusCode, resp.StatusCode, "Response status code does not match expected status")
+		})
+	}
+}
@@ -0,0 +1,22 @@
+package app
+
+import (
+	"context"
+
+	"github.com/Stern-Ritter/gophermart/internal/config"
+	"github.com/Stern-Ritter/gophermart/internal/logger"
+	"github.com/Stern-Ritter/gophermart/internal/model"
+	"github.com/Stern-Ritter/gophermart/internal/service"
+)
+
+// VerifyAuditChain checks the integrity of the audit trail stored in the configured database.
+func VerifyAuditChain(config *config.ServerConfig, logger *logger.ServerLogger) (model.AuditChainVerification, error) {
+	var verification model.AuditChainVerification
+	err := withDatabase(config, logger, func(ctx context.Context, s storages) error {
+		var err error
+		verification, err = service.NewAuditService(s.auditEvents, logger).VerifyChain(ctx)
+		return err
+	})
+
+	return verification, err
+}
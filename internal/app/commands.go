@@ -0,0 +1,159 @@
+package app
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/Stern-Ritter/gophermart/internal/audit"
+	"github.com/Stern-Ritter/gophermart/internal/auth"
+	"github.com/Stern-Ritter/gophermart/internal/config"
+	er "github.com/Stern-Ritter/gophermart/internal/errors"
+	"github.com/Stern-Ritter/gophermart/internal/logger"
+	"github.com/Stern-Ritter/gophermart/internal/model"
+	"github.com/Stern-Ritter/gophermart/internal/service"
+	"github.com/Stern-Ritter/gophermart/internal/storage/sqlite"
+	"github.com/Stern-Ritter/gophermart/internal/utils"
+	"github.com/Stern-Ritter/gophermart/internal/validator"
+	"github.com/Stern-Ritter/gophermart/migrations"
+)
+
+// cliClient marks audit events recorded by the admin commands.
+var cliClient = audit.Client{UserAgent: "gophermart-cli"}
+
+// MigrateDatabase runs the goose command, one of up, down, status or redo, against the configured database.
+func MigrateDatabase(config *config.ServerConfig, command string) error {
+	if isMemoryDatabase(config.DatabaseURL) {
+		return errMemoryDatabase
+	}
+	if isSQLiteDatabase(config.DatabaseURL) {
+		dsn, err := sqlite.DataSourceName(config.DatabaseURL)
+		if err != nil {
+			return err
+		}
+		return migrations.Run(dsn, migrations.DialectSQLite, sqlite.DriverName, command)
+	}
+	return migrations.Run(config.DatabaseURL, migrations.DialectPostgres, "pgx", command)
+}
+
+// CreateUser creates a user with the role, the password is checked against the configured password policy.
+func CreateUser(config *config.ServerConfig, request model.SignUpRequest, role model.UserRole,
+	logger *logger.ServerLogger) error {
+	validate, err := validator.GetValidator()
+	if err != nil {
+		return err
+	}
+	passwordPolicy, err := getPasswordPolicy(config.PasswordConfig)
+	if err != nil {
+		return err
+	}
+	if err = validator.RegisterPasswordPolicy(validate, passwordPolicy); err != nil {
+		return err
+	}
+	if err = request.Validate(validate); err != nil {
+		return err
+	}
+
+	return withDatabase(config, logger, func(ctx context.Context, s storages) error {
+		return newAuthService(config, s, logger).CreateUser(ctx, request, role)
+	})
+}
+
+// DisableUser prevents the user from signing in and using api keys.
+func DisableUser(config *config.ServerConfig, login string, logger *logger.ServerLogger) error {
+	return withDatabase(config, logger, func(ctx context.Context, s storages) error {
+		return newAuthService(config, s, logger).DisableUser(ctx, login)
+	})
+}
+
+// RequeueOrder makes the accruals scheduler fetch the accrual of the order again.
+func RequeueOrder(config *config.ServerConfig, orderNumber string, logger *logger.ServerLogger) error {
+	number, err := utils.ParseOrderNumber(orderNumber)
+	if err != nil {
+		return err
+	}
+
+	return withDatabase(config, logger, func(ctx context.Context, s storages) error {
+		return service.NewAccrualService(s.accruals, logger).RequeueAccrual(ctx, number)
+	})
+}
+
+// RecomputeBalance computes the balance of the user from accruals, withdrawals and adjustments. The balance is
+// not stored, so the result is what the user sees from now on.
+func RecomputeBalance(config *config.ServerConfig, login string, logger *logger.ServerLogger) (model.Balance, error) {
+	var balance model.Balance
+	err := withDatabase(config, logger, func(ctx context.Context, s storages) error {
+		user, err := service.NewUserService(s.users, logger).GetUserByLogin(ctx, login)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return er.NewNotFoundError("User not found", err)
+		}
+		if err != nil {
+			return err
+		}
+
+		balance, err = service.NewBalanceService(s.balances, logger).
+			GetBalanceByUserID(ctx, user.ID)
+		return err
+	})
+
+	return balance, err
+}
+
+// SyncAccrualsNow processes all accruals waiting for the accrual system without waiting for the scheduler and
+// returns the number of processed accruals.
+func SyncAccrualsNow(config *config.ServerConfig, logger *logger.ServerLogger) (int, error) {
+	var synced int
+	err := withDatabase(config, logger, func(ctx context.Context, s storages) error {
+		accrualService := service.NewAccrualService(s.accruals, logger)
+		accrualsScheduler := newAccrualsScheduler(config, accrualService, logger)
+
+		var err error
+		synced, err = accrualsScheduler.SyncNow(ctx)
+		return err
+	})
+
+	return synced, err
+}
+
+func withDatabase(config *config.ServerConfig, logger *logger.ServerLogger,
+	f func(ctx context.Context, s storages) error) error {
+	if isMemoryDatabase(config.DatabaseURL) {
+		return errMemoryDatabase
+	}
+
+	ctx := audit.WithClient(context.Background(), cliClient)
+	if isSQLiteDatabase(config.DatabaseURL) {
+		s, closeStorages, err := openSQLiteStorages(config.DatabaseURL, false, logger)
+		if err != nil {
+			return err
+		}
+		defer closeStorages()
+
+		return f(ctx, s)
+	}
+
+	db, err := pgxpool.New(ctx, config.DatabaseURL)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return f(ctx, newPostgresStorages(db, logger))
+}
+
+func newAuthService(config *config.ServerConfig, s storages, logger *logger.ServerLogger) service.AuthService {
+	passwordHasher := auth.NewPasswordHasher(auth.NewArgon2idHasher(getArgon2idParams(config.PasswordConfig)),
+		auth.NewBcryptHasher(bcrypt.DefaultCost))
+
+	return service.NewAuthService(
+		service.NewUserService(s.users, logger),
+		service.NewTotpService(s.totps, logger),
+		service.NewAuditService(s.auditEvents, logger),
+		passwordHasher,
+		auth.GenerateAuthToken(config.JwtSecretKey),
+		logger,
+	)
+}
@@ -1,37 +1,144 @@
 package app
 
 import (
+	"errors"
 	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
 
 	"github.com/caarlos0/env"
 
 	"github.com/Stern-Ritter/gophermart/internal/config"
 )
 
-func GetConfig(c config.ServerConfig) (config.ServerConfig, error) {
-	err := parseFlags(&c)
+var ErrUnexpectedArgument = errors.New("unexpected argument")
+
+// GetConfig builds the config from defaults, the optional config file, command line args and environment variables,
+// each overriding the previous one, and validates the result.
+func GetConfig(c config.ServerConfig, args []string) (config.ServerConfig, error) {
+	setDefaults(&c)
+
+	// The config file is located before the other flags are applied, so that flags override its settings.
+	probe := c
+	probeFlags := newFlagSet(&probe, io.Discard)
+	_ = probeFlags.Parse(args)
+	configFile := probe.ConfigFile
+	if path, ok := os.LookupEnv("CONFIG_FILE"); ok {
+		configFile = path
+	}
+	if configFile != "" {
+		if err := config.LoadFile(configFile, &c); err != nil {
+			return c, err
+		}
+	}
+
+	fs := newFlagSet(&c, os.Stderr)
+	err := fs.Parse(args)
 	if err != nil {
 		return c, err
 	}
+	// Flag parsing stops at the first positional argument, so a command after the flags would be silently ignored.
+	if fs.NArg() > 0 {
+		return c, fmt.Errorf("%w %q, the command should precede the flags", ErrUnexpectedArgument, fs.Arg(0))
+	}
 
-	err = env.Parse(&c)
+	err = parseEnv(&c)
 	if err != nil {
-		return c, err
+		return c, fmt.Errorf("environment: %w", err)
 	}
-	err = env.Parse(&c.ProcessAccrualsConfig)
 
-	return c, err
+	return c, c.Validate()
 }
 
-func parseFlags(c *config.ServerConfig) error {
-	flag.StringVar(&c.URL, "a", ":8080", "address to run gophermart in format <host>:<port>")
-	flag.StringVar(&c.DatabaseURL, "d", "", "database URL")
-	flag.StringVar(&c.AccrualSystemURL, "r", "", "address for sending requests to loyalty point accrual system")
-	flag.StringVar(&c.JwtSecretKey, "k", "secretKey", "secret used for jwt key")
-	flag.IntVar(&c.ProcessAccrualsConfig.ProcessAccrualsBatchMaxSize, "bs", 1, "processing accruals batch max size")
-	flag.IntVar(&c.ProcessAccrualsConfig.ProcessAccrualsBufferSize, "s", 10, "processing accruals buffer size")
-	flag.IntVar(&c.ProcessAccrualsConfig.ProcessAccrualsWorkerPoolSize, "w", 10, "processing accruals worker pool size")
-	flag.IntVar(&c.ProcessAccrualsConfig.GetNewAccrualsInterval, "i", 1, "interval to fetch new accruals")
+func PrintConfig(w io.Writer, c config.ServerConfig) error {
+	return config.Write(w, c.Redacted())
+}
+
+func setDefaults(c *config.ServerConfig) {
+	c.URL = ":8080"
+	c.JwtSecretKey = "secretKey"
+	c.ProcessAccrualsConfig = config.ProcessAccrualsConfig{
+		ProcessAccrualsBatchMaxSize:   1,
+		ProcessAccrualsBufferSize:     10,
+		ProcessAccrualsWorkerPoolSize: 10,
+		GetNewAccrualsInterval:        time.Second,
+	}
+	c.PasswordConfig = config.PasswordConfig{
+		Argon2Memory:      64 * 1024,
+		Argon2Iterations:  3,
+		Argon2Parallelism: 4,
+		MinCharClasses:    3,
+	}
+	c.TracingConfig.OtlpEndpoint = "http://localhost:4318"
+	c.ReadinessConfig.SchedulerHeartbeatMaxAge = 2 * time.Minute
+	if c.LoggerLvl == "" {
+		c.LoggerLvl = "info"
+	}
+}
 
+func parseEnv(c *config.ServerConfig) error {
+	for _, v := range []interface{}{c, &c.ProcessAccrualsConfig, &c.PasswordConfig, &c.ApiVersionConfig,
+		&c.TracingConfig, &c.ReadinessConfig} {
+		if err := env.Parse(v); err != nil {
+			return err
+		}
+	}
 	return nil
 }
+
+// newFlagSet binds flags to c using its current values as defaults. Every setting has a descriptive name,
+// the short names are kept for compatibility.
+func newFlagSet(c *config.ServerConfig, output io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet("gophermart", flag.ContinueOnError)
+	fs.SetOutput(output)
+
+	stringVar(fs, &c.ConfigFile, "config", "c", "path to YAML config file")
+	fs.BoolVar(&c.PrintConfig, "print-config", false, "print the resulting config with secrets redacted and exit")
+	stringVar(fs, &c.LoggerLvl, "log-level", "l", "log level: debug, info, warn or error")
+	stringVar(fs, &c.URL, "run-address", "a", "address to run gophermart in format <host>:<port>")
+	stringVar(fs, &c.GrpcURL, "grpc-run-address", "g", "address to run gophermart grpc server in format <host>:<port>, empty to disable")
+	stringVar(fs, &c.MetricsURL, "metrics-run-address", "ma", "address to serve prometheus metrics in format <host>:<port>, empty to disable")
+	stringVar(fs, &c.DatabaseURL, "database-uri", "d", "database URL")
+	boolVar(fs, &c.MigrateOnStart, "migrate-on-start", "m", "apply database migrations before starting the server")
+	stringVar(fs, &c.AccrualSystemURL, "accrual-system-address", "r", "address for sending requests to loyalty point accrual system")
+	stringVar(fs, &c.JwtSecretKey, "jwt-secret-key", "k", "secret used for jwt key")
+	intVar(fs, &c.ProcessAccrualsConfig.ProcessAccrualsBatchMaxSize, "accruals-batch-max-size", "bs", "processing accruals batch max size")
+	intVar(fs, &c.ProcessAccrualsConfig.ProcessAccrualsBufferSize, "accruals-buffer-size", "s", "processing accruals buffer size")
+	intVar(fs, &c.ProcessAccrualsConfig.ProcessAccrualsWorkerPoolSize, "accruals-worker-pool-size", "w", "processing accruals worker pool size")
+	durationVar(fs, &c.ProcessAccrualsConfig.GetNewAccrualsInterval, "accruals-fetch-interval", "i", "interval to fetch new accruals, e.g. 1s")
+	intVar(fs, &c.PasswordConfig.Argon2Memory, "password-argon2-memory", "pm", "argon2id password hashing memory in KiB")
+	intVar(fs, &c.PasswordConfig.Argon2Iterations, "password-argon2-iterations", "pt", "argon2id password hashing iterations")
+	intVar(fs, &c.PasswordConfig.Argon2Parallelism, "password-argon2-parallelism", "pp", "argon2id password hashing parallelism")
+	intVar(fs, &c.PasswordConfig.MinCharClasses, "password-min-char-classes", "pc", "minimum number of character classes in password")
+	stringVar(fs, &c.PasswordConfig.BreachedListFile, "password-breached-list-file", "pb", "path to file with breached passwords, one per line")
+	stringVar(fs, &c.ApiVersionConfig.V1DeprecatedAt, "api-v1-deprecated-at", "vd", "api v1 deprecation date in RFC3339 format, empty to omit Deprecation header")
+	stringVar(fs, &c.ApiVersionConfig.V1SunsetAt, "api-v1-sunset-at", "vs", "api v1 sunset date in RFC3339 format, empty to omit Sunset header")
+	stringVar(fs, &c.TracingConfig.Exporter, "tracing-exporter", "te", "tracing exporter: otlp, stdout or empty to disable tracing")
+	stringVar(fs, &c.TracingConfig.OtlpEndpoint, "tracing-otlp-endpoint", "tu", "otlp http endpoint to export traces to")
+	durationVar(fs, &c.ReadinessConfig.SchedulerHeartbeatMaxAge, "readiness-scheduler-heartbeat-max-age", "hs", "max age of accruals scheduler heartbeat for readiness, e.g. 2m")
+	boolVar(fs, &c.ReadinessConfig.CheckAccrualSystem, "readiness-check-accrual-system", "ha", "check loyalty point accrual system reachability for readiness")
+
+	return fs
+}
+
+func stringVar(fs *flag.FlagSet, p *string, name string, short string, usage string) {
+	fs.StringVar(p, name, *p, usage)
+	fs.StringVar(p, short, *p, "shorthand for -"+name)
+}
+
+func intVar(fs *flag.FlagSet, p *int, name string, short string, usage string) {
+	fs.IntVar(p, name, *p, usage)
+	fs.IntVar(p, short, *p, "shorthand for -"+name)
+}
+
+func boolVar(fs *flag.FlagSet, p *bool, name string, short string, usage string) {
+	fs.BoolVar(p, name, *p, usage)
+	fs.BoolVar(p, short, *p, "shorthand for -"+name)
+}
+
+func durationVar(fs *flag.FlagSet, p *time.Duration, name string, short string, usage string) {
+	fs.DurationVar(p, name, *p, usage)
+	fs.DurationVar(p, short, *p, "shorthand for -"+name)
+}
@@ -0,0 +1,58 @@
+package app
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"go.uber.org/zap"
+
+	"github.com/Stern-Ritter/gophermart/internal/config"
+	"github.com/Stern-Ritter/gophermart/internal/logger"
+	"github.com/Stern-Ritter/gophermart/internal/scheduler"
+)
+
+func watchReload(ctx context.Context, current config.ServerConfig, reloadConfig func() (config.ServerConfig, error),
+	accrualsScheduler scheduler.Scheduler, logger *logger.ServerLogger) {
+	hupCh := make(chan os.Signal, 1)
+	signal.Notify(hupCh, syscall.SIGHUP)
+	defer signal.Stop(hupCh)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hupCh:
+			current = reload(current, reloadConfig, accrualsScheduler, logger)
+		}
+	}
+}
+
+// reload applies the log level and the accruals scheduler sizes of the reloaded config. An invalid config is
+// rejected as a whole and the current settings are kept.
+func reload(current config.ServerConfig, reloadConfig func() (config.ServerConfig, error),
+	accrualsScheduler scheduler.Scheduler, logger *logger.ServerLogger) config.ServerConfig {
+	next, err := reloadConfig()
+	if err != nil {
+		logger.Error("Error reloading config, keeping current settings", zap.String("event", "reload config"),
+			zap.Error(err))
+		return current
+	}
+
+	if err = logger.SetLevel(next.LoggerLvl); err != nil {
+		logger.Error("Error changing log level", zap.String("event", "reload config"), zap.Error(err))
+		return current
+	}
+	accrualsScheduler.Reconfigure(next.ProcessAccrualsConfig.ProcessAccrualsBatchMaxSize,
+		next.ProcessAccrualsConfig.ProcessAccrualsWorkerPoolSize)
+
+	if config.RequiresRestart(current, next) {
+		logger.Warn("Config contains changes that are applied only after restart", zap.String("event", "reload config"))
+	}
+	logger.Info("Config reloaded", zap.String("event", "reload config"), zap.String("log level", next.LoggerLvl),
+		zap.Int("accruals batch max size", next.ProcessAccrualsConfig.ProcessAccrualsBatchMaxSize),
+		zap.Int("accruals worker pool size", next.ProcessAccrualsConfig.ProcessAccrualsWorkerPoolSize))
+
+	return next
+}
@@ -0,0 +1,108 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/Stern-Ritter/gophermart/internal/config"
+	"github.com/Stern-Ritter/gophermart/internal/logger"
+	"github.com/Stern-Ritter/gophermart/internal/scheduler"
+)
+
+type testScheduler struct {
+	batchMaxSize   int
+	workerPoolSize int
+}
+
+func (s *testScheduler) RunTasks()                {}
+func (s *testScheduler) StopTasks()               {}
+func (s *testScheduler) LastHeartbeat() time.Time { return time.Time{} }
+func (s *testScheduler) SyncNow(ctx context.Context) (int, error) {
+	return 0, nil
+}
+func (s *testScheduler) Reconfigure(batchMaxSize int, workerPoolSize int) {
+	s.batchMaxSize = batchMaxSize
+	s.workerPoolSize = workerPoolSize
+}
+
+func TestReload(t *testing.T) {
+	current := config.ServerConfig{
+		LoggerLvl: "info",
+		ProcessAccrualsConfig: config.ProcessAccrualsConfig{
+			ProcessAccrualsBatchMaxSize:   1,
+			ProcessAccrualsWorkerPoolSize: 10,
+		},
+	}
+	next := current
+	next.LoggerLvl = "debug"
+	next.ProcessAccrualsConfig.ProcessAccrualsBatchMaxSize = 5
+	next.ProcessAccrualsConfig.ProcessAccrualsWorkerPoolSize = 2
+
+	tests := []struct {
+		name               string
+		reloadErr          error
+		expectedConfig     config.ServerConfig
+		expectedDebug      bool
+		expectedBatchSize  int
+		expectedWorkerPool int
+	}{
+		{
+			name:               "should apply log level and scheduler sizes when config is valid",
+			expectedConfig:     next,
+			expectedDebug:      true,
+			expectedBatchSize:  5,
+			expectedWorkerPool: 2,
+		},
+		{
+			name:           "should keep current settings when config is invalid",
+			reloadErr:      errors.New("process_accruals.worker_pool_size must be greater than zero, got 0"),
+			expectedConfig: current,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, err := logger.Initialize(current.LoggerLvl)
+			require.NoError(t, err, "Error init logger")
+			s := &testScheduler{}
+
+			got := reload(current, func() (config.ServerConfig, error) {
+				return next, tt.reloadErr
+			}, s, l)
+
+			assert.Equal(t, tt.expectedConfig, got, "Config does not match expected config")
+			assert.Equal(t, tt.expectedDebug, l.Core().Enabled(zap.DebugLevel), "Log level does not match expected level")
+			assert.Equal(t, tt.expectedBatchSize, s.batchMaxSize, "Batch size does not match expected batch size")
+			assert.Equal(t, tt.expectedWorkerPool, s.workerPoolSize, "Worker pool size does not match expected size")
+		})
+	}
+}
+
+func TestReloadWithUnchangedConfigKeepsSchedulerBatchSize(t *testing.T) {
+	l, err := logger.Initialize("error")
+	require.NoError(t, err, "Error init logger")
+	current := config.ServerConfig{
+		LoggerLvl: "error",
+		ProcessAccrualsConfig: config.ProcessAccrualsConfig{
+			ProcessAccrualsBatchMaxSize:   1,
+			ProcessAccrualsBufferSize:     10,
+			ProcessAccrualsWorkerPoolSize: 1,
+			GetNewAccrualsInterval:        time.Second,
+		},
+	}
+
+	s, ok := newAccrualsScheduler(&current, nil, l).(*scheduler.AccrualsScheduler)
+	require.True(t, ok, "Scheduler should be accruals scheduler")
+	assert.Equal(t, 1, s.BatchMaxSize(), "Batch size does not match configured batch size")
+
+	reload(current, func() (config.ServerConfig, error) {
+		return current, nil
+	}, s, l)
+	assert.Equal(t, 1, s.BatchMaxSize(), "Batch size should not change after reload with unchanged config")
+}
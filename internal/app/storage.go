@@ -0,0 +1,163 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+
+	"github.com/Stern-Ritter/gophermart/internal/config"
+	"github.com/Stern-Ritter/gophermart/internal/logger"
+	"github.com/Stern-Ritter/gophermart/internal/metrics"
+	"github.com/Stern-Ritter/gophermart/internal/storage"
+	"github.com/Stern-Ritter/gophermart/internal/storage/memory"
+	"github.com/Stern-Ritter/gophermart/internal/storage/sqlite"
+	"github.com/Stern-Ritter/gophermart/internal/tracing"
+	"github.com/Stern-Ritter/gophermart/migrations"
+)
+
+const memoryScheme = "memory"
+
+var errMemoryDatabase = errors.New("memory:// database lives inside the server process, " +
+	"admin commands need a persistent database")
+
+type storages struct {
+	// dialect selects the migrations the schema version is checked against.
+	dialect              string
+	users                storage.UserStorage
+	accruals             storage.AccrualStorage
+	withdrawals          storage.WithdrawnStorage
+	balances             storage.BalanceStorage
+	totps                storage.TotpStorage
+	adjustments          storage.AdjustmentStorage
+	apiKeys              storage.ApiKeyStorage
+	accrualEvents        storage.AccrualEventStorage
+	accrualEventListener storage.AccrualEventListener
+	health               storage.HealthStorage
+	auditEvents          storage.AuditEventStorage
+}
+
+// openStorages builds the storages for the database URL scheme: memory:// keeps the data in the process, sqlite://
+// points to a database file, any other URL is a Postgres connection. The returned func releases the database.
+func openStorages(ctx context.Context, config *config.ServerConfig, logger *logger.ServerLogger) (storages, func(), error) {
+	if isMemoryDatabase(config.DatabaseURL) {
+		logger.Warn("Using in-memory storage, data is lost on restart", zap.String("event", "connect database"))
+		return newMemoryStorages(memory.NewDB(), logger), func() {}, nil
+	}
+	if isSQLiteDatabase(config.DatabaseURL) {
+		return openSQLiteStorages(config.DatabaseURL, config.MigrateOnStart, logger)
+	}
+
+	poolConfig, err := pgxpool.ParseConfig(config.DatabaseURL)
+	if err != nil {
+		return storages{}, nil, fmt.Errorf("failed to parse database url: %w", err)
+	}
+	poolConfig.ConnConfig.Tracer = tracing.NewQueryTracer()
+
+	db, err := pgxpool.NewWithConfig(ctx, poolConfig)
+	if err != nil {
+		return storages{}, nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	err = metrics.Registry.Register(metrics.NewPoolCollector(db))
+	if err != nil {
+		db.Close()
+		return storages{}, nil, fmt.Errorf("failed to register database pool metrics: %w", err)
+	}
+
+	if config.MigrateOnStart {
+		err = migrations.Migrate(config.DatabaseURL, migrations.DialectPostgres, "pgx")
+		if err != nil {
+			db.Close()
+			return storages{}, nil, fmt.Errorf("failed to migrate database: %w", err)
+		}
+	}
+
+	return newPostgresStorages(db, logger), db.Close, nil
+}
+
+func openSQLiteStorages(databaseURL string, migrate bool, logger *logger.ServerLogger) (storages, func(), error) {
+	dsn, err := sqlite.DataSourceName(databaseURL)
+	if err != nil {
+		return storages{}, nil, fmt.Errorf("failed to parse database url: %w", err)
+	}
+
+	if migrate {
+		err = migrations.Migrate(dsn, migrations.DialectSQLite, sqlite.DriverName)
+		if err != nil {
+			return storages{}, nil, fmt.Errorf("failed to migrate database: %w", err)
+		}
+	}
+
+	db, err := sqlite.Open(databaseURL)
+	if err != nil {
+		return storages{}, nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	return newSQLiteStorages(db, logger), func() { db.Close() }, nil
+}
+
+func newPostgresStorages(db *pgxpool.Pool, logger *logger.ServerLogger) storages {
+	return storages{
+		dialect:              migrations.DialectPostgres,
+		users:                storage.NewUserStorage(db, logger),
+		accruals:             storage.NewAccrualStorage(db, logger),
+		withdrawals:          storage.NewWithdrawnStorage(db, logger),
+		balances:             storage.NewBalanceStorage(db, logger),
+		totps:                storage.NewTotpStorage(db, logger),
+		adjustments:          storage.NewAdjustmentStorage(db, logger),
+		apiKeys:              storage.NewApiKeyStorage(db, logger),
+		accrualEvents:        storage.NewAccrualEventStorage(db, logger),
+		accrualEventListener: storage.NewAccrualEventListener(db, logger),
+		health:               storage.NewHealthStorage(db, logger),
+		auditEvents:          storage.NewAuditEventStorage(db, logger),
+	}
+}
+
+func newMemoryStorages(db *memory.DB, logger *logger.ServerLogger) storages {
+	return storages{
+		dialect:              migrations.DialectPostgres,
+		users:                memory.NewUserStorage(db, logger),
+		accruals:             memory.NewAccrualStorage(db, logger),
+		withdrawals:          memory.NewWithdrawnStorage(db, logger),
+		balances:             memory.NewBalanceStorage(db, logger),
+		totps:                memory.NewTotpStorage(db, logger),
+		adjustments:          memory.NewAdjustmentStorage(db, logger),
+		apiKeys:              memory.NewApiKeyStorage(db, logger),
+		accrualEvents:        memory.NewAccrualEventStorage(db, logger),
+		accrualEventListener: memory.NewAccrualEventListener(db, logger),
+		health:               memory.NewHealthStorage(db, logger),
+		auditEvents:          memory.NewAuditEventStorage(db, logger),
+	}
+}
+
+func newSQLiteStorages(db *sql.DB, logger *logger.ServerLogger) storages {
+	return storages{
+		dialect:              migrations.DialectSQLite,
+		users:                sqlite.NewUserStorage(db, logger),
+		accruals:             sqlite.NewAccrualStorage(db, logger),
+		withdrawals:          sqlite.NewWithdrawnStorage(db, logger),
+		balances:             sqlite.NewBalanceStorage(db, logger),
+		totps:                sqlite.NewTotpStorage(db, logger),
+		adjustments:          sqlite.NewAdjustmentStorage(db, logger),
+		apiKeys:              sqlite.NewApiKeyStorage(db, logger),
+		accrualEvents:        sqlite.NewAccrualEventStorage(db, logger),
+		accrualEventListener: sqlite.NewAccrualEventListener(db, logger),
+		health:               sqlite.NewHealthStorage(db, logger),
+		auditEvents:          sqlite.NewAuditEventStorage(db, logger),
+	}
+}
+
+func isMemoryDatabase(databaseURL string) bool {
+	u, err := url.Parse(databaseURL)
+	return err == nil && u.Scheme == memoryScheme
+}
+
+func isSQLiteDatabase(databaseURL string) bool {
+	u, err := url.Parse(databaseURL)
+	return err == nil && u.Scheme == sqlite.Scheme
+}
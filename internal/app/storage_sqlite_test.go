@@ -0,0 +1,31 @@
+//go:build cgo
+
+package app
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Stern-Ritter/gophermart/internal/config"
+	er "github.com/Stern-Ritter/gophermart/internal/errors"
+	"github.com/Stern-Ritter/gophermart/internal/logger"
+)
+
+func TestAdminCommandsUseSQLiteDatabase(t *testing.T) {
+	l, err := logger.Initialize("error")
+	require.NoError(t, err, "Error init logger")
+	c := &config.ServerConfig{DatabaseURL: "sqlite://" + filepath.Join(t.TempDir(), "gophermart.db")}
+
+	require.NoError(t, MigrateDatabase(c, "up"), "Error migrate database")
+
+	_, err = RecomputeBalance(c, "user", l)
+	var notFoundErr er.NotFoundError
+	assert.ErrorAs(t, err, &notFoundErr, "Error does not match expected error")
+
+	verification, err := VerifyAuditChain(c, l)
+	require.NoError(t, err, "Error verify audit chain")
+	assert.True(t, verification.Valid, "Empty audit chain should be valid")
+}
@@ -0,0 +1,71 @@
+package audit
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+const maxUserAgentLength = 512
+
+type clientContextKey struct{}
+
+// Client describes the remote side of the request that caused an audit event.
+type Client struct {
+	IP        string
+	UserAgent string
+}
+
+func WithClient(ctx context.Context, client Client) context.Context {
+	return context.WithValue(ctx, clientContextKey{}, client)
+}
+
+func ClientFromContext(ctx context.Context) Client {
+	client, _ := ctx.Value(clientContextKey{}).(Client)
+	return client
+}
+
+// Middleware stores the remote address and user agent of the request for audit events. Forwarding headers are
+// not trusted, so behind a proxy the proxy address is recorded.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		client := Client{IP: hostFromAddr(r.RemoteAddr), UserAgent: truncate(r.UserAgent(), maxUserAgentLength)}
+		next.ServeHTTP(w, r.WithContext(WithClient(r.Context(), client)))
+	})
+}
+
+func UnaryInterceptor(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler) (interface{}, error) {
+	client := Client{}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		client.IP = hostFromAddr(p.Addr.String())
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get("user-agent"); len(values) > 0 {
+			client.UserAgent = truncate(values[0], maxUserAgentLength)
+		}
+	}
+
+	return handler(WithClient(ctx, client), req)
+}
+
+func hostFromAddr(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	return host
+}
+
+// truncate cuts value to maxLength bytes and drops invalid UTF-8 sequences, which Postgres rejects.
+func truncate(value string, maxLength int) string {
+	if len(value) > maxLength {
+		value = value[:maxLength]
+	}
+	return strings.ToValidUTF8(value, "")
+}
@@ -0,0 +1,34 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+const (
+	apiKeyPrefix     = "gm_"
+	apiKeySize       = 32
+	apiKeyPrefixSize = 8
+)
+
+func GenerateApiKey() (string, error) {
+	key := make([]byte, apiKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return apiKeyPrefix + hex.EncodeToString(key), nil
+}
+
+func GetApiKeyPrefix(key string) string {
+	if len(key) <= len(apiKeyPrefix)+apiKeyPrefixSize {
+		return key
+	}
+	return key[:len(apiKeyPrefix)+apiKeyPrefixSize]
+}
+
+func GetApiKeyHash(key string) string {
+	sum := sha256.Sum256([]byte(strings.TrimSpace(key)))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,32 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateApiKey(t *testing.T) {
+	key, err := GenerateApiKey()
+	require.NoError(t, err, "Error generating api key")
+	assert.True(t, strings.HasPrefix(key, "gm_"), "Api key should start with gm_ prefix")
+	assert.Len(t, key, 3+64, "Api key length does not match expected")
+
+	other, err := GenerateApiKey()
+	require.NoError(t, err, "Error generating api key")
+	assert.NotEqual(t, key, other, "Generated api keys should be unique")
+}
+
+func TestGetApiKeyPrefix(t *testing.T) {
+	assert.Equal(t, "gm_01234567", GetApiKeyPrefix("gm_0123456789abcdef"), "Api key prefix does not match expected")
+	assert.Equal(t, "gm_0", GetApiKeyPrefix("gm_0"), "Short api key prefix does not match expected")
+}
+
+func TestGetApiKeyHash(t *testing.T) {
+	hash := GetApiKeyHash("gm_0123456789abcdef")
+	assert.Len(t, hash, 64, "Api key hash should be sha256 hex")
+	assert.Equal(t, hash, GetApiKeyHash(" gm_0123456789abcdef "), "Api key hash should ignore surrounding spaces")
+	assert.NotEqual(t, hash, GetApiKeyHash("gm_0123456789abcdee"), "Different api keys should have different hashes")
+}
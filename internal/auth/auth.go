@@ -1,32 +1,30 @@
 package auth
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
 
 	"github.com/go-chi/jwtauth/v5"
 	"github.com/lestrrat-go/jwx/v2/jwt"
-	"golang.org/x/crypto/bcrypt"
+
+	er "github.com/Stern-Ritter/gophermart/internal/errors"
+	"github.com/Stern-Ritter/gophermart/internal/model"
+	"github.com/Stern-Ritter/gophermart/internal/problem"
 )
 
-func GetPasswordHash(password string) (string, error) {
-	if strings.TrimSpace(password) == "" {
-		return "", fmt.Errorf("empty password")
-	}
-	bytePassword := []byte(password)
-	hashedPassword, err := bcrypt.GenerateFromPassword(bytePassword, bcrypt.DefaultCost)
-	if err != nil {
-		return "", err
-	}
-	return string(hashedPassword), nil
-}
+const (
+	LoginClaim        = "login"
+	ApiKeyScopesClaim = "api_key_scopes"
+	apiKeyScheme      = "ApiKey"
 
-func CheckPasswordHash(password, hash string) bool {
-	byteHash := []byte(hash)
-	bytePassword := []byte(password)
-	err := bcrypt.CompareHashAndPassword(byteHash, bytePassword)
-	return err == nil
+	unauthorizedDetail = "User is not authorized to access this resource"
+)
+
+type ApiKeyVerifier interface {
+	VerifyApiKey(ctx context.Context, key string) (model.User, model.ApiKey, error)
 }
 
 func GenerateAuthToken(secretKey string) *jwtauth.JWTAuth {
@@ -34,8 +32,38 @@ func GenerateAuthToken(secretKey string) *jwtauth.JWTAuth {
 	return tokenAuth
 }
 
-func Verifier(ja *jwtauth.JWTAuth) func(http.Handler) http.Handler {
-	return jwtauth.Verify(ja, AuthorizationTokenFromHeader, jwtauth.TokenFromHeader, jwtauth.TokenFromCookie)
+func Verifier(ja *jwtauth.JWTAuth, apiKeyVerifier ApiKeyVerifier) func(http.Handler) http.Handler {
+	jwtVerifier := jwtauth.Verify(ja, AuthorizationTokenFromHeader, jwtauth.TokenFromHeader, jwtauth.TokenFromCookie)
+	return func(next http.Handler) http.Handler {
+		jwtNext := jwtVerifier(next)
+		hfn := func(w http.ResponseWriter, r *http.Request) {
+			key, ok := ApiKeyFromHeader(r)
+			if !ok || apiKeyVerifier == nil {
+				jwtNext.ServeHTTP(w, r)
+				return
+			}
+
+			user, apiKey, err := apiKeyVerifier.VerifyApiKey(r.Context(), key)
+			if err != nil {
+				var unauthorizedError er.UnauthorizedError
+				if !errors.As(err, &unauthorizedError) {
+					problem.Write(w, r, problem.FromError(err))
+					return
+				}
+				next.ServeHTTP(w, r.WithContext(jwtauth.NewContext(r.Context(), nil, err)))
+				return
+			}
+
+			token, err := newApiKeyToken(user, apiKey)
+			if err != nil {
+				problem.Write(w, r, problem.FromError(err))
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(jwtauth.NewContext(r.Context(), token, nil)))
+		}
+		return http.HandlerFunc(hfn)
+	}
 }
 
 func Authenticator(ja *jwtauth.JWTAuth) func(http.Handler) http.Handler {
@@ -44,12 +72,13 @@ func Authenticator(ja *jwtauth.JWTAuth) func(http.Handler) http.Handler {
 			token, _, err := jwtauth.FromContext(r.Context())
 
 			if err != nil {
-				http.Error(w, err.Error(), http.StatusUnauthorized)
+				writeUnauthorized(w, r, err)
 				return
 			}
 
 			if token == nil || jwt.Validate(token, ja.ValidateOptions()...) != nil {
-				http.Error(w, "Invalid jwt token", http.StatusUnauthorized)
+				problem.Write(w, r, problem.New(http.StatusUnauthorized, problem.CodeUnauthorized, "Invalid jwt token"))
+				return
 			}
 
 			next.ServeHTTP(w, r)
@@ -58,6 +87,111 @@ func Authenticator(ja *jwtauth.JWTAuth) func(http.Handler) http.Handler {
 	}
 }
 
+func ScopeAuthorizer(scope model.ApiKeyScope) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		hfn := func(w http.ResponseWriter, r *http.Request) {
+			_, claims, err := jwtauth.FromContext(r.Context())
+			if err != nil {
+				writeUnauthorized(w, r, err)
+				return
+			}
+
+			scopes, isApiKey := getApiKeyScopes(claims)
+			if !isApiKey {
+				next.ServeHTTP(w, r)
+				return
+			}
+			for _, allowedScope := range scopes {
+				if allowedScope == string(scope) {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+
+			problem.Write(w, r, problem.New(http.StatusForbidden, problem.CodeForbidden, fmt.Sprintf("Api key does not have %s scope", scope)))
+		}
+		return http.HandlerFunc(hfn)
+	}
+}
+
+func SessionAuthorizer(next http.Handler) http.Handler {
+	hfn := func(w http.ResponseWriter, r *http.Request) {
+		_, claims, err := jwtauth.FromContext(r.Context())
+		if err != nil {
+			writeUnauthorized(w, r, err)
+			return
+		}
+
+		if _, isApiKey := getApiKeyScopes(claims); isApiKey {
+			problem.Write(w, r, problem.New(http.StatusForbidden, problem.CodeForbidden, "Resource is not available with api key"))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	}
+	return http.HandlerFunc(hfn)
+}
+
+// writeUnauthorized keeps the messages of the api key verification, other errors come from jwtauth and are not
+// shown to clients.
+func writeUnauthorized(w http.ResponseWriter, r *http.Request, err error) {
+	detail := unauthorizedDetail
+	var unauthorizedError er.UnauthorizedError
+	if errors.As(err, &unauthorizedError) {
+		detail = unauthorizedError.Error()
+	}
+	problem.Write(w, r, problem.New(http.StatusUnauthorized, problem.CodeUnauthorized, detail))
+}
+
 func AuthorizationTokenFromHeader(r *http.Request) string {
 	return r.Header.Get("Authorization")
 }
+
+func ApiKeyFromHeader(r *http.Request) (string, bool) {
+	scheme, key, found := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !found || !strings.EqualFold(scheme, apiKeyScheme) {
+		return "", false
+	}
+
+	key = strings.TrimSpace(key)
+	return key, key != ""
+}
+
+func newApiKeyToken(user model.User, apiKey model.ApiKey) (jwt.Token, error) {
+	scopes := make([]string, len(apiKey.Scopes))
+	for i, scope := range apiKey.Scopes {
+		scopes[i] = string(scope)
+	}
+
+	token := jwt.New()
+	if err := token.Set(LoginClaim, user.Login); err != nil {
+		return nil, err
+	}
+	if err := token.Set(ApiKeyScopesClaim, scopes); err != nil {
+		return nil, err
+	}
+
+	return token, nil
+}
+
+func getApiKeyScopes(claims map[string]interface{}) ([]string, bool) {
+	value, ok := claims[ApiKeyScopesClaim]
+	if !ok {
+		return nil, false
+	}
+
+	switch scopes := value.(type) {
+	case []string:
+		return scopes, true
+	case []interface{}:
+		result := make([]string, 0, len(scopes))
+		for _, scope := range scopes {
+			if s, ok := scope.(string); ok {
+				result = append(result, s)
+			}
+		}
+		return result, true
+	}
+
+	return nil, true
+}
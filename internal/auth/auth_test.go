@@ -1,67 +1,145 @@
 package auth
 
 import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
-	"golang.org/x/crypto/bcrypt"
-)
-
-func TestGetPasswordHash(t *testing.T) {
-	t.Run("should correct hash password", func(t *testing.T) {
-		password := "secretPassword"
-		hashedPassword, err := GetPasswordHash(password)
-
-		require.NoError(t, err, "Error hashing password")
-		require.NotEmpty(t, hashedPassword, "Hashed password should not be empty")
 
-		err = bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
-
-		assert.NoError(t, err, "Expected hashed password to match origin password")
-	})
-
-	t.Run("should return error when hashing password is empty", func(t *testing.T) {
-		invalidPassword := ""
-		_, err := GetPasswordHash(invalidPassword)
+	er "github.com/Stern-Ritter/gophermart/internal/errors"
+	"github.com/Stern-Ritter/gophermart/internal/model"
+)
 
-		assert.Error(t, err, "Expected error when hashing password is empty")
-	})
+type apiKeyVerifierStub struct {
+	key    string
+	scopes []model.ApiKeyScope
+	err    error
 }
 
-func TestCheckPasswordHash(t *testing.T) {
-	password := "secretPassword"
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	require.NoError(t, err, "Error hashing password")
+func (v apiKeyVerifierStub) VerifyApiKey(_ context.Context, key string) (model.User, model.ApiKey, error) {
+	if v.err != nil {
+		return model.User{}, model.ApiKey{}, v.err
+	}
+	if key != v.key {
+		return model.User{}, model.ApiKey{}, er.NewUnauthorizedError("Invalid api key", nil)
+	}
+	return model.User{ID: 1, Login: "user", Role: model.UserRoleAdmin},
+		model.ApiKey{ID: 1, UserID: 1, Scopes: v.scopes}, nil
+}
 
+func TestVerifierWithApiKey(t *testing.T) {
 	tests := []struct {
-		name     string
-		password string
-		hash     string
-		want     bool
+		name               string
+		authorization      string
+		verifierErr        error
+		middleware         func(http.Handler) http.Handler
+		expectedStatusCode int
 	}{
 		{
-			name:     "should return true when password and hash are valid",
-			password: password,
-			hash:     string(hashedPassword),
-			want:     true},
+			name:               "should pass request when api key has required scope",
+			authorization:      "ApiKey gm_valid",
+			middleware:         ScopeAuthorizer(model.ApiKeyScopeOrdersWrite),
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "should return status 403 when api key has not required scope",
+			authorization:      "ApiKey gm_valid",
+			middleware:         ScopeAuthorizer(model.ApiKeyScopeBalanceRead),
+			expectedStatusCode: http.StatusForbidden,
+		},
 		{
-			name:     "should return false when password is invalid",
-			password: "wrongPassword",
-			hash:     string(hashedPassword),
-			want:     false,
+			name:               "should return status 401 when api key is invalid",
+			authorization:      "ApiKey gm_invalid",
+			middleware:         ScopeAuthorizer(model.ApiKeyScopeOrdersWrite),
+			expectedStatusCode: http.StatusUnauthorized,
 		},
 		{
-			name:     "should return false when hash is invalid",
-			password: password,
-			hash:     "$2a$10$",
-			want:     false},
+			name:               "should return status 500 when api key verification failed",
+			authorization:      "ApiKey gm_valid",
+			verifierErr:        errors.New("unexpected error"),
+			middleware:         ScopeAuthorizer(model.ApiKeyScopeOrdersWrite),
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		{
+			name:               "should return status 403 when api key is used for session only resource",
+			authorization:      "ApiKey gm_valid",
+			middleware:         SessionAuthorizer,
+			expectedStatusCode: http.StatusForbidden,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := CheckPasswordHash(tt.password, tt.hash)
-			assert.Equal(t, tt.want, got, "CheckPasswordHash(%s, %s) = %v, want %v", tt.password, tt.hash, got, tt.want)
+			ja := GenerateAuthToken("secret")
+			verifier := apiKeyVerifierStub{
+				key:    "gm_valid",
+				scopes: []model.ApiKeyScope{model.ApiKeyScopeOrdersWrite},
+				err:    tt.verifierErr,
+			}
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})
+			handler := Verifier(ja, verifier)(Authenticator(ja)(tt.middleware(next)))
+
+			req := httptest.NewRequest(http.MethodPost, "/api/user/orders", nil)
+			req.Header.Set("Authorization", tt.authorization)
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			resp := w.Result()
+			defer resp.Body.Close()
+
+			assert.Equal(t, tt.expectedStatusCode, resp.StatusCode, "Response status code does not match expected status")
 		})
 	}
 }
+
+func TestScopeAuthorizerWithJwt(t *testing.T) {
+	ja := GenerateAuthToken("secret")
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	handler := Verifier(ja, apiKeyVerifierStub{})(Authenticator(ja)(ScopeAuthorizer(model.ApiKeyScopeOrdersWrite)(next)))
+
+	_, tokenString, err := ja.Encode(map[string]interface{}{LoginClaim: "user"})
+	require.NoError(t, err, "Error encoding token")
+	req := httptest.NewRequest(http.MethodPost, "/api/user/orders", nil)
+	req.Header.Set("Authorization", tokenString)
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	resp := w.Result()
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "Jwt session should not be restricted by api key scopes")
+}
+
+func TestAuthenticatorHidesTokenError(t *testing.T) {
+	ja := GenerateAuthToken("secret")
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	handler := Verifier(ja, nil)(Authenticator(ja)(next))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/user/balance", nil)
+	req.Header.Set("Authorization", "malformed")
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	resp := w.Result()
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err, "Error reading response body")
+
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode, "Response status code does not match expected status")
+	assert.Contains(t, string(body), unauthorizedDetail, "Response detail does not match expected detail")
+	assert.NotContains(t, string(body), "jwtauth", "Response should not contain jwtauth error")
+}
@@ -0,0 +1,55 @@
+package auth
+
+import (
+	"context"
+	"strings"
+
+	"github.com/go-chi/jwtauth/v5"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/Stern-Ritter/gophermart/internal/utils"
+)
+
+const authorizationMetadataKey = "authorization"
+
+func UnaryAuthenticator(ja *jwtauth.JWTAuth, publicMethods ...string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler) (interface{}, error) {
+		if utils.Contains(publicMethods, info.FullMethod) {
+			return handler(ctx, req)
+		}
+
+		tokenString := AuthorizationTokenFromMetadata(ctx)
+		if tokenString == "" {
+			return nil, status.Error(codes.Unauthenticated, "Authorization token is missing")
+		}
+
+		token, err := jwtauth.VerifyToken(ja, tokenString)
+		if err != nil {
+			return nil, status.Error(codes.Unauthenticated, "Invalid jwt token")
+		}
+
+		return handler(jwtauth.NewContext(ctx, token, nil), req)
+	}
+}
+
+func AuthorizationTokenFromMetadata(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+
+	values := md.Get(authorizationMetadataKey)
+	if len(values) == 0 {
+		return ""
+	}
+
+	value := strings.TrimSpace(values[0])
+	if len(value) > 7 && strings.EqualFold(value[:7], "Bearer ") {
+		return strings.TrimSpace(value[7:])
+	}
+	return value
+}
@@ -0,0 +1,204 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const argon2idHashPrefix = "$argon2id$"
+
+var argon2idEncoding = base64.RawStdEncoding
+
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	Verify(password string, hash string) bool
+	Supports(hash string) bool
+	NeedsRehash(hash string) bool
+}
+
+type Argon2idParams struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+var DefaultArgon2idParams = Argon2idParams{
+	Memory:      64 * 1024,
+	Iterations:  3,
+	Parallelism: 4,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+type Argon2idHasher struct {
+	params Argon2idParams
+}
+
+func NewArgon2idHasher(params Argon2idParams) *Argon2idHasher {
+	return &Argon2idHasher{params: params}
+}
+
+func (h *Argon2idHasher) Hash(password string) (string, error) {
+	if strings.TrimSpace(password) == "" {
+		return "", fmt.Errorf("empty password")
+	}
+
+	salt := make([]byte, h.params.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, h.params.Iterations, h.params.Memory, h.params.Parallelism,
+		h.params.KeyLength)
+
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s", argon2idHashPrefix, argon2.Version, h.params.Memory,
+		h.params.Iterations, h.params.Parallelism, argon2idEncoding.EncodeToString(salt),
+		argon2idEncoding.EncodeToString(key)), nil
+}
+
+func (h *Argon2idHasher) Verify(password string, hash string) bool {
+	params, salt, key, err := decodeArgon2idHash(hash)
+	if err != nil {
+		return false
+	}
+
+	otherKey := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism,
+		params.KeyLength)
+
+	return subtle.ConstantTimeCompare(key, otherKey) == 1
+}
+
+func (h *Argon2idHasher) Supports(hash string) bool {
+	return strings.HasPrefix(hash, argon2idHashPrefix)
+}
+
+func (h *Argon2idHasher) NeedsRehash(hash string) bool {
+	params, _, _, err := decodeArgon2idHash(hash)
+	if err != nil {
+		return true
+	}
+
+	return params != h.params
+}
+
+type BcryptHasher struct {
+	cost int
+}
+
+func NewBcryptHasher(cost int) *BcryptHasher {
+	return &BcryptHasher{cost: cost}
+}
+
+func (h *BcryptHasher) Hash(password string) (string, error) {
+	if strings.TrimSpace(password) == "" {
+		return "", fmt.Errorf("empty password")
+	}
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), h.cost)
+	if err != nil {
+		return "", err
+	}
+	return string(hashedPassword), nil
+}
+
+func (h *BcryptHasher) Verify(password string, hash string) bool {
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	return err == nil
+}
+
+func (h *BcryptHasher) Supports(hash string) bool {
+	return strings.HasPrefix(hash, "$2a$") || strings.HasPrefix(hash, "$2b$") || strings.HasPrefix(hash, "$2y$")
+}
+
+func (h *BcryptHasher) NeedsRehash(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err != nil || cost != h.cost
+}
+
+type MultiPasswordHasher struct {
+	primary PasswordHasher
+	legacy  []PasswordHasher
+}
+
+func NewPasswordHasher(primary PasswordHasher, legacy ...PasswordHasher) *MultiPasswordHasher {
+	return &MultiPasswordHasher{
+		primary: primary,
+		legacy:  legacy,
+	}
+}
+
+func (h *MultiPasswordHasher) Hash(password string) (string, error) {
+	return h.primary.Hash(password)
+}
+
+func (h *MultiPasswordHasher) Verify(password string, hash string) bool {
+	hasher := h.getHasher(hash)
+	if hasher == nil {
+		return false
+	}
+	return hasher.Verify(password, hash)
+}
+
+func (h *MultiPasswordHasher) Supports(hash string) bool {
+	return h.getHasher(hash) != nil
+}
+
+func (h *MultiPasswordHasher) NeedsRehash(hash string) bool {
+	if !h.primary.Supports(hash) {
+		return true
+	}
+	return h.primary.NeedsRehash(hash)
+}
+
+func (h *MultiPasswordHasher) getHasher(hash string) PasswordHasher {
+	if h.primary.Supports(hash) {
+		return h.primary
+	}
+	for _, hasher := range h.legacy {
+		if hasher.Supports(hash) {
+			return hasher
+		}
+	}
+	return nil
+}
+
+func decodeArgon2idHash(hash string) (Argon2idParams, []byte, []byte, error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return Argon2idParams{}, nil, nil, fmt.Errorf("invalid argon2id hash format")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return Argon2idParams{}, nil, nil, err
+	}
+	if version != argon2.Version {
+		return Argon2idParams{}, nil, nil, fmt.Errorf("unsupported argon2 version: %d", version)
+	}
+
+	params := Argon2idParams{}
+	_, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism)
+	if err != nil {
+		return Argon2idParams{}, nil, nil, err
+	}
+
+	salt, err := argon2idEncoding.DecodeString(parts[4])
+	if err != nil {
+		return Argon2idParams{}, nil, nil, err
+	}
+	key, err := argon2idEncoding.DecodeString(parts[5])
+	if err != nil {
+		return Argon2idParams{}, nil, nil, err
+	}
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+
+	return params, salt, key, nil
+}
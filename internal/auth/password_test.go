@@ -0,0 +1,129 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var testArgon2idParams = Argon2idParams{
+	Memory:      1024,
+	Iterations:  1,
+	Parallelism: 1,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+func TestArgon2idHasherHash(t *testing.T) {
+	t.Run("should correct hash password", func(t *testing.T) {
+		hasher := NewArgon2idHasher(testArgon2idParams)
+		password := "secretPassword"
+		hashedPassword, err := hasher.Hash(password)
+
+		require.NoError(t, err, "Error hashing password")
+		assert.True(t, strings.HasPrefix(hashedPassword, "$argon2id$v=19$m=1024,t=1,p=1$"),
+			"Hashed password should be in argon2id encoded format")
+		assert.True(t, hasher.Verify(password, hashedPassword), "Expected hashed password to match origin password")
+		assert.False(t, hasher.NeedsRehash(hashedPassword), "Hash with current parameters should not need rehash")
+	})
+
+	t.Run("should not exceed 72 bytes limit like bcrypt", func(t *testing.T) {
+		hasher := NewArgon2idHasher(testArgon2idParams)
+		password := strings.Repeat("a", 72)
+		hashedPassword, err := hasher.Hash(password + "b")
+
+		require.NoError(t, err, "Error hashing password")
+		assert.False(t, hasher.Verify(password+"c", hashedPassword), "Password bytes after 72 should not be ignored")
+	})
+
+	t.Run("should return error when hashing password is empty", func(t *testing.T) {
+		_, err := NewArgon2idHasher(testArgon2idParams).Hash("")
+
+		assert.Error(t, err, "Expected error when hashing password is empty")
+	})
+}
+
+func TestArgon2idHasherNeedsRehash(t *testing.T) {
+	hashedPassword, err := NewArgon2idHasher(testArgon2idParams).Hash("secretPassword")
+	require.NoError(t, err, "Error hashing password")
+
+	params := testArgon2idParams
+	params.Iterations = 2
+
+	assert.True(t, NewArgon2idHasher(params).NeedsRehash(hashedPassword),
+		"Hash with outdated parameters should need rehash")
+	assert.True(t, NewArgon2idHasher(params).Verify("secretPassword", hashedPassword),
+		"Hash with outdated parameters should still be verified")
+}
+
+func TestBcryptHasherHash(t *testing.T) {
+	t.Run("should correct hash password", func(t *testing.T) {
+		password := "secretPassword"
+		hashedPassword, err := NewBcryptHasher(bcrypt.MinCost).Hash(password)
+
+		require.NoError(t, err, "Error hashing password")
+		err = bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
+		assert.NoError(t, err, "Expected hashed password to match origin password")
+	})
+
+	t.Run("should return error when hashing password is empty", func(t *testing.T) {
+		_, err := NewBcryptHasher(bcrypt.MinCost).Hash("")
+
+		assert.Error(t, err, "Expected error when hashing password is empty")
+	})
+}
+
+func TestMultiPasswordHasherVerify(t *testing.T) {
+	password := "secretPassword"
+	bcryptHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	require.NoError(t, err, "Error hashing password")
+	hasher := NewPasswordHasher(NewArgon2idHasher(testArgon2idParams), NewBcryptHasher(bcrypt.MinCost))
+	argon2idHash, err := hasher.Hash(password)
+	require.NoError(t, err, "Error hashing password")
+
+	tests := []struct {
+		name            string
+		password        string
+		hash            string
+		want            bool
+		wantNeedsRehash bool
+	}{
+		{
+			name:     "should return true when password matches argon2id hash",
+			password: password,
+			hash:     argon2idHash,
+			want:     true,
+		},
+		{
+			name:            "should return true and require rehash when password matches legacy bcrypt hash",
+			password:        password,
+			hash:            string(bcryptHash),
+			want:            true,
+			wantNeedsRehash: true,
+		},
+		{
+			name:            "should return false when password is invalid",
+			password:        "wrongPassword",
+			hash:            string(bcryptHash),
+			want:            false,
+			wantNeedsRehash: true,
+		},
+		{
+			name:            "should return false when hash format is unknown",
+			password:        password,
+			hash:            "plain",
+			want:            false,
+			wantNeedsRehash: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, hasher.Verify(tt.password, tt.hash), "Verify result does not match expected")
+			assert.Equal(t, tt.wantNeedsRehash, hasher.NeedsRehash(tt.hash), "NeedsRehash result does not match expected")
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package auth
+
+import (
+	"encoding/base32"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var rfcTestSecret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+func TestGenerateTotpCode(t *testing.T) {
+	tests := []struct {
+		name string
+		time time.Time
+		want string
+	}{
+		{
+			name: "should generate code for RFC 6238 time 59",
+			time: time.Unix(59, 0),
+			want: "287082",
+		},
+		{
+			name: "should generate code for RFC 6238 time 1111111109",
+			time: time.Unix(1111111109, 0),
+			want: "081804",
+		},
+		{
+			name: "should generate code for RFC 6238 time 2000000000",
+			time: time.Unix(2000000000, 0),
+			want: "279037",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GenerateTotpCode(rfcTestSecret, GetTotpStep(tt.time))
+			require.NoError(t, err, "Error generating totp code")
+			assert.Equal(t, tt.want, got, "Generated totp code does not match expected")
+		})
+	}
+
+	t.Run("should return error when secret is invalid", func(t *testing.T) {
+		_, err := GenerateTotpCode("invalid secret!", 1)
+		assert.Error(t, err, "Expected error when totp secret is invalid")
+	})
+}
+
+func TestValidateTotpCode(t *testing.T) {
+	now := time.Unix(1111111109, 0)
+
+	tests := []struct {
+		name     string
+		code     string
+		wantStep int64
+		wantOk   bool
+	}{
+		{
+			name:     "should accept code for current time step",
+			code:     "081804",
+			wantStep: GetTotpStep(now),
+			wantOk:   true,
+		},
+		{
+			name:     "should accept code for previous time step",
+			code:     mustGenerateTotpCode(t, GetTotpStep(now)-1),
+			wantStep: GetTotpStep(now) - 1,
+			wantOk:   true,
+		},
+		{
+			name:   "should reject code outside of allowed time skew",
+			code:   mustGenerateTotpCode(t, GetTotpStep(now)-2),
+			wantOk: false,
+		},
+		{
+			name:   "should reject code with invalid length",
+			code:   "81804",
+			wantOk: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := ValidateTotpCode(rfcTestSecret, tt.code, now)
+			assert.Equal(t, tt.wantOk, ok, "ValidateTotpCode(%s) = %v, want %v", tt.code, ok, tt.wantOk)
+			if tt.wantOk {
+				assert.Equal(t, tt.wantStep, step, "Matched time step does not match expected")
+			}
+		})
+	}
+}
+
+func TestGetTotpURI(t *testing.T) {
+	uri := GetTotpURI("Gophermart", "user42", rfcTestSecret)
+
+	parsed, err := url.Parse(uri)
+	require.NoError(t, err, "Error parsing otpauth uri")
+
+	assert.Equal(t, "otpauth", parsed.Scheme, "Uri scheme does not match expected")
+	assert.Equal(t, "totp", parsed.Host, "Uri type does not match expected")
+	assert.Equal(t, "/Gophermart:user42", parsed.Path, "Uri label does not match expected")
+	assert.Equal(t, rfcTestSecret, parsed.Query().Get("secret"), "Uri secret does not match expected")
+	assert.Equal(t, "Gophermart", parsed.Query().Get("issuer"), "Uri issuer does not match expected")
+}
+
+func TestGenerateRecoveryCodes(t *testing.T) {
+	codes, err := GenerateRecoveryCodes(10)
+	require.NoError(t, err, "Error generating recovery codes")
+	require.Len(t, codes, 10, "Recovery codes count does not match expected")
+
+	unique := make(map[string]bool)
+	for _, code := range codes {
+		assert.Regexp(t, `^[a-z2-7]{5}-[a-z2-7]{5}$`, code, "Recovery code format does not match expected")
+		unique[code] = true
+	}
+	assert.Len(t, unique, 10, "Recovery codes should be unique")
+}
+
+func TestGetRecoveryCodeHash(t *testing.T) {
+	hash := GetRecoveryCodeHash("abcde-fghij")
+
+	assert.Len(t, hash, 64, "Recovery code hash length does not match expected")
+	assert.Equal(t, hash, GetRecoveryCodeHash("ABCDE FGHIJ"), "Recovery code hash should ignore case and separators")
+	assert.NotEqual(t, hash, GetRecoveryCodeHash("abcde-fghik"), "Different recovery codes should have different hashes")
+}
+
+func mustGenerateTotpCode(t *testing.T, step int64) string {
+	code, err := GenerateTotpCode(rfcTestSecret, step)
+	require.NoError(t, err, "Error generating totp code")
+	return code
+}
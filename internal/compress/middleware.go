@@ -8,11 +8,12 @@ import (
 	"github.com/Stern-Ritter/gophermart/internal/utils"
 )
 
-var compressedContentTypes = []string{"application/json", "text/plain", "text/html"}
+var compressedContentTypes = []string{"application/json", "application/problem+json", "text/plain", "text/html"}
 
 type compressWriter struct {
-	w  http.ResponseWriter
-	zw *gzip.Writer
+	w          http.ResponseWriter
+	zw         *gzip.Writer
+	compressed bool
 }
 
 func newCompressWriter(w http.ResponseWriter) *compressWriter {
@@ -31,6 +32,7 @@ func (c *compressWriter) Write(body []byte) (int, error) {
 	needCompress := utils.Contains(contentType, compressedContentTypes...)
 	if needCompress {
 		c.w.Header().Set("Content-Encoding", "gzip")
+		c.compressed = true
 		return c.zw.Write(body)
 	}
 	return c.w.Write(body)
@@ -40,7 +42,19 @@ func (c *compressWriter) WriteHeader(statusCode int) {
 	c.w.WriteHeader(statusCode)
 }
 
+func (c *compressWriter) Flush() {
+	if c.compressed {
+		c.zw.Flush() //nolint:errcheck
+	}
+	if flusher, ok := c.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
 func (c *compressWriter) Close() error {
+	if !c.compressed {
+		return nil
+	}
 	return c.zw.Close()
 }
 
@@ -122,3 +122,25 @@ func TestGzipMiddleware(t *testing.T) {
 		})
 	}
 }
+
+func TestGzipMiddlewareWithEventStream(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, err := w.Write([]byte("data: 42\n\n"))
+		require.NoError(t, err, "Error writing response body")
+
+		flusher, ok := w.(http.Flusher)
+		require.True(t, ok, "Response writer should support flushing")
+		flusher.Flush()
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+
+	GzipMiddleware(handler).ServeHTTP(w, req)
+
+	assert.True(t, w.Flushed, "Response should be flushed")
+	assert.NotContains(t, w.Header().Get("Content-Encoding"), "gzip", "Unexpected gzipped response body")
+	assert.Equal(t, "data: 42\n\n", w.Body.String(), "Response body should not contain gzip trailer")
+}
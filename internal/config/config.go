@@ -1,17 +1,51 @@
 package config
 
+import "time"
+
 type ProcessAccrualsConfig struct {
-	ProcessAccrualsBatchMaxSize   int `env:"PROCESS_ACCRUALS_BATCH_MAX_SIZE"`
-	ProcessAccrualsBufferSize     int `env:"PROCESS_ACCRUALS_BUFFER_SIZE"`
-	ProcessAccrualsWorkerPoolSize int `env:"PROCESS_ACCRUALS_WORKER_POOL_SIZE"`
-	GetNewAccrualsInterval        int `env:"GET_NEW_ACCRUALS_INTERVAL"`
+	ProcessAccrualsBatchMaxSize   int           `env:"PROCESS_ACCRUALS_BATCH_MAX_SIZE" yaml:"batch_max_size"`
+	ProcessAccrualsBufferSize     int           `env:"PROCESS_ACCRUALS_BUFFER_SIZE" yaml:"buffer_size"`
+	ProcessAccrualsWorkerPoolSize int           `env:"PROCESS_ACCRUALS_WORKER_POOL_SIZE" yaml:"worker_pool_size"`
+	GetNewAccrualsInterval        time.Duration `env:"GET_NEW_ACCRUALS_INTERVAL" yaml:"get_new_accruals_interval"`
+}
+
+type PasswordConfig struct {
+	Argon2Memory      int    `env:"PASSWORD_ARGON2_MEMORY" yaml:"argon2_memory"`
+	Argon2Iterations  int    `env:"PASSWORD_ARGON2_ITERATIONS" yaml:"argon2_iterations"`
+	Argon2Parallelism int    `env:"PASSWORD_ARGON2_PARALLELISM" yaml:"argon2_parallelism"`
+	MinCharClasses    int    `env:"PASSWORD_MIN_CHAR_CLASSES" yaml:"min_char_classes"`
+	BreachedListFile  string `env:"PASSWORD_BREACHED_LIST_FILE" yaml:"breached_list_file"`
+}
+
+type ApiVersionConfig struct {
+	V1DeprecatedAt string `env:"API_V1_DEPRECATED_AT" yaml:"v1_deprecated_at"`
+	V1SunsetAt     string `env:"API_V1_SUNSET_AT" yaml:"v1_sunset_at"`
+}
+
+type TracingConfig struct {
+	Exporter     string `env:"TRACING_EXPORTER" yaml:"exporter"`
+	OtlpEndpoint string `env:"TRACING_OTLP_ENDPOINT" yaml:"otlp_endpoint"`
+}
+
+type ReadinessConfig struct {
+	SchedulerHeartbeatMaxAge time.Duration `env:"READINESS_SCHEDULER_HEARTBEAT_MAX_AGE" yaml:"scheduler_heartbeat_max_age"`
+	CheckAccrualSystem       bool          `env:"READINESS_CHECK_ACCRUAL_SYSTEM" yaml:"check_accrual_system"`
 }
 
 type ServerConfig struct {
-	URL                   string `env:"RUN_ADDRESS"`
-	DatabaseURL           string `env:"DATABASE_URI"`
-	AccrualSystemURL      string `env:"ACCRUAL_SYSTEM_ADDRESS"`
-	JwtSecretKey          string `env:"JWT_SECRET_KEY"`
-	ProcessAccrualsConfig ProcessAccrualsConfig
-	LoggerLvl             string
+	URL                   string                `env:"RUN_ADDRESS" yaml:"run_address"`
+	GrpcURL               string                `env:"GRPC_RUN_ADDRESS" yaml:"grpc_run_address"`
+	MetricsURL            string                `env:"METRICS_RUN_ADDRESS" yaml:"metrics_run_address"`
+	DatabaseURL           string                `env:"DATABASE_URI" yaml:"database_uri"`
+	MigrateOnStart        bool                  `env:"MIGRATE_ON_START" yaml:"migrate_on_start"`
+	AccrualSystemURL      string                `env:"ACCRUAL_SYSTEM_ADDRESS" yaml:"accrual_system_address"`
+	JwtSecretKey          string                `env:"JWT_SECRET_KEY" yaml:"jwt_secret_key"`
+	ProcessAccrualsConfig ProcessAccrualsConfig `yaml:"process_accruals"`
+	PasswordConfig        PasswordConfig        `yaml:"password"`
+	ApiVersionConfig      ApiVersionConfig      `yaml:"api_version"`
+	TracingConfig         TracingConfig         `yaml:"tracing"`
+	ReadinessConfig       ReadinessConfig       `yaml:"readiness"`
+	LoggerLvl             string                `env:"LOG_LEVEL" yaml:"log_level"`
+	ConfigFile            string                `env:"CONFIG_FILE" yaml:"-"`
+	PrintConfig           bool                  `yaml:"-"`
 }
@@ -0,0 +1,110 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+const redacted = "REDACTED"
+
+// dsnPassword matches the password of a keyword/value connection string, quoted or not.
+var dsnPassword = regexp.MustCompile(`(?i)(\bpassword\s*=\s*)('(?:[^'\\]|\\.)*'|\S+)`)
+
+// Validate reports every invalid setting at once, using the config file keys to name them.
+func (c ServerConfig) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(c.URL != "", "run_address must not be empty")
+	check(c.DatabaseURL != "", "database_uri must not be empty")
+	check(c.JwtSecretKey != "", "jwt_secret_key must not be empty")
+	_, err := zapcore.ParseLevel(c.LoggerLvl)
+	check(err == nil, "log_level must be one of debug, info, warn, error, dpanic, panic, fatal, got %q", c.LoggerLvl)
+
+	p := c.ProcessAccrualsConfig
+	check(p.ProcessAccrualsBatchMaxSize > 0, "process_accruals.batch_max_size must be greater than zero, got %d",
+		p.ProcessAccrualsBatchMaxSize)
+	check(p.ProcessAccrualsBufferSize >= 0, "process_accruals.buffer_size must not be negative, got %d",
+		p.ProcessAccrualsBufferSize)
+	check(p.ProcessAccrualsWorkerPoolSize > 0, "process_accruals.worker_pool_size must be greater than zero, got %d",
+		p.ProcessAccrualsWorkerPoolSize)
+	check(p.GetNewAccrualsInterval > 0, "process_accruals.get_new_accruals_interval must be greater than zero, got %s",
+		p.GetNewAccrualsInterval)
+
+	pw := c.PasswordConfig
+	check(pw.Argon2Memory > 0, "password.argon2_memory must be greater than zero, got %d", pw.Argon2Memory)
+	check(pw.Argon2Iterations > 0, "password.argon2_iterations must be greater than zero, got %d", pw.Argon2Iterations)
+	check(pw.Argon2Parallelism > 0 && pw.Argon2Parallelism <= 255,
+		"password.argon2_parallelism must be between 1 and 255, got %d", pw.Argon2Parallelism)
+	check(pw.MinCharClasses >= 1 && pw.MinCharClasses <= 4,
+		"password.min_char_classes must be between 1 and 4, got %d", pw.MinCharClasses)
+
+	for _, date := range []struct{ key, value string }{
+		{"api_version.v1_deprecated_at", c.ApiVersionConfig.V1DeprecatedAt},
+		{"api_version.v1_sunset_at", c.ApiVersionConfig.V1SunsetAt},
+	} {
+		if date.value != "" {
+			_, err := time.Parse(time.RFC3339, date.value)
+			check(err == nil, "%s must be a date in RFC3339 format, got %q", date.key, date.value)
+		}
+	}
+
+	switch c.TracingConfig.Exporter {
+	case "", "otlp", "stdout":
+	default:
+		check(false, "tracing.exporter must be one of otlp, stdout or empty, got %q", c.TracingConfig.Exporter)
+	}
+
+	check(c.ReadinessConfig.SchedulerHeartbeatMaxAge > 0,
+		"readiness.scheduler_heartbeat_max_age must be greater than zero, got %s",
+		c.ReadinessConfig.SchedulerHeartbeatMaxAge)
+
+	return errors.Join(errs...)
+}
+
+// Redacted returns a copy of the config that is safe to print: the jwt secret and the database password are masked.
+func (c ServerConfig) Redacted() ServerConfig {
+	if c.JwtSecretKey != "" {
+		c.JwtSecretKey = redacted
+	}
+	c.DatabaseURL = redactDatabaseURL(c.DatabaseURL)
+	return c
+}
+
+// redactDatabaseURL masks the password of a postgres URL or of a keyword/value connection string.
+func redactDatabaseURL(dsn string) string {
+	u, err := url.Parse(dsn)
+	if err != nil || u.Scheme == "" {
+		return dsnPassword.ReplaceAllString(dsn, "${1}"+redacted)
+	}
+	if _, ok := u.User.Password(); ok {
+		u.User = url.UserPassword(u.User.Username(), redacted)
+	}
+	if q := u.Query(); q.Has("password") {
+		q.Set("password", redacted)
+		u.RawQuery = q.Encode()
+	}
+	return u.String()
+}
+
+// RequiresRestart reports whether next changes settings other than the log level and the accruals scheduler
+// batch and worker pool sizes, which are applied on reload.
+func RequiresRestart(current ServerConfig, next ServerConfig) bool {
+	return withoutReloadable(current) != withoutReloadable(next)
+}
+
+func withoutReloadable(c ServerConfig) ServerConfig {
+	c.LoggerLvl = ""
+	c.ProcessAccrualsConfig.ProcessAccrualsBatchMaxSize = 0
+	c.ProcessAccrualsConfig.ProcessAccrualsWorkerPoolSize = 0
+	return c
+}
@@ -0,0 +1,28 @@
+package errors
+
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+type ValidationError struct {
+	message string
+	fields  []FieldError
+	err     error
+}
+
+func (e ValidationError) Error() string {
+	return e.message
+}
+
+func (e ValidationError) Unwrap() error {
+	return e.err
+}
+
+func (e ValidationError) Fields() []FieldError {
+	return e.fields
+}
+
+func NewValidationError(message string, fields []FieldError, err error) error {
+	return ValidationError{message: message, fields: fields, err: err}
+}
@@ -0,0 +1,87 @@
+package logger
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+)
+
+const (
+	RequestIDHeader      = "X-Request-ID"
+	requestIDMetadataKey = "x-request-id"
+	maxRequestIDLength   = 128
+)
+
+type correlationContextKey struct{}
+
+// correlation is shared by pointer between a request context and all contexts derived from it,
+// so a user id resolved deep inside a handler is visible to the request log written by the middleware.
+type correlation struct {
+	requestID string
+	userID    atomic.Int64
+}
+
+// WithRequestID returns a context whose logs are correlated by requestID.
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, correlationContextKey{}, &correlation{requestID: requestID})
+}
+
+// SetUserID attaches the authenticated user to the request correlated by ctx. It is a no-op without a request id.
+func SetUserID(ctx context.Context, userID int64) {
+	if c, ok := ctx.Value(correlationContextKey{}).(*correlation); ok {
+		c.userID.Store(userID)
+	}
+}
+
+func RequestIDFromContext(ctx context.Context) string {
+	if c, ok := ctx.Value(correlationContextKey{}).(*correlation); ok {
+		return c.requestID
+	}
+	return ""
+}
+
+// WithContext returns a logger that adds the request id and user id stored in ctx to every entry.
+func (logger *ServerLogger) WithContext(ctx context.Context) *ServerLogger {
+	c, ok := ctx.Value(correlationContextKey{}).(*correlation)
+	if !ok {
+		return logger
+	}
+
+	fields := []zap.Field{zap.String("request_id", c.requestID)}
+	if userID := c.userID.Load(); userID != 0 {
+		fields = append(fields, zap.Int64("user_id", userID))
+	}
+	return &ServerLogger{Logger: logger.With(fields...), level: logger.level}
+}
+
+func NewRequestID() string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(buf)
+}
+
+// isValidRequestID accepts client supplied ids of reasonable length made of printable ASCII characters only,
+// so that they can't be used to inject content into logs or response headers.
+func isValidRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < '!' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+func requestIDOrNew(id string) string {
+	if isValidRequestID(id) {
+		return id
+	}
+	return NewRequestID()
+}
@@ -0,0 +1,85 @@
+package logger
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestRequestIDMiddleware(t *testing.T) {
+	tests := []struct {
+		name              string
+		requestID         string
+		expectedRequestID string
+	}{
+		{
+			name:              "should reuse incoming request id",
+			requestID:         "3f2a9c1e-request",
+			expectedRequestID: "3f2a9c1e-request",
+		},
+		{
+			name: "should generate request id when header is missing",
+		},
+		{
+			name:      "should generate request id when incoming one contains control characters",
+			requestID: "bad\tid",
+		},
+		{
+			name:      "should generate request id when incoming one is too long",
+			requestID: strings.Repeat("a", maxRequestIDLength+1),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var contextRequestID string
+			handler := RequestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				contextRequestID = RequestIDFromContext(r.Context())
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/api/user/balance", nil)
+			if tt.requestID != "" {
+				req.Header.Set(RequestIDHeader, tt.requestID)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			resp := w.Result()
+			defer resp.Body.Close()
+
+			responseRequestID := resp.Header.Get(RequestIDHeader)
+			if tt.expectedRequestID != "" {
+				assert.Equal(t, tt.expectedRequestID, responseRequestID, "Response request id does not match incoming one")
+			} else {
+				assert.Len(t, responseRequestID, 32, "Response should contain generated request id")
+			}
+			assert.Equal(t, responseRequestID, contextRequestID, "Context request id does not match response header")
+		})
+	}
+}
+
+func TestWithContext(t *testing.T) {
+	core, logs := observer.New(zap.DebugLevel)
+	l := &ServerLogger{Logger: zap.New(core)}
+
+	ctx := WithRequestID(context.Background(), "request-1")
+	l.WithContext(ctx).Info("before authentication")
+	SetUserID(ctx, 42)
+	l.WithContext(ctx).Info("after authentication")
+	l.WithContext(context.Background()).Info("without request")
+
+	entries := logs.All()
+	require.Len(t, entries, 3, "Logger should write all entries")
+	assert.Equal(t, map[string]interface{}{"request_id": "request-1"}, entries[0].ContextMap(),
+		"Entry before authentication should contain only request id")
+	assert.Equal(t, map[string]interface{}{"request_id": "request-1", "user_id": int64(42)}, entries[1].ContextMap(),
+		"Entry after authentication should contain request id and user id")
+	assert.Empty(t, entries[2].ContextMap(), "Entry without request should not contain correlation fields")
+}
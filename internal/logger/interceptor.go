@@ -0,0 +1,45 @@
+package logger
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// RequestIDInterceptor is the grpc counterpart of RequestIDMiddleware, it reads and returns the x-request-id metadata.
+func RequestIDInterceptor(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler) (interface{}, error) {
+	var requestID string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(requestIDMetadataKey); len(values) > 0 {
+			requestID = values[0]
+		}
+	}
+	requestID = requestIDOrNew(requestID)
+
+	grpc.SetHeader(ctx, metadata.Pairs(requestIDMetadataKey, requestID)) //nolint:errcheck
+	return handler(WithRequestID(ctx, requestID), req)
+}
+
+func (logger *ServerLogger) LoggerInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler) (interface{}, error) {
+	start := time.Now()
+
+	resp, err := handler(ctx, req)
+
+	duration := time.Since(start)
+
+	logger.WithContext(ctx).Info(
+		"Request received: ",
+		zap.String("event", "grpc request"),
+		zap.String("method", info.FullMethod),
+		zap.Duration("duration", duration),
+		zap.String("code", status.Code(err).String()),
+	)
+
+	return resp, err
+}
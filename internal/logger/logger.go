@@ -6,6 +6,7 @@ import (
 
 type ServerLogger struct {
 	*zap.Logger
+	level zap.AtomicLevel
 }
 
 func Initialize(level string) (*ServerLogger, error) {
@@ -22,5 +23,10 @@ func Initialize(level string) (*ServerLogger, error) {
 		return nil, err
 	}
 
-	return &ServerLogger{logger}, nil
+	return &ServerLogger{Logger: logger, level: lvl}, nil
+}
+
+// SetLevel changes the level of the logger and of all loggers derived from it.
+func (logger *ServerLogger) SetLevel(level string) error {
+	return logger.level.UnmarshalText([]byte(level))
 }
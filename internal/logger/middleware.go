@@ -28,6 +28,22 @@ func (r *loggingResponseWriter) WriteHeader(statusCode int) {
 	r.responseData.status = statusCode
 }
 
+func (r *loggingResponseWriter) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// RequestIDMiddleware reuses the incoming X-Request-ID header or generates a new id, echoes it in the response
+// and stores it in the request context for WithContext.
+func RequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := requestIDOrNew(r.Header.Get(RequestIDHeader))
+		w.Header().Set(RequestIDHeader, requestID)
+		next.ServeHTTP(w, r.WithContext(WithRequestID(r.Context(), requestID)))
+	})
+}
+
 func (logger *ServerLogger) LoggerMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
@@ -46,7 +62,7 @@ func (logger *ServerLogger) LoggerMiddleware(next http.Handler) http.Handler {
 
 		duration := time.Since(start)
 
-		logger.Info(
+		logger.WithContext(r.Context()).Info(
 			"Request received: ",
 			zap.String("event", "request"),
 			zap.String("uri", r.RequestURI),
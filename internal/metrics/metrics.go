@@ -0,0 +1,98 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "gophermart"
+
+var Registry = prometheus.NewRegistry()
+
+var (
+	HTTPRequestsTotal = promauto.With(Registry).NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "Number of HTTP requests by route pattern, method and status code.",
+	}, []string{"method", "route", "status"})
+
+	HTTPRequestDuration = promauto.With(Registry).NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "HTTP request latency by route pattern, method and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	AccrualQueueDepth = promauto.With(Registry).NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "accrual",
+		Name:      "queue_depth",
+		Help:      "Number of order batches waiting in the processing channel.",
+	})
+
+	AccrualBusyWorkers = promauto.With(Registry).NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "accrual",
+		Name:      "busy_workers",
+		Help:      "Number of workers currently processing an order batch.",
+	})
+
+	Orders = promauto.With(Registry).NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "orders",
+		Help:      "Number of uploaded orders by accrual status.",
+	}, []string{"status"})
+
+	AccrualSystemRequestsTotal = promauto.With(Registry).NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "accrual_system",
+		Name:      "requests_total",
+		Help:      "Number of requests to the accrual system by outcome.",
+	}, []string{"outcome"})
+
+	AccrualSystemRequestDuration = promauto.With(Registry).NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "accrual_system",
+		Name:      "request_duration_seconds",
+		Help:      "Accrual system request latency by outcome.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"outcome"})
+
+	PointsAccruedTotal = promauto.With(Registry).NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "points_accrued_total",
+		Help:      "Loyalty points accrued for processed orders.",
+	})
+
+	PointsWithdrawnTotal = promauto.With(Registry).NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "points_withdrawn_total",
+		Help:      "Loyalty points withdrawn by users.",
+	})
+)
+
+const (
+	AccrualSystemOutcomeOK               = "ok"
+	AccrualSystemOutcomeNotRegistered    = "not_registered"
+	AccrualSystemOutcomeRateLimited      = "rate_limited"
+	AccrualSystemOutcomeServerError      = "server_error"
+	AccrualSystemOutcomeUnexpectedStatus = "unexpected_status"
+	AccrualSystemOutcomeError            = "error"
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
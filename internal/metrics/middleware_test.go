@@ -0,0 +1,72 @@
+package metrics
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMiddleware(t *testing.T) {
+	tests := []struct {
+		name          string
+		url           string
+		expectedRoute string
+		expectedCode  string
+	}{
+		{
+			name:          "should label request with route pattern instead of path",
+			url:           "/orders/12345678903",
+			expectedRoute: "/orders/{number}",
+			expectedCode:  "202",
+		},
+		{
+			name:          "should label request to unknown route with placeholder",
+			url:           "/unknown/12345678903",
+			expectedRoute: unknownRoute,
+			expectedCode:  "404",
+		},
+	}
+
+	r := chi.NewRouter()
+	r.Use(Middleware)
+	r.Get("/orders/{number}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			counter := HTTPRequestsTotal.WithLabelValues(http.MethodGet, tt.expectedRoute, tt.expectedCode)
+			before := testutil.ToFloat64(counter)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.url, nil))
+
+			assert.Equal(t, before+1, testutil.ToFloat64(counter), "Request counter should be incremented")
+		})
+	}
+}
+
+func TestHandler(t *testing.T) {
+	PointsWithdrawnTotal.Add(0)
+
+	w := httptest.NewRecorder()
+	Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	resp := w.Result()
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err, "Error reading response body")
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "Response status code does not match expected status")
+	assert.True(t, strings.Contains(string(body), "gophermart_points_withdrawn_total"),
+		"Metrics should contain business counters")
+	assert.True(t, strings.Contains(string(body), "go_goroutines"), "Metrics should contain runtime metrics")
+}
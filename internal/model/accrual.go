@@ -1,6 +1,8 @@
 package model
 
 import (
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/Stern-Ritter/gophermart/internal/utils"
@@ -24,6 +26,41 @@ type Accrual struct {
 	ProcessedAt  time.Time
 }
 
+type AccrualBatchItemStatus string
+
+const (
+	AccrualBatchItemAccepted        AccrualBatchItemStatus = "ACCEPTED"
+	AccrualBatchItemAlreadyUploaded AccrualBatchItemStatus = "ALREADY_UPLOADED"
+	AccrualBatchItemConflict        AccrualBatchItemStatus = "CONFLICT"
+	AccrualBatchItemInvalid         AccrualBatchItemStatus = "INVALID"
+)
+
+type AccrualBatchItemResult struct {
+	OrderNumber int64
+	Status      AccrualBatchItemStatus
+}
+
+type AccrualBatchItemResultDto struct {
+	OrderNumber string                 `json:"number"`
+	Status      AccrualBatchItemStatus `json:"status"`
+	Error       string                 `json:"error,omitempty"`
+}
+
+type AccrualsFilter struct {
+	UserID        int64
+	Statuses      []AccrualStatus
+	UploadedFrom  time.Time
+	UploadedTo    time.Time
+	After         *Cursor
+	SortDirection SortDirection
+	Limit         int64
+}
+
+type AccrualsPage struct {
+	Accruals   []Accrual
+	NextCursor *Cursor
+}
+
 type AccrualDto struct {
 	OrderNumber  string        `json:"number"`
 	Status       AccrualStatus `json:"status"`
@@ -31,6 +68,22 @@ type AccrualDto struct {
 	UploadedAt   Time          `json:"uploaded_at"`
 }
 
+type AccrualV2Dto struct {
+	OrderNumber  string        `json:"number"`
+	Status       AccrualStatus `json:"status"`
+	PointsAmount Amount        `json:"accrual,omitempty"`
+	UploadedAt   Time          `json:"uploaded_at"`
+}
+
+type AccrualDetailsDto struct {
+	UserID       int64         `json:"user_id"`
+	OrderNumber  string        `json:"number"`
+	Status       AccrualStatus `json:"status"`
+	PointsAmount float64       `json:"accrual,omitempty"`
+	UploadedAt   Time          `json:"uploaded_at"`
+	ProcessedAt  *Time         `json:"processed_at,omitempty"`
+}
+
 type AccrualProcessStatus string
 
 const (
@@ -46,6 +99,15 @@ type AccrualProcessDto struct {
 	Accrual     float64              `json:"accrual"`
 }
 
+func ParseAccrualStatus(value string) (AccrualStatus, error) {
+	status := AccrualStatus(strings.ToUpper(value))
+	switch status {
+	case AccrualNew, AccrualProcessing, AccrualInvalid, AccrualProcessed:
+		return status, nil
+	}
+	return "", fmt.Errorf("status should be one of: NEW, PROCESSING, INVALID, PROCESSED")
+}
+
 func NewAccrual(userID int64, orderNumber int64) Accrual {
 	return Accrual{
 		UserID:       userID,
@@ -73,6 +135,37 @@ func ToAccrualsDto(accruals []Accrual) []AccrualDto {
 	return accrualsResponse
 }
 
+func ToAccrualV2Dto(accrual Accrual) AccrualV2Dto {
+	return AccrualV2Dto{
+		OrderNumber:  utils.FormatOrderNumber(accrual.OrderNumber),
+		Status:       accrual.Status,
+		PointsAmount: Amount(accrual.PointsAmount),
+		UploadedAt:   Time{accrual.UploadedAt},
+	}
+}
+
+func ToAccrualsV2Dto(accruals []Accrual) []AccrualV2Dto {
+	accrualsResponse := make([]AccrualV2Dto, len(accruals))
+	for i, accrual := range accruals {
+		accrualsResponse[i] = ToAccrualV2Dto(accrual)
+	}
+	return accrualsResponse
+}
+
+func ToAccrualDetailsDto(accrual Accrual) AccrualDetailsDto {
+	dto := AccrualDetailsDto{
+		UserID:       accrual.UserID,
+		OrderNumber:  utils.FormatOrderNumber(accrual.OrderNumber),
+		Status:       accrual.Status,
+		PointsAmount: accrual.PointsAmount,
+		UploadedAt:   Time{accrual.UploadedAt},
+	}
+	if !accrual.ProcessedAt.IsZero() {
+		dto.ProcessedAt = &Time{accrual.ProcessedAt}
+	}
+	return dto
+}
+
 func UpdateAccrualFormAccrualProcessDto(accrual Accrual, dto AccrualProcessDto) Accrual {
 	return Accrual{
 		UserID:       accrual.UserID,
@@ -0,0 +1,54 @@
+package model
+
+import (
+	"time"
+
+	"github.com/Stern-Ritter/gophermart/internal/utils"
+)
+
+type AccrualEvent struct {
+	ID           int64
+	UserID       int64
+	OrderNumber  int64
+	Status       AccrualStatus
+	PointsAmount float64
+	ProcessedAt  time.Time
+}
+
+type AccrualEventDto struct {
+	OrderNumber  string        `json:"number"`
+	Status       AccrualStatus `json:"status"`
+	PointsAmount float64       `json:"accrual,omitempty"`
+	ProcessedAt  *Time         `json:"processed_at,omitempty"`
+}
+
+type AccrualEventV2Dto struct {
+	OrderNumber  string        `json:"number"`
+	Status       AccrualStatus `json:"status"`
+	PointsAmount Amount        `json:"accrual,omitempty"`
+	ProcessedAt  *Time         `json:"processed_at,omitempty"`
+}
+
+func ToAccrualEventDto(event AccrualEvent) AccrualEventDto {
+	dto := AccrualEventDto{
+		OrderNumber:  utils.FormatOrderNumber(event.OrderNumber),
+		Status:       event.Status,
+		PointsAmount: event.PointsAmount,
+	}
+	if !event.ProcessedAt.IsZero() {
+		dto.ProcessedAt = &Time{event.ProcessedAt}
+	}
+	return dto
+}
+
+func ToAccrualEventV2Dto(event AccrualEvent) AccrualEventV2Dto {
+	dto := AccrualEventV2Dto{
+		OrderNumber:  utils.FormatOrderNumber(event.OrderNumber),
+		Status:       event.Status,
+		PointsAmount: Amount(event.PointsAmount),
+	}
+	if !event.ProcessedAt.IsZero() {
+		dto.ProcessedAt = &Time{event.ProcessedAt}
+	}
+	return dto
+}
@@ -0,0 +1,79 @@
+package model
+
+import (
+	"time"
+
+	"github.com/go-playground/validator/v10"
+
+	v "github.com/Stern-Ritter/gophermart/internal/validator"
+)
+
+type AdjustmentReasonCode string
+
+const (
+	AdjustmentGoodwill        AdjustmentReasonCode = "GOODWILL"
+	AdjustmentCompensation    AdjustmentReasonCode = "COMPENSATION"
+	AdjustmentFraudCorrection AdjustmentReasonCode = "FRAUD_CORRECTION"
+	AdjustmentDataCorrection  AdjustmentReasonCode = "DATA_CORRECTION"
+)
+
+type Adjustment struct {
+	ID           int64
+	UserID       int64
+	AdminID      int64
+	PointsAmount float64
+	ReasonCode   AdjustmentReasonCode
+	Note         string
+	CreatedAt    time.Time
+}
+
+type CreateAdjustmentDto struct {
+	PointsAmount float64              `json:"amount" validate:"required" msg:"Amount should be non-zero number: positive to credit, negative to debit"`
+	ReasonCode   AdjustmentReasonCode `json:"reason_code" validate:"required,oneof=GOODWILL COMPENSATION FRAUD_CORRECTION DATA_CORRECTION" msg:"Reason code should be one of: GOODWILL, COMPENSATION, FRAUD_CORRECTION, DATA_CORRECTION"`
+	Note         string               `json:"note" validate:"required,max=1024" msg:"Note is required and should be at most 1024 characters."`
+}
+
+func (s *CreateAdjustmentDto) Validate(validate *validator.Validate) error {
+	return v.Validate[CreateAdjustmentDto](*s, validate)
+}
+
+type AdjustmentDto struct {
+	ID           int64                `json:"id"`
+	UserID       int64                `json:"user_id"`
+	AdminID      int64                `json:"admin_id"`
+	PointsAmount float64              `json:"amount"`
+	ReasonCode   AdjustmentReasonCode `json:"reason_code"`
+	Note         string               `json:"note"`
+	CreatedAt    Time                 `json:"created_at"`
+}
+
+func NewAdjustment(userID int64, adminID int64, dto CreateAdjustmentDto) Adjustment {
+	return Adjustment{
+		UserID:       userID,
+		AdminID:      adminID,
+		PointsAmount: dto.PointsAmount,
+		ReasonCode:   dto.ReasonCode,
+		Note:         dto.Note,
+		CreatedAt:    time.Now(),
+	}
+}
+
+func ToAdjustmentDto(adjustment Adjustment) AdjustmentDto {
+	return AdjustmentDto{
+		ID:           adjustment.ID,
+		UserID:       adjustment.UserID,
+		AdminID:      adjustment.AdminID,
+		PointsAmount: adjustment.PointsAmount,
+		ReasonCode:   adjustment.ReasonCode,
+		Note:         adjustment.Note,
+		CreatedAt:    Time{adjustment.CreatedAt},
+	}
+}
+
+func ToAdjustmentsDto(adjustments []Adjustment) []AdjustmentDto {
+	adjustmentsResponse := make([]AdjustmentDto, len(adjustments))
+	for i, adjustment := range adjustments {
+		adjustmentsResponse[i] = ToAdjustmentDto(adjustment)
+	}
+	return adjustmentsResponse
+}
@@ -0,0 +1,34 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+var amountPattern = regexp.MustCompile(`^\d+(\.\d{1,2})?$`)
+
+// Amount is a points amount represented in JSON as a decimal string with two fraction digits.
+type Amount float64
+
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + strconv.FormatFloat(float64(a), 'f', 2, 64) + `"`), nil
+}
+
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("amount should be a decimal string")
+	}
+	if !amountPattern.MatchString(value) {
+		return fmt.Errorf("amount should be a decimal string with at most two fraction digits")
+	}
+
+	amount, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return fmt.Errorf("amount should be a decimal string")
+	}
+	*a = Amount(amount)
+	return nil
+}
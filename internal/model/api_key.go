@@ -0,0 +1,95 @@
+package model
+
+import (
+	"time"
+
+	"github.com/go-playground/validator/v10"
+
+	v "github.com/Stern-Ritter/gophermart/internal/validator"
+)
+
+type ApiKeyScope string
+
+const (
+	ApiKeyScopeOrdersRead   ApiKeyScope = "orders:read"
+	ApiKeyScopeOrdersWrite  ApiKeyScope = "orders:write"
+	ApiKeyScopeBalanceRead  ApiKeyScope = "balance:read"
+	ApiKeyScopeBalanceWrite ApiKeyScope = "balance:write"
+)
+
+type ApiKey struct {
+	ID        int64
+	UserID    int64
+	Name      string
+	Prefix    string
+	KeyHash   string
+	Scopes    []ApiKeyScope
+	CreatedAt time.Time
+}
+
+type CreateApiKeyDto struct {
+	Name   string        `json:"name" validate:"required,max=64" msg:"Name is required and should be at most 64 characters."`
+	Scopes []ApiKeyScope `json:"scopes" validate:"required,min=1,unique,dive,oneof=orders:read orders:write balance:read balance:write" msg:"Scopes should be a non-empty list of unique values: orders:read, orders:write, balance:read, balance:write"`
+}
+
+func (s *CreateApiKeyDto) Validate(validate *validator.Validate) error {
+	return v.Validate[CreateApiKeyDto](*s, validate)
+}
+
+type ApiKeyDto struct {
+	ID        int64         `json:"id"`
+	Name      string        `json:"name"`
+	Prefix    string        `json:"prefix"`
+	Scopes    []ApiKeyScope `json:"scopes"`
+	CreatedAt Time          `json:"created_at"`
+}
+
+type CreatedApiKeyDto struct {
+	ApiKeyDto
+	Key string `json:"key"`
+}
+
+func NewApiKey(userID int64, name string, prefix string, keyHash string, scopes []ApiKeyScope) ApiKey {
+	return ApiKey{
+		UserID:    userID,
+		Name:      name,
+		Prefix:    prefix,
+		KeyHash:   keyHash,
+		Scopes:    scopes,
+		CreatedAt: time.Now(),
+	}
+}
+
+func (k ApiKey) HasScope(scope ApiKeyScope) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+func ToApiKeyDto(apiKey ApiKey) ApiKeyDto {
+	return ApiKeyDto{
+		ID:        apiKey.ID,
+		Name:      apiKey.Name,
+		Prefix:    apiKey.Prefix,
+		Scopes:    apiKey.Scopes,
+		CreatedAt: Time{apiKey.CreatedAt},
+	}
+}
+
+func ToApiKeysDto(apiKeys []ApiKey) []ApiKeyDto {
+	apiKeysResponse := make([]ApiKeyDto, len(apiKeys))
+	for i, apiKey := range apiKeys {
+		apiKeysResponse[i] = ToApiKeyDto(apiKey)
+	}
+	return apiKeysResponse
+}
+
+func ToCreatedApiKeyDto(apiKey ApiKey, key string) CreatedApiKeyDto {
+	return CreatedApiKeyDto{
+		ApiKeyDto: ToApiKeyDto(apiKey),
+		Key:       key,
+	}
+}
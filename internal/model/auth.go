@@ -17,7 +17,7 @@ func (s *SignInRequest) Validate(validate *validator.Validate) error {
 
 type SignUpRequest struct {
 	Login    string `json:"login" validate:"required,min=4,max=30" msg:"Login length should be between 4 and 30 characters."`
-	Password string `json:"password" validate:"required,min=8,max=256" msg:"Password length should be between 8 and 256 characters."`
+	Password string `json:"password" validate:"required,min=8,max=256,password_policy" msg:"Password length should be between 8 and 256 characters, it should combine lowercase and uppercase letters, digits and special characters and must not be a known breached password."`
 }
 
 func (s *SignUpRequest) Validate(validate *validator.Validate) error {
@@ -28,5 +28,28 @@ func SignUpRequestToUser(request SignUpRequest) User {
 	return User{
 		Login:    request.Login,
 		Password: request.Password,
+		Role:     UserRoleUser,
 	}
 }
+
+type SignInResult struct {
+	AuthToken      string
+	ChallengeToken string
+}
+
+func (r SignInResult) MfaRequired() bool {
+	return r.ChallengeToken != ""
+}
+
+type MfaChallengeDto struct {
+	ChallengeToken string `json:"challenge_token"`
+}
+
+type SignInSecondFactorRequest struct {
+	ChallengeToken string `json:"challenge_token" validate:"required" msg:"Challenge token is required."`
+	Code           string `json:"code" validate:"required,min=6,max=32" msg:"Code length should be between 6 and 32 characters."`
+}
+
+func (s *SignInSecondFactorRequest) Validate(validate *validator.Validate) error {
+	return v.Validate[SignInSecondFactorRequest](*s, validate)
+}
@@ -11,9 +11,21 @@ type BalanceDto struct {
 	WithdrawnPointsAmount float64 `json:"withdrawn"`
 }
 
+type BalanceV2Dto struct {
+	CurrentPointsAmount   Amount `json:"current"`
+	WithdrawnPointsAmount Amount `json:"withdrawn"`
+}
+
 func ToBalanceDto(balance Balance) BalanceDto {
 	return BalanceDto{
 		CurrentPointsAmount:   balance.CurrentPointsAmount,
 		WithdrawnPointsAmount: balance.WithdrawnPointsAmount,
 	}
 }
+
+func ToBalanceV2Dto(balance Balance) BalanceV2Dto {
+	return BalanceV2Dto{
+		CurrentPointsAmount:   Amount(balance.CurrentPointsAmount),
+		WithdrawnPointsAmount: Amount(balance.WithdrawnPointsAmount),
+	}
+}
@@ -0,0 +1,58 @@
+package model
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type SortDirection string
+
+const (
+	SortAsc  SortDirection = "asc"
+	SortDesc SortDirection = "desc"
+)
+
+type Cursor struct {
+	Time time.Time
+	Key  int64
+}
+
+func (c Cursor) Encode() string {
+	value := fmt.Sprintf("%d:%d", c.Time.UnixMicro(), c.Key)
+	return base64.RawURLEncoding.EncodeToString([]byte(value))
+}
+
+func ParseCursor(value string) (Cursor, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return Cursor{}, fmt.Errorf("invalid cursor")
+	}
+
+	timePart, keyPart, found := strings.Cut(string(decoded), ":")
+	if !found {
+		return Cursor{}, fmt.Errorf("invalid cursor")
+	}
+	micros, err := strconv.ParseInt(timePart, 10, 64)
+	if err != nil {
+		return Cursor{}, fmt.Errorf("invalid cursor")
+	}
+	key, err := strconv.ParseInt(keyPart, 10, 64)
+	if err != nil {
+		return Cursor{}, fmt.Errorf("invalid cursor")
+	}
+
+	return Cursor{Time: time.UnixMicro(micros), Key: key}, nil
+}
+
+func ParseSortDirection(value string) (SortDirection, error) {
+	switch SortDirection(strings.ToLower(value)) {
+	case "", SortAsc:
+		return SortAsc, nil
+	case SortDesc:
+		return SortDesc, nil
+	}
+	return "", fmt.Errorf("sort direction should be one of: asc, desc")
+}
@@ -0,0 +1,53 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Stern-Ritter/gophermart/internal/utils"
+)
+
+type ExportFormat string
+
+const (
+	ExportFormatJSON ExportFormat = "json"
+	ExportFormatCSV  ExportFormat = "csv"
+)
+
+type AccrualExportDto struct {
+	OrderNumber  string        `json:"number"`
+	Status       AccrualStatus `json:"status"`
+	PointsAmount float64       `json:"accrual"`
+	UploadedAt   Time          `json:"uploaded_at"`
+	ProcessedAt  *Time         `json:"processed_at,omitempty"`
+}
+
+type HistoryExportHeaderDto struct {
+	Login      string     `json:"login"`
+	ExportedAt Time       `json:"exported_at"`
+	Balance    BalanceDto `json:"balance"`
+}
+
+func ParseExportFormat(value string) (ExportFormat, error) {
+	switch ExportFormat(strings.ToLower(value)) {
+	case "", ExportFormatJSON:
+		return ExportFormatJSON, nil
+	case ExportFormatCSV:
+		return ExportFormatCSV, nil
+	}
+	return "", fmt.Errorf("format should be one of: csv, json")
+}
+
+func ToAccrualExportDto(accrual Accrual) AccrualExportDto {
+	dto := AccrualExportDto{
+		OrderNumber:  utils.FormatOrderNumber(accrual.OrderNumber),
+		Status:       accrual.Status,
+		PointsAmount: accrual.PointsAmount,
+		UploadedAt:   Time{accrual.UploadedAt},
+	}
+	if !accrual.ProcessedAt.IsZero() {
+		dto.ProcessedAt = &Time{accrual.ProcessedAt}
+	}
+
+	return dto
+}
@@ -0,0 +1,22 @@
+package model
+
+type HealthStatus string
+
+const (
+	HealthStatusOK   HealthStatus = "ok"
+	HealthStatusFail HealthStatus = "fail"
+)
+
+type ComponentHealth struct {
+	Status HealthStatus `json:"status"`
+	Detail string       `json:"detail,omitempty"`
+}
+
+type HealthReport struct {
+	Status     HealthStatus               `json:"status"`
+	Components map[string]ComponentHealth `json:"components,omitempty"`
+}
+
+func (r HealthReport) IsOK() bool {
+	return r.Status == HealthStatusOK
+}
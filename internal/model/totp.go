@@ -0,0 +1,55 @@
+package model
+
+import (
+	"time"
+
+	"github.com/go-playground/validator/v10"
+
+	v "github.com/Stern-Ritter/gophermart/internal/validator"
+)
+
+type Totp struct {
+	UserID       int64
+	Secret       string
+	Enabled      bool
+	LastUsedStep int64
+	CreatedAt    time.Time
+}
+
+type TotpEnrollment struct {
+	Secret string
+	URI    string
+}
+
+type TotpEnrollmentDto struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+}
+
+type TotpCodeRequest struct {
+	Code string `json:"code" validate:"required,min=6,max=32" msg:"Code length should be between 6 and 32 characters."`
+}
+
+func (s *TotpCodeRequest) Validate(validate *validator.Validate) error {
+	return v.Validate[TotpCodeRequest](*s, validate)
+}
+
+type RecoveryCodesDto struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+func NewTotp(userID int64, secret string) Totp {
+	return Totp{
+		UserID:    userID,
+		Secret:    secret,
+		Enabled:   false,
+		CreatedAt: time.Now(),
+	}
+}
+
+func ToTotpEnrollmentDto(enrollment TotpEnrollment) TotpEnrollmentDto {
+	return TotpEnrollmentDto{
+		Secret: enrollment.Secret,
+		URI:    enrollment.URI,
+	}
+}
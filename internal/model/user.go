@@ -1,7 +1,38 @@
 package model
 
+type UserRole string
+
+const (
+	UserRoleUser  UserRole = "USER"
+	UserRoleAdmin UserRole = "ADMIN"
+)
+
 type User struct {
 	ID       int64
 	Login    string
 	Password string
+	Role     UserRole
+	Disabled bool
+}
+
+type UserDto struct {
+	ID    int64    `json:"id"`
+	Login string   `json:"login"`
+	Role  UserRole `json:"role"`
+}
+
+func ToUserDto(user User) UserDto {
+	return UserDto{
+		ID:    user.ID,
+		Login: user.Login,
+		Role:  user.Role,
+	}
+}
+
+func ToUsersDto(users []User) []UserDto {
+	usersResponse := make([]UserDto, len(users))
+	for i, user := range users {
+		usersResponse[i] = ToUserDto(user)
+	}
+	return usersResponse
 }
@@ -16,6 +16,23 @@ type Withdrawn struct {
 	ProcessedAt  time.Time
 }
 
+type WithdrawalsFilter struct {
+	UserID        int64
+	ProcessedFrom time.Time
+	ProcessedTo   time.Time
+	MinSum        float64
+	MaxSum        float64
+	After         *Cursor
+	SortDirection SortDirection
+	Limit         int64
+}
+
+type WithdrawalsPage struct {
+	Withdrawals []Withdrawn
+	NextCursor  *Cursor
+	TotalCount  int64
+}
+
 type CreateWithdrawnDto struct {
 	OrderNumber  string  `json:"order" validate:"required,numeric,order_number" msg:"Order should be correct numeric value"`
 	PointsAmount float64 `json:"sum" validate:"required,gt=0" msg:"Sum should be greater than 0"`
@@ -25,12 +42,27 @@ func (s *CreateWithdrawnDto) Validate(validate *validator.Validate) error {
 	return v.Validate[CreateWithdrawnDto](*s, validate)
 }
 
+type CreateWithdrawnV2Dto struct {
+	OrderNumber  string `json:"order"`
+	PointsAmount Amount `json:"sum"`
+}
+
+func (s CreateWithdrawnV2Dto) ToCreateWithdrawnDto() CreateWithdrawnDto {
+	return CreateWithdrawnDto{OrderNumber: s.OrderNumber, PointsAmount: float64(s.PointsAmount)}
+}
+
 type WithdrawnDto struct {
 	OrderNumber  string  `json:"order"`
 	PointsAmount float64 `json:"sum"`
 	ProcessedAt  Time    `json:"processed_at"`
 }
 
+type WithdrawnV2Dto struct {
+	OrderNumber  string `json:"order"`
+	PointsAmount Amount `json:"sum"`
+	ProcessedAt  Time   `json:"processed_at"`
+}
+
 func NewWithdrawn(userID int64, orderNumber int64, pointsAmount float64) Withdrawn {
 	return Withdrawn{
 		UserID:       userID,
@@ -56,3 +88,20 @@ func ToWithdrawalsDto(withdrawals []Withdrawn) []WithdrawnDto {
 
 	return withdrawalsResponse
 }
+
+func ToWithdrawnV2Dto(withdrawn Withdrawn) WithdrawnV2Dto {
+	return WithdrawnV2Dto{
+		OrderNumber:  utils.FormatOrderNumber(withdrawn.OrderNumber),
+		PointsAmount: Amount(withdrawn.PointsAmount),
+		ProcessedAt:  Time{withdrawn.ProcessedAt},
+	}
+}
+
+func ToWithdrawalsV2Dto(withdrawals []Withdrawn) []WithdrawnV2Dto {
+	withdrawalsResponse := make([]WithdrawnV2Dto, len(withdrawals))
+	for i, withdrawal := range withdrawals {
+		withdrawalsResponse[i] = ToWithdrawnV2Dto(withdrawal)
+	}
+
+	return withdrawalsResponse
+}
@@ -0,0 +1,165 @@
+package openapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+
+	"github.com/Stern-Ritter/gophermart/internal/problem"
+)
+
+const BasePath = "/api"
+
+// maxRequestBodySize bounds the request bodies read for validation, it is well above the largest request described
+// in the spec, an orders batch.
+const maxRequestBodySize = 1 << 20
+
+// v1Prefix is the path prefix of the API v1 routes. v1 predates the spec and accepted request bodies regardless of
+// their Content-Type.
+const v1Prefix = BasePath + "/user/"
+
+type Validator struct {
+	doc       *openapi3.T
+	router    routers.Router
+	options   *openapi3filter.Options
+	v1Options *openapi3filter.Options
+}
+
+func NewValidator(spec []byte) (*Validator, error) {
+	loader := openapi3.NewLoader()
+	doc, err := loader.LoadFromData(spec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load openapi spec: %w", err)
+	}
+	if err := doc.Validate(loader.Context); err != nil {
+		return nil, fmt.Errorf("invalid openapi spec: %w", err)
+	}
+
+	// Requests are matched by path only, so the spec is usable behind any host.
+	doc.Servers = openapi3.Servers{{URL: BasePath}}
+	router, err := gorillamux.NewRouter(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build openapi router: %w", err)
+	}
+
+	return &Validator{
+		doc:    doc,
+		router: router,
+		options: &openapi3filter.Options{
+			AuthenticationFunc:    openapi3filter.NoopAuthenticationFunc,
+			IncludeResponseStatus: true,
+		},
+		v1Options: &openapi3filter.Options{
+			AuthenticationFunc:    openapi3filter.NoopAuthenticationFunc,
+			IncludeResponseStatus: true,
+			ExcludeRequestBody:    true,
+		},
+	}, nil
+}
+
+func (v *Validator) HasOperation(method string, path string) bool {
+	pathItem := v.doc.Paths.Find(strings.TrimPrefix(path, BasePath))
+	if pathItem == nil {
+		return false
+	}
+	return pathItem.GetOperation(method) != nil
+}
+
+func (v *Validator) Middleware(next http.Handler) http.Handler {
+	fn := func(res http.ResponseWriter, req *http.Request) {
+		route, pathParams, err := v.router.FindRoute(req)
+		if err != nil {
+			next.ServeHTTP(res, req)
+			return
+		}
+
+		req.Body = http.MaxBytesReader(res, req.Body, maxRequestBodySize)
+		options := v.options
+		if strings.HasPrefix(req.URL.Path, v1Prefix) && !acceptsContentType(route.Operation, req.Header.Get("Content-Type")) {
+			options = v.v1Options
+		}
+		input := &openapi3filter.RequestValidationInput{
+			Request:    req,
+			PathParams: pathParams,
+			Route:      route,
+			Options:    options,
+		}
+		if err := openapi3filter.ValidateRequest(req.Context(), input); err != nil {
+			problem.Write(res, req, requestProblem(err))
+			return
+		}
+
+		next.ServeHTTP(res, req)
+	}
+
+	return http.HandlerFunc(fn)
+}
+
+// acceptsContentType reports whether the operation describes a request body of the content type, bodies of other
+// types are left to the handlers on v1 routes.
+func acceptsContentType(operation *openapi3.Operation, contentType string) bool {
+	if operation.RequestBody == nil || operation.RequestBody.Value == nil {
+		return true
+	}
+	return contentType != "" && operation.RequestBody.Value.Content.Get(contentType) != nil
+}
+
+func (v *Validator) ValidateResponse(req *http.Request, status int, header http.Header, body []byte) error {
+	route, pathParams, err := v.router.FindRoute(req)
+	if err != nil {
+		return fmt.Errorf("%s %s is not described in openapi spec: %w", req.Method, req.URL.Path, err)
+	}
+
+	input := &openapi3filter.ResponseValidationInput{
+		RequestValidationInput: &openapi3filter.RequestValidationInput{
+			Request:    req,
+			PathParams: pathParams,
+			Route:      route,
+			Options:    v.options,
+		},
+		Status:  status,
+		Header:  header,
+		Options: v.options,
+	}
+	input.SetBodyBytes(body)
+
+	return openapi3filter.ValidateResponse(context.Background(), input)
+}
+
+func requestProblem(err error) problem.Problem {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return problem.New(http.StatusRequestEntityTooLarge, problem.CodeRequestTooLarge,
+			fmt.Sprintf("Request body should not be larger than %d bytes", maxBytesErr.Limit))
+	}
+
+	var requestErr *openapi3filter.RequestError
+	if !errors.As(err, &requestErr) {
+		return problem.New(http.StatusBadRequest, problem.CodeInvalidRequest, err.Error())
+	}
+
+	var schemaErr *openapi3.SchemaError
+	if !errors.As(requestErr.Err, &schemaErr) {
+		return problem.New(http.StatusBadRequest, problem.CodeInvalidRequest, requestErr.Error())
+	}
+
+	p := problem.New(http.StatusBadRequest, problem.CodeValidationFailed, "Request does not match API specification")
+	p.Errors = append(p.Errors, problem.FieldError{Field: requestErrorField(requestErr, schemaErr), Message: schemaErr.Reason})
+	return p
+}
+
+func requestErrorField(requestErr *openapi3filter.RequestError, schemaErr *openapi3.SchemaError) string {
+	pointer := schemaErr.JSONPointer()
+	if requestErr.Parameter != nil {
+		pointer = append([]string{requestErr.Parameter.Name}, pointer...)
+	}
+
+	return strings.Join(pointer, ".")
+}
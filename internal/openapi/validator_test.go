@@ -0,0 +1,245 @@
+package openapi
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Stern-Ritter/gophermart/api"
+	"github.com/Stern-Ritter/gophermart/internal/problem"
+)
+
+func TestValidatorMiddleware(t *testing.T) {
+	tests := []struct {
+		name                string
+		method              string
+		url                 string
+		contentType         string
+		body                string
+		expectedStatusCode  int
+		expectedProblemCode string
+		expectedField       string
+	}{
+		{
+			name:               "should pass request when it matches spec",
+			method:             http.MethodPost,
+			url:                "/api/user/register",
+			contentType:        "application/json",
+			body:               `{"login":"user42","password":"Secret-Passw0rd"}`,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "should pass plain text request when it matches spec",
+			method:             http.MethodPost,
+			url:                "/api/user/orders",
+			contentType:        "text/plain",
+			body:               "12345678903",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:                "should return status 400 when required field is missing",
+			method:              http.MethodPost,
+			url:                 "/api/user/register",
+			contentType:         "application/json",
+			body:                `{"login":"user42"}`,
+			expectedStatusCode:  http.StatusBadRequest,
+			expectedProblemCode: problem.CodeValidationFailed,
+			expectedField:       "password",
+		},
+		{
+			name:                "should return status 400 when field has wrong type",
+			method:              http.MethodPost,
+			url:                 "/api/user/balance/withdraw",
+			contentType:         "application/json",
+			body:                `{"order":"12345678903","sum":"100"}`,
+			expectedStatusCode:  http.StatusBadRequest,
+			expectedProblemCode: problem.CodeValidationFailed,
+			expectedField:       "sum",
+		},
+		{
+			name:                "should return status 400 when query parameter is out of range",
+			method:              http.MethodGet,
+			url:                 "/api/user/orders?limit=1000",
+			expectedStatusCode:  http.StatusBadRequest,
+			expectedProblemCode: problem.CodeValidationFailed,
+			expectedField:       "limit",
+		},
+		{
+			name:                "should return status 400 when content type is not described in spec",
+			method:              http.MethodPost,
+			url:                 "/api/v2/user/register",
+			contentType:         "application/xml",
+			body:                `<login>user42</login>`,
+			expectedStatusCode:  http.StatusBadRequest,
+			expectedProblemCode: problem.CodeInvalidRequest,
+		},
+		{
+			name:                "should return status 413 when body is too large",
+			method:              http.MethodPost,
+			url:                 "/api/user/orders/batch",
+			contentType:         "text/plain",
+			body:                strings.Repeat("12345678903\n", maxRequestBodySize/12+1),
+			expectedStatusCode:  http.StatusRequestEntityTooLarge,
+			expectedProblemCode: problem.CodeRequestTooLarge,
+		},
+		{
+			name:               "should pass v1 register request without content type",
+			method:             http.MethodPost,
+			url:                "/api/user/register",
+			body:               `{"login":"user42","password":"Secret-Passw0rd"}`,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "should pass v1 order request without content type",
+			method:             http.MethodPost,
+			url:                "/api/user/orders",
+			body:               "12345678903",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "should pass v1 withdraw request without content type",
+			method:             http.MethodPost,
+			url:                "/api/user/balance/withdraw",
+			body:               `{"order":"12345678903","sum":100}`,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "should pass v1 orders batch request with newline-delimited body of other content type",
+			method:             http.MethodPost,
+			url:                "/api/user/orders/batch",
+			contentType:        "application/octet-stream",
+			body:               "12345678903\n2377225624",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:                "should validate v1 request body when content type is described in spec",
+			method:              http.MethodPost,
+			url:                 "/api/user/register",
+			contentType:         "application/json; charset=utf-8",
+			body:                `{"login":"user42"}`,
+			expectedStatusCode:  http.StatusBadRequest,
+			expectedProblemCode: problem.CodeValidationFailed,
+			expectedField:       "password",
+		},
+		{
+			name:                "should return status 400 when v2 request has no content type",
+			method:              http.MethodPost,
+			url:                 "/api/v2/user/register",
+			body:                `{"login":"user42","password":"Secret-Passw0rd"}`,
+			expectedStatusCode:  http.StatusBadRequest,
+			expectedProblemCode: problem.CodeInvalidRequest,
+		},
+		{
+			name:               "should accept comma-separated statuses",
+			method:             http.MethodGet,
+			url:                "/api/user/orders?status=NEW,PROCESSED",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "should accept repeated statuses",
+			method:             http.MethodGet,
+			url:                "/api/v2/user/orders?status=NEW&status=PROCESSED",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:                "should return status 400 when one of comma-separated statuses is unknown",
+			method:              http.MethodGet,
+			url:                 "/api/user/orders?status=NEW,DONE",
+			expectedStatusCode:  http.StatusBadRequest,
+			expectedProblemCode: problem.CodeValidationFailed,
+			expectedField:       "status.1",
+		},
+		{
+			name:               "should pass request when route is not described in spec",
+			method:             http.MethodGet,
+			url:                "/api/unknown",
+			expectedStatusCode: http.StatusOK,
+		},
+	}
+
+	validator, err := NewValidator(api.Spec)
+	require.NoError(t, err, "Error loading openapi spec")
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})
+			handler := validator.Middleware(next)
+
+			req := httptest.NewRequest(tt.method, tt.url, strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			resp := w.Result()
+			defer resp.Body.Close()
+
+			assert.Equal(t, tt.expectedStatusCode, resp.StatusCode, "Response status code does not match expected status")
+			if tt.expectedProblemCode == "" {
+				return
+			}
+
+			var p problem.Problem
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&p), "Error decoding response body")
+			assert.Equal(t, tt.expectedProblemCode, p.Code, "Problem code does not match expected code")
+			if tt.expectedField != "" {
+				require.Len(t, p.Errors, 1, "Problem should contain field error")
+				assert.Equal(t, tt.expectedField, p.Errors[0].Field, "Problem field does not match expected field")
+			}
+		})
+	}
+}
+
+func TestValidatorPassesRequestBodyToNextHandler(t *testing.T) {
+	validator, err := NewValidator(api.Spec)
+	require.NoError(t, err, "Error loading openapi spec")
+
+	var body string
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, err := io.ReadAll(r.Body)
+		require.NoError(t, err, "Error reading request body")
+		body = string(data)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/user/orders", strings.NewReader("12345678903"))
+	req.Header.Set("Content-Type", "text/plain")
+	validator.Middleware(next).ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Equal(t, "12345678903", body, "Request body should be available to next handler")
+}
+
+func TestValidateResponse(t *testing.T) {
+	validator, err := NewValidator(api.Spec)
+	require.NoError(t, err, "Error loading openapi spec")
+
+	req := httptest.NewRequest(http.MethodGet, "/api/user/balance", nil)
+	header := http.Header{"Content-Type": []string{"application/json"}}
+
+	err = validator.ValidateResponse(req, http.StatusOK, header, []byte(`{"current":500.5,"withdrawn":42}`))
+	assert.NoError(t, err, "Response matching spec should be valid")
+
+	err = validator.ValidateResponse(req, http.StatusOK, header, []byte(`{"current":"500.5","withdrawn":42}`))
+	assert.Error(t, err, "Response with wrong field type should be invalid")
+
+	err = validator.ValidateResponse(req, http.StatusTeapot, header, nil)
+	assert.Error(t, err, "Response with undocumented status should be invalid")
+}
+
+func TestHasOperation(t *testing.T) {
+	validator, err := NewValidator(api.Spec)
+	require.NoError(t, err, "Error loading openapi spec")
+
+	assert.True(t, validator.HasOperation(http.MethodGet, "/api/user/orders/{number}"), "Operation should be described in spec")
+	assert.False(t, validator.HasOperation(http.MethodDelete, "/api/user/orders/{number}"), "Operation should not be described in spec")
+	assert.False(t, validator.HasOperation(http.MethodGet, "/api/unknown"), "Path should not be described in spec")
+}
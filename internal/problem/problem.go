@@ -0,0 +1,128 @@
+package problem
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"go.opentelemetry.io/otel/trace"
+
+	er "github.com/Stern-Ritter/gophermart/internal/errors"
+)
+
+const (
+	ContentType = "application/problem+json"
+	typePrefix  = "urn:gophermart:problem:"
+)
+
+const (
+	CodeInvalidRequest     = "invalid_request"
+	CodeValidationFailed   = "validation_failed"
+	CodeInvalidOrderNumber = "invalid_order_number"
+	CodeUnauthorized       = "unauthorized"
+	CodeForbidden          = "forbidden"
+	CodeNotFound           = "not_found"
+	CodeConflict           = "conflict"
+	CodeAlreadyExists      = "already_exists"
+	CodeInsufficientFunds  = "insufficient_funds"
+	CodeRequestTooLarge    = "request_too_large"
+	CodeInternalError      = "internal_error"
+)
+
+const internalErrorDetail = "An unexpected error occurred, please report the trace id if the problem persists"
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+type Problem struct {
+	Type     string       `json:"type"`
+	Title    string       `json:"title"`
+	Status   int          `json:"status"`
+	Detail   string       `json:"detail,omitempty"`
+	Instance string       `json:"instance,omitempty"`
+	Code     string       `json:"code"`
+	TraceID  string       `json:"trace_id"`
+	Errors   []FieldError `json:"errors,omitempty"`
+}
+
+func New(status int, code string, detail string) Problem {
+	if status >= http.StatusInternalServerError {
+		detail = internalErrorDetail
+	}
+
+	return Problem{
+		Type:    typePrefix + code,
+		Title:   http.StatusText(status),
+		Status:  status,
+		Detail:  detail,
+		Code:    code,
+		TraceID: newTraceID(),
+	}
+}
+
+func FromError(err error) Problem {
+	var validationError er.ValidationError
+	var unauthorizedError er.UnauthorizedError
+	var notFoundError er.NotFoundError
+	var conflictError er.ConflictError
+	var alreadyExistsError er.AlreadyExistsError
+	var paymentRequiredError er.PaymentRequiredError
+
+	switch {
+	case errors.As(err, &validationError):
+		p := New(http.StatusBadRequest, CodeValidationFailed, "Request validation failed")
+		for _, field := range validationError.Fields() {
+			p.Errors = append(p.Errors, FieldError{Field: field.Field, Message: field.Message})
+		}
+		return p
+	case errors.As(err, &unauthorizedError):
+		return New(http.StatusUnauthorized, CodeUnauthorized, unauthorizedError.Error())
+	case errors.As(err, &notFoundError):
+		return New(http.StatusNotFound, CodeNotFound, notFoundError.Error())
+	case errors.As(err, &conflictError):
+		return New(http.StatusConflict, CodeConflict, conflictError.Error())
+	case errors.As(err, &alreadyExistsError):
+		return New(http.StatusConflict, CodeAlreadyExists, alreadyExistsError.Error())
+	case errors.As(err, &paymentRequiredError):
+		return New(http.StatusPaymentRequired, CodeInsufficientFunds, paymentRequiredError.Error())
+	}
+
+	return New(http.StatusInternalServerError, CodeInternalError, "")
+}
+
+// WithTraceContext replaces the generated trace id with the id of the trace recorded for the request, if any.
+func (p Problem) WithTraceContext(ctx context.Context) Problem {
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.HasTraceID() {
+		p.TraceID = spanContext.TraceID().String()
+	}
+	return p
+}
+
+func Write(res http.ResponseWriter, req *http.Request, p Problem) {
+	p = p.WithTraceContext(req.Context())
+	p.Instance = req.URL.Path
+
+	body, err := json.Marshal(p)
+	if err != nil {
+		http.Error(res, p.Title, p.Status)
+		return
+	}
+
+	res.Header().Set("Content-Type", ContentType)
+	res.Header().Set("X-Content-Type-Options", "nosniff")
+	res.WriteHeader(p.Status)
+	res.Write(body) //nolint:errcheck
+}
+
+func newTraceID() string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(buf)
+}
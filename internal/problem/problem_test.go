@@ -0,0 +1,132 @@
+package problem
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+
+	er "github.com/Stern-Ritter/gophermart/internal/errors"
+)
+
+func TestFromError(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		expectedStatus int
+		expectedCode   string
+		expectedDetail string
+		expectedErrors []FieldError
+	}{
+		{
+			name: "should map validation error to status 400 with field errors",
+			err: er.NewValidationError("login: is required", []er.FieldError{{Field: "login", Message: "is required"}},
+				nil),
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   CodeValidationFailed,
+			expectedDetail: "Request validation failed",
+			expectedErrors: []FieldError{{Field: "login", Message: "is required"}},
+		},
+		{
+			name:           "should map unauthorized error to status 401",
+			err:            er.NewUnauthorizedError("Invalid login or password", nil),
+			expectedStatus: http.StatusUnauthorized,
+			expectedCode:   CodeUnauthorized,
+			expectedDetail: "Invalid login or password",
+		},
+		{
+			name:           "should map not found error to status 404",
+			err:            er.NewNotFoundError("Order not found", nil),
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   CodeNotFound,
+			expectedDetail: "Order not found",
+		},
+		{
+			name:           "should map conflict error to status 409",
+			err:            er.NewConflictError("Login is already taken", nil),
+			expectedStatus: http.StatusConflict,
+			expectedCode:   CodeConflict,
+			expectedDetail: "Login is already taken",
+		},
+		{
+			name:           "should map already exists error to status 409",
+			err:            er.NewAlreadyExistsError("Order already uploaded", nil),
+			expectedStatus: http.StatusConflict,
+			expectedCode:   CodeAlreadyExists,
+			expectedDetail: "Order already uploaded",
+		},
+		{
+			name:           "should map payment required error to status 402",
+			err:            er.NewPaymentRequiredError("Insufficient funds", nil),
+			expectedStatus: http.StatusPaymentRequired,
+			expectedCode:   CodeInsufficientFunds,
+			expectedDetail: "Insufficient funds",
+		},
+		{
+			name:           "should hide details of unexpected error",
+			err:            errors.New("pq: connection refused"),
+			expectedStatus: http.StatusInternalServerError,
+			expectedCode:   CodeInternalError,
+			expectedDetail: internalErrorDetail,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := FromError(tt.err)
+
+			assert.Equal(t, tt.expectedStatus, p.Status, "Problem status does not match expected status")
+			assert.Equal(t, tt.expectedCode, p.Code, "Problem code does not match expected code")
+			assert.Equal(t, "urn:gophermart:problem:"+tt.expectedCode, p.Type, "Problem type does not match expected type")
+			assert.Equal(t, http.StatusText(tt.expectedStatus), p.Title, "Problem title does not match expected title")
+			assert.Equal(t, tt.expectedDetail, p.Detail, "Problem detail does not match expected detail")
+			assert.Equal(t, tt.expectedErrors, p.Errors, "Problem field errors do not match expected errors")
+			assert.Len(t, p.TraceID, 32, "Problem should have trace id")
+		})
+	}
+}
+
+func TestWrite(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/api/user/orders/12345678903", nil)
+	w := httptest.NewRecorder()
+
+	Write(w, req, New(http.StatusNotFound, CodeNotFound, "Order not found"))
+
+	resp := w.Result()
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode, "Response status code does not match expected status")
+	assert.Equal(t, ContentType, resp.Header.Get("Content-Type"), "Response content type does not match expected")
+
+	var p Problem
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&p), "Error decoding response body")
+	assert.Equal(t, "/api/user/orders/12345678903", p.Instance, "Problem instance does not match request path")
+	assert.Equal(t, "Order not found", p.Detail, "Problem detail does not match expected detail")
+}
+
+func TestWriteWithTraceContext(t *testing.T) {
+	traceID, err := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	require.NoError(t, err, "Error parsing trace id")
+	spanID, err := trace.SpanIDFromHex("00f067aa0ba902b7")
+	require.NoError(t, err, "Error parsing span id")
+	ctx := trace.ContextWithSpanContext(context.Background(),
+		trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: spanID}))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/user/balance", nil).WithContext(ctx)
+	w := httptest.NewRecorder()
+
+	Write(w, req, New(http.StatusInternalServerError, CodeInternalError, ""))
+
+	resp := w.Result()
+	defer resp.Body.Close()
+
+	var p Problem
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&p), "Error decoding response body")
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", p.TraceID, "Problem trace id does not match request trace id")
+}
@@ -0,0 +1,108 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	er "github.com/Stern-Ritter/gophermart/internal/errors"
+	"github.com/Stern-Ritter/gophermart/internal/model"
+	"github.com/Stern-Ritter/gophermart/internal/rpc/pb"
+	"github.com/Stern-Ritter/gophermart/internal/utils"
+)
+
+var orderStatuses = map[model.AccrualStatus]pb.OrderStatus{
+	model.AccrualNew:        pb.OrderStatus_ORDER_STATUS_NEW,
+	model.AccrualProcessing: pb.OrderStatus_ORDER_STATUS_PROCESSING,
+	model.AccrualInvalid:    pb.OrderStatus_ORDER_STATUS_INVALID,
+	model.AccrualProcessed:  pb.OrderStatus_ORDER_STATUS_PROCESSED,
+}
+
+func (s *Server) UploadOrder(ctx context.Context, in *pb.UploadOrderRequest) (*pb.UploadOrderResponse, error) {
+	currentUser, err := s.UserService.GetCurrentUser(ctx)
+	if err != nil {
+		return nil, s.toStatusError(ctx, err)
+	}
+
+	parsedOrderNumber, err := utils.ParseOrderNumber(in.GetNumber())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err := utils.ValidateOrderNumber(in.GetNumber()); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	accrual := model.NewAccrual(currentUser.ID, parsedOrderNumber)
+	err = s.AccrualService.CreateAccrual(ctx, accrual)
+	if err != nil {
+		var alreadyExistsError er.AlreadyExistsError
+		if errors.As(err, &alreadyExistsError) {
+			return &pb.UploadOrderResponse{AlreadyUploaded: true}, nil
+		}
+		return nil, s.toStatusError(ctx, err)
+	}
+
+	return &pb.UploadOrderResponse{}, nil
+}
+
+func (s *Server) ListOrders(ctx context.Context, in *pb.ListOrdersRequest) (*pb.ListOrdersResponse, error) {
+	currentUser, err := s.UserService.GetCurrentUser(ctx)
+	if err != nil {
+		return nil, s.toStatusError(ctx, err)
+	}
+
+	filter, err := parseAccrualsFilter(in, currentUser.ID)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	page, err := s.AccrualService.GetAccrualsPage(ctx, filter)
+	if err != nil {
+		return nil, s.toStatusError(ctx, err)
+	}
+
+	orders := make([]*pb.Order, len(page.Accruals))
+	for i, accrual := range page.Accruals {
+		orders[i] = &pb.Order{
+			Number:     utils.FormatOrderNumber(accrual.OrderNumber),
+			Status:     orderStatuses[accrual.Status],
+			Accrual:    accrual.PointsAmount,
+			UploadedAt: toTimestamp(accrual.UploadedAt),
+		}
+	}
+
+	return &pb.ListOrdersResponse{Orders: orders, NextPageToken: encodePageToken(page.NextCursor)}, nil
+}
+
+func parseAccrualsFilter(in *pb.ListOrdersRequest, userID int64) (model.AccrualsFilter, error) {
+	params, err := parsePageParams(in.GetPageSize(), in.GetPageToken(), in.GetSort())
+	if err != nil {
+		return model.AccrualsFilter{}, err
+	}
+
+	uploadedFrom, uploadedTo, err := parseTimeRange(in.GetUploadedFrom(), in.GetUploadedTo(), "uploaded")
+	if err != nil {
+		return model.AccrualsFilter{}, err
+	}
+
+	statuses := make([]model.AccrualStatus, 0, len(in.GetStatuses()))
+	for _, orderStatus := range in.GetStatuses() {
+		for accrualStatus, value := range orderStatuses {
+			if value == orderStatus {
+				statuses = append(statuses, accrualStatus)
+			}
+		}
+	}
+
+	return model.AccrualsFilter{
+		UserID:        userID,
+		Statuses:      statuses,
+		UploadedFrom:  uploadedFrom,
+		UploadedTo:    uploadedTo,
+		After:         params.After,
+		SortDirection: params.SortDirection,
+		Limit:         params.Limit,
+	}, nil
+}
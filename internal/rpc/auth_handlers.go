@@ -0,0 +1,53 @@
+package rpc
+
+import (
+	"context"
+
+	"github.com/Stern-Ritter/gophermart/internal/model"
+	"github.com/Stern-Ritter/gophermart/internal/rpc/pb"
+)
+
+func (s *Server) Register(ctx context.Context, in *pb.RegisterRequest) (*pb.AuthResponse, error) {
+	signUpRequest := model.SignUpRequest{Login: in.GetLogin(), Password: in.GetPassword()}
+	if err := signUpRequest.Validate(s.Validate); err != nil {
+		return nil, s.toStatusError(ctx, err)
+	}
+
+	tokenString, err := s.AuthService.SignUp(ctx, signUpRequest)
+	if err != nil {
+		return nil, s.toStatusError(ctx, err)
+	}
+
+	return &pb.AuthResponse{AccessToken: tokenString}, nil
+}
+
+func (s *Server) Login(ctx context.Context, in *pb.LoginRequest) (*pb.LoginResponse, error) {
+	signInRequest := model.SignInRequest{Login: in.GetLogin(), Password: in.GetPassword()}
+	if err := signInRequest.Validate(s.Validate); err != nil {
+		return nil, s.toStatusError(ctx, err)
+	}
+
+	signInResult, err := s.AuthService.SignIn(ctx, signInRequest)
+	if err != nil {
+		return nil, s.toStatusError(ctx, err)
+	}
+
+	if signInResult.MfaRequired() {
+		return &pb.LoginResponse{ChallengeToken: signInResult.ChallengeToken}, nil
+	}
+	return &pb.LoginResponse{AccessToken: signInResult.AuthToken}, nil
+}
+
+func (s *Server) LoginSecondFactor(ctx context.Context, in *pb.LoginSecondFactorRequest) (*pb.AuthResponse, error) {
+	signInSecondFactorRequest := model.SignInSecondFactorRequest{ChallengeToken: in.GetChallengeToken(), Code: in.GetCode()}
+	if err := signInSecondFactorRequest.Validate(s.Validate); err != nil {
+		return nil, s.toStatusError(ctx, err)
+	}
+
+	tokenString, err := s.AuthService.SignInWithSecondFactor(ctx, signInSecondFactorRequest)
+	if err != nil {
+		return nil, s.toStatusError(ctx, err)
+	}
+
+	return &pb.AuthResponse{AccessToken: tokenString}, nil
+}
@@ -0,0 +1,21 @@
+package rpc
+
+import (
+	"context"
+
+	"github.com/Stern-Ritter/gophermart/internal/rpc/pb"
+)
+
+func (s *Server) GetBalance(ctx context.Context, _ *pb.GetBalanceRequest) (*pb.Balance, error) {
+	currentUser, err := s.UserService.GetCurrentUser(ctx)
+	if err != nil {
+		return nil, s.toStatusError(ctx, err)
+	}
+
+	balance, err := s.BalanceService.GetBalanceByUserID(ctx, currentUser.ID)
+	if err != nil {
+		return nil, s.toStatusError(ctx, err)
+	}
+
+	return &pb.Balance{Current: balance.CurrentPointsAmount, Withdrawn: balance.WithdrawnPointsAmount}, nil
+}
@@ -0,0 +1,42 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	er "github.com/Stern-Ritter/gophermart/internal/errors"
+)
+
+func (s *Server) toStatusError(ctx context.Context, err error) error {
+	var validationError er.ValidationError
+	var unauthorizedError er.UnauthorizedError
+	var notFoundError er.NotFoundError
+	var conflictError er.ConflictError
+	var alreadyExistsError er.AlreadyExistsError
+	var paymentRequiredError er.PaymentRequiredError
+
+	switch {
+	case errors.As(err, &validationError):
+		return status.Error(codes.InvalidArgument, validationError.Error())
+	case errors.As(err, &unauthorizedError):
+		return status.Error(codes.Unauthenticated, unauthorizedError.Error())
+	case errors.As(err, &notFoundError):
+		return status.Error(codes.NotFound, notFoundError.Error())
+	case errors.As(err, &conflictError):
+		return status.Error(codes.AlreadyExists, conflictError.Error())
+	case errors.As(err, &alreadyExistsError):
+		return status.Error(codes.AlreadyExists, alreadyExistsError.Error())
+	case errors.As(err, &paymentRequiredError):
+		return status.Error(codes.FailedPrecondition, paymentRequiredError.Error())
+	}
+
+	method, _ := grpc.Method(ctx)
+	s.Logger.WithContext(ctx).Error("Error processing grpc request", zap.String("event", "grpc request error"),
+		zap.String("method", method), zap.Error(err))
+	return status.Error(codes.Internal, "Internal server error")
+}
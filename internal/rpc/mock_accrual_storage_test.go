@@ -0,0 +1,201 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./internal/storage/accrual_storage.go
+//
+// Generated by this command:
+//
+//	mockgen -source=./internal/storage/accrual_storage.go -destination ./internal/rpc/mock_accrual_storage_test.go -package rpc
+//
+
+// Package rpc is a generated GoMock package.
+package rpc
+
+import (
+	context "context"
+	reflect "reflect"
+
+	model "github.com/Stern-Ritter/gophermart/internal/model"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockAccrualStorage is a mock of AccrualStorage interface.
+type MockAccrualStorage struct {
+	ctrl     *gomock.Controller
+	recorder *MockAccrualStorageMockRecorder
+}
+
+// MockAccrualStorageMockRecorder is the mock recorder for MockAccrualStorage.
+type MockAccrualStorageMockRecorder struct {
+	mock *MockAccrualStorage
+}
+
+// NewMockAccrualStorage creates a new mock instance.
+func NewMockAccrualStorage(ctrl *gomock.Controller) *MockAccrualStorage {
+	mock := &MockAccrualStorage{ctrl: ctrl}
+	mock.recorder = &MockAccrualStorageMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAccrualStorage) EXPECT() *MockAccrualStorageMockRecorder {
+	return m.recorder
+}
+
+// CountByStatus mocks base method.
+func (m *MockAccrualStorage) CountByStatus(ctx context.Context) (map[model.AccrualStatus]int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountByStatus", ctx)
+	ret0, _ := ret[0].(map[model.AccrualStatus]int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountByStatus indicates an expected call of CountByStatus.
+func (mr *MockAccrualStorageMockRecorder) CountByStatus(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountByStatus", reflect.TypeOf((*MockAccrualStorage)(nil).CountByStatus), ctx)
+}
+
+// ForEachByUserIDOrderByUploadedAtAsc mocks base method.
+func (m *MockAccrualStorage) ForEachByUserIDOrderByUploadedAtAsc(ctx context.Context, userID int64, fn func(model.Accrual) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ForEachByUserIDOrderByUploadedAtAsc", ctx, userID, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ForEachByUserIDOrderByUploadedAtAsc indicates an expected call of ForEachByUserIDOrderByUploadedAtAsc.
+func (mr *MockAccrualStorageMockRecorder) ForEachByUserIDOrderByUploadedAtAsc(ctx, userID, fn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ForEachByUserIDOrderByUploadedAtAsc", reflect.TypeOf((*MockAccrualStorage)(nil).ForEachByUserIDOrderByUploadedAtAsc), ctx, userID, fn)
+}
+
+// GetAllByFilter mocks base method.
+func (m *MockAccrualStorage) GetAllByFilter(ctx context.Context, filter model.AccrualsFilter) ([]model.Accrual, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAllByFilter", ctx, filter)
+	ret0, _ := ret[0].([]model.Accrual)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAllByFilter indicates an expected call of GetAllByFilter.
+func (mr *MockAccrualStorageMockRecorder) GetAllByFilter(ctx, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllByFilter", reflect.TypeOf((*MockAccrualStorage)(nil).GetAllByFilter), ctx, filter)
+}
+
+// GetAllByUserIDOrderByUploadedAtAsc mocks base method.
+func (m *MockAccrualStorage) GetAllByUserIDOrderByUploadedAtAsc(ctx context.Context, userID int64) ([]model.Accrual, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAllByUserIDOrderByUploadedAtAsc", ctx, userID)
+	ret0, _ := ret[0].([]model.Accrual)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAllByUserIDOrderByUploadedAtAsc indicates an expected call of GetAllByUserIDOrderByUploadedAtAsc.
+func (mr *MockAccrualStorageMockRecorder) GetAllByUserIDOrderByUploadedAtAsc(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllByUserIDOrderByUploadedAtAsc", reflect.TypeOf((*MockAccrualStorage)(nil).GetAllByUserIDOrderByUploadedAtAsc), ctx, userID)
+}
+
+// GetAllUnprocessedWithLimit mocks base method.
+func (m *MockAccrualStorage) GetAllUnprocessedWithLimit(ctx context.Context, limit int64) ([]model.Accrual, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAllUnprocessedWithLimit", ctx, limit)
+	ret0, _ := ret[0].([]model.Accrual)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAllUnprocessedWithLimit indicates an expected call of GetAllUnprocessedWithLimit.
+func (mr *MockAccrualStorageMockRecorder) GetAllUnprocessedWithLimit(ctx, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllUnprocessedWithLimit", reflect.TypeOf((*MockAccrualStorage)(nil).GetAllUnprocessedWithLimit), ctx, limit)
+}
+
+// GetOneByOrderNumber mocks base method.
+func (m *MockAccrualStorage) GetOneByOrderNumber(ctx context.Context, orderNumber int64) (model.Accrual, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOneByOrderNumber", ctx, orderNumber)
+	ret0, _ := ret[0].(model.Accrual)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOneByOrderNumber indicates an expected call of GetOneByOrderNumber.
+func (mr *MockAccrualStorageMockRecorder) GetOneByOrderNumber(ctx, orderNumber any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOneByOrderNumber", reflect.TypeOf((*MockAccrualStorage)(nil).GetOneByOrderNumber), ctx, orderNumber)
+}
+
+// Requeue mocks base method.
+func (m *MockAccrualStorage) Requeue(ctx context.Context, orderNumber int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Requeue", ctx, orderNumber)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Requeue indicates an expected call of Requeue.
+func (mr *MockAccrualStorageMockRecorder) Requeue(ctx, orderNumber any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Requeue", reflect.TypeOf((*MockAccrualStorage)(nil).Requeue), ctx, orderNumber)
+}
+
+// Save mocks base method.
+func (m *MockAccrualStorage) Save(ctx context.Context, accrual model.Accrual) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Save", ctx, accrual)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Save indicates an expected call of Save.
+func (mr *MockAccrualStorageMockRecorder) Save(ctx, accrual any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockAccrualStorage)(nil).Save), ctx, accrual)
+}
+
+// SaveInBatch mocks base method.
+func (m *MockAccrualStorage) SaveInBatch(ctx context.Context, accruals []model.Accrual) ([]model.AccrualBatchItemResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveInBatch", ctx, accruals)
+	ret0, _ := ret[0].([]model.AccrualBatchItemResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SaveInBatch indicates an expected call of SaveInBatch.
+func (mr *MockAccrualStorageMockRecorder) SaveInBatch(ctx, accruals any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveInBatch", reflect.TypeOf((*MockAccrualStorage)(nil).SaveInBatch), ctx, accruals)
+}
+
+// Update mocks base method.
+func (m *MockAccrualStorage) Update(ctx context.Context, accrual model.Accrual) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", ctx, accrual)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockAccrualStorageMockRecorder) Update(ctx, accrual any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockAccrualStorage)(nil).Update), ctx, accrual)
+}
+
+// UpdateInBatch mocks base method.
+func (m *MockAccrualStorage) UpdateInBatch(ctx context.Context, accruals []model.Accrual) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateInBatch", ctx, accruals)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateInBatch indicates an expected call of UpdateInBatch.
+func (mr *MockAccrualStorageMockRecorder) UpdateInBatch(ctx, accruals any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateInBatch", reflect.TypeOf((*MockAccrualStorage)(nil).UpdateInBatch), ctx, accruals)
+}
@@ -0,0 +1,158 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./internal/storage/totp_storage.go
+//
+// Generated by this command:
+//
+//	mockgen -source=./internal/storage/totp_storage.go -destination ./internal/rpc/mock_totp_storage_test.go -package rpc
+//
+
+// Package rpc is a generated GoMock package.
+package rpc
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	model "github.com/Stern-Ritter/gophermart/internal/model"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockTotpStorage is a mock of TotpStorage interface.
+type MockTotpStorage struct {
+	ctrl     *gomock.Controller
+	recorder *MockTotpStorageMockRecorder
+}
+
+// MockTotpStorageMockRecorder is the mock recorder for MockTotpStorage.
+type MockTotpStorageMockRecorder struct {
+	mock *MockTotpStorage
+}
+
+// NewMockTotpStorage creates a new mock instance.
+func NewMockTotpStorage(ctrl *gomock.Controller) *MockTotpStorage {
+	mock := &MockTotpStorage{ctrl: ctrl}
+	mock.recorder = &MockTotpStorageMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTotpStorage) EXPECT() *MockTotpStorageMockRecorder {
+	return m.recorder
+}
+
+// Delete mocks base method.
+func (m *MockTotpStorage) Delete(ctx context.Context, userID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockTotpStorageMockRecorder) Delete(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockTotpStorage)(nil).Delete), ctx, userID)
+}
+
+// Enable mocks base method.
+func (m *MockTotpStorage) Enable(ctx context.Context, userID int64, recoveryCodeHashes []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Enable", ctx, userID, recoveryCodeHashes)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Enable indicates an expected call of Enable.
+func (mr *MockTotpStorageMockRecorder) Enable(ctx, userID, recoveryCodeHashes any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Enable", reflect.TypeOf((*MockTotpStorage)(nil).Enable), ctx, userID, recoveryCodeHashes)
+}
+
+// GetOneByUserID mocks base method.
+func (m *MockTotpStorage) GetOneByUserID(ctx context.Context, userID int64) (model.Totp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOneByUserID", ctx, userID)
+	ret0, _ := ret[0].(model.Totp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOneByUserID indicates an expected call of GetOneByUserID.
+func (mr *MockTotpStorageMockRecorder) GetOneByUserID(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOneByUserID", reflect.TypeOf((*MockTotpStorage)(nil).GetOneByUserID), ctx, userID)
+}
+
+// IncrementFailedAttempts mocks base method.
+func (m *MockTotpStorage) IncrementFailedAttempts(ctx context.Context, userID int64, attemptedAt, windowStart time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IncrementFailedAttempts", ctx, userID, attemptedAt, windowStart)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IncrementFailedAttempts indicates an expected call of IncrementFailedAttempts.
+func (mr *MockTotpStorageMockRecorder) IncrementFailedAttempts(ctx, userID, attemptedAt, windowStart any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncrementFailedAttempts", reflect.TypeOf((*MockTotpStorage)(nil).IncrementFailedAttempts), ctx, userID, attemptedAt, windowStart)
+}
+
+// ResetFailedAttempts mocks base method.
+func (m *MockTotpStorage) ResetFailedAttempts(ctx context.Context, userID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetFailedAttempts", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResetFailedAttempts indicates an expected call of ResetFailedAttempts.
+func (mr *MockTotpStorageMockRecorder) ResetFailedAttempts(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetFailedAttempts", reflect.TypeOf((*MockTotpStorage)(nil).ResetFailedAttempts), ctx, userID)
+}
+
+// Save mocks base method.
+func (m *MockTotpStorage) Save(ctx context.Context, totp model.Totp) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Save", ctx, totp)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Save indicates an expected call of Save.
+func (mr *MockTotpStorageMockRecorder) Save(ctx, totp any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockTotpStorage)(nil).Save), ctx, totp)
+}
+
+// UpdateLastUsedStep mocks base method.
+func (m *MockTotpStorage) UpdateLastUsedStep(ctx context.Context, userID, step int64) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateLastUsedStep", ctx, userID, step)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateLastUsedStep indicates an expected call of UpdateLastUsedStep.
+func (mr *MockTotpStorageMockRecorder) UpdateLastUsedStep(ctx, userID, step any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateLastUsedStep", reflect.TypeOf((*MockTotpStorage)(nil).UpdateLastUsedStep), ctx, userID, step)
+}
+
+// UseRecoveryCode mocks base method.
+func (m *MockTotpStorage) UseRecoveryCode(ctx context.Context, userID int64, codeHash string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseRecoveryCode", ctx, userID, codeHash)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UseRecoveryCode indicates an expected call of UseRecoveryCode.
+func (mr *MockTotpStorageMockRecorder) UseRecoveryCode(ctx, userID, codeHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseRecoveryCode", reflect.TypeOf((*MockTotpStorage)(nil).UseRecoveryCode), ctx, userID, codeHash)
+}
@@ -0,0 +1,128 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./internal/storage/user_storage.go
+//
+// Generated by this command:
+//
+//	mockgen -source=./internal/storage/user_storage.go -destination ./internal/rpc/mock_user_storage_test.go -package rpc
+//
+
+// Package rpc is a generated GoMock package.
+package rpc
+
+import (
+	context "context"
+	reflect "reflect"
+
+	model "github.com/Stern-Ritter/gophermart/internal/model"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockUserStorage is a mock of UserStorage interface.
+type MockUserStorage struct {
+	ctrl     *gomock.Controller
+	recorder *MockUserStorageMockRecorder
+}
+
+// MockUserStorageMockRecorder is the mock recorder for MockUserStorage.
+type MockUserStorageMockRecorder struct {
+	mock *MockUserStorage
+}
+
+// NewMockUserStorage creates a new mock instance.
+func NewMockUserStorage(ctrl *gomock.Controller) *MockUserStorage {
+	mock := &MockUserStorage{ctrl: ctrl}
+	mock.recorder = &MockUserStorageMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUserStorage) EXPECT() *MockUserStorageMockRecorder {
+	return m.recorder
+}
+
+// DisableByLogin mocks base method.
+func (m *MockUserStorage) DisableByLogin(ctx context.Context, login string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DisableByLogin", ctx, login)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DisableByLogin indicates an expected call of DisableByLogin.
+func (mr *MockUserStorageMockRecorder) DisableByLogin(ctx, login any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DisableByLogin", reflect.TypeOf((*MockUserStorage)(nil).DisableByLogin), ctx, login)
+}
+
+// GetAllByLoginContainingOrderByLogin mocks base method.
+func (m *MockUserStorage) GetAllByLoginContainingOrderByLogin(ctx context.Context, login string, limit int64) ([]model.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAllByLoginContainingOrderByLogin", ctx, login, limit)
+	ret0, _ := ret[0].([]model.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAllByLoginContainingOrderByLogin indicates an expected call of GetAllByLoginContainingOrderByLogin.
+func (mr *MockUserStorageMockRecorder) GetAllByLoginContainingOrderByLogin(ctx, login, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllByLoginContainingOrderByLogin", reflect.TypeOf((*MockUserStorage)(nil).GetAllByLoginContainingOrderByLogin), ctx, login, limit)
+}
+
+// GetOneByID mocks base method.
+func (m *MockUserStorage) GetOneByID(ctx context.Context, id int64) (model.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOneByID", ctx, id)
+	ret0, _ := ret[0].(model.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOneByID indicates an expected call of GetOneByID.
+func (mr *MockUserStorageMockRecorder) GetOneByID(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOneByID", reflect.TypeOf((*MockUserStorage)(nil).GetOneByID), ctx, id)
+}
+
+// GetOneByLogin mocks base method.
+func (m *MockUserStorage) GetOneByLogin(ctx context.Context, login string) (model.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOneByLogin", ctx, login)
+	ret0, _ := ret[0].(model.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOneByLogin indicates an expected call of GetOneByLogin.
+func (mr *MockUserStorageMockRecorder) GetOneByLogin(ctx, login any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOneByLogin", reflect.TypeOf((*MockUserStorage)(nil).GetOneByLogin), ctx, login)
+}
+
+// Save mocks base method.
+func (m *MockUserStorage) Save(ctx context.Context, user model.User) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Save", ctx, user)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Save indicates an expected call of Save.
+func (mr *MockUserStorageMockRecorder) Save(ctx, user any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockUserStorage)(nil).Save), ctx, user)
+}
+
+// UpdatePassword mocks base method.
+func (m *MockUserStorage) UpdatePassword(ctx context.Context, userID int64, passwordHash string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePassword", ctx, userID, passwordHash)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdatePassword indicates an expected call of UpdatePassword.
+func (mr *MockUserStorageMockRecorder) UpdatePassword(ctx, userID, passwordHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePassword", reflect.TypeOf((*MockUserStorage)(nil).UpdatePassword), ctx, userID, passwordHash)
+}
@@ -0,0 +1,75 @@
+package rpc
+
+import (
+	"fmt"
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/Stern-Ritter/gophermart/internal/model"
+	"github.com/Stern-Ritter/gophermart/internal/rpc/pb"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 200
+)
+
+type pageParams struct {
+	After         *model.Cursor
+	SortDirection model.SortDirection
+	Limit         int64
+}
+
+func parsePageParams(pageSize int64, pageToken string, sort pb.SortDirection) (pageParams, error) {
+	params := pageParams{Limit: pageSize, SortDirection: model.SortAsc}
+
+	switch {
+	case pageSize == 0:
+		params.Limit = defaultPageSize
+	case pageSize < 0 || pageSize > maxPageSize:
+		return pageParams{}, fmt.Errorf("page_size should be between 1 and %d", maxPageSize)
+	}
+
+	if pageToken != "" {
+		cursor, err := model.ParseCursor(pageToken)
+		if err != nil {
+			return pageParams{}, fmt.Errorf("invalid page_token")
+		}
+		params.After = &cursor
+	}
+
+	if sort == pb.SortDirection_SORT_DIRECTION_DESC {
+		params.SortDirection = model.SortDesc
+	}
+
+	return params, nil
+}
+
+func parseTimeRange(from *timestamppb.Timestamp, to *timestamppb.Timestamp, name string) (time.Time, time.Time, error) {
+	var fromTime, toTime time.Time
+	if from != nil {
+		fromTime = from.AsTime()
+	}
+	if to != nil {
+		toTime = to.AsTime()
+	}
+	if !fromTime.IsZero() && !toTime.IsZero() && !fromTime.Before(toTime) {
+		return time.Time{}, time.Time{}, fmt.Errorf("%s_from should be before %s_to", name, name)
+	}
+	return fromTime, toTime, nil
+}
+
+func encodePageToken(next *model.Cursor) string {
+	if next == nil {
+		return ""
+	}
+	return next.Encode()
+}
+
+func toTimestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
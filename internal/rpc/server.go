@@ -0,0 +1,56 @@
+package rpc
+
+//go:generate protoc -I ../.. --go_out=../.. --go_opt=module=github.com/Stern-Ritter/gophermart --go-grpc_out=../.. --go-grpc_opt=module=github.com/Stern-Ritter/gophermart api/proto/gophermart.proto
+
+import (
+	"github.com/go-chi/jwtauth/v5"
+	"github.com/go-playground/validator/v10"
+	"google.golang.org/grpc"
+
+	"github.com/Stern-Ritter/gophermart/internal/audit"
+	"github.com/Stern-Ritter/gophermart/internal/auth"
+	"github.com/Stern-Ritter/gophermart/internal/logger"
+	"github.com/Stern-Ritter/gophermart/internal/rpc/pb"
+	"github.com/Stern-Ritter/gophermart/internal/service"
+)
+
+type Server struct {
+	pb.UnimplementedGophermartServer
+
+	AuthService      service.AuthService
+	UserService      service.UserService
+	AccrualService   service.AccrualService
+	WithdrawnService service.WithdrawnService
+	BalanceService   service.BalanceService
+	Validate         *validator.Validate
+	AuthToken        *jwtauth.JWTAuth
+	Logger           *logger.ServerLogger
+}
+
+func NewServer(authService service.AuthService, userService service.UserService, accrualService service.AccrualService,
+	withdrawnService service.WithdrawnService, balanceService service.BalanceService, validate *validator.Validate,
+	authToken *jwtauth.JWTAuth, logger *logger.ServerLogger) *Server {
+	return &Server{
+		AuthService:      authService,
+		UserService:      userService,
+		AccrualService:   accrualService,
+		WithdrawnService: withdrawnService,
+		BalanceService:   balanceService,
+		Validate:         validate,
+		AuthToken:        authToken,
+		Logger:           logger,
+	}
+}
+
+var publicMethods = []string{
+	pb.Gophermart_Register_FullMethodName,
+	pb.Gophermart_Login_FullMethodName,
+	pb.Gophermart_LoginSecondFactor_FullMethodName,
+}
+
+func (s *Server) NewGrpcServer() *grpc.Server {
+	grpcServer := grpc.NewServer(grpc.ChainUnaryInterceptor(logger.RequestIDInterceptor, audit.UnaryInterceptor, s.Logger.LoggerInterceptor,
+		auth.UnaryAuthenticator(s.AuthToken, publicMethods...)))
+	pb.RegisterGophermartServer(grpcServer, s)
+	return grpcServer
+}
@@ -0,0 +1,367 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"golang.org/x/crypto/bcrypt"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/Stern-Ritter/gophermart/internal/auth"
+	er "github.com/Stern-Ritter/gophermart/internal/errors"
+	"github.com/Stern-Ritter/gophermart/internal/logger"
+	"github.com/Stern-Ritter/gophermart/internal/model"
+	"github.com/Stern-Ritter/gophermart/internal/rpc/pb"
+	"github.com/Stern-Ritter/gophermart/internal/service"
+	"github.com/Stern-Ritter/gophermart/internal/validator"
+)
+
+type rpcTestMocks struct {
+	userStorage      *MockUserStorage
+	accrualStorage   *MockAccrualStorage
+	balanceStorage   *MockBalanceStorage
+	withdrawnStorage *MockWithdrawnStorage
+}
+
+func TestRegister(t *testing.T) {
+	tests := []struct {
+		name           string
+		request        *pb.RegisterRequest
+		useUserStorage bool
+		userStorageErr error
+		expectedCode   codes.Code
+	}{
+		{
+			name:           "should return access token when user with this login not exists",
+			request:        &pb.RegisterRequest{Login: "user42", Password: "Secret-Passw0rd"},
+			useUserStorage: true,
+			expectedCode:   codes.OK,
+		},
+		{
+			name:         "should return invalid argument when password is too short",
+			request:      &pb.RegisterRequest{Login: "user42", Password: "word"},
+			expectedCode: codes.InvalidArgument,
+		},
+		{
+			name:           "should return already exists when user with this login already exists",
+			request:        &pb.RegisterRequest{Login: "user42", Password: "Secret-Passw0rd"},
+			useUserStorage: true,
+			userStorageErr: &pgconn.PgError{ConstraintName: "users_login_unique"},
+			expectedCode:   codes.AlreadyExists,
+		},
+		{
+			name:           "should return internal when unexpected error occurred",
+			request:        &pb.RegisterRequest{Login: "user42", Password: "Secret-Passw0rd"},
+			useUserStorage: true,
+			userStorageErr: errors.New("unexpected error"),
+			expectedCode:   codes.Internal,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			client, mocks := newTestClient(t, ctrl)
+			if tt.useUserStorage {
+				mocks.userStorage.EXPECT().Save(gomock.Any(), gomock.Any()).Return(tt.userStorageErr)
+			}
+
+			resp, err := client.Register(context.Background(), tt.request)
+
+			assert.Equal(t, tt.expectedCode, status.Code(err), "Response code does not match expected code")
+			if tt.expectedCode == codes.OK {
+				assert.NotEmpty(t, resp.GetAccessToken(), "Response should contain access token")
+			}
+		})
+	}
+}
+
+func TestAuthenticator(t *testing.T) {
+	tests := []struct {
+		name          string
+		authorization string
+		expectedCode  codes.Code
+	}{
+		{
+			name:         "should return unauthenticated when token is missing",
+			expectedCode: codes.Unauthenticated,
+		},
+		{
+			name:          "should return unauthenticated when token is invalid",
+			authorization: "Bearer invalid",
+			expectedCode:  codes.Unauthenticated,
+		},
+		{
+			name:          "should pass request when token is valid",
+			authorization: "Bearer " + newTestToken(t, "user"),
+			expectedCode:  codes.OK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			client, mocks := newTestClient(t, ctrl)
+			if tt.expectedCode == codes.OK {
+				mocks.userStorage.EXPECT().GetOneByLogin(gomock.Any(), "user").Return(model.User{ID: 1, Login: "user"}, nil)
+				mocks.balanceStorage.EXPECT().GetByUserID(gomock.Any(), int64(1)).
+					Return(model.Balance{UserID: 1, CurrentPointsAmount: 500.5, WithdrawnPointsAmount: 42}, nil)
+			}
+
+			ctx := context.Background()
+			if tt.authorization != "" {
+				ctx = metadata.AppendToOutgoingContext(ctx, "authorization", tt.authorization)
+			}
+			resp, err := client.GetBalance(ctx, &pb.GetBalanceRequest{})
+
+			assert.Equal(t, tt.expectedCode, status.Code(err), "Response code does not match expected code")
+			if tt.expectedCode == codes.OK {
+				assert.Equal(t, 500.5, resp.GetCurrent(), "Current balance does not match expected")
+				assert.Equal(t, float64(42), resp.GetWithdrawn(), "Withdrawn balance does not match expected")
+			}
+		})
+	}
+}
+
+func TestUploadOrder(t *testing.T) {
+	tests := []struct {
+		name                    string
+		number                  string
+		useAccrualStorage       bool
+		accrualStorageErr       error
+		expectedCode            codes.Code
+		expectedAlreadyUploaded bool
+	}{
+		{
+			name:              "should accept new order number",
+			number:            "12345678903",
+			useAccrualStorage: true,
+			expectedCode:      codes.OK,
+		},
+		{
+			name:                    "should report order number already uploaded by this user",
+			number:                  "12345678903",
+			useAccrualStorage:       true,
+			accrualStorageErr:       &pgconn.PgError{ConstraintName: "pk_loyalty_points_accrual"},
+			expectedCode:            codes.OK,
+			expectedAlreadyUploaded: true,
+		},
+		{
+			name:              "should return already exists when order number uploaded by other user",
+			number:            "12345678903",
+			useAccrualStorage: true,
+			accrualStorageErr: &pgconn.PgError{ConstraintName: "loyalty_points_accrual_order_number_unique"},
+			expectedCode:      codes.AlreadyExists,
+		},
+		{
+			name:         "should return invalid argument when order number is not numeric",
+			number:       "s12345678903",
+			expectedCode: codes.InvalidArgument,
+		},
+		{
+			name:         "should return invalid argument when order number is invalid",
+			number:       "49927398717",
+			expectedCode: codes.InvalidArgument,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			client, mocks := newTestClient(t, ctrl)
+			mocks.userStorage.EXPECT().GetOneByLogin(gomock.Any(), "user").Return(model.User{ID: 1, Login: "user"}, nil)
+			if tt.useAccrualStorage {
+				mocks.accrualStorage.EXPECT().Save(gomock.Any(), gomock.Any()).Return(tt.accrualStorageErr)
+			}
+
+			resp, err := client.UploadOrder(newAuthorizedContext(t, "user"), &pb.UploadOrderRequest{Number: tt.number})
+
+			assert.Equal(t, tt.expectedCode, status.Code(err), "Response code does not match expected code")
+			assert.Equal(t, tt.expectedAlreadyUploaded, resp.GetAlreadyUploaded(), "Already uploaded flag does not match expected")
+		})
+	}
+}
+
+func TestListOrders(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	client, mocks := newTestClient(t, ctrl)
+	uploadedAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	mocks.userStorage.EXPECT().GetOneByLogin(gomock.Any(), "user").Return(model.User{ID: 1, Login: "user"}, nil)
+	mocks.accrualStorage.EXPECT().GetAllByFilter(gomock.Any(), model.AccrualsFilter{
+		UserID:        1,
+		Statuses:      []model.AccrualStatus{model.AccrualProcessed},
+		UploadedFrom:  uploadedAt.Add(-time.Hour),
+		SortDirection: model.SortDesc,
+		Limit:         2,
+	}).Return([]model.Accrual{
+		{UserID: 1, OrderNumber: 12345678903, Status: model.AccrualProcessed, PointsAmount: 500, UploadedAt: uploadedAt},
+		{UserID: 1, OrderNumber: 2377225624, Status: model.AccrualProcessed, PointsAmount: 100, UploadedAt: uploadedAt},
+	}, nil)
+
+	resp, err := client.ListOrders(newAuthorizedContext(t, "user"), &pb.ListOrdersRequest{
+		PageSize:     1,
+		Sort:         pb.SortDirection_SORT_DIRECTION_DESC,
+		Statuses:     []pb.OrderStatus{pb.OrderStatus_ORDER_STATUS_PROCESSED},
+		UploadedFrom: timestamppb.New(uploadedAt.Add(-time.Hour)),
+	})
+
+	require.NoError(t, err, "Error listing orders")
+	require.Len(t, resp.GetOrders(), 1, "Response should contain one order")
+	assert.Equal(t, "12345678903", resp.GetOrders()[0].GetNumber(), "Order number does not match expected")
+	assert.Equal(t, pb.OrderStatus_ORDER_STATUS_PROCESSED, resp.GetOrders()[0].GetStatus(), "Order status does not match expected")
+	assert.Equal(t, float64(500), resp.GetOrders()[0].GetAccrual(), "Order accrual does not match expected")
+	assert.Equal(t, model.Cursor{Time: uploadedAt, Key: 12345678903}.Encode(), resp.GetNextPageToken(),
+		"Next page token does not match expected")
+}
+
+func TestWithdraw(t *testing.T) {
+	tests := []struct {
+		name                string
+		request             *pb.WithdrawRequest
+		useWithdrawnStorage bool
+		withdrawnStorageErr error
+		expectedCode        codes.Code
+	}{
+		{
+			name:                "should withdraw points",
+			request:             &pb.WithdrawRequest{Order: "2377225624", Sum: 100},
+			useWithdrawnStorage: true,
+			expectedCode:        codes.OK,
+		},
+		{
+			name:         "should return invalid argument when sum is less or equal to 0",
+			request:      &pb.WithdrawRequest{Order: "2377225624", Sum: 0},
+			expectedCode: codes.InvalidArgument,
+		},
+		{
+			name:                "should return failed precondition when user does not have enough points",
+			request:             &pb.WithdrawRequest{Order: "2377225624", Sum: 100},
+			useWithdrawnStorage: true,
+			withdrawnStorageErr: er.PaymentRequiredError{},
+			expectedCode:        codes.FailedPrecondition,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			client, mocks := newTestClient(t, ctrl)
+			mocks.userStorage.EXPECT().GetOneByLogin(gomock.Any(), "user").Return(model.User{ID: 1, Login: "user"}, nil)
+			if tt.useWithdrawnStorage {
+				mocks.withdrawnStorage.EXPECT().Save(gomock.Any(), gomock.Any()).Return(tt.withdrawnStorageErr)
+			}
+
+			_, err := client.Withdraw(newAuthorizedContext(t, "user"), tt.request)
+
+			assert.Equal(t, tt.expectedCode, status.Code(err), "Response code does not match expected code")
+		})
+	}
+}
+
+func TestListWithdrawals(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	client, mocks := newTestClient(t, ctrl)
+	processedAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	filter := model.WithdrawalsFilter{UserID: 1, MinSum: 10, SortDirection: model.SortAsc, Limit: defaultPageSize}
+	pageFilter := filter
+	pageFilter.Limit = defaultPageSize + 1
+	mocks.userStorage.EXPECT().GetOneByLogin(gomock.Any(), "user").Return(model.User{ID: 1, Login: "user"}, nil)
+	mocks.withdrawnStorage.EXPECT().GetAllByFilter(gomock.Any(), pageFilter).Return([]model.Withdrawn{
+		{UserID: 1, OrderNumber: 2377225624, PointsAmount: 100, ProcessedAt: processedAt},
+	}, nil)
+	mocks.withdrawnStorage.EXPECT().CountByFilter(gomock.Any(), gomock.Any()).Return(int64(1), nil)
+
+	resp, err := client.ListWithdrawals(newAuthorizedContext(t, "user"), &pb.ListWithdrawalsRequest{MinSum: 10})
+
+	require.NoError(t, err, "Error listing withdrawals")
+	require.Len(t, resp.GetWithdrawals(), 1, "Response should contain one withdrawal")
+	assert.Equal(t, "2377225624", resp.GetWithdrawals()[0].GetOrder(), "Withdrawal order does not match expected")
+	assert.Equal(t, processedAt, resp.GetWithdrawals()[0].GetProcessedAt().AsTime(), "Withdrawal time does not match expected")
+	assert.Equal(t, int64(1), resp.GetTotalCount(), "Total count does not match expected")
+	assert.Empty(t, resp.GetNextPageToken(), "Last page should not have next page token")
+}
+
+func newTestClient(t *testing.T, ctrl *gomock.Controller) (pb.GophermartClient, rpcTestMocks) {
+	validate, err := validator.GetValidator()
+	require.NoError(t, err, "Error init validator")
+	err = validator.RegisterPasswordPolicy(validate, validator.NewPasswordPolicy(3, nil))
+	require.NoError(t, err, "Error init password policy")
+	l, err := logger.Initialize("error")
+	require.NoError(t, err, "Error init logger")
+	authToken := auth.GenerateAuthToken("secret")
+
+	mocks := rpcTestMocks{
+		userStorage:      NewMockUserStorage(ctrl),
+		accrualStorage:   NewMockAccrualStorage(ctrl),
+		balanceStorage:   NewMockBalanceStorage(ctrl),
+		withdrawnStorage: NewMockWithdrawnStorage(ctrl),
+	}
+	passwordHasher := auth.NewPasswordHasher(auth.NewArgon2idHasher(auth.Argon2idParams{
+		Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32,
+	}), auth.NewBcryptHasher(bcrypt.DefaultCost))
+
+	userService := service.NewUserService(mocks.userStorage, l)
+	totpService := service.NewTotpService(NewMockTotpStorage(ctrl), l)
+	auditService := newTestAuditService(ctrl, l)
+	authService := service.NewAuthService(userService, totpService, auditService, passwordHasher, authToken, l)
+	accrualService := service.NewAccrualService(mocks.accrualStorage, l)
+	withdrawnService := service.NewWithdrawnService(mocks.withdrawnStorage, auditService, l)
+	balanceService := service.NewBalanceService(mocks.balanceStorage, l)
+
+	server := NewServer(authService, userService, accrualService, withdrawnService, balanceService, validate, authToken, l)
+	grpcServer := server.NewGrpcServer()
+	listener := bufconn.Listen(1024 * 1024)
+	go grpcServer.Serve(listener) //nolint:errcheck
+	t.Cleanup(grpcServer.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err, "Error connecting to grpc server")
+	t.Cleanup(func() { conn.Close() })
+
+	return pb.NewGophermartClient(conn), mocks
+}
+
+func newTestToken(t *testing.T, login string) string {
+	_, tokenString, err := auth.GenerateAuthToken("secret").Encode(map[string]interface{}{auth.LoginClaim: login})
+	require.NoError(t, err, "Error encoding token")
+	return tokenString
+}
+
+func newAuthorizedContext(t *testing.T, login string) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", newTestToken(t, login))
+}
+
+func newTestAuditService(ctrl *gomock.Controller, l *logger.ServerLogger) service.AuditService {
+	auditEventStorage := NewMockAuditEventStorage(ctrl)
+	auditEventStorage.EXPECT().Append(gomock.Any(), gomock.Any()).Return(model.AuditEvent{}, nil).AnyTimes()
+	return service.NewAuditService(auditEventStorage, l)
+}
@@ -0,0 +1,99 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/Stern-Ritter/gophermart/internal/model"
+	"github.com/Stern-Ritter/gophermart/internal/rpc/pb"
+	"github.com/Stern-Ritter/gophermart/internal/utils"
+)
+
+func (s *Server) Withdraw(ctx context.Context, in *pb.WithdrawRequest) (*pb.WithdrawResponse, error) {
+	currentUser, err := s.UserService.GetCurrentUser(ctx)
+	if err != nil {
+		return nil, s.toStatusError(ctx, err)
+	}
+
+	createWithdrawnDto := model.CreateWithdrawnDto{OrderNumber: in.GetOrder(), PointsAmount: in.GetSum()}
+	if err := createWithdrawnDto.Validate(s.Validate); err != nil {
+		return nil, s.toStatusError(ctx, err)
+	}
+	parsedOrderNumber, err := utils.ParseOrderNumber(createWithdrawnDto.OrderNumber)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	withdrawn := model.NewWithdrawn(currentUser.ID, parsedOrderNumber, createWithdrawnDto.PointsAmount)
+	err = s.WithdrawnService.CreateWithdrawn(ctx, withdrawn)
+	if err != nil {
+		return nil, s.toStatusError(ctx, err)
+	}
+
+	return &pb.WithdrawResponse{}, nil
+}
+
+func (s *Server) ListWithdrawals(ctx context.Context, in *pb.ListWithdrawalsRequest) (*pb.ListWithdrawalsResponse, error) {
+	currentUser, err := s.UserService.GetCurrentUser(ctx)
+	if err != nil {
+		return nil, s.toStatusError(ctx, err)
+	}
+
+	filter, err := parseWithdrawalsFilter(in, currentUser.ID)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	page, err := s.WithdrawnService.GetWithdrawalsPage(ctx, filter)
+	if err != nil {
+		return nil, s.toStatusError(ctx, err)
+	}
+
+	withdrawals := make([]*pb.Withdrawal, len(page.Withdrawals))
+	for i, withdrawn := range page.Withdrawals {
+		withdrawals[i] = &pb.Withdrawal{
+			Order:       utils.FormatOrderNumber(withdrawn.OrderNumber),
+			Sum:         withdrawn.PointsAmount,
+			ProcessedAt: toTimestamp(withdrawn.ProcessedAt),
+		}
+	}
+
+	return &pb.ListWithdrawalsResponse{
+		Withdrawals:   withdrawals,
+		NextPageToken: encodePageToken(page.NextCursor),
+		TotalCount:    page.TotalCount,
+	}, nil
+}
+
+func parseWithdrawalsFilter(in *pb.ListWithdrawalsRequest, userID int64) (model.WithdrawalsFilter, error) {
+	params, err := parsePageParams(in.GetPageSize(), in.GetPageToken(), in.GetSort())
+	if err != nil {
+		return model.WithdrawalsFilter{}, err
+	}
+
+	processedFrom, processedTo, err := parseTimeRange(in.GetProcessedFrom(), in.GetProcessedTo(), "processed")
+	if err != nil {
+		return model.WithdrawalsFilter{}, err
+	}
+
+	if in.GetMinSum() < 0 || in.GetMaxSum() < 0 {
+		return model.WithdrawalsFilter{}, fmt.Errorf("min_sum and max_sum should not be negative")
+	}
+	if in.GetMinSum() > 0 && in.GetMaxSum() > 0 && in.GetMinSum() > in.GetMaxSum() {
+		return model.WithdrawalsFilter{}, fmt.Errorf("min_sum should not be greater than max_sum")
+	}
+
+	return model.WithdrawalsFilter{
+		UserID:        userID,
+		ProcessedFrom: processedFrom,
+		ProcessedTo:   processedTo,
+		MinSum:        in.GetMinSum(),
+		MaxSum:        in.GetMaxSum(),
+		After:         params.After,
+		SortDirection: params.SortDirection,
+		Limit:         params.Limit,
+	}, nil
+}
@@ -2,18 +2,45 @@ package scheduler
 
 import (
 	"context"
+	"net/http"
 	"sync"
 	"time"
 
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.25.0"
+	"go.opentelemetry.io/otel/trace"
 	"gopkg.in/h2non/gentleman.v2"
+
+	"github.com/Stern-Ritter/gophermart/internal/tracing"
 )
 
-func sendGetRequest(client *gentleman.Client, endpoint string) (*gentleman.Response, error) {
+func sendGetRequest(ctx context.Context, client *gentleman.Client, endpoint string) (*gentleman.Response, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "accrual_system.get_order", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.HTTPRequestMethodKey.String(http.MethodGet), semconv.URLPath(endpoint)))
+	defer span.End()
+
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+
 	req := client.Request()
 	req.Method("GET")
 	req.Path(endpoint)
+	req.SetHeaders(carrier)
+
+	resp, err := req.Send()
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return resp, err
+	}
 
-	return req.Send()
+	span.SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode))
+	if resp.StatusCode >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, http.StatusText(resp.StatusCode))
+	}
+	return resp, nil
 }
 
 func setInterval(ctx context.Context, wg *sync.WaitGroup, task func(), interval time.Duration) {
@@ -2,11 +2,19 @@ package scheduler
 
 import (
 	"context"
+	"net/http"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	gmock "gopkg.in/h2non/gentleman-mock.v2"
+	"gopkg.in/h2non/gentleman.v2"
 )
 
 func TestSetInterval(t *testing.T) {
@@ -33,3 +41,36 @@ func TestSetInterval(t *testing.T) {
 		"Expected number of task function calls with interval: %d and duration: %d should be at least: %d",
 		interval, duration, expectedCountAtLeast)
 }
+
+func TestSendGetRequestPropagatesTraceContext(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previousProvider, previousPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer func() {
+		otel.SetTracerProvider(previousProvider)
+		otel.SetTextMapPropagator(previousPropagator)
+	}()
+
+	defer gmock.Disable()
+	gmock.New("").
+		Get("/api/orders/12345678903").
+		MatchHeader("traceparent", "^00-[0-9a-f]{32}-[0-9a-f]{16}-01$").
+		Reply(http.StatusNoContent)
+	httpClient := gentleman.New()
+	httpClient.Use(gmock.Plugin)
+
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "parent")
+	resp, err := sendGetRequest(ctx, httpClient, "/api/orders/12345678903")
+	parent.End()
+
+	require.NoError(t, err, "Request should carry traceparent header")
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode, "Response status code does not match expected status")
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2, "Request span and parent span should be recorded")
+	assert.Equal(t, "accrual_system.get_order", spans[0].Name(), "Request span name does not match expected name")
+	assert.Equal(t, parent.SpanContext().TraceID(), spans[0].SpanContext().TraceID(),
+		"Request span should belong to parent trace")
+}
@@ -40,6 +40,50 @@ func (m *MockAccrualStorage) EXPECT() *MockAccrualStorageMockRecorder {
 	return m.recorder
 }
 
+// CountByStatus mocks base method.
+func (m *MockAccrualStorage) CountByStatus(ctx context.Context) (map[model.AccrualStatus]int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountByStatus", ctx)
+	ret0, _ := ret[0].(map[model.AccrualStatus]int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountByStatus indicates an expected call of CountByStatus.
+func (mr *MockAccrualStorageMockRecorder) CountByStatus(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountByStatus", reflect.TypeOf((*MockAccrualStorage)(nil).CountByStatus), ctx)
+}
+
+// ForEachByUserIDOrderByUploadedAtAsc mocks base method.
+func (m *MockAccrualStorage) ForEachByUserIDOrderByUploadedAtAsc(ctx context.Context, userID int64, fn func(model.Accrual) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ForEachByUserIDOrderByUploadedAtAsc", ctx, userID, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ForEachByUserIDOrderByUploadedAtAsc indicates an expected call of ForEachByUserIDOrderByUploadedAtAsc.
+func (mr *MockAccrualStorageMockRecorder) ForEachByUserIDOrderByUploadedAtAsc(ctx, userID, fn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ForEachByUserIDOrderByUploadedAtAsc", reflect.TypeOf((*MockAccrualStorage)(nil).ForEachByUserIDOrderByUploadedAtAsc), ctx, userID, fn)
+}
+
+// GetAllByFilter mocks base method.
+func (m *MockAccrualStorage) GetAllByFilter(ctx context.Context, filter model.AccrualsFilter) ([]model.Accrual, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAllByFilter", ctx, filter)
+	ret0, _ := ret[0].([]model.Accrual)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAllByFilter indicates an expected call of GetAllByFilter.
+func (mr *MockAccrualStorageMockRecorder) GetAllByFilter(ctx, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllByFilter", reflect.TypeOf((*MockAccrualStorage)(nil).GetAllByFilter), ctx, filter)
+}
+
 // GetAllByUserIDOrderByUploadedAtAsc mocks base method.
 func (m *MockAccrualStorage) GetAllByUserIDOrderByUploadedAtAsc(ctx context.Context, userID int64) ([]model.Accrual, error) {
 	m.ctrl.T.Helper()
@@ -70,6 +114,35 @@ func (mr *MockAccrualStorageMockRecorder) GetAllUnprocessedWithLimit(ctx, limit
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllUnprocessedWithLimit", reflect.TypeOf((*MockAccrualStorage)(nil).GetAllUnprocessedWithLimit), ctx, limit)
 }
 
+// GetOneByOrderNumber mocks base method.
+func (m *MockAccrualStorage) GetOneByOrderNumber(ctx context.Context, orderNumber int64) (model.Accrual, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOneByOrderNumber", ctx, orderNumber)
+	ret0, _ := ret[0].(model.Accrual)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOneByOrderNumber indicates an expected call of GetOneByOrderNumber.
+func (mr *MockAccrualStorageMockRecorder) GetOneByOrderNumber(ctx, orderNumber any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOneByOrderNumber", reflect.TypeOf((*MockAccrualStorage)(nil).GetOneByOrderNumber), ctx, orderNumber)
+}
+
+// Requeue mocks base method.
+func (m *MockAccrualStorage) Requeue(ctx context.Context, orderNumber int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Requeue", ctx, orderNumber)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Requeue indicates an expected call of Requeue.
+func (mr *MockAccrualStorageMockRecorder) Requeue(ctx, orderNumber any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Requeue", reflect.TypeOf((*MockAccrualStorage)(nil).Requeue), ctx, orderNumber)
+}
+
 // Save mocks base method.
 func (m *MockAccrualStorage) Save(ctx context.Context, accrual model.Accrual) error {
 	m.ctrl.T.Helper()
@@ -84,6 +157,21 @@ func (mr *MockAccrualStorageMockRecorder) Save(ctx, accrual any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockAccrualStorage)(nil).Save), ctx, accrual)
 }
 
+// SaveInBatch mocks base method.
+func (m *MockAccrualStorage) SaveInBatch(ctx context.Context, accruals []model.Accrual) ([]model.AccrualBatchItemResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveInBatch", ctx, accruals)
+	ret0, _ := ret[0].([]model.AccrualBatchItemResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SaveInBatch indicates an expected call of SaveInBatch.
+func (mr *MockAccrualStorageMockRecorder) SaveInBatch(ctx, accruals any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveInBatch", reflect.TypeOf((*MockAccrualStorage)(nil).SaveInBatch), ctx, accruals)
+}
+
 // Update mocks base method.
 func (m *MockAccrualStorage) Update(ctx context.Context, accrual model.Accrual) error {
 	m.ctrl.T.Helper()
@@ -1,9 +1,13 @@
 package scheduler
 
 import (
+	"context"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/cenkalti/backoff/v4"
+	"go.opentelemetry.io/otel/trace"
 	"gopkg.in/h2non/gentleman.v2"
 
 	"github.com/Stern-Ritter/gophermart/internal/logger"
@@ -14,28 +18,42 @@ import (
 type Scheduler interface {
 	RunTasks()
 	StopTasks()
+	LastHeartbeat() time.Time
+	Reconfigure(processAccrualsBatchMaxSize int, processAccrualsWorkerPoolSize int)
+	SyncNow(ctx context.Context) (int, error)
+}
+
+// accrualsBatch carries the id and span context of the claim that produced it, so that processing logs and spans
+// can be correlated with it.
+type accrualsBatch struct {
+	id          string
+	accruals    []model.Accrual
+	spanContext trace.SpanContext
 }
 
 type AccrualsScheduler struct {
 	HTTPClient                    *gentleman.Client
 	accrualService                service.AccrualService
-	processingAccrualsCh          chan []model.Accrual
+	processingAccrualsCh          chan accrualsBatch
 	doneCh                        chan struct{}
-	processAccrualsBatchMaxSize   int
+	processAccrualsBatchMaxSize   atomic.Int64
 	processAccrualsWorkerPoolSize int
-	getNewAccrualsInterval        int
+	getNewAccrualsInterval        time.Duration
 	processAccrualsRetryInterval  *backoff.ExponentialBackOff
+	workersMu                     sync.Mutex
+	workerStopChs                 []chan struct{}
+	lastHeartbeat                 atomic.Int64
 	logger                        *logger.ServerLogger
 }
 
 func NewAccrualsScheduler(accrualService service.AccrualService,
 	processAccrualsSystemURL string, processAccrualsBufferSize int, processAccrualsBatchMaxSize int,
-	processAccrualsWorkerPoolSize int, getNewAccrualsInterval int, logger *logger.ServerLogger) Scheduler {
+	processAccrualsWorkerPoolSize int, getNewAccrualsInterval time.Duration, logger *logger.ServerLogger) Scheduler {
 
 	httpClient := gentleman.New()
 	httpClient.URL(processAccrualsSystemURL)
 
-	processingAccrualsCh := make(chan []model.Accrual, processAccrualsBufferSize)
+	processingAccrualsCh := make(chan accrualsBatch, processAccrualsBufferSize)
 	doneCh := make(chan struct{})
 
 	processAccrualsRetryInterval := backoff.NewExponentialBackOff(
@@ -45,15 +63,41 @@ func NewAccrualsScheduler(accrualService service.AccrualService,
 		backoff.WithMaxInterval(60*time.Second),
 		backoff.WithMaxElapsedTime(120*time.Second))
 
-	return &AccrualsScheduler{
+	s := &AccrualsScheduler{
 		HTTPClient:                    httpClient,
 		accrualService:                accrualService,
 		processingAccrualsCh:          processingAccrualsCh,
 		doneCh:                        doneCh,
-		processAccrualsBatchMaxSize:   processAccrualsBatchMaxSize,
 		processAccrualsWorkerPoolSize: processAccrualsWorkerPoolSize,
 		getNewAccrualsInterval:        getNewAccrualsInterval,
 		processAccrualsRetryInterval:  processAccrualsRetryInterval,
 		logger:                        logger,
 	}
+	s.processAccrualsBatchMaxSize.Store(int64(processAccrualsBatchMaxSize))
+	return s
+}
+
+// Reconfigure applies new batch and worker pool sizes to the running scheduler. The batch size is used by the next
+// fetch, the worker pool is grown or shrunk, removed workers finish their current batch first.
+func (s *AccrualsScheduler) Reconfigure(processAccrualsBatchMaxSize int, processAccrualsWorkerPoolSize int) {
+	s.processAccrualsBatchMaxSize.Store(int64(processAccrualsBatchMaxSize))
+	s.resizeWorkerPool(processAccrualsWorkerPoolSize)
+}
+
+// BatchMaxSize returns the number of accruals fetched for processing at once.
+func (s *AccrualsScheduler) BatchMaxSize() int {
+	return int(s.processAccrualsBatchMaxSize.Load())
+}
+
+// LastHeartbeat returns the time the accruals fetching loop or one of the workers last made progress.
+func (s *AccrualsScheduler) LastHeartbeat() time.Time {
+	nanos := s.lastHeartbeat.Load()
+	if nanos == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, nanos)
+}
+
+func (s *AccrualsScheduler) heartbeat() {
+	s.lastHeartbeat.Store(time.Now().UnixNano())
 }
@@ -10,18 +10,27 @@ import (
 	"time"
 
 	"github.com/cenkalti/backoff/v4"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
+	"gopkg.in/h2non/gentleman.v2"
 
 	er "github.com/Stern-Ritter/gophermart/internal/errors"
+	"github.com/Stern-Ritter/gophermart/internal/logger"
+	"github.com/Stern-Ritter/gophermart/internal/metrics"
 	"github.com/Stern-Ritter/gophermart/internal/model"
+	"github.com/Stern-Ritter/gophermart/internal/tracing"
 	"github.com/Stern-Ritter/gophermart/internal/utils"
 )
 
 const (
-	taskCount = 1
+	taskCount               = 2
+	accrualsMetricsInterval = 15 * time.Second
 )
 
 func (s *AccrualsScheduler) RunTasks() {
+	s.heartbeat()
 	go func() {
 		wg := sync.WaitGroup{}
 		wg.Add(taskCount)
@@ -30,7 +39,8 @@ func (s *AccrualsScheduler) RunTasks() {
 		defer cancel()
 
 		s.startProcessingAccrualsWorkerPool()
-		setInterval(ctx, &wg, s.getNewAccrualsInProcessing, time.Duration(s.getNewAccrualsInterval)*time.Second)
+		setInterval(ctx, &wg, s.getNewAccrualsInProcessing, s.getNewAccrualsInterval)
+		setInterval(ctx, &wg, s.updateAccrualsMetrics, accrualsMetricsInterval)
 
 		wg.Wait()
 	}()
@@ -41,111 +51,252 @@ func (s *AccrualsScheduler) StopTasks() {
 }
 
 func (s *AccrualsScheduler) getNewAccrualsInProcessing() {
-	accruals, err := s.accrualService.GetAllNewAccrualsInProcessingWithLimit(context.Background(),
-		int64(s.processAccrualsBatchMaxSize))
+	s.heartbeat()
+	batchID := logger.NewRequestID()
+	ctx, span := tracing.Tracer().Start(logger.WithRequestID(context.Background(), batchID), "accruals.claim")
+	defer span.End()
+	l := s.logger.WithContext(ctx)
+
+	accruals, err := s.accrualService.GetAllNewAccrualsInProcessingWithLimit(ctx, s.processAccrualsBatchMaxSize.Load())
 	if err != nil {
-		s.logger.Error("Error getting new accruals from database",
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		l.Error("Error getting new accruals from database",
 			zap.String("event", "getting new accruals"), zap.Error(err))
 		return
 	}
+	span.SetAttributes(attribute.Int("accruals.count", len(accruals)))
 	if len(accruals) == 0 {
-		s.logger.Info("No new accruals found",
+		l.Info("No new accruals found",
 			zap.String("event", "getting new accruals"))
 		return
 	}
-	s.logger.Info("Success getting new accruals from database",
+	l.Info("Success getting new accruals from database",
 		zap.String("event", "getting new accruals"))
 
 	select {
 	case <-s.doneCh:
 		close(s.processingAccrualsCh)
-		s.logger.Info("Getting new accruals stopped",
+		l.Info("Getting new accruals stopped",
 			zap.String("event", "getting new accruals stopped"))
-	case s.processingAccrualsCh <- accruals:
+	case s.processingAccrualsCh <- accrualsBatch{id: batchID, accruals: accruals, spanContext: span.SpanContext()}:
+		metrics.AccrualQueueDepth.Set(float64(len(s.processingAccrualsCh)))
 	}
 }
 
-func (s *AccrualsScheduler) startProcessingAccrualsWorkerPool() {
-	processAccrualsWorkerPoolSize := s.processAccrualsWorkerPoolSize
-	if processAccrualsWorkerPoolSize <= 0 {
-		s.logger.Error("Process accruals worker pool size can't be less than or equal to zero",
-			zap.String("event", "start send accruals worker pool"))
-		processAccrualsWorkerPoolSize = 1
+func (s *AccrualsScheduler) updateAccrualsMetrics() {
+	counts, err := s.accrualService.CountAccrualsByStatus(context.Background())
+	if err != nil {
+		s.logger.Error("Error counting accruals by status",
+			zap.String("event", "update accruals metrics"), zap.Error(err))
+		return
 	}
 
-	for w := 1; w <= processAccrualsWorkerPoolSize; w++ {
-		go s.processingAccrualsWorker(w, s.processingAccrualsCh)
+	for _, status := range []model.AccrualStatus{model.AccrualNew, model.AccrualProcessing, model.AccrualInvalid,
+		model.AccrualProcessed} {
+		metrics.Orders.WithLabelValues(string(status)).Set(float64(counts[status]))
 	}
+}
 
+func (s *AccrualsScheduler) startProcessingAccrualsWorkerPool() {
+	s.resizeWorkerPool(s.processAccrualsWorkerPoolSize)
 	s.logger.Debug("Worker pool started",
 		zap.String("event", "start send accruals worker pool"))
 }
 
-func (s *AccrualsScheduler) processingAccrualsWorker(id int, processingAccrualsCh <-chan []model.Accrual) {
+func (s *AccrualsScheduler) resizeWorkerPool(size int) {
+	if size <= 0 {
+		s.logger.Error("Process accruals worker pool size can't be less than or equal to zero",
+			zap.String("event", "resize send accruals worker pool"), zap.Int("size", size))
+		size = 1
+	}
+
+	s.workersMu.Lock()
+	defer s.workersMu.Unlock()
+
+	for len(s.workerStopChs) < size {
+		stopCh := make(chan struct{})
+		s.workerStopChs = append(s.workerStopChs, stopCh)
+		go s.processingAccrualsWorker(len(s.workerStopChs), s.processingAccrualsCh, stopCh)
+	}
+	for len(s.workerStopChs) > size {
+		last := len(s.workerStopChs) - 1
+		close(s.workerStopChs[last])
+		s.workerStopChs = s.workerStopChs[:last]
+	}
+}
+
+func (s *AccrualsScheduler) processingAccrualsWorker(id int, processingAccrualsCh <-chan accrualsBatch,
+	stopCh <-chan struct{}) {
 	s.logger.Debug("Worker started", zap.Int("worker id", id),
 		zap.String("event", "start processing accruals worker"))
+	defer s.logger.Debug("Worker stopped", zap.Int("worker id", id),
+		zap.String("event", "stop processing accruals worker"))
+
+	for {
+		var batch accrualsBatch
+		select {
+		case <-stopCh:
+			return
+		case b, ok := <-processingAccrualsCh:
+			if !ok {
+				return
+			}
+			batch = b
+		}
+
+		metrics.AccrualQueueDepth.Set(float64(len(processingAccrualsCh)))
+		metrics.AccrualBusyWorkers.Inc()
+		ctx, span := tracing.Tracer().Start(logger.WithRequestID(context.Background(), batch.id), "accruals.process_batch",
+			trace.WithLinks(trace.Link{SpanContext: batch.spanContext}),
+			trace.WithAttributes(attribute.Int("worker.id", id), attribute.Int("accruals.count", len(batch.accruals))))
+
+		err := s.processBatch(ctx, id, batch.accruals)
+		metrics.AccrualBusyWorkers.Dec()
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			span.End()
+			return
+		}
+		span.End()
+	}
+}
+
+// SyncNow claims all accruals waiting for processing and processes them in the calling goroutine, in batches of
+// the configured size. It returns the number of claimed accruals.
+func (s *AccrualsScheduler) SyncNow(ctx context.Context) (int, error) {
+	counts, err := s.accrualService.CountAccrualsByStatus(ctx)
+	if err != nil {
+		return 0, err
+	}
+	pending := counts[model.AccrualNew] + counts[model.AccrualProcessing]
+	if pending == 0 {
+		return 0, nil
+	}
 
-	for accrualsBatch := range processingAccrualsCh {
-		processedAccruals := make([]model.Accrual, 0)
+	// Accruals still pending in the accrual system are released after processing, so everything is claimed at once
+	// to process each accrual only once. Accruals left unprocessed after an error are released.
+	accruals, err := s.accrualService.GetAllNewAccrualsInProcessingWithLimit(ctx, pending)
+	if err != nil {
+		return 0, err
+	}
+
+	batchSize := int(s.processAccrualsBatchMaxSize.Load())
+	for start := 0; start < len(accruals); start += batchSize {
+		end := min(start+batchSize, len(accruals))
+		err = ctx.Err()
+		if err == nil {
+			err = s.processBatch(ctx, 0, accruals[start:end])
+		}
+		if err != nil {
+			s.releaseAccruals(ctx, accruals[start:])
+			return start, err
+		}
+	}
+
+	return len(accruals), nil
+}
+
+// releaseAccruals saves claimed accruals unchanged, which removes their processing lock, so that the scheduler can
+// claim them again.
+func (s *AccrualsScheduler) releaseAccruals(ctx context.Context, accruals []model.Accrual) {
+	if err := s.accrualService.UpdateAccruals(context.WithoutCancel(ctx), accruals); err != nil {
+		s.logger.WithContext(ctx).Error("Error releasing unprocessed accruals",
+			zap.String("event", "releasing accruals"), zap.Int("count", len(accruals)), zap.Error(err))
+	}
+}
 
-		for _, accrual := range accrualsBatch {
-			processingAccrual := func() error {
-				endpoint := strings.Join([]string{"/api/orders", utils.FormatOrderNumber(accrual.OrderNumber)}, "/")
-				resp, err := sendGetRequest(s.HTTPClient, endpoint)
-				s.logger.Debug("Received response", zap.String("event", "received response"),
-					zap.String("body", string(resp.Bytes())), zap.Error(err))
+func (s *AccrualsScheduler) processBatch(ctx context.Context, id int, accruals []model.Accrual) error {
+	l := s.logger.WithContext(ctx)
+
+	processedAccruals := make([]model.Accrual, 0)
+	accruedPoints := 0.0
+
+	for _, accrual := range accruals {
+		processingAccrual := func() error {
+			s.heartbeat()
+			endpoint := strings.Join([]string{"/api/orders", utils.FormatOrderNumber(accrual.OrderNumber)}, "/")
+			start := time.Now()
+			resp, err := sendGetRequest(ctx, s.HTTPClient, endpoint)
+			observeAccrualSystemRequest(resp, err, time.Since(start))
+			l.Debug("Received response", zap.String("event", "received response"),
+				zap.String("body", string(resp.Bytes())), zap.Error(err))
+
+			if err != nil {
+				return backoff.Permanent(err)
+			}
 
+			switch resp.StatusCode {
+			case http.StatusOK:
+				accrualProcessDto, err := decodeAccrualProcessDto(resp.Bytes())
 				if err != nil {
 					return backoff.Permanent(err)
 				}
-
-				switch resp.StatusCode {
-				case http.StatusOK:
-					accrualProcessDto, err := decodeAccrualProcessDto(resp.Bytes())
-					if err != nil {
-						return backoff.Permanent(err)
-					}
-					switch accrualProcessDto.Status {
-					case model.AccrualProcessInvalid, model.AccrualProcessProcessed:
-						processedAccrual := model.UpdateAccrualFormAccrualProcessDto(accrual, accrualProcessDto)
-						processedAccruals = append(processedAccruals, processedAccrual)
-					case model.AccrualProcessRegistered, model.AccrualProcessProcessing:
-						processedAccruals = append(processedAccruals, accrual)
+				switch accrualProcessDto.Status {
+				case model.AccrualProcessInvalid, model.AccrualProcessProcessed:
+					processedAccrual := model.UpdateAccrualFormAccrualProcessDto(accrual, accrualProcessDto)
+					processedAccruals = append(processedAccruals, processedAccrual)
+					if processedAccrual.Status == model.AccrualProcessed {
+						accruedPoints += processedAccrual.PointsAmount
 					}
-				case http.StatusNoContent:
+				case model.AccrualProcessRegistered, model.AccrualProcessProcessing:
 					processedAccruals = append(processedAccruals, accrual)
-				case http.StatusTooManyRequests, http.StatusInternalServerError:
-					return er.NewRequestProcessingError(
-						fmt.Sprintf("Unsuccess request sent on url: %s, status code: %d", endpoint, resp.StatusCode), nil)
-				default:
-					return backoff.Permanent(fmt.Errorf("unexpected response status code: %d", resp.StatusCode))
 				}
-				return nil
-			}
-
-			if sendErr := backoff.Retry(processingAccrual, s.processAccrualsRetryInterval); sendErr != nil {
-				s.logger.Error("Error processing accrual", zap.Int("worker id", id),
-					zap.Error(sendErr), zap.String("event", "processing accrual"))
+			case http.StatusNoContent:
 				processedAccruals = append(processedAccruals, accrual)
-				continue
+			case http.StatusTooManyRequests, http.StatusInternalServerError:
+				return er.NewRequestProcessingError(
+					fmt.Sprintf("Unsuccess request sent on url: %s, status code: %d", endpoint, resp.StatusCode), nil)
+			default:
+				return backoff.Permanent(fmt.Errorf("unexpected response status code: %d", resp.StatusCode))
 			}
+			return nil
+		}
 
-			s.logger.Debug("Processing accrual done", zap.Int("worker id", id),
-				zap.String("event", "processing accrual"))
+		if sendErr := backoff.Retry(processingAccrual, s.processAccrualsRetryInterval); sendErr != nil {
+			l.Error("Error processing accrual", zap.Int("worker id", id),
+				zap.Error(sendErr), zap.String("event", "processing accrual"))
+			processedAccruals = append(processedAccruals, accrual)
+			continue
 		}
 
-		err := s.accrualService.UpdateAccruals(context.Background(), processedAccruals)
-		if err != nil {
-			s.logger.Error("Error saving processed accruals in database",
-				zap.String("event", "saving processed accruals"), zap.Error(err))
-			return
+		l.Debug("Processing accrual done", zap.Int("worker id", id),
+			zap.String("event", "processing accrual"))
+	}
+
+	err := s.accrualService.UpdateAccruals(ctx, processedAccruals)
+	if err != nil {
+		l.Error("Error saving processed accruals in database",
+			zap.String("event", "saving processed accruals"), zap.Error(err))
+		return err
+	}
+	metrics.PointsAccruedTotal.Add(accruedPoints)
+	l.Info("Success saving processed accruals in database",
+		zap.String("event", "saving processed accruals"))
+
+	return nil
+}
+
+func observeAccrualSystemRequest(resp *gentleman.Response, err error, duration time.Duration) {
+	outcome := metrics.AccrualSystemOutcomeError
+	if err == nil {
+		switch resp.StatusCode {
+		case http.StatusOK:
+			outcome = metrics.AccrualSystemOutcomeOK
+		case http.StatusNoContent:
+			outcome = metrics.AccrualSystemOutcomeNotRegistered
+		case http.StatusTooManyRequests:
+			outcome = metrics.AccrualSystemOutcomeRateLimited
+		case http.StatusInternalServerError:
+			outcome = metrics.AccrualSystemOutcomeServerError
+		default:
+			outcome = metrics.AccrualSystemOutcomeUnexpectedStatus
 		}
-		s.logger.Info("Success saving processed accruals in database",
-			zap.String("event", "saving processed accruals"))
 	}
 
-	s.logger.Debug("Worker stopped", zap.Int("worker id", id),
-		zap.String("event", "stop processing accruals worker"))
+	metrics.AccrualSystemRequestsTotal.WithLabelValues(outcome).Inc()
+	metrics.AccrualSystemRequestDuration.WithLabelValues(outcome).Observe(duration.Seconds())
 }
 
 func decodeAccrualProcessDto(buf []byte) (model.AccrualProcessDto, error) {
@@ -2,12 +2,15 @@ package scheduler
 
 import (
 	"context"
+	"errors"
 	"net/http"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/cenkalti/backoff/v4"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
@@ -15,6 +18,7 @@ import (
 	"gopkg.in/h2non/gentleman.v2"
 
 	"github.com/Stern-Ritter/gophermart/internal/logger"
+	"github.com/Stern-Ritter/gophermart/internal/metrics"
 	"github.com/Stern-Ritter/gophermart/internal/model"
 	"github.com/Stern-Ritter/gophermart/internal/service"
 )
@@ -34,12 +38,16 @@ func TestProcessingAccrualsWorker(t *testing.T) {
 		expectedAccruals   []model.Accrual
 		responseStatus     int
 		responseBody       string
+		expectedOutcome    string
+		expectedPoints     float64
 	}{
 		{
 			name:               "should update accrual when response status code is 200 and status is 'PROCESSED'",
 			processingAccruals: processingAccruals,
 			responseStatus:     http.StatusOK,
 			responseBody:       `{"order":"12345678903","status":"PROCESSED","accrual":500}`,
+			expectedOutcome:    metrics.AccrualSystemOutcomeOK,
+			expectedPoints:     500,
 
 			expectedAccruals: []model.Accrual{{
 				UserID:       1,
@@ -54,6 +62,7 @@ func TestProcessingAccrualsWorker(t *testing.T) {
 			processingAccruals: processingAccruals,
 			responseStatus:     http.StatusOK,
 			responseBody:       `{"order":"12345678903","status":"REGISTERED"}`,
+			expectedOutcome:    metrics.AccrualSystemOutcomeOK,
 
 			expectedAccruals: processingAccruals,
 		},
@@ -62,24 +71,28 @@ func TestProcessingAccrualsWorker(t *testing.T) {
 			processingAccruals: processingAccruals,
 			responseStatus:     http.StatusOK,
 			responseBody:       `{"order":"12345678903","status":"PROCESSING"}`,
+			expectedOutcome:    metrics.AccrualSystemOutcomeOK,
 			expectedAccruals:   processingAccruals,
 		},
 		{
 			name:               "should not update accrual when response status code is 204",
 			processingAccruals: processingAccruals,
 			responseStatus:     http.StatusNoContent,
+			expectedOutcome:    metrics.AccrualSystemOutcomeNotRegistered,
 			expectedAccruals:   processingAccruals,
 		},
 		{
 			name:               "should not update accrual when response status code is 429",
 			processingAccruals: processingAccruals,
 			responseStatus:     http.StatusTooManyRequests,
+			expectedOutcome:    metrics.AccrualSystemOutcomeRateLimited,
 			expectedAccruals:   processingAccruals,
 		},
 		{
 			name:               "should not update accrual when response status code is 500",
 			processingAccruals: processingAccruals,
 			responseStatus:     http.StatusInternalServerError,
+			expectedOutcome:    metrics.AccrualSystemOutcomeServerError,
 			expectedAccruals:   processingAccruals,
 		},
 	}
@@ -115,9 +128,9 @@ func TestProcessingAccrualsWorker(t *testing.T) {
 				logger: logger,
 			}
 
-			processingAccrualsCh := make(chan []model.Accrual, 1)
+			processingAccrualsCh := make(chan accrualsBatch, 1)
 
-			processingAccrualsCh <- tt.processingAccruals
+			processingAccrualsCh <- accrualsBatch{accruals: tt.processingAccruals}
 			close(processingAccrualsCh)
 
 			var processedAccruals []model.Accrual
@@ -128,8 +141,179 @@ func TestProcessingAccrualsWorker(t *testing.T) {
 				}).
 				Return(nil)
 
-			s.processingAccrualsWorker(1, processingAccrualsCh)
+			requestsBefore := testutil.ToFloat64(metrics.AccrualSystemRequestsTotal.WithLabelValues(tt.expectedOutcome))
+			pointsBefore := testutil.ToFloat64(metrics.PointsAccruedTotal)
+
+			s.processingAccrualsWorker(1, processingAccrualsCh, make(chan struct{}))
 			equalAccruals(t, tt.expectedAccruals, processedAccruals)
+
+			assert.Less(t, requestsBefore, testutil.ToFloat64(metrics.AccrualSystemRequestsTotal.WithLabelValues(tt.expectedOutcome)),
+				"Accrual system requests should be counted by outcome")
+			assert.Equal(t, pointsBefore+tt.expectedPoints, testutil.ToFloat64(metrics.PointsAccruedTotal),
+				"Accrued points should be counted for processed orders")
+			assert.Zero(t, testutil.ToFloat64(metrics.AccrualBusyWorkers), "Worker should not be busy after processing batch")
+		})
+	}
+}
+
+func TestSyncNow(t *testing.T) {
+	pendingAccruals := []model.Accrual{
+		{UserID: 1, OrderNumber: 12345678903, Status: model.AccrualNew},
+		{UserID: 1, OrderNumber: 2377225624, Status: model.AccrualProcessing},
+		{UserID: 2, OrderNumber: 49927398716, Status: model.AccrualNew},
+	}
+
+	tests := []struct {
+		name            string
+		counts          map[model.AccrualStatus]int64
+		expectedLimit   int64
+		expectedSynced  int
+		expectedBatches int
+	}{
+		{
+			name:            "should process all pending accruals in batches of configured size",
+			counts:          map[model.AccrualStatus]int64{model.AccrualNew: 2, model.AccrualProcessing: 1, model.AccrualProcessed: 5},
+			expectedLimit:   3,
+			expectedSynced:  3,
+			expectedBatches: 2,
+		},
+		{
+			name:           "should not claim accruals when there are no pending accruals",
+			counts:         map[model.AccrualStatus]int64{model.AccrualProcessed: 5},
+			expectedSynced: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer gmock.Disable()
+			for _, accrual := range pendingAccruals {
+				gmock.New("").
+					Get("/api/orders/" + strconv.FormatInt(accrual.OrderNumber, 10)).
+					Reply(http.StatusNoContent)
+			}
+			httpClient := gentleman.New()
+			httpClient.Use(gmock.Plugin)
+
+			logger, err := logger.Initialize("error")
+			require.NoError(t, err, "Error init logger")
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			accrualStorage := NewMockAccrualStorage(ctrl)
+			accrualService := service.NewAccrualService(accrualStorage, logger)
+
+			s := &AccrualsScheduler{
+				HTTPClient:                   httpClient,
+				accrualService:               accrualService,
+				processAccrualsRetryInterval: backoff.NewExponentialBackOff(),
+				logger:                       logger,
+			}
+			s.processAccrualsBatchMaxSize.Store(2)
+
+			accrualStorage.EXPECT().CountByStatus(gomock.Any()).Return(tt.counts, nil)
+			if tt.expectedLimit > 0 {
+				accrualStorage.EXPECT().GetAllUnprocessedWithLimit(gomock.Any(), tt.expectedLimit).
+					Return(pendingAccruals, nil)
+			}
+			batches := 0
+			accrualStorage.EXPECT().UpdateInBatch(gomock.Any(), gomock.Any()).
+				Do(func(ctx context.Context, accruals []model.Accrual) {
+					batches++
+				}).
+				Return(nil).
+				Times(tt.expectedBatches)
+
+			synced, err := s.SyncNow(context.Background())
+			require.NoError(t, err, "Error syncing accruals")
+			assert.Equal(t, tt.expectedSynced, synced, "Synced accruals count does not match expected count")
+			assert.Equal(t, tt.expectedBatches, batches, "Processed batches count does not match expected count")
+		})
+	}
+}
+
+func TestSyncNowReleasesUnprocessedAccruals(t *testing.T) {
+	pendingAccruals := []model.Accrual{
+		{UserID: 1, OrderNumber: 12345678903, Status: model.AccrualNew},
+		{UserID: 1, OrderNumber: 2377225624, Status: model.AccrualProcessing},
+		{UserID: 2, OrderNumber: 49927398716, Status: model.AccrualNew},
+	}
+
+	tests := []struct {
+		name             string
+		updateErr        error
+		cancel           bool
+		expectedSynced   int
+		expectedReleased []model.Accrual
+	}{
+		{
+			name:             "should release all claimed accruals when saving the first batch failed",
+			updateErr:        errors.New("unexpected error"),
+			expectedSynced:   0,
+			expectedReleased: pendingAccruals,
+		},
+		{
+			name:             "should release remaining accruals when sync was interrupted",
+			cancel:           true,
+			expectedSynced:   2,
+			expectedReleased: pendingAccruals[2:],
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer gmock.Disable()
+			for _, accrual := range pendingAccruals {
+				gmock.New("").
+					Get("/api/orders/" + strconv.FormatInt(accrual.OrderNumber, 10)).
+					Reply(http.StatusNoContent)
+			}
+			httpClient := gentleman.New()
+			httpClient.Use(gmock.Plugin)
+
+			logger, err := logger.Initialize("error")
+			require.NoError(t, err, "Error init logger")
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			accrualStorage := NewMockAccrualStorage(ctrl)
+			accrualService := service.NewAccrualService(accrualStorage, logger)
+
+			s := &AccrualsScheduler{
+				HTTPClient:                   httpClient,
+				accrualService:               accrualService,
+				processAccrualsRetryInterval: backoff.NewExponentialBackOff(),
+				logger:                       logger,
+			}
+			s.processAccrualsBatchMaxSize.Store(2)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			accrualStorage.EXPECT().CountByStatus(gomock.Any()).
+				Return(map[model.AccrualStatus]int64{model.AccrualNew: 3}, nil)
+			accrualStorage.EXPECT().GetAllUnprocessedWithLimit(gomock.Any(), int64(3)).Return(pendingAccruals, nil)
+			var released []model.Accrual
+			gomock.InOrder(
+				accrualStorage.EXPECT().UpdateInBatch(gomock.Any(), gomock.Any()).
+					Do(func(ctx context.Context, accruals []model.Accrual) {
+						if tt.cancel {
+							cancel()
+						}
+					}).
+					Return(tt.updateErr),
+				accrualStorage.EXPECT().UpdateInBatch(gomock.Any(), gomock.Any()).
+					Do(func(ctx context.Context, accruals []model.Accrual) {
+						assert.NoError(t, ctx.Err(), "Accruals should be released with live context")
+						released = accruals
+					}).
+					Return(nil),
+			)
+
+			synced, err := s.SyncNow(ctx)
+			assert.Error(t, err, "Sync should return error")
+			assert.Equal(t, tt.expectedSynced, synced, "Synced accruals count does not match expected count")
+			assert.Equal(t, tt.expectedReleased, released, "Released accruals do not match expected accruals")
 		})
 	}
 }
@@ -161,3 +345,23 @@ func isWithinLastTenMinutes(t time.Time) bool {
 	tenMinutesAgo := now.Add(-10 * time.Minute)
 	return t.After(tenMinutesAgo) && t.Before(now)
 }
+
+func TestReconfigure(t *testing.T) {
+	logger, err := logger.Initialize("error")
+	require.NoError(t, err, "Error init logger")
+
+	s := &AccrualsScheduler{processingAccrualsCh: make(chan accrualsBatch), logger: logger}
+	s.resizeWorkerPool(3)
+	require.Len(t, s.workerStopChs, 3, "Worker pool should be started with configured size")
+
+	s.Reconfigure(5, 1)
+	assert.Len(t, s.workerStopChs, 1, "Worker pool should shrink to reconfigured size")
+	assert.Equal(t, int64(5), s.processAccrualsBatchMaxSize.Load(), "Batch max size does not match reconfigured size")
+
+	s.Reconfigure(5, 4)
+	assert.Len(t, s.workerStopChs, 4, "Worker pool should grow to reconfigured size")
+
+	s.Reconfigure(5, 0)
+	assert.Len(t, s.workerStopChs, 1, "Worker pool should keep at least one worker")
+	close(s.processingAccrualsCh)
+}
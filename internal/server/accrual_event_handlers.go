@@ -0,0 +1,135 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Stern-Ritter/gophermart/internal/model"
+	"github.com/Stern-Ritter/gophermart/internal/problem"
+)
+
+const (
+	orderEventName                = "order-status"
+	orderEventsReplayBatchSize    = 100
+	orderEventsHeartbeatInterval  = 15 * time.Second
+	orderEventsReconnectionTimeMs = 3000
+)
+
+func (s *Server) StreamOrdersHandler(res http.ResponseWriter, req *http.Request) {
+	currentUser, err := s.UserService.GetCurrentUser(req.Context())
+	if err != nil {
+		s.writeError(res, req, err)
+		return
+	}
+
+	lastEventID, err := parseLastEventID(req)
+	if err != nil {
+		s.writeProblem(res, req, http.StatusBadRequest, problem.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	flusher, ok := res.(http.Flusher)
+	if !ok {
+		s.writeError(res, req, errors.New("streaming is not supported"))
+		return
+	}
+
+	version := getAPIVersion(req.Context())
+	events, unsubscribe := s.AccrualEventService.Subscribe(currentUser.ID)
+	defer unsubscribe()
+
+	res.Header().Set("Content-Type", "text/event-stream")
+	res.Header().Set("Cache-Control", "no-cache")
+	res.Header().Set("Connection", "keep-alive")
+	res.Header().Set("X-Accel-Buffering", "no")
+	res.WriteHeader(http.StatusOK)
+
+	if _, err := fmt.Fprintf(res, "retry: %d\n\n", orderEventsReconnectionTimeMs); err != nil {
+		return
+	}
+
+	if lastEventID > 0 {
+		for {
+			missedEvents, err := s.AccrualEventService.GetAllAccrualEventsByUserIDAfterID(req.Context(), currentUser.ID,
+				lastEventID, orderEventsReplayBatchSize)
+			if err != nil {
+				s.Logger.WithContext(req.Context()).Error("Error getting missed order events",
+					zap.String("event", "stream order events"), zap.Error(err))
+				return
+			}
+			for _, event := range missedEvents {
+				if err := writeAccrualEvent(res, event, version); err != nil {
+					return
+				}
+				lastEventID = event.ID
+			}
+			if len(missedEvents) < orderEventsReplayBatchSize {
+				break
+			}
+		}
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(orderEventsHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-req.Context().Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			if event.ID <= lastEventID {
+				continue
+			}
+			if err := writeAccrualEvent(res, event, version); err != nil {
+				return
+			}
+			lastEventID = event.ID
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(res, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+	}
+}
+
+func parseLastEventID(req *http.Request) (int64, error) {
+	value := req.Header.Get("Last-Event-ID")
+	if value == "" {
+		value = req.URL.Query().Get("last_event_id")
+	}
+	if value == "" {
+		return 0, nil
+	}
+
+	lastEventID, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || lastEventID < 0 {
+		return 0, fmt.Errorf("last event id should be a non-negative integer")
+	}
+	return lastEventID, nil
+}
+
+func writeAccrualEvent(res http.ResponseWriter, event model.AccrualEvent, version APIVersion) error {
+	var eventDto any = model.ToAccrualEventDto(event)
+	if version == APIVersion2 {
+		eventDto = model.ToAccrualEventV2Dto(event)
+	}
+
+	data, err := json.Marshal(eventDto)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(res, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, orderEventName, data)
+	return err
+}
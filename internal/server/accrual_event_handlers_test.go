@@ -0,0 +1,121 @@
+package server
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/Stern-Ritter/gophermart/internal/model"
+)
+
+func TestStreamOrdersHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	server, mocks := newAdminTestServer(t, ctrl)
+	mocks.userStorage.EXPECT().GetOneByLogin(gomock.Any(), "user").
+		Return(model.User{ID: 1, Login: "user", Role: model.UserRoleUser}, nil)
+	mocks.accrualEventStorage.EXPECT().GetAllByUserIDAfterIDOrderByIDAsc(gomock.Any(), int64(1), int64(4),
+		int64(orderEventsReplayBatchSize)).
+		Return([]model.AccrualEvent{
+			{ID: 5, UserID: 1, OrderNumber: 12345678903, Status: model.AccrualProcessing},
+		}, nil)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		server.StreamOrdersHandler(res, withAuthorizedUser(t, server, req, "user"))
+	}))
+	defer ts.Close()
+
+	req, err := http.NewRequest(http.MethodGet, ts.URL+"/api/user/orders/stream", nil)
+	require.NoError(t, err, "Error creating request")
+	req.Header.Set("Last-Event-ID", "4")
+
+	resp, err := ts.Client().Do(req)
+	require.NoError(t, err, "Error sending request")
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "Response status code does not match expected status")
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"), "Content type does not match expected")
+
+	reader := bufio.NewReader(resp.Body)
+	assert.Equal(t, "retry: 3000\n", readServerSentEvent(t, reader), "Reconnection time does not match expected")
+	assert.Equal(t, "id: 5\nevent: order-status\ndata: {\"number\":\"12345678903\",\"status\":\"PROCESSING\"}\n",
+		readServerSentEvent(t, reader), "Missed event does not match expected")
+
+	processedAt := time.Date(2024, 1, 1, 0, 1, 0, 0, time.UTC)
+	server.AccrualEventService.Publish(model.AccrualEvent{ID: 5, UserID: 1, OrderNumber: 12345678903,
+		Status: model.AccrualProcessing})
+	server.AccrualEventService.Publish(model.AccrualEvent{ID: 6, UserID: 2, OrderNumber: 12345678904,
+		Status: model.AccrualProcessed, PointsAmount: 10, ProcessedAt: processedAt})
+	server.AccrualEventService.Publish(model.AccrualEvent{ID: 7, UserID: 1, OrderNumber: 12345678903,
+		Status: model.AccrualProcessed, PointsAmount: 42, ProcessedAt: processedAt})
+
+	assert.Equal(t, "id: 7\nevent: order-status\ndata: {\"number\":\"12345678903\",\"status\":\"PROCESSED\","+
+		"\"accrual\":42,\"processed_at\":\"2024-01-01T00:01:00Z\"}\n",
+		readServerSentEvent(t, reader), "Live event does not match expected")
+}
+
+func TestStreamOrdersHandlerWithInvalidRequest(t *testing.T) {
+	tests := []struct {
+		name               string
+		isAuthorized       bool
+		lastEventID        string
+		expectedStatusCode int
+	}{
+		{
+			name:               "should return status 401 when user is unauthorized",
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:               "should return status 400 when last event id is invalid",
+			isAuthorized:       true,
+			lastEventID:        "last",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			server, mocks := newAdminTestServer(t, ctrl)
+			handler := http.HandlerFunc(server.StreamOrdersHandler)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/api/user/orders/stream", nil)
+			req.Header.Set("Last-Event-ID", tt.lastEventID)
+			if tt.isAuthorized {
+				mocks.userStorage.EXPECT().GetOneByLogin(gomock.Any(), "user").
+					Return(model.User{ID: 1, Login: "user", Role: model.UserRoleUser}, nil)
+				req = withAuthorizedUser(t, server, req, "user")
+			}
+
+			handler.ServeHTTP(w, req)
+
+			resp := w.Result()
+			defer resp.Body.Close()
+
+			assert.Equal(t, tt.expectedStatusCode, resp.StatusCode, "Response status code does not match expected status")
+		})
+	}
+}
+
+func readServerSentEvent(t *testing.T, reader *bufio.Reader) string {
+	event := strings.Builder{}
+	for {
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err, "Error reading event stream")
+		if line == "\n" {
+			return event.String()
+		}
+		event.WriteString(line)
+	}
+}
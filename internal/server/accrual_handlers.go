@@ -1,35 +1,50 @@
 package server
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi"
 
 	er "github.com/Stern-Ritter/gophermart/internal/errors"
 	"github.com/Stern-Ritter/gophermart/internal/model"
+	"github.com/Stern-Ritter/gophermart/internal/problem"
 	"github.com/Stern-Ritter/gophermart/internal/utils"
 )
 
+const (
+	maxOrdersBatchSize = 1000
+	// maxOrdersBatchBodySize leaves room for an order number with quotes, separators and whitespace per item.
+	maxOrdersBatchBodySize = maxOrdersBatchSize * 64
+)
+
 func (s *Server) LoadOrderHandler(res http.ResponseWriter, req *http.Request) {
 	currentUser, err := s.UserService.GetCurrentUser(req.Context())
 	if err != nil {
-		http.Error(res, err.Error(), http.StatusUnauthorized)
+		s.writeError(res, req, err)
 		return
 	}
 
 	body, err := io.ReadAll(req.Body)
 	if err != nil {
-		http.Error(res, "Error decode request plain/text body", http.StatusBadRequest)
+		s.writeProblem(res, req, http.StatusBadRequest, problem.CodeInvalidRequest, "Error decode request plain/text body")
+		return
 	}
 	orderNumber := string(body)
 	parsedOrderNumber, err := utils.ParseOrderNumber(orderNumber)
 	if err != nil {
-		http.Error(res, err.Error(), http.StatusBadRequest)
+		s.writeProblem(res, req, http.StatusBadRequest, problem.CodeInvalidRequest, err.Error())
 		return
 	}
 	if err := utils.ValidateOrderNumber(orderNumber); err != nil {
-		http.Error(res, err.Error(), http.StatusUnprocessableEntity)
+		s.writeProblem(res, req, http.StatusUnprocessableEntity, problem.CodeInvalidOrderNumber, err.Error())
 		return
 	}
 
@@ -37,51 +52,290 @@ func (s *Server) LoadOrderHandler(res http.ResponseWriter, req *http.Request) {
 	err = s.AccrualService.CreateAccrual(req.Context(), accrual)
 	if err != nil {
 		var alreadyExistsError er.AlreadyExistsError
-		var conflictError er.ConflictError
-		switch {
-		case errors.As(err, &alreadyExistsError):
-			http.Error(res, err.Error(), http.StatusOK)
-			return
-		case errors.As(err, &conflictError):
-			http.Error(res, err.Error(), http.StatusConflict)
+		if errors.As(err, &alreadyExistsError) {
+			res.WriteHeader(http.StatusOK)
 			return
 		}
-		http.Error(res, err.Error(), http.StatusInternalServerError)
+		s.writeError(res, req, err)
 		return
 	}
 
 	res.WriteHeader(http.StatusAccepted)
 }
 
+func (s *Server) LoadOrdersBatchHandler(res http.ResponseWriter, req *http.Request) {
+	currentUser, err := s.UserService.GetCurrentUser(req.Context())
+	if err != nil {
+		s.writeError(res, req, err)
+		return
+	}
+
+	orderNumbers, err := decodeOrdersBatch(res, req)
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		s.writeProblem(res, req, http.StatusRequestEntityTooLarge, problem.CodeRequestTooLarge,
+			fmt.Sprintf("Request body should not be larger than %d bytes", maxBytesErr.Limit))
+		return
+	}
+	if err != nil {
+		s.writeProblem(res, req, http.StatusBadRequest, problem.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	results := make([]model.AccrualBatchItemResultDto, len(orderNumbers))
+	accruals := make([]model.Accrual, 0, len(orderNumbers))
+	positions := make([]int, 0, len(orderNumbers))
+	for i, orderNumber := range orderNumbers {
+		results[i] = model.AccrualBatchItemResultDto{OrderNumber: orderNumber}
+
+		parsedOrderNumber, err := utils.ParseOrderNumber(orderNumber)
+		if err == nil {
+			err = utils.ValidateOrderNumber(orderNumber)
+		}
+		if err != nil {
+			results[i].Status = model.AccrualBatchItemInvalid
+			results[i].Error = err.Error()
+			continue
+		}
+
+		accruals = append(accruals, model.NewAccrual(currentUser.ID, parsedOrderNumber))
+		positions = append(positions, i)
+	}
+
+	if len(accruals) > 0 {
+		savedResults, err := s.AccrualService.CreateAccruals(req.Context(), accruals)
+		if err != nil {
+			s.writeError(res, req, err)
+			return
+		}
+		for i, result := range savedResults {
+			results[positions[i]].Status = result.Status
+		}
+	}
+
+	body, err := json.Marshal(results)
+	if err != nil {
+		s.writeError(res, req, err)
+		return
+	}
+
+	res.Header().Set("Content-Type", "application/json")
+	_, err = res.Write(body)
+	if err != nil {
+		s.writeError(res, req, err)
+		return
+	}
+}
+
+func decodeOrdersBatch(res http.ResponseWriter, req *http.Request) ([]string, error) {
+	body, err := io.ReadAll(http.MaxBytesReader(res, req.Body, maxOrdersBatchBodySize))
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading request body")
+	}
+
+	var orderNumbers []string
+	if strings.HasPrefix(req.Header.Get("Content-Type"), "application/json") {
+		orderNumbers, err = decodeOrdersBatchJSON(body)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		for _, line := range strings.Split(string(body), "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				orderNumbers = append(orderNumbers, line)
+			}
+		}
+	}
+
+	if len(orderNumbers) == 0 {
+		return nil, fmt.Errorf("orders batch should contain at least one order number")
+	}
+	if len(orderNumbers) > maxOrdersBatchSize {
+		return nil, fmt.Errorf("orders batch should contain at most %d order numbers", maxOrdersBatchSize)
+	}
+	return orderNumbers, nil
+}
+
+func decodeOrdersBatchJSON(body []byte) ([]string, error) {
+	var items []json.RawMessage
+	if err := json.Unmarshal(body, &items); err != nil {
+		return nil, fmt.Errorf("request body should be a JSON array of order numbers")
+	}
+
+	orderNumbers := make([]string, len(items))
+	for i, item := range items {
+		var orderNumber string
+		if err := json.Unmarshal(item, &orderNumber); err == nil {
+			orderNumbers[i] = strings.TrimSpace(orderNumber)
+			continue
+		}
+		var number json.Number
+		if err := json.Unmarshal(item, &number); err == nil {
+			orderNumbers[i] = number.String()
+			continue
+		}
+		orderNumbers[i] = string(item)
+	}
+	return orderNumbers, nil
+}
+
 func (s *Server) FindAllOrdersLoadedByUserHandler(res http.ResponseWriter, req *http.Request) {
 	currentUser, err := s.UserService.GetCurrentUser(req.Context())
 	if err != nil {
-		http.Error(res, err.Error(), http.StatusUnauthorized)
+		s.writeError(res, req, err)
 		return
 	}
 
-	accruals, err := s.AccrualService.GetAllAccrualsByUserID(req.Context(), currentUser.ID)
+	filter, err := parseAccrualsFilter(req, currentUser.ID)
 	if err != nil {
-		http.Error(res, err.Error(), http.StatusInternalServerError)
+		s.writeProblem(res, req, http.StatusBadRequest, problem.CodeInvalidRequest, err.Error())
 		return
 	}
-	if len(accruals) == 0 {
+
+	var page model.AccrualsPage
+	if isUnpaginatedRequest(req) {
+		page, err = s.getAllAccrualsPages(req.Context(), filter)
+	} else {
+		page, err = s.AccrualService.GetAccrualsPage(req.Context(), filter)
+	}
+	if err != nil {
+		s.writeError(res, req, err)
+		return
+	}
+	if len(page.Accruals) == 0 {
 		res.WriteHeader(http.StatusNoContent)
 		return
 	}
 
-	accrualsDto := model.ToAccrualsDto(accruals)
+	var accrualsDto any = model.ToAccrualsDto(page.Accruals)
+	if getAPIVersion(req.Context()) == APIVersion2 {
+		accrualsDto = model.ToAccrualsV2Dto(page.Accruals)
+	}
 
 	body, err := json.Marshal(accrualsDto)
 	if err != nil {
-		http.Error(res, "Error encoding response", http.StatusInternalServerError)
+		s.writeError(res, req, err)
+		return
+	}
+
+	setNextPageHeaders(res, req, page.NextCursor, filter.Limit)
+	res.Header().Set("Content-Type", "application/json")
+	_, err = res.Write(body)
+	if err != nil {
+		s.writeError(res, req, err)
+		return
+	}
+}
+
+func (s *Server) getAllAccrualsPages(ctx context.Context, filter model.AccrualsFilter) (model.AccrualsPage, error) {
+	filter.Limit = maxPageLimit
+	all := model.AccrualsPage{}
+	for {
+		page, err := s.AccrualService.GetAccrualsPage(ctx, filter)
+		if err != nil {
+			return model.AccrualsPage{}, err
+		}
+		all.Accruals = append(all.Accruals, page.Accruals...)
+		if page.NextCursor == nil {
+			return all, nil
+		}
+		filter.After = page.NextCursor
+	}
+}
+
+func (s *Server) GetOrderLoadedByUserHandler(res http.ResponseWriter, req *http.Request) {
+	currentUser, err := s.UserService.GetCurrentUser(req.Context())
+	if err != nil {
+		s.writeError(res, req, err)
+		return
+	}
+
+	orderNumber, err := utils.ParseOrderNumber(chi.URLParam(req, "number"))
+	if err != nil {
+		s.writeProblem(res, req, http.StatusBadRequest, problem.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	accrual, err := s.AccrualService.GetUserAccrualByOrderNumber(req.Context(), currentUser.ID, orderNumber)
+	if err != nil {
+		s.writeError(res, req, err)
+		return
+	}
+
+	etag := getAccrualETag(accrual)
+	res.Header().Set("ETag", etag)
+	res.Header().Set("Cache-Control", "private, no-cache")
+	if matchesETag(req.Header.Get("If-None-Match"), etag) {
+		res.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	var accrualDto any = model.ToAccrualDto(accrual)
+	if getAPIVersion(req.Context()) == APIVersion2 {
+		accrualDto = model.ToAccrualV2Dto(accrual)
+	}
+
+	body, err := json.Marshal(accrualDto)
+	if err != nil {
+		s.writeError(res, req, err)
 		return
 	}
 
 	res.Header().Set("Content-Type", "application/json")
 	_, err = res.Write(body)
 	if err != nil {
-		http.Error(res, "Error encoding response", http.StatusInternalServerError)
+		s.writeError(res, req, err)
 		return
 	}
 }
+
+func getAccrualETag(accrual model.Accrual) string {
+	var processedAt int64
+	if !accrual.ProcessedAt.IsZero() {
+		processedAt = accrual.ProcessedAt.UnixMicro()
+	}
+
+	value := fmt.Sprintf("%d:%s:%d:%g", accrual.OrderNumber, accrual.Status, processedAt, accrual.PointsAmount)
+	hash := sha256.Sum256([]byte(value))
+	return fmt.Sprintf("\"%s\"", hex.EncodeToString(hash[:16]))
+}
+
+func matchesETag(ifNoneMatch string, etag string) bool {
+	for _, value := range strings.Split(ifNoneMatch, ",") {
+		value = strings.TrimPrefix(strings.TrimSpace(value), "W/")
+		if value == "*" || value == etag {
+			return true
+		}
+	}
+	return false
+}
+
+func parseAccrualsFilter(req *http.Request, userID int64) (model.AccrualsFilter, error) {
+	params, err := parsePageParams(req, "uploaded_from", "uploaded_to")
+	if err != nil {
+		return model.AccrualsFilter{}, err
+	}
+
+	var statuses []model.AccrualStatus
+	for _, value := range parseListParam(req, "status") {
+		status, err := model.ParseAccrualStatus(value)
+		if err != nil {
+			return model.AccrualsFilter{}, err
+		}
+		statuses = append(statuses, status)
+	}
+
+	return model.AccrualsFilter{
+		UserID:        userID,
+		Statuses:      statuses,
+		UploadedFrom:  params.From,
+		UploadedTo:    params.To,
+		After:         params.After,
+		SortDirection: params.SortDirection,
+		Limit:         params.Limit,
+	}, nil
+}
@@ -1,6 +1,8 @@
 package server
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"io"
 	"net/http"
@@ -10,6 +12,7 @@ import (
 	"time"
 
 	"github.com/go-chi/jwtauth/v5"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -111,15 +114,26 @@ func TestLoadOrderHandler(t *testing.T) {
 			accrualStorage := NewMockAccrualStorage(ctrl)
 			withdrawnStorage := NewMockWithdrawnStorage(ctrl)
 			balanceStorage := NewMockBalanceStorage(ctrl)
+			totpStorage := NewMockTotpStorage(ctrl)
+			adjustmentStorage := NewMockAdjustmentStorage(ctrl)
+			apiKeyStorage := NewMockApiKeyStorage(ctrl)
+			accrualEventStorage := NewMockAccrualEventStorage(ctrl)
+			accrualEventListener := NewMockAccrualEventListener(ctrl)
 
 			userService := service.NewUserService(userStorage, logger)
-			authService := service.NewAuthService(userService, authToken, logger)
+			totpService := service.NewTotpService(totpStorage, logger)
+			auditService := newTestAuditService(ctrl, logger)
+			authService := service.NewAuthService(userService, totpService, auditService, newTestPasswordHasher(),
+				authToken, logger)
 			accrualService := service.NewAccrualService(accrualStorage, logger)
-			withdrawnService := service.NewWithdrawnService(withdrawnStorage, logger)
+			withdrawnService := service.NewWithdrawnService(withdrawnStorage, auditService, logger)
 			balanceService := service.NewBalanceService(balanceStorage, logger)
+			adjustmentService := service.NewAdjustmentService(adjustmentStorage, logger)
+			apiKeyService := service.NewApiKeyService(apiKeyStorage, userService, logger)
+			accrualEventService := service.NewAccrualEventService(accrualEventStorage, accrualEventListener, logger)
 
-			server := NewServer(authService, userService, accrualService, withdrawnService, balanceService, validate,
-				authToken, cfg, logger)
+			server := NewServer(authService, userService, accrualService, withdrawnService, balanceService, totpService,
+				adjustmentService, apiKeyService, accrualEventService, nil, auditService, validate, authToken, cfg, logger)
 
 			handler := http.HandlerFunc(server.LoadOrderHandler)
 
@@ -151,6 +165,7 @@ func TestLoadOrderHandler(t *testing.T) {
 
 			resp := w.Result()
 			defer resp.Body.Close()
+			assertResponseMatchesSpec(t, req, resp)
 
 			assert.Equal(t, tt.expectedStatusCode, resp.StatusCode, "Response status code does not match expected status")
 		})
@@ -166,7 +181,10 @@ func TestFindAllOrdersLoadedByUserHandler(t *testing.T) {
 		userStorageErr              error
 		accrualStorageReturnedValue []model.Accrual
 		accrualStorageErr           error
+		query                       string
+		expectedFilter              *model.AccrualsFilter
 		expectedBody                string
+		expectedNextCursor          string
 		expectedStatusCode          int
 	}{
 		{
@@ -208,6 +226,68 @@ func TestFindAllOrdersLoadedByUserHandler(t *testing.T) {
 			accrualStorageErr:  errors.New("unexpected error"),
 			expectedStatusCode: http.StatusInternalServerError,
 		},
+		{
+			name:              "should return next cursor when there are more orders than limit",
+			isAuthorized:      true,
+			useUserStorage:    true,
+			useAccrualStorage: true,
+			query:             "?limit=1",
+			expectedFilter:    &model.AccrualsFilter{UserID: 1, SortDirection: model.SortAsc, Limit: 2},
+			accrualStorageReturnedValue: []model.Accrual{
+				{OrderNumber: 1, Status: model.AccrualNew, UploadedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+				{OrderNumber: 2, Status: model.AccrualNew, UploadedAt: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+			},
+			expectedBody:       `[{"number":"1","status":"NEW","uploaded_at":"2024-01-01T00:00:00Z"}]`,
+			expectedNextCursor: model.Cursor{Time: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), Key: 1}.Encode(),
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:              "should pass filters and cursor to storage",
+			isAuthorized:      true,
+			useUserStorage:    true,
+			useAccrualStorage: true,
+			query: "?status=processed,NEW&uploaded_from=2024-01-01T00:00:00Z&uploaded_to=2024-02-01T00:00:00Z" +
+				"&sort=desc&limit=10&after=" + testOrdersCursor.Encode(),
+			expectedFilter: &model.AccrualsFilter{
+				UserID:        1,
+				Statuses:      []model.AccrualStatus{model.AccrualProcessed, model.AccrualNew},
+				UploadedFrom:  time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+				UploadedTo:    time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+				After:         &testOrdersCursor,
+				SortDirection: model.SortDesc,
+				Limit:         11,
+			},
+			accrualStorageReturnedValue: make([]model.Accrual, 0),
+			expectedStatusCode:          http.StatusNoContent,
+		},
+		{
+			name:               "should return status 400 when status is unknown",
+			isAuthorized:       true,
+			useUserStorage:     true,
+			query:              "?status=DONE",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "should return status 400 when cursor is invalid",
+			isAuthorized:       true,
+			useUserStorage:     true,
+			query:              "?after=cursor",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "should return status 400 when uploaded_from is not before uploaded_to",
+			isAuthorized:       true,
+			useUserStorage:     true,
+			query:              "?uploaded_from=2024-02-01T00:00:00Z&uploaded_to=2024-01-01T00:00:00Z",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "should return status 400 when sort direction is unknown",
+			isAuthorized:       true,
+			useUserStorage:     true,
+			query:              "?sort=up",
+			expectedStatusCode: http.StatusBadRequest,
+		},
 	}
 
 	for _, tt := range tests {
@@ -226,15 +306,26 @@ func TestFindAllOrdersLoadedByUserHandler(t *testing.T) {
 			accrualStorage := NewMockAccrualStorage(ctrl)
 			withdrawnStorage := NewMockWithdrawnStorage(ctrl)
 			balanceStorage := NewMockBalanceStorage(ctrl)
+			totpStorage := NewMockTotpStorage(ctrl)
+			adjustmentStorage := NewMockAdjustmentStorage(ctrl)
+			apiKeyStorage := NewMockApiKeyStorage(ctrl)
+			accrualEventStorage := NewMockAccrualEventStorage(ctrl)
+			accrualEventListener := NewMockAccrualEventListener(ctrl)
 
 			userService := service.NewUserService(userStorage, logger)
-			authService := service.NewAuthService(userService, authToken, logger)
+			totpService := service.NewTotpService(totpStorage, logger)
+			auditService := newTestAuditService(ctrl, logger)
+			authService := service.NewAuthService(userService, totpService, auditService, newTestPasswordHasher(),
+				authToken, logger)
 			accrualService := service.NewAccrualService(accrualStorage, logger)
-			withdrawnService := service.NewWithdrawnService(withdrawnStorage, logger)
+			withdrawnService := service.NewWithdrawnService(withdrawnStorage, auditService, logger)
 			balanceService := service.NewBalanceService(balanceStorage, logger)
+			adjustmentService := service.NewAdjustmentService(adjustmentStorage, logger)
+			apiKeyService := service.NewApiKeyService(apiKeyStorage, userService, logger)
+			accrualEventService := service.NewAccrualEventService(accrualEventStorage, accrualEventListener, logger)
 
-			server := NewServer(authService, userService, accrualService, withdrawnService, balanceService, validate,
-				authToken, cfg, logger)
+			server := NewServer(authService, userService, accrualService, withdrawnService, balanceService, totpService,
+				adjustmentService, apiKeyService, accrualEventService, nil, auditService, validate, authToken, cfg, logger)
 
 			handler := http.HandlerFunc(server.FindAllOrdersLoadedByUserHandler)
 
@@ -243,7 +334,11 @@ func TestFindAllOrdersLoadedByUserHandler(t *testing.T) {
 					Return(model.User{ID: 1, Login: "user", Password: "password"}, tt.userStorageErr)
 			}
 			if tt.useAccrualStorage {
-				accrualStorage.EXPECT().GetAllByUserIDOrderByUploadedAtAsc(gomock.Any(), gomock.Any()).
+				expectedFilter := model.AccrualsFilter{UserID: 1, SortDirection: model.SortAsc, Limit: maxPageLimit + 1}
+				if tt.expectedFilter != nil {
+					expectedFilter = *tt.expectedFilter
+				}
+				accrualStorage.EXPECT().GetAllByFilter(gomock.Any(), expectedFilter).
 					Return(tt.accrualStorageReturnedValue, tt.accrualStorageErr)
 			}
 
@@ -254,7 +349,7 @@ func TestFindAllOrdersLoadedByUserHandler(t *testing.T) {
 			decodedClaims, _ := server.AuthToken.Decode(tokenString)
 
 			w := httptest.NewRecorder()
-			req := httptest.NewRequest(http.MethodGet, "/api/user/orders", nil)
+			req := httptest.NewRequest(http.MethodGet, "/api/user/orders"+tt.query, nil)
 			req.Header.Set("Authorization", tokenString)
 			if tt.isAuthorized {
 				ctx := jwtauth.NewContext(req.Context(), decodedClaims, nil)
@@ -265,6 +360,7 @@ func TestFindAllOrdersLoadedByUserHandler(t *testing.T) {
 
 			resp := w.Result()
 			defer resp.Body.Close()
+			assertResponseMatchesSpec(t, req, resp)
 
 			assert.Equal(t, tt.expectedStatusCode, resp.StatusCode, "Response status code does not match expected status")
 			if tt.expectedBody != "" {
@@ -272,6 +368,395 @@ func TestFindAllOrdersLoadedByUserHandler(t *testing.T) {
 				require.NoError(t, err, "Error reading response body")
 				require.Equal(t, tt.expectedBody, string(body), "Response body does not match expected body")
 			}
+			assert.Equal(t, tt.expectedNextCursor, resp.Header.Get("X-Next-Cursor"), "Next cursor does not match expected")
+			if tt.expectedNextCursor != "" {
+				assert.Contains(t, resp.Header.Get("Link"), "after="+tt.expectedNextCursor, "Link header should contain next cursor")
+				assert.Contains(t, resp.Header.Get("Link"), `rel="next"`, "Link header should have next relation")
+			}
+		})
+	}
+}
+
+func TestGetOrderLoadedByUserHandler(t *testing.T) {
+	processedAccrual := model.Accrual{
+		UserID:       1,
+		OrderNumber:  12345678903,
+		Status:       model.AccrualProcessed,
+		PointsAmount: 42,
+		UploadedAt:   time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		ProcessedAt:  time.Date(2024, 1, 1, 0, 1, 0, 0, time.UTC),
+	}
+	newAccrual := model.Accrual{
+		UserID:      1,
+		OrderNumber: 12345678903,
+		Status:      model.AccrualNew,
+		UploadedAt:  time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	tests := []struct {
+		name                        string
+		number                      string
+		isAuthorized                bool
+		useAccrualStorage           bool
+		accrualStorageReturnedValue model.Accrual
+		accrualStorageErr           error
+		ifNoneMatch                 string
+		expectedBody                string
+		expectedETag                string
+		expectedStatusCode          int
+	}{
+		{
+			name:                        "should return status 200 with etag when order belongs to user",
+			number:                      "12345678903",
+			isAuthorized:                true,
+			useAccrualStorage:           true,
+			accrualStorageReturnedValue: processedAccrual,
+			expectedBody: `{"number":"12345678903","status":"PROCESSED","accrual":42,` +
+				`"uploaded_at":"2024-01-01T00:00:00Z"}`,
+			expectedETag:       getAccrualETag(processedAccrual),
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:                        "should return status 304 when etag matches",
+			number:                      "12345678903",
+			isAuthorized:                true,
+			useAccrualStorage:           true,
+			accrualStorageReturnedValue: processedAccrual,
+			ifNoneMatch:                 `"outdated", ` + getAccrualETag(processedAccrual),
+			expectedETag:                getAccrualETag(processedAccrual),
+			expectedStatusCode:          http.StatusNotModified,
+		},
+		{
+			name:                        "should return status 200 when order status changed since etag",
+			number:                      "12345678903",
+			isAuthorized:                true,
+			useAccrualStorage:           true,
+			accrualStorageReturnedValue: processedAccrual,
+			ifNoneMatch:                 getAccrualETag(newAccrual),
+			expectedETag:                getAccrualETag(processedAccrual),
+			expectedStatusCode:          http.StatusOK,
+		},
+		{
+			name:              "should return status 404 when order belongs to another user",
+			number:            "12345678903",
+			isAuthorized:      true,
+			useAccrualStorage: true,
+			accrualStorageReturnedValue: model.Accrual{
+				UserID:      2,
+				OrderNumber: 12345678903,
+				Status:      model.AccrualNew,
+			},
+			expectedStatusCode: http.StatusNotFound,
+		},
+		{
+			name:               "should return status 404 when order not exists",
+			number:             "12345678903",
+			isAuthorized:       true,
+			useAccrualStorage:  true,
+			accrualStorageErr:  pgx.ErrNoRows,
+			expectedStatusCode: http.StatusNotFound,
+		},
+		{
+			name:               "should return status 500 when unexpected error occurred",
+			number:             "12345678903",
+			isAuthorized:       true,
+			useAccrualStorage:  true,
+			accrualStorageErr:  errors.New("unexpected error"),
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		{
+			name:               "should return status 400 when order number is invalid",
+			number:             "order",
+			isAuthorized:       true,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "should return status 401 when user is unauthorized",
+			number:             "12345678903",
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			server, mocks := newAdminTestServer(t, ctrl)
+			handler := http.HandlerFunc(server.GetOrderLoadedByUserHandler)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/api/user/orders/"+tt.number, nil)
+			req = withURLParam(req, "number", tt.number)
+			if tt.ifNoneMatch != "" {
+				req.Header.Set("If-None-Match", tt.ifNoneMatch)
+			}
+			if tt.isAuthorized {
+				mocks.userStorage.EXPECT().GetOneByLogin(gomock.Any(), "user").
+					Return(model.User{ID: 1, Login: "user", Role: model.UserRoleUser}, nil)
+				req = withAuthorizedUser(t, server, req, "user")
+			}
+			if tt.useAccrualStorage {
+				mocks.accrualStorage.EXPECT().GetOneByOrderNumber(gomock.Any(), int64(12345678903)).
+					Return(tt.accrualStorageReturnedValue, tt.accrualStorageErr)
+			}
+
+			handler.ServeHTTP(w, req)
+
+			resp := w.Result()
+			defer resp.Body.Close()
+			assertResponseMatchesSpec(t, req, resp)
+
+			assert.Equal(t, tt.expectedStatusCode, resp.StatusCode, "Response status code does not match expected status")
+			assert.Equal(t, tt.expectedETag, resp.Header.Get("ETag"), "ETag does not match expected")
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err, "Error reading response body")
+			if tt.expectedBody != "" {
+				require.Equal(t, tt.expectedBody, string(body), "Response body does not match expected body")
+			}
+			if tt.expectedStatusCode == http.StatusNotModified {
+				assert.Empty(t, body, "Response body should be empty when not modified")
+			}
+		})
+	}
+}
+
+func TestLoadOrdersBatchHandler(t *testing.T) {
+	tests := []struct {
+		name                        string
+		contentType                 string
+		body                        string
+		isAuthorized                bool
+		useAccrualStorage           bool
+		expectedSavedOrders         []int64
+		accrualStorageReturnedValue []model.AccrualBatchItemResult
+		accrualStorageErr           error
+		expectedBody                string
+		expectedStatusCode          int
+	}{
+		{
+			name:                "should return result for each order number from json array",
+			contentType:         "application/json",
+			body:                `["12345678903", 2377225624, "12345678904", "order", "12345678903", "4561261212345467"]`,
+			isAuthorized:        true,
+			useAccrualStorage:   true,
+			expectedSavedOrders: []int64{12345678903, 2377225624, 4561261212345467},
+			accrualStorageReturnedValue: []model.AccrualBatchItemResult{
+				{OrderNumber: 12345678903, Status: model.AccrualBatchItemAccepted},
+				{OrderNumber: 2377225624, Status: model.AccrualBatchItemAlreadyUploaded},
+				{OrderNumber: 4561261212345467, Status: model.AccrualBatchItemConflict},
+			},
+			expectedBody: `[{"number":"12345678903","status":"ACCEPTED"},` +
+				`{"number":"2377225624","status":"ALREADY_UPLOADED"},` +
+				`{"number":"12345678904","status":"INVALID","error":"invalid order number: 12345678904"},` +
+				`{"number":"order","status":"INVALID","error":"invalid order number: order"},` +
+				`{"number":"12345678903","status":"ALREADY_UPLOADED"},` +
+				`{"number":"4561261212345467","status":"CONFLICT"}]`,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:                "should return status of first occurrence for repeated order numbers",
+			contentType:         "application/json",
+			body:                `["4561261212345467", "12345678904", "12345678903", "4561261212345467", "12345678904", "12345678903"]`,
+			isAuthorized:        true,
+			useAccrualStorage:   true,
+			expectedSavedOrders: []int64{4561261212345467, 12345678903},
+			accrualStorageReturnedValue: []model.AccrualBatchItemResult{
+				{OrderNumber: 4561261212345467, Status: model.AccrualBatchItemConflict},
+				{OrderNumber: 12345678903, Status: model.AccrualBatchItemAccepted},
+			},
+			expectedBody: `[{"number":"4561261212345467","status":"CONFLICT"},` +
+				`{"number":"12345678904","status":"INVALID","error":"invalid order number: 12345678904"},` +
+				`{"number":"12345678903","status":"ACCEPTED"},` +
+				`{"number":"4561261212345467","status":"CONFLICT"},` +
+				`{"number":"12345678904","status":"INVALID","error":"invalid order number: 12345678904"},` +
+				`{"number":"12345678903","status":"ALREADY_UPLOADED"}]`,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:                "should return result for each order number from newline-delimited body",
+			contentType:         "text/plain",
+			body:                "12345678903\r\n\n 2377225624 \n",
+			isAuthorized:        true,
+			useAccrualStorage:   true,
+			expectedSavedOrders: []int64{12345678903, 2377225624},
+			accrualStorageReturnedValue: []model.AccrualBatchItemResult{
+				{OrderNumber: 12345678903, Status: model.AccrualBatchItemAccepted},
+				{OrderNumber: 2377225624, Status: model.AccrualBatchItemAccepted},
+			},
+			expectedBody: `[{"number":"12345678903","status":"ACCEPTED"},` +
+				`{"number":"2377225624","status":"ACCEPTED"}]`,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "should not call storage when all order numbers are invalid",
+			contentType:        "text/plain",
+			body:               "12345678904",
+			isAuthorized:       true,
+			expectedBody:       `[{"number":"12345678904","status":"INVALID","error":"invalid order number: 12345678904"}]`,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "should return status 400 when batch is empty",
+			contentType:        "application/json",
+			body:               `[]`,
+			isAuthorized:       true,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "should return status 400 when json body is not an array",
+			contentType:        "application/json",
+			body:               `{"number":"12345678903"}`,
+			isAuthorized:       true,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "should return status 400 when batch is too large",
+			contentType:        "text/plain",
+			body:               strings.Repeat("12345678903\n", maxOrdersBatchSize+1),
+			isAuthorized:       true,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "should return status 413 when body is too large",
+			contentType:        "text/plain",
+			body:               strings.Repeat(" ", maxOrdersBatchBodySize) + "12345678903",
+			isAuthorized:       true,
+			expectedStatusCode: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:                "should return status 500 when unexpected error occurred",
+			contentType:         "text/plain",
+			body:                "12345678903",
+			isAuthorized:        true,
+			useAccrualStorage:   true,
+			expectedSavedOrders: []int64{12345678903},
+			accrualStorageErr:   errors.New("unexpected error"),
+			expectedStatusCode:  http.StatusInternalServerError,
+		},
+		{
+			name:               "should return status 401 when user is unauthorized",
+			contentType:        "text/plain",
+			body:               "12345678903",
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			server, mocks := newAdminTestServer(t, ctrl)
+			handler := http.HandlerFunc(server.LoadOrdersBatchHandler)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/api/user/orders/batch", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			if tt.isAuthorized {
+				mocks.userStorage.EXPECT().GetOneByLogin(gomock.Any(), "user").
+					Return(model.User{ID: 1, Login: "user", Role: model.UserRoleUser}, nil)
+				req = withAuthorizedUser(t, server, req, "user")
+			}
+			if tt.useAccrualStorage {
+				mocks.accrualStorage.EXPECT().SaveInBatch(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, accruals []model.Accrual) ([]model.AccrualBatchItemResult, error) {
+						savedOrders := make([]int64, len(accruals))
+						for i, accrual := range accruals {
+							assert.Equal(t, int64(1), accrual.UserID, "Accrual should belong to current user")
+							assert.Equal(t, model.AccrualNew, accrual.Status, "Accrual should have status NEW")
+							savedOrders[i] = accrual.OrderNumber
+						}
+						assert.Equal(t, tt.expectedSavedOrders, savedOrders, "Saved orders do not match expected")
+						return tt.accrualStorageReturnedValue, tt.accrualStorageErr
+					})
+			}
+
+			handler.ServeHTTP(w, req)
+
+			resp := w.Result()
+			defer resp.Body.Close()
+			assertResponseMatchesSpec(t, req, resp)
+
+			assert.Equal(t, tt.expectedStatusCode, resp.StatusCode, "Response status code does not match expected status")
+			if tt.expectedBody != "" {
+				body, err := io.ReadAll(resp.Body)
+				require.NoError(t, err, "Error reading response body")
+				require.Equal(t, tt.expectedBody, string(body), "Response body does not match expected body")
+			}
+		})
+	}
+}
+
+var testOrdersCursor = model.Cursor{Time: time.UnixMicro(1704067200000000), Key: 12345678903}
+
+func TestFindAllOrdersLoadedByUserHandlerPagination(t *testing.T) {
+	firstPage := make([]model.Accrual, maxPageLimit+1)
+	for i := range firstPage {
+		firstPage[i] = model.Accrual{UserID: 1, OrderNumber: int64(i + 1), Status: model.AccrualNew,
+			UploadedAt: time.Date(2024, 1, 1, 0, 0, i, 0, time.UTC)}
+	}
+	lastCursor := model.Cursor{Time: firstPage[maxPageLimit-1].UploadedAt, Key: firstPage[maxPageLimit-1].OrderNumber}
+
+	tests := []struct {
+		name               string
+		version            APIVersion
+		expectedFilters    []model.AccrualsFilter
+		expectedOrders     int
+		expectedNextCursor string
+	}{
+		{
+			name:    "should return all orders of v1 request without limit and after",
+			version: APIVersion1,
+			expectedFilters: []model.AccrualsFilter{
+				{UserID: 1, SortDirection: model.SortAsc, Limit: maxPageLimit + 1},
+				{UserID: 1, SortDirection: model.SortAsc, Limit: maxPageLimit + 1, After: &lastCursor},
+			},
+			expectedOrders: maxPageLimit + 1,
+		},
+		{
+			name:    "should return first page of v2 request without limit",
+			version: APIVersion2,
+			expectedFilters: []model.AccrualsFilter{
+				{UserID: 1, SortDirection: model.SortAsc, Limit: defaultPageLimit + 1},
+			},
+			expectedOrders: defaultPageLimit,
+			expectedNextCursor: model.Cursor{Time: firstPage[defaultPageLimit-1].UploadedAt,
+				Key: firstPage[defaultPageLimit-1].OrderNumber}.Encode(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			server, mocks := newAdminTestServer(t, ctrl)
+			handler := WithAPIVersion(tt.version)(http.HandlerFunc(server.FindAllOrdersLoadedByUserHandler))
+
+			mocks.userStorage.EXPECT().GetOneByLogin(gomock.Any(), "user").
+				Return(model.User{ID: 1, Login: "user", Password: "password"}, nil)
+			pages := [][]model.Accrual{firstPage[:tt.expectedFilters[0].Limit], firstPage[maxPageLimit:]}
+			calls := make([]any, len(tt.expectedFilters))
+			for i, filter := range tt.expectedFilters {
+				calls[i] = mocks.accrualStorage.EXPECT().GetAllByFilter(gomock.Any(), filter).Return(pages[i], nil)
+			}
+			gomock.InOrder(calls...)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/api/user/orders", nil)
+			req = withAuthorizedUser(t, server, req, "user")
+
+			handler.ServeHTTP(w, req)
+
+			resp := w.Result()
+			defer resp.Body.Close()
+
+			require.Equal(t, http.StatusOK, resp.StatusCode, "Response status code does not match expected status")
+			var orders []json.RawMessage
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&orders), "Error decoding response body")
+			assert.Len(t, orders, tt.expectedOrders, "Orders count does not match expected count")
+			assert.Equal(t, tt.expectedNextCursor, resp.Header.Get("X-Next-Cursor"), "Next cursor does not match expected")
 		})
 	}
 }
@@ -0,0 +1,240 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi"
+
+	"github.com/Stern-Ritter/gophermart/internal/model"
+	"github.com/Stern-Ritter/gophermart/internal/problem"
+	"github.com/Stern-Ritter/gophermart/internal/utils"
+)
+
+const (
+	defaultUsersSearchLimit = 20
+	maxUsersSearchLimit     = 100
+)
+
+// RoleAuthorizer checks the role of the current user in storage rather than in the token, so that demoted and
+// disabled users lose access before their tokens expire.
+func (s *Server) RoleAuthorizer(roles ...model.UserRole) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			currentUser, err := s.UserService.GetCurrentUser(req.Context())
+			if err != nil {
+				s.writeError(res, req, err)
+				return
+			}
+
+			for _, role := range roles {
+				if currentUser.Role == role {
+					next.ServeHTTP(res, req)
+					return
+				}
+			}
+
+			s.writeProblem(res, req, http.StatusForbidden, problem.CodeForbidden,
+				"User does not have permission to access this resource")
+		})
+	}
+}
+
+func (s *Server) SearchUsersHandler(res http.ResponseWriter, req *http.Request) {
+	login := req.URL.Query().Get("login")
+	limit, err := parseLimit(req.URL.Query().Get("limit"), defaultUsersSearchLimit, maxUsersSearchLimit)
+	if err != nil {
+		s.writeProblem(res, req, http.StatusBadRequest, problem.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	users, err := s.UserService.SearchUsersByLogin(req.Context(), login, limit)
+	if err != nil {
+		s.writeError(res, req, err)
+		return
+	}
+	if len(users) == 0 {
+		res.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	usersDto := model.ToUsersDto(users)
+
+	body, err := json.Marshal(usersDto)
+	if err != nil {
+		s.writeError(res, req, err)
+		return
+	}
+
+	res.Header().Set("Content-Type", "application/json")
+	_, err = res.Write(body)
+	if err != nil {
+		s.writeError(res, req, err)
+		return
+	}
+}
+
+func (s *Server) GetUserBalanceHandler(res http.ResponseWriter, req *http.Request) {
+	userID, err := strconv.ParseInt(chi.URLParam(req, "userID"), 10, 64)
+	if err != nil {
+		s.writeProblem(res, req, http.StatusBadRequest, problem.CodeInvalidRequest, "Invalid user id")
+		return
+	}
+
+	user, err := s.UserService.GetUserByID(req.Context(), userID)
+	if err != nil {
+		s.writeError(res, req, err)
+		return
+	}
+
+	balance, err := s.BalanceService.GetBalanceByUserID(req.Context(), user.ID)
+	if err != nil {
+		s.writeError(res, req, err)
+		return
+	}
+
+	balanceDto := model.ToBalanceDto(balance)
+
+	body, err := json.Marshal(balanceDto)
+	if err != nil {
+		s.writeError(res, req, err)
+		return
+	}
+
+	res.Header().Set("Content-Type", "application/json")
+	_, err = res.Write(body)
+	if err != nil {
+		s.writeError(res, req, err)
+		return
+	}
+}
+
+func (s *Server) GetOrderByNumberHandler(res http.ResponseWriter, req *http.Request) {
+	orderNumber, err := utils.ParseOrderNumber(chi.URLParam(req, "number"))
+	if err != nil {
+		s.writeProblem(res, req, http.StatusBadRequest, problem.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	accrual, err := s.AccrualService.GetAccrualByOrderNumber(req.Context(), orderNumber)
+	if err != nil {
+		s.writeError(res, req, err)
+		return
+	}
+
+	accrualDto := model.ToAccrualDetailsDto(accrual)
+
+	body, err := json.Marshal(accrualDto)
+	if err != nil {
+		s.writeError(res, req, err)
+		return
+	}
+
+	res.Header().Set("Content-Type", "application/json")
+	_, err = res.Write(body)
+	if err != nil {
+		s.writeError(res, req, err)
+		return
+	}
+}
+
+func parseLimit(value string, defaultLimit int64, maxLimit int64) (int64, error) {
+	if value == "" {
+		return defaultLimit, nil
+	}
+
+	limit, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || limit <= 0 || limit > maxLimit {
+		return 0, fmt.Errorf("limit should be an integer between 1 and %d", maxLimit)
+	}
+
+	return limit, nil
+}
+
+func (s *Server) CreateAdjustmentHandler(res http.ResponseWriter, req *http.Request) {
+	currentUser, err := s.UserService.GetCurrentUser(req.Context())
+	if err != nil {
+		s.writeError(res, req, err)
+		return
+	}
+
+	userID, err := strconv.ParseInt(chi.URLParam(req, "userID"), 10, 64)
+	if err != nil {
+		s.writeProblem(res, req, http.StatusBadRequest, problem.CodeInvalidRequest, "Invalid user id")
+		return
+	}
+
+	createAdjustmentDto := model.CreateAdjustmentDto{}
+	err = decodeWithUnknownAndDuplicateFieldsCheck(req.Body, &createAdjustmentDto)
+	if err != nil {
+		s.writeProblem(res, req, http.StatusBadRequest, problem.CodeInvalidRequest, "Error decode request JSON body")
+		return
+	}
+	if err := createAdjustmentDto.Validate(s.Validate); err != nil {
+		s.writeError(res, req, err)
+		return
+	}
+
+	user, err := s.UserService.GetUserByID(req.Context(), userID)
+	if err != nil {
+		s.writeError(res, req, err)
+		return
+	}
+
+	adjustment := model.NewAdjustment(user.ID, currentUser.ID, createAdjustmentDto)
+	adjustment, err = s.AdjustmentService.CreateAdjustment(req.Context(), adjustment)
+	if err != nil {
+		s.writeError(res, req, err)
+		return
+	}
+
+	adjustmentDto := model.ToAdjustmentDto(adjustment)
+
+	body, err := json.Marshal(adjustmentDto)
+	if err != nil {
+		s.writeError(res, req, err)
+		return
+	}
+
+	res.Header().Set("Content-Type", "application/json")
+	_, err = res.Write(body)
+	if err != nil {
+		s.writeError(res, req, err)
+		return
+	}
+}
+
+func (s *Server) FindAllAdjustmentsByUserHandler(res http.ResponseWriter, req *http.Request) {
+	userID, err := strconv.ParseInt(chi.URLParam(req, "userID"), 10, 64)
+	if err != nil {
+		s.writeProblem(res, req, http.StatusBadRequest, problem.CodeInvalidRequest, "Invalid user id")
+		return
+	}
+
+	adjustments, err := s.AdjustmentService.GetAllAdjustmentsByUserID(req.Context(), userID)
+	if err != nil {
+		s.writeError(res, req, err)
+		return
+	}
+	if len(adjustments) == 0 {
+		res.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	adjustmentsDto := model.ToAdjustmentsDto(adjustments)
+
+	body, err := json.Marshal(adjustmentsDto)
+	if err != nil {
+		s.writeError(res, req, err)
+		return
+	}
+
+	res.Header().Set("Content-Type", "application/json")
+	_, err = res.Write(body)
+	if err != nil {
+		s.writeError(res, req, err)
+		return
+	}
+}
@@ -0,0 +1,590 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/Stern-Ritter/gophermart/internal/auth"
+	"github.com/Stern-Ritter/gophermart/internal/config"
+	er "github.com/Stern-Ritter/gophermart/internal/errors"
+	"github.com/Stern-Ritter/gophermart/internal/logger"
+	"github.com/Stern-Ritter/gophermart/internal/model"
+	"github.com/Stern-Ritter/gophermart/internal/service"
+	"github.com/Stern-Ritter/gophermart/internal/validator"
+)
+
+func TestSearchUsersHandler(t *testing.T) {
+	tests := []struct {
+		name                     string
+		query                    string
+		useUserStorage           bool
+		expectedLogin            string
+		expectedLimit            int64
+		userStorageReturnedValue []model.User
+		userStorageErr           error
+		expectedBody             string
+		expectedStatusCode       int
+	}{
+		{
+			name:           "should return status 200 when users found",
+			query:          "?login=user&limit=10",
+			useUserStorage: true,
+			expectedLogin:  "user",
+			expectedLimit:  10,
+			userStorageReturnedValue: []model.User{
+				{ID: 1, Login: "user", Password: "password", Role: model.UserRoleUser},
+			},
+			expectedBody:       `[{"id":1,"login":"user","role":"USER"}]`,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:                     "should use default limit when limit is not specified",
+			query:                    "?login=user",
+			useUserStorage:           true,
+			expectedLogin:            "user",
+			expectedLimit:            20,
+			userStorageReturnedValue: make([]model.User, 0),
+			expectedStatusCode:       http.StatusNoContent,
+		},
+		{
+			name:               "should return status 400 when limit is greater than max limit",
+			query:              "?login=user&limit=1000",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "should return status 400 when limit is not a number",
+			query:              "?limit=ten",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "should return status 500 when unexpected error occurred",
+			query:              "?login=user",
+			useUserStorage:     true,
+			expectedLogin:      "user",
+			expectedLimit:      20,
+			userStorageErr:     errors.New("unexpected error"),
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			server, mocks := newAdminTestServer(t, ctrl)
+			handler := http.HandlerFunc(server.SearchUsersHandler)
+
+			if tt.useUserStorage {
+				mocks.userStorage.EXPECT().GetAllByLoginContainingOrderByLogin(gomock.Any(), tt.expectedLogin, tt.expectedLimit).
+					Return(tt.userStorageReturnedValue, tt.userStorageErr)
+			}
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/api/admin/users"+tt.query, nil)
+
+			handler.ServeHTTP(w, req)
+
+			resp := w.Result()
+			defer resp.Body.Close()
+			assertResponseMatchesSpec(t, req, resp)
+
+			assert.Equal(t, tt.expectedStatusCode, resp.StatusCode, "Response status code does not match expected status")
+			if tt.expectedBody != "" {
+				body, err := io.ReadAll(resp.Body)
+				require.NoError(t, err, "Error reading response body")
+				require.Equal(t, tt.expectedBody, string(body), "Response body does not match expected body")
+			}
+		})
+	}
+}
+
+func TestGetUserBalanceHandler(t *testing.T) {
+	tests := []struct {
+		name               string
+		userID             string
+		useUserStorage     bool
+		userStorageErr     error
+		useBalanceStorage  bool
+		expectedBody       string
+		expectedStatusCode int
+	}{
+		{
+			name:               "should return status 200 when user exists",
+			userID:             "1",
+			useUserStorage:     true,
+			useBalanceStorage:  true,
+			expectedBody:       `{"current":500.5,"withdrawn":42}`,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "should return status 404 when user not exists",
+			userID:             "1",
+			useUserStorage:     true,
+			userStorageErr:     pgx.ErrNoRows,
+			expectedStatusCode: http.StatusNotFound,
+		},
+		{
+			name:               "should return status 400 when user id is invalid",
+			userID:             "user",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			server, mocks := newAdminTestServer(t, ctrl)
+			handler := http.HandlerFunc(server.GetUserBalanceHandler)
+
+			if tt.useUserStorage {
+				mocks.userStorage.EXPECT().GetOneByID(gomock.Any(), int64(1)).
+					Return(model.User{ID: 1, Login: "user", Role: model.UserRoleUser}, tt.userStorageErr)
+			}
+			if tt.useBalanceStorage {
+				mocks.balanceStorage.EXPECT().GetByUserID(gomock.Any(), int64(1)).
+					Return(model.Balance{UserID: 1, CurrentPointsAmount: 500.5, WithdrawnPointsAmount: 42}, nil)
+			}
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/api/admin/users/"+tt.userID+"/balance", nil)
+			req = withURLParam(req, "userID", tt.userID)
+
+			handler.ServeHTTP(w, req)
+
+			resp := w.Result()
+			defer resp.Body.Close()
+			assertResponseMatchesSpec(t, req, resp)
+
+			assert.Equal(t, tt.expectedStatusCode, resp.StatusCode, "Response status code does not match expected status")
+			if tt.expectedBody != "" {
+				body, err := io.ReadAll(resp.Body)
+				require.NoError(t, err, "Error reading response body")
+				require.Equal(t, tt.expectedBody, string(body), "Response body does not match expected body")
+			}
+		})
+	}
+}
+
+func TestGetOrderByNumberHandler(t *testing.T) {
+	tests := []struct {
+		name                        string
+		number                      string
+		useAccrualStorage           bool
+		accrualStorageReturnedValue model.Accrual
+		accrualStorageErr           error
+		expectedBody                string
+		expectedStatusCode          int
+	}{
+		{
+			name:              "should return status 200 when order exists",
+			number:            "12345678903",
+			useAccrualStorage: true,
+			accrualStorageReturnedValue: model.Accrual{
+				UserID:       1,
+				OrderNumber:  12345678903,
+				Status:       model.AccrualProcessed,
+				PointsAmount: 42,
+				UploadedAt:   time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+				ProcessedAt:  time.Date(2024, 1, 1, 0, 1, 0, 0, time.UTC),
+			},
+			expectedBody: `{"user_id":1,"number":"12345678903","status":"PROCESSED","accrual":42,` +
+				`"uploaded_at":"2024-01-01T00:00:00Z","processed_at":"2024-01-01T00:01:00Z"}`,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:              "should omit processed_at when order is not processed yet",
+			number:            "12345678903",
+			useAccrualStorage: true,
+			accrualStorageReturnedValue: model.Accrual{
+				UserID:      1,
+				OrderNumber: 12345678903,
+				Status:      model.AccrualNew,
+				UploadedAt:  time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			},
+			expectedBody:       `{"user_id":1,"number":"12345678903","status":"NEW","uploaded_at":"2024-01-01T00:00:00Z"}`,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "should return status 404 when order not exists",
+			number:             "12345678903",
+			useAccrualStorage:  true,
+			accrualStorageErr:  pgx.ErrNoRows,
+			expectedStatusCode: http.StatusNotFound,
+		},
+		{
+			name:               "should return status 400 when order number is invalid",
+			number:             "order",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			server, mocks := newAdminTestServer(t, ctrl)
+			handler := http.HandlerFunc(server.GetOrderByNumberHandler)
+
+			if tt.useAccrualStorage {
+				mocks.accrualStorage.EXPECT().GetOneByOrderNumber(gomock.Any(), int64(12345678903)).
+					Return(tt.accrualStorageReturnedValue, tt.accrualStorageErr)
+			}
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/api/admin/orders/"+tt.number, nil)
+			req = withURLParam(req, "number", tt.number)
+
+			handler.ServeHTTP(w, req)
+
+			resp := w.Result()
+			defer resp.Body.Close()
+			assertResponseMatchesSpec(t, req, resp)
+
+			assert.Equal(t, tt.expectedStatusCode, resp.StatusCode, "Response status code does not match expected status")
+			if tt.expectedBody != "" {
+				body, err := io.ReadAll(resp.Body)
+				require.NoError(t, err, "Error reading response body")
+				require.Equal(t, tt.expectedBody, string(body), "Response body does not match expected body")
+			}
+		})
+	}
+}
+
+func TestCreateAdjustmentHandler(t *testing.T) {
+	tests := []struct {
+		name                 string
+		userID               string
+		body                 string
+		isAuthorized         bool
+		useAdminStorage      bool
+		useUserStorage       bool
+		userStorageErr       error
+		useAdjustmentStorage bool
+		adjustmentStorageErr error
+		expectedStatusCode   int
+	}{
+		{
+			name:                 "should return status 200 when adjustment created",
+			userID:               "2",
+			body:                 `{"amount":-50,"reason_code":"FRAUD_CORRECTION","note":"duplicate order"}`,
+			isAuthorized:         true,
+			useAdminStorage:      true,
+			useUserStorage:       true,
+			useAdjustmentStorage: true,
+			expectedStatusCode:   http.StatusOK,
+		},
+		{
+			name:               "should return status 401 when admin is unauthorized",
+			userID:             "2",
+			body:               `{"amount":50,"reason_code":"GOODWILL","note":"sorry"}`,
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:               "should return status 400 when reason code is unknown",
+			userID:             "2",
+			body:               `{"amount":50,"reason_code":"BIRTHDAY","note":"sorry"}`,
+			isAuthorized:       true,
+			useAdminStorage:    true,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "should return status 400 when note is empty",
+			userID:             "2",
+			body:               `{"amount":50,"reason_code":"GOODWILL","note":""}`,
+			isAuthorized:       true,
+			useAdminStorage:    true,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "should return status 400 when amount is zero",
+			userID:             "2",
+			body:               `{"amount":0,"reason_code":"GOODWILL","note":"sorry"}`,
+			isAuthorized:       true,
+			useAdminStorage:    true,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "should return status 404 when user not exists",
+			userID:             "2",
+			body:               `{"amount":50,"reason_code":"GOODWILL","note":"sorry"}`,
+			isAuthorized:       true,
+			useAdminStorage:    true,
+			useUserStorage:     true,
+			userStorageErr:     pgx.ErrNoRows,
+			expectedStatusCode: http.StatusNotFound,
+		},
+		{
+			name:                 "should return status 409 when user has not enough loyalty points to debit",
+			userID:               "2",
+			body:                 `{"amount":-50,"reason_code":"FRAUD_CORRECTION","note":"duplicate order"}`,
+			isAuthorized:         true,
+			useAdminStorage:      true,
+			useUserStorage:       true,
+			useAdjustmentStorage: true,
+			adjustmentStorageErr: er.NewConflictError("Not enough loyalty points to debit", nil),
+			expectedStatusCode:   http.StatusConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			server, mocks := newAdminTestServer(t, ctrl)
+			handler := http.HandlerFunc(server.CreateAdjustmentHandler)
+
+			if tt.useAdminStorage {
+				mocks.userStorage.EXPECT().GetOneByLogin(gomock.Any(), "admin").
+					Return(model.User{ID: 1, Login: "admin", Role: model.UserRoleAdmin}, nil)
+			}
+			if tt.useUserStorage {
+				mocks.userStorage.EXPECT().GetOneByID(gomock.Any(), int64(2)).
+					Return(model.User{ID: 2, Login: "user", Role: model.UserRoleUser}, tt.userStorageErr)
+			}
+			if tt.useAdjustmentStorage {
+				mocks.adjustmentStorage.EXPECT().Save(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, adjustment model.Adjustment) (model.Adjustment, error) {
+						assert.Equal(t, int64(2), adjustment.UserID, "Adjustment user id does not match expected")
+						assert.Equal(t, int64(1), adjustment.AdminID, "Adjustment admin id does not match expected")
+						adjustment.ID = 1
+						return adjustment, tt.adjustmentStorageErr
+					})
+			}
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/api/admin/users/"+tt.userID+"/adjustments",
+				strings.NewReader(tt.body))
+			req = withURLParam(req, "userID", tt.userID)
+			if tt.isAuthorized {
+				req = withAuthorizedUser(t, server, req, "admin")
+			}
+
+			handler.ServeHTTP(w, req)
+
+			resp := w.Result()
+			defer resp.Body.Close()
+			assertResponseMatchesSpec(t, req, resp)
+
+			assert.Equal(t, tt.expectedStatusCode, resp.StatusCode, "Response status code does not match expected status")
+		})
+	}
+}
+
+func TestFindAllAdjustmentsByUserHandler(t *testing.T) {
+	createdAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name                           string
+		userID                         string
+		useAdjustmentStorage           bool
+		adjustmentStorageReturnedValue []model.Adjustment
+		adjustmentStorageErr           error
+		expectedBody                   string
+		expectedStatusCode             int
+	}{
+		{
+			name:                 "should return status 200 when adjustments found",
+			userID:               "2",
+			useAdjustmentStorage: true,
+			adjustmentStorageReturnedValue: []model.Adjustment{
+				{ID: 1, UserID: 2, AdminID: 1, PointsAmount: 10.5, ReasonCode: model.AdjustmentGoodwill,
+					Note: "sorry", CreatedAt: createdAt},
+			},
+			expectedBody: `[{"id":1,"user_id":2,"admin_id":1,"amount":10.5,"reason_code":"GOODWILL",` +
+				`"note":"sorry","created_at":"2024-01-01T00:00:00Z"}]`,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:                           "should return status 204 when adjustments not found",
+			userID:                         "2",
+			useAdjustmentStorage:           true,
+			adjustmentStorageReturnedValue: make([]model.Adjustment, 0),
+			expectedStatusCode:             http.StatusNoContent,
+		},
+		{
+			name:               "should return status 400 when user id is invalid",
+			userID:             "user",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:                 "should return status 500 when unexpected error occurred",
+			userID:               "2",
+			useAdjustmentStorage: true,
+			adjustmentStorageErr: errors.New("unexpected error"),
+			expectedStatusCode:   http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			server, mocks := newAdminTestServer(t, ctrl)
+			handler := http.HandlerFunc(server.FindAllAdjustmentsByUserHandler)
+
+			if tt.useAdjustmentStorage {
+				mocks.adjustmentStorage.EXPECT().GetAllByUserIDOrderByCreatedAtAsc(gomock.Any(), int64(2)).
+					Return(tt.adjustmentStorageReturnedValue, tt.adjustmentStorageErr)
+			}
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/api/admin/users/"+tt.userID+"/adjustments", nil)
+			req = withURLParam(req, "userID", tt.userID)
+
+			handler.ServeHTTP(w, req)
+
+			resp := w.Result()
+			defer resp.Body.Close()
+			assertResponseMatchesSpec(t, req, resp)
+
+			assert.Equal(t, tt.expectedStatusCode, resp.StatusCode, "Response status code does not match expected status")
+			if tt.expectedBody != "" {
+				body, err := io.ReadAll(resp.Body)
+				require.NoError(t, err, "Error reading response body")
+				require.Equal(t, tt.expectedBody, string(body), "Response body does not match expected body")
+			}
+		})
+	}
+}
+
+type adminTestMocks struct {
+	userStorage         *MockUserStorage
+	accrualStorage      *MockAccrualStorage
+	withdrawnStorage    *MockWithdrawnStorage
+	balanceStorage      *MockBalanceStorage
+	adjustmentStorage   *MockAdjustmentStorage
+	accrualEventStorage *MockAccrualEventStorage
+}
+
+func newAdminTestServer(t *testing.T, ctrl *gomock.Controller) (*Server, adminTestMocks) {
+	validate, err := validator.GetValidator()
+	require.NoError(t, err, "Error init validator")
+	authToken := auth.GenerateAuthToken("secret")
+	cfg := &config.ServerConfig{}
+	logger, err := logger.Initialize("error")
+	require.NoError(t, err, "Error init logger")
+
+	userStorage := NewMockUserStorage(ctrl)
+	accrualStorage := NewMockAccrualStorage(ctrl)
+	withdrawnStorage := NewMockWithdrawnStorage(ctrl)
+	balanceStorage := NewMockBalanceStorage(ctrl)
+	totpStorage := NewMockTotpStorage(ctrl)
+	adjustmentStorage := NewMockAdjustmentStorage(ctrl)
+	apiKeyStorage := NewMockApiKeyStorage(ctrl)
+	accrualEventStorage := NewMockAccrualEventStorage(ctrl)
+	accrualEventListener := NewMockAccrualEventListener(ctrl)
+
+	userService := service.NewUserService(userStorage, logger)
+	totpService := service.NewTotpService(totpStorage, logger)
+	auditService := newTestAuditService(ctrl, logger)
+	authService := service.NewAuthService(userService, totpService, auditService, newTestPasswordHasher(),
+		authToken, logger)
+	accrualService := service.NewAccrualService(accrualStorage, logger)
+	withdrawnService := service.NewWithdrawnService(withdrawnStorage, auditService, logger)
+	balanceService := service.NewBalanceService(balanceStorage, logger)
+	adjustmentService := service.NewAdjustmentService(adjustmentStorage, logger)
+	apiKeyService := service.NewApiKeyService(apiKeyStorage, userService, logger)
+	accrualEventService := service.NewAccrualEventService(accrualEventStorage, accrualEventListener, logger)
+
+	server := NewServer(authService, userService, accrualService, withdrawnService, balanceService, totpService,
+		adjustmentService, apiKeyService, accrualEventService, nil, auditService, validate, authToken, cfg, logger)
+
+	return server, adminTestMocks{
+		userStorage:         userStorage,
+		accrualStorage:      accrualStorage,
+		withdrawnStorage:    withdrawnStorage,
+		balanceStorage:      balanceStorage,
+		adjustmentStorage:   adjustmentStorage,
+		accrualEventStorage: accrualEventStorage,
+	}
+}
+
+func withURLParam(req *http.Request, key string, value string) *http.Request {
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add(key, value)
+	return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+}
+
+func TestRoleAuthorizer(t *testing.T) {
+	tests := []struct {
+		name               string
+		storedUser         model.User
+		userStorageErr     error
+		expectNextCalled   bool
+		expectedStatusCode int
+	}{
+		{
+			name:               "should call next handler when stored user is admin",
+			storedUser:         model.User{ID: 1, Login: "admin", Role: model.UserRoleAdmin},
+			expectNextCalled:   true,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "should return status 403 when admin has been demoted",
+			storedUser:         model.User{ID: 1, Login: "admin", Role: model.UserRoleUser},
+			expectedStatusCode: http.StatusForbidden,
+		},
+		{
+			name:               "should return status 401 when admin has been disabled",
+			storedUser:         model.User{ID: 1, Login: "admin", Role: model.UserRoleAdmin, Disabled: true},
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:               "should return status 401 when admin has been deleted",
+			userStorageErr:     pgx.ErrNoRows,
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:               "should return status 500 when unexpected error occurred",
+			userStorageErr:     errors.New("unexpected error"),
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			server, mocks := newAdminTestServer(t, ctrl)
+			nextCalled := false
+			next := http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+				nextCalled = true
+				res.WriteHeader(http.StatusOK)
+			})
+			handler := server.RoleAuthorizer(model.UserRoleAdmin)(next)
+
+			mocks.userStorage.EXPECT().GetOneByLogin(gomock.Any(), "admin").Return(tt.storedUser, tt.userStorageErr)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/api/admin/users", nil)
+			req = withAuthorizedUser(t, server, req, "admin")
+
+			handler.ServeHTTP(w, req)
+
+			resp := w.Result()
+			defer resp.Body.Close()
+
+			assert.Equal(t, tt.expectedStatusCode, resp.StatusCode, "Response status code does not match expected status")
+			assert.Equal(t, tt.expectNextCalled, nextCalled, "Next handler call does not match expected")
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi"
+
+	"github.com/Stern-Ritter/gophermart/internal/model"
+	"github.com/Stern-Ritter/gophermart/internal/problem"
+)
+
+func (s *Server) CreateApiKeyHandler(res http.ResponseWriter, req *http.Request) {
+	currentUser, err := s.UserService.GetCurrentUser(req.Context())
+	if err != nil {
+		s.writeError(res, req, err)
+		return
+	}
+
+	createApiKeyDto := model.CreateApiKeyDto{}
+	err = decodeWithUnknownAndDuplicateFieldsCheck(req.Body, &createApiKeyDto)
+	if err != nil {
+		s.writeProblem(res, req, http.StatusBadRequest, problem.CodeInvalidRequest, "Error decode request JSON body")
+		return
+	}
+	if err := createApiKeyDto.Validate(s.Validate); err != nil {
+		s.writeError(res, req, err)
+		return
+	}
+
+	apiKey, key, err := s.ApiKeyService.CreateApiKey(req.Context(), currentUser.ID, createApiKeyDto.Name,
+		createApiKeyDto.Scopes)
+	if err != nil {
+		s.writeError(res, req, err)
+		return
+	}
+
+	body, err := json.Marshal(model.ToCreatedApiKeyDto(apiKey, key))
+	if err != nil {
+		s.writeError(res, req, err)
+		return
+	}
+
+	res.Header().Set("Content-Type", "application/json")
+	_, err = res.Write(body)
+	if err != nil {
+		s.writeError(res, req, err)
+		return
+	}
+}
+
+func (s *Server) FindAllApiKeysByUserHandler(res http.ResponseWriter, req *http.Request) {
+	currentUser, err := s.UserService.GetCurrentUser(req.Context())
+	if err != nil {
+		s.writeError(res, req, err)
+		return
+	}
+
+	apiKeys, err := s.ApiKeyService.GetAllApiKeysByUserID(req.Context(), currentUser.ID)
+	if err != nil {
+		s.writeError(res, req, err)
+		return
+	}
+	if len(apiKeys) == 0 {
+		res.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	body, err := json.Marshal(model.ToApiKeysDto(apiKeys))
+	if err != nil {
+		s.writeError(res, req, err)
+		return
+	}
+
+	res.Header().Set("Content-Type", "application/json")
+	_, err = res.Write(body)
+	if err != nil {
+		s.writeError(res, req, err)
+		return
+	}
+}
+
+func (s *Server) RevokeApiKeyHandler(res http.ResponseWriter, req *http.Request) {
+	currentUser, err := s.UserService.GetCurrentUser(req.Context())
+	if err != nil {
+		s.writeError(res, req, err)
+		return
+	}
+
+	keyID, err := strconv.ParseInt(chi.URLParam(req, "keyID"), 10, 64)
+	if err != nil {
+		s.writeProblem(res, req, http.StatusBadRequest, problem.CodeInvalidRequest, "Invalid api key id")
+		return
+	}
+
+	err = s.ApiKeyService.RevokeApiKey(req.Context(), currentUser.ID, keyID)
+	if err != nil {
+		s.writeError(res, req, err)
+		return
+	}
+
+	res.WriteHeader(http.StatusOK)
+}
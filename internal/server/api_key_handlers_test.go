@@ -0,0 +1,279 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/Stern-Ritter/gophermart/internal/auth"
+	"github.com/Stern-Ritter/gophermart/internal/config"
+	"github.com/Stern-Ritter/gophermart/internal/logger"
+	"github.com/Stern-Ritter/gophermart/internal/model"
+	"github.com/Stern-Ritter/gophermart/internal/service"
+	"github.com/Stern-Ritter/gophermart/internal/validator"
+)
+
+func TestCreateApiKeyHandler(t *testing.T) {
+	tests := []struct {
+		name               string
+		body               string
+		useApiKeyStorage   bool
+		apiKeyStorageErr   error
+		expectedStatusCode int
+	}{
+		{
+			name:               "should return status 200 with key when api key created",
+			body:               `{"name":"pos","scopes":["orders:write","balance:read"]}`,
+			useApiKeyStorage:   true,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "should return status 400 when scope is unknown",
+			body:               `{"name":"pos","scopes":["orders:delete"]}`,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "should return status 400 when scopes are empty",
+			body:               `{"name":"pos","scopes":[]}`,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "should return status 400 when name is empty",
+			body:               `{"name":"","scopes":["orders:write"]}`,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "should return status 409 when api key with this name already exists",
+			body:               `{"name":"pos","scopes":["orders:write"]}`,
+			useApiKeyStorage:   true,
+			apiKeyStorageErr:   &pgconn.PgError{ConstraintName: "user_api_keys_user_id_name_active_idx"},
+			expectedStatusCode: http.StatusConflict,
+		},
+		{
+			name:               "should return status 500 when unexpected error occurred",
+			body:               `{"name":"pos","scopes":["orders:write"]}`,
+			useApiKeyStorage:   true,
+			apiKeyStorageErr:   errors.New("unexpected error"),
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			server, mocks := newApiKeyTestServer(t, ctrl)
+			handler := http.HandlerFunc(server.CreateApiKeyHandler)
+
+			mocks.userStorage.EXPECT().GetOneByLogin(gomock.Any(), "user").
+				Return(model.User{ID: 1, Login: "user", Role: model.UserRoleUser}, nil)
+			if tt.useApiKeyStorage {
+				mocks.apiKeyStorage.EXPECT().Save(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, apiKey model.ApiKey) (model.ApiKey, error) {
+						apiKey.ID = 1
+						return apiKey, tt.apiKeyStorageErr
+					})
+			}
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/api/user/api-keys", strings.NewReader(tt.body))
+			req = withAuthorizedUser(t, server, req, "user")
+
+			handler.ServeHTTP(w, req)
+
+			resp := w.Result()
+			defer resp.Body.Close()
+			assertResponseMatchesSpec(t, req, resp)
+
+			assert.Equal(t, tt.expectedStatusCode, resp.StatusCode, "Response status code does not match expected status")
+			if tt.expectedStatusCode == http.StatusOK {
+				created := model.CreatedApiKeyDto{}
+				err := json.NewDecoder(resp.Body).Decode(&created)
+				require.NoError(t, err, "Error decoding response body")
+				assert.True(t, strings.HasPrefix(created.Key, created.Prefix), "Api key should start with its prefix")
+				assert.Equal(t, []model.ApiKeyScope{model.ApiKeyScopeOrdersWrite, model.ApiKeyScopeBalanceRead},
+					created.Scopes, "Api key scopes does not match expected")
+			}
+		})
+	}
+}
+
+func TestFindAllApiKeysByUserHandler(t *testing.T) {
+	createdAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name                       string
+		apiKeyStorageReturnedValue []model.ApiKey
+		apiKeyStorageErr           error
+		expectedBody               string
+		expectedStatusCode         int
+	}{
+		{
+			name: "should return status 200 without key hashes when api keys found",
+			apiKeyStorageReturnedValue: []model.ApiKey{
+				{ID: 1, UserID: 1, Name: "pos", Prefix: "gm_01234567", KeyHash: "hash",
+					Scopes: []model.ApiKeyScope{model.ApiKeyScopeOrdersWrite}, CreatedAt: createdAt},
+			},
+			expectedBody: `[{"id":1,"name":"pos","prefix":"gm_01234567","scopes":["orders:write"],` +
+				`"created_at":"2024-01-01T00:00:00Z"}]`,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:                       "should return status 204 when api keys not found",
+			apiKeyStorageReturnedValue: make([]model.ApiKey, 0),
+			expectedStatusCode:         http.StatusNoContent,
+		},
+		{
+			name:               "should return status 500 when unexpected error occurred",
+			apiKeyStorageErr:   errors.New("unexpected error"),
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			server, mocks := newApiKeyTestServer(t, ctrl)
+			handler := http.HandlerFunc(server.FindAllApiKeysByUserHandler)
+
+			mocks.userStorage.EXPECT().GetOneByLogin(gomock.Any(), "user").
+				Return(model.User{ID: 1, Login: "user", Role: model.UserRoleUser}, nil)
+			mocks.apiKeyStorage.EXPECT().GetAllActiveByUserIDOrderByCreatedAtAsc(gomock.Any(), int64(1)).
+				Return(tt.apiKeyStorageReturnedValue, tt.apiKeyStorageErr)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/api/user/api-keys", nil)
+			req = withAuthorizedUser(t, server, req, "user")
+
+			handler.ServeHTTP(w, req)
+
+			resp := w.Result()
+			defer resp.Body.Close()
+			assertResponseMatchesSpec(t, req, resp)
+
+			assert.Equal(t, tt.expectedStatusCode, resp.StatusCode, "Response status code does not match expected status")
+			if tt.expectedBody != "" {
+				body, err := io.ReadAll(resp.Body)
+				require.NoError(t, err, "Error reading response body")
+				require.Equal(t, tt.expectedBody, string(body), "Response body does not match expected body")
+			}
+		})
+	}
+}
+
+func TestRevokeApiKeyHandler(t *testing.T) {
+	tests := []struct {
+		name               string
+		keyID              string
+		useApiKeyStorage   bool
+		revoked            bool
+		expectedStatusCode int
+	}{
+		{
+			name:               "should return status 200 when api key revoked",
+			keyID:              "1",
+			useApiKeyStorage:   true,
+			revoked:            true,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "should return status 404 when api key not found",
+			keyID:              "1",
+			useApiKeyStorage:   true,
+			revoked:            false,
+			expectedStatusCode: http.StatusNotFound,
+		},
+		{
+			name:               "should return status 400 when api key id is invalid",
+			keyID:              "key",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			server, mocks := newApiKeyTestServer(t, ctrl)
+			handler := http.HandlerFunc(server.RevokeApiKeyHandler)
+
+			mocks.userStorage.EXPECT().GetOneByLogin(gomock.Any(), "user").
+				Return(model.User{ID: 1, Login: "user", Role: model.UserRoleUser}, nil)
+			if tt.useApiKeyStorage {
+				mocks.apiKeyStorage.EXPECT().Revoke(gomock.Any(), int64(1), int64(1)).Return(tt.revoked, nil)
+			}
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodDelete, "/api/user/api-keys/"+tt.keyID, nil)
+			req = withURLParam(req, "keyID", tt.keyID)
+			req = withAuthorizedUser(t, server, req, "user")
+
+			handler.ServeHTTP(w, req)
+
+			resp := w.Result()
+			defer resp.Body.Close()
+			assertResponseMatchesSpec(t, req, resp)
+
+			assert.Equal(t, tt.expectedStatusCode, resp.StatusCode, "Response status code does not match expected status")
+		})
+	}
+}
+
+type apiKeyTestMocks struct {
+	userStorage   *MockUserStorage
+	apiKeyStorage *MockApiKeyStorage
+}
+
+func newApiKeyTestServer(t *testing.T, ctrl *gomock.Controller) (*Server, apiKeyTestMocks) {
+	validate, err := validator.GetValidator()
+	require.NoError(t, err, "Error init validator")
+	authToken := auth.GenerateAuthToken("secret")
+	cfg := &config.ServerConfig{}
+	logger, err := logger.Initialize("error")
+	require.NoError(t, err, "Error init logger")
+
+	userStorage := NewMockUserStorage(ctrl)
+	accrualStorage := NewMockAccrualStorage(ctrl)
+	withdrawnStorage := NewMockWithdrawnStorage(ctrl)
+	balanceStorage := NewMockBalanceStorage(ctrl)
+	totpStorage := NewMockTotpStorage(ctrl)
+	adjustmentStorage := NewMockAdjustmentStorage(ctrl)
+	apiKeyStorage := NewMockApiKeyStorage(ctrl)
+	accrualEventStorage := NewMockAccrualEventStorage(ctrl)
+	accrualEventListener := NewMockAccrualEventListener(ctrl)
+
+	userService := service.NewUserService(userStorage, logger)
+	totpService := service.NewTotpService(totpStorage, logger)
+	auditService := newTestAuditService(ctrl, logger)
+	authService := service.NewAuthService(userService, totpService, auditService, newTestPasswordHasher(),
+		authToken, logger)
+	accrualService := service.NewAccrualService(accrualStorage, logger)
+	withdrawnService := service.NewWithdrawnService(withdrawnStorage, auditService, logger)
+	balanceService := service.NewBalanceService(balanceStorage, logger)
+	adjustmentService := service.NewAdjustmentService(adjustmentStorage, logger)
+	apiKeyService := service.NewApiKeyService(apiKeyStorage, userService, logger)
+	accrualEventService := service.NewAccrualEventService(accrualEventStorage, accrualEventListener, logger)
+
+	server := NewServer(authService, userService, accrualService, withdrawnService, balanceService, totpService,
+		adjustmentService, apiKeyService, accrualEventService, nil, auditService, validate, authToken, cfg, logger)
+
+	return server, apiKeyTestMocks{
+		userStorage:   userStorage,
+		apiKeyStorage: apiKeyStorage,
+	}
+}
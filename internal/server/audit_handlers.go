@@ -0,0 +1,104 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/go-chi/jwtauth/v5"
+
+	"github.com/Stern-Ritter/gophermart/internal/auth"
+	"github.com/Stern-Ritter/gophermart/internal/model"
+	"github.com/Stern-Ritter/gophermart/internal/problem"
+)
+
+// AuditAdminActions records an audit event for every request to the wrapped admin routes. The action is the
+// route pattern, the target is the requested path and the outcome is derived from the response status.
+func (s *Server) AuditAdminActions(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		ww := middleware.NewWrapResponseWriter(res, req.ProtoMajor)
+		next.ServeHTTP(ww, req)
+
+		routePattern := req.URL.Path
+		if rctx := chi.RouteContext(req.Context()); rctx != nil && rctx.RoutePattern() != "" {
+			routePattern = rctx.RoutePattern()
+		}
+
+		var actor string
+		if _, claims, err := jwtauth.FromContext(req.Context()); err == nil {
+			actor, _ = claims[auth.LoginClaim].(string)
+		}
+
+		outcome := model.AuditOutcomeSuccess
+		if ww.Status() >= http.StatusBadRequest {
+			outcome = model.AuditOutcomeFailure
+		}
+
+		s.AuditService.Record(req.Context(), model.NewAuditEvent(0, actor,
+			model.NewAdminAuditAction(req.Method, routePattern), req.URL.Path, outcome))
+	})
+}
+
+func (s *Server) FindAllAuditEventsHandler(res http.ResponseWriter, req *http.Request) {
+	params, err := parsePageParams(req, "from", "to")
+	if err != nil {
+		s.writeProblem(res, req, http.StatusBadRequest, problem.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	filter := model.AuditEventsFilter{
+		Actor:         req.URL.Query().Get("actor"),
+		Action:        model.AuditAction(req.URL.Query().Get("action")),
+		CreatedFrom:   params.From,
+		CreatedTo:     params.To,
+		After:         params.After,
+		SortDirection: params.SortDirection,
+		Limit:         params.Limit,
+	}
+
+	page, err := s.AuditService.GetAuditEventsPage(req.Context(), filter)
+	if err != nil {
+		s.writeError(res, req, err)
+		return
+	}
+	if len(page.Events) == 0 {
+		res.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	body, err := json.Marshal(model.ToAuditEventsDto(page.Events))
+	if err != nil {
+		s.writeError(res, req, err)
+		return
+	}
+
+	setNextPageHeaders(res, req, page.NextCursor, filter.Limit)
+	res.Header().Set("Content-Type", "application/json")
+	_, err = res.Write(body)
+	if err != nil {
+		s.writeError(res, req, err)
+		return
+	}
+}
+
+func (s *Server) VerifyAuditChainHandler(res http.ResponseWriter, req *http.Request) {
+	verification, err := s.AuditService.VerifyChain(req.Context())
+	if err != nil {
+		s.writeError(res, req, err)
+		return
+	}
+
+	body, err := json.Marshal(verification)
+	if err != nil {
+		s.writeError(res, req, err)
+		return
+	}
+
+	res.Header().Set("Content-Type", "application/json")
+	_, err = res.Write(body)
+	if err != nil {
+		s.writeError(res, req, err)
+		return
+	}
+}
@@ -0,0 +1,453 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/Stern-Ritter/gophermart/internal/audit"
+	"github.com/Stern-Ritter/gophermart/internal/auth"
+	"github.com/Stern-Ritter/gophermart/internal/config"
+	er "github.com/Stern-Ritter/gophermart/internal/errors"
+	"github.com/Stern-Ritter/gophermart/internal/logger"
+	"github.com/Stern-Ritter/gophermart/internal/model"
+	"github.com/Stern-Ritter/gophermart/internal/service"
+	"github.com/Stern-Ritter/gophermart/internal/validator"
+)
+
+func TestSignInHandlerRecordsAuditEvent(t *testing.T) {
+	tests := []struct {
+		name            string
+		body            string
+		userStorageErr  error
+		useTotpStorage  bool
+		totp            model.Totp
+		totpStorageErr  error
+		expectedOutcome model.AuditOutcome
+	}{
+		{
+			name:            "should record success when password is valid",
+			body:            `{"login":"user42","password":"password"}`,
+			useTotpStorage:  true,
+			totpStorageErr:  pgx.ErrNoRows,
+			expectedOutcome: model.AuditOutcomeSuccess,
+		},
+		{
+			name:            "should record mfa required when user has two-factor authentication enabled",
+			body:            `{"login":"user42","password":"password"}`,
+			useTotpStorage:  true,
+			totp:            model.Totp{UserID: 1, Enabled: true},
+			expectedOutcome: model.AuditOutcomeMfaRequired,
+		},
+		{
+			name:            "should record failure when password is invalid",
+			body:            `{"login":"user42","password":"invalidPassword"}`,
+			expectedOutcome: model.AuditOutcomeFailure,
+		},
+		{
+			name:            "should record failure when user with this login not exists",
+			body:            `{"login":"user42","password":"password"}`,
+			userStorageErr:  pgx.ErrNoRows,
+			expectedOutcome: model.AuditOutcomeFailure,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			server, mocks := newAuditTestServer(t, ctrl)
+			handler := audit.Middleware(http.HandlerFunc(server.SignInHandler))
+
+			hashedPassword, err := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+			require.NoError(t, err, "Error hashing password")
+			mocks.userStorage.EXPECT().GetOneByLogin(gomock.Any(), "user42").
+				Return(model.User{ID: 1, Login: "user42", Password: string(hashedPassword)}, tt.userStorageErr)
+			mocks.userStorage.EXPECT().UpdatePassword(gomock.Any(), int64(1), gomock.Any()).Return(nil).AnyTimes()
+			if tt.useTotpStorage {
+				mocks.totpStorage.EXPECT().GetOneByUserID(gomock.Any(), int64(1)).Return(tt.totp, tt.totpStorageErr)
+			}
+
+			var recorded model.AuditEvent
+			mocks.auditEventStorage.EXPECT().Append(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ context.Context, event model.AuditEvent) (model.AuditEvent, error) {
+					recorded = event
+					return event, nil
+				})
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/api/user/login", strings.NewReader(tt.body))
+			req.RemoteAddr = "192.0.2.10:51234"
+			req.Header.Set("User-Agent", "curl/8.5.0")
+
+			handler.ServeHTTP(w, req)
+
+			resp := w.Result()
+			defer resp.Body.Close()
+
+			assert.Equal(t, model.AuditActionSignIn, recorded.Action, "Audit event action does not match expected action")
+			assert.Equal(t, tt.expectedOutcome, recorded.Outcome, "Audit event outcome does not match expected outcome")
+			assert.Equal(t, "user42", recorded.Actor, "Audit event actor does not match expected actor")
+			assert.Equal(t, "192.0.2.10", recorded.IP, "Audit event ip does not match expected ip")
+			assert.Equal(t, "curl/8.5.0", recorded.UserAgent, "Audit event user agent does not match expected user agent")
+		})
+	}
+}
+
+func TestWithdrawLoyaltyPointsHandlerRecordsAuditEvent(t *testing.T) {
+	tests := []struct {
+		name                string
+		withdrawnStorageErr error
+		expectedStatusCode  int
+		expectedOutcome     model.AuditOutcome
+	}{
+		{
+			name:               "should record success when points are withdrawn",
+			expectedStatusCode: http.StatusOK,
+			expectedOutcome:    model.AuditOutcomeSuccess,
+		},
+		{
+			name:                "should record failure when there are insufficient funds",
+			withdrawnStorageErr: er.PaymentRequiredError{},
+			expectedStatusCode:  http.StatusPaymentRequired,
+			expectedOutcome:     model.AuditOutcomeFailure,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			server, mocks := newAuditTestServer(t, ctrl)
+			handler := http.HandlerFunc(server.WithdrawLoyaltyPointsHandler)
+
+			mocks.userStorage.EXPECT().GetOneByLogin(gomock.Any(), "user").
+				Return(model.User{ID: 1, Login: "user"}, nil)
+			mocks.withdrawnStorage.EXPECT().Save(gomock.Any(), gomock.Any()).Return(tt.withdrawnStorageErr)
+
+			var recorded model.AuditEvent
+			mocks.auditEventStorage.EXPECT().Append(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ context.Context, event model.AuditEvent) (model.AuditEvent, error) {
+					recorded = event
+					return event, nil
+				})
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/api/user/balance/withdraw",
+				strings.NewReader(`{"order":"2377225624","sum":751}`))
+			req.Header.Set("Content-Type", "application/json")
+			req = withAuthorizedUser(t, server, req, "user")
+
+			handler.ServeHTTP(w, req)
+
+			resp := w.Result()
+			defer resp.Body.Close()
+
+			assert.Equal(t, tt.expectedStatusCode, resp.StatusCode, "Response status code does not match expected status")
+			assert.Equal(t, model.AuditActionWithdraw, recorded.Action, "Audit event action does not match expected action")
+			assert.Equal(t, tt.expectedOutcome, recorded.Outcome, "Audit event outcome does not match expected outcome")
+			assert.Equal(t, int64(1), recorded.ActorID, "Audit event actor id does not match expected actor id")
+			assert.Equal(t, "user", recorded.Actor, "Audit event actor does not match expected actor")
+			assert.Equal(t, "order:2377225624", recorded.Target, "Audit event target does not match expected target")
+		})
+	}
+}
+
+func TestAuditAdminActions(t *testing.T) {
+	tests := []struct {
+		name            string
+		status          int
+		expectedOutcome model.AuditOutcome
+	}{
+		{
+			name:            "should record success when admin action succeeded",
+			status:          http.StatusOK,
+			expectedOutcome: model.AuditOutcomeSuccess,
+		},
+		{
+			name:            "should record failure when admin action failed",
+			status:          http.StatusNotFound,
+			expectedOutcome: model.AuditOutcomeFailure,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			server, mocks := newAuditTestServer(t, ctrl)
+
+			var recorded model.AuditEvent
+			mocks.auditEventStorage.EXPECT().Append(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ context.Context, event model.AuditEvent) (model.AuditEvent, error) {
+					recorded = event
+					return event, nil
+				})
+
+			r := chi.NewRouter()
+			r.Use(server.AuditAdminActions)
+			r.Get("/users/{userID}/balance", func(res http.ResponseWriter, _ *http.Request) {
+				res.WriteHeader(tt.status)
+			})
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/users/2/balance", nil)
+			req = withAuthorizedUser(t, server, req, "admin")
+
+			r.ServeHTTP(w, req)
+
+			resp := w.Result()
+			defer resp.Body.Close()
+
+			assert.Equal(t, tt.status, resp.StatusCode, "Response status code does not match expected status")
+			assert.Equal(t, model.AuditAction("admin:GET /users/{userID}/balance"), recorded.Action,
+				"Audit event action does not match expected action")
+			assert.Equal(t, "/users/2/balance", recorded.Target, "Audit event target does not match expected target")
+			assert.Equal(t, "admin", recorded.Actor, "Audit event actor does not match expected actor")
+			assert.Equal(t, tt.expectedOutcome, recorded.Outcome, "Audit event outcome does not match expected outcome")
+		})
+	}
+}
+
+func TestFindAllAuditEventsHandler(t *testing.T) {
+	createdAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	events := []model.AuditEvent{
+		{ID: 1, ActorID: 1, Actor: "user", Action: model.AuditActionSignIn, Target: "user:user", IP: "192.0.2.10",
+			UserAgent: "curl/8.5.0", Outcome: model.AuditOutcomeSuccess, CreatedAt: createdAt,
+			PrevHash: model.AuditGenesisHash, Hash: "hash1"},
+		{ID: 2, ActorID: 1, Actor: "user", Action: model.AuditActionWithdraw, Target: "order:2377225624",
+			IP: "192.0.2.10", UserAgent: "curl/8.5.0", Outcome: model.AuditOutcomeSuccess, CreatedAt: createdAt,
+			PrevHash: "hash1", Hash: "hash2"},
+	}
+
+	tests := []struct {
+		name                           string
+		query                          string
+		useAuditEventStorage           bool
+		auditEventStorageReturnedValue []model.AuditEvent
+		auditEventStorageErr           error
+		expectedFilter                 model.AuditEventsFilter
+		expectedBody                   string
+		expectedLink                   bool
+		expectedStatusCode             int
+	}{
+		{
+			name:                           "should return status 200 with next page link when there are more events",
+			query:                          "?actor=user&action=user.sign_in&limit=1",
+			useAuditEventStorage:           true,
+			auditEventStorageReturnedValue: events,
+			expectedFilter: model.AuditEventsFilter{Actor: "user", Action: model.AuditActionSignIn,
+				SortDirection: model.SortAsc, Limit: 2},
+			expectedBody: `[{"id":1,"actor_id":1,"actor":"user","action":"user.sign_in","target":"user:user",` +
+				`"ip":"192.0.2.10","user_agent":"curl/8.5.0","outcome":"success","created_at":"2024-01-01T00:00:00Z",` +
+				`"prev_hash":"` + model.AuditGenesisHash + `","hash":"hash1"}]`,
+			expectedLink:       true,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:                           "should return status 204 when events not found",
+			useAuditEventStorage:           true,
+			auditEventStorageReturnedValue: make([]model.AuditEvent, 0),
+			expectedFilter:                 model.AuditEventsFilter{SortDirection: model.SortAsc, Limit: 51},
+			expectedStatusCode:             http.StatusNoContent,
+		},
+		{
+			name:               "should return status 400 when from date is invalid",
+			query:              "?from=yesterday",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:                 "should return status 500 when unexpected error occurred",
+			useAuditEventStorage: true,
+			auditEventStorageErr: errors.New("unexpected error"),
+			expectedFilter:       model.AuditEventsFilter{SortDirection: model.SortAsc, Limit: 51},
+			expectedStatusCode:   http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			server, mocks := newAuditTestServer(t, ctrl)
+			handler := http.HandlerFunc(server.FindAllAuditEventsHandler)
+
+			if tt.useAuditEventStorage {
+				mocks.auditEventStorage.EXPECT().GetAllByFilter(gomock.Any(), tt.expectedFilter).
+					Return(tt.auditEventStorageReturnedValue, tt.auditEventStorageErr)
+			}
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/api/admin/audit-events"+tt.query, nil)
+
+			handler.ServeHTTP(w, req)
+
+			resp := w.Result()
+			defer resp.Body.Close()
+			assertResponseMatchesSpec(t, req, resp)
+
+			assert.Equal(t, tt.expectedStatusCode, resp.StatusCode, "Response status code does not match expected status")
+			if tt.expectedLink {
+				assert.NotEmpty(t, resp.Header.Get("Link"), "Response header should contain next page link")
+				assert.NotEmpty(t, resp.Header.Get("X-Next-Cursor"), "Response header should contain next page cursor")
+			}
+			if tt.expectedBody != "" {
+				body, err := io.ReadAll(resp.Body)
+				require.NoError(t, err, "Error reading response body")
+				require.Equal(t, tt.expectedBody, string(body), "Response body does not match expected body")
+			}
+		})
+	}
+}
+
+func TestVerifyAuditChainHandler(t *testing.T) {
+	createdAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	first := model.AuditEvent{ID: 1, ActorID: 1, Actor: "user", Action: model.AuditActionSignIn,
+		Outcome: model.AuditOutcomeSuccess, CreatedAt: createdAt, PrevHash: model.AuditGenesisHash}
+	first.Hash = first.ComputeHash()
+	second := model.AuditEvent{ID: 2, ActorID: 1, Actor: "user", Action: model.AuditActionWithdraw,
+		Target: "order:2377225624", Outcome: model.AuditOutcomeSuccess, CreatedAt: createdAt, PrevHash: first.Hash}
+	second.Hash = second.ComputeHash()
+	third := model.AuditEvent{ID: 3, Actor: "admin", Action: model.NewAdminAuditAction(http.MethodGet, "/users"),
+		Target: "/api/admin/users", Outcome: model.AuditOutcomeSuccess, CreatedAt: createdAt, PrevHash: second.Hash}
+	third.Hash = third.ComputeHash()
+
+	modified := second
+	modified.Outcome = model.AuditOutcomeFailure
+
+	tests := []struct {
+		name                 string
+		events               []model.AuditEvent
+		auditEventStorageErr error
+		expectedBody         string
+		expectedStatusCode   int
+	}{
+		{
+			name:               "should return valid verification when chain is intact",
+			events:             []model.AuditEvent{first, second, third},
+			expectedBody:       `{"valid":true,"checked_events":3}`,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "should return valid verification when there are no events",
+			expectedBody:       `{"valid":true,"checked_events":0}`,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:   "should return broken verification when event was modified",
+			events: []model.AuditEvent{first, modified, third},
+			expectedBody: `{"valid":false,"checked_events":2,"broken_at_id":2,` +
+				`"reason":"event 2 hash does not match its content, the event was modified"}`,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:   "should return broken verification when event was removed",
+			events: []model.AuditEvent{first, third},
+			expectedBody: `{"valid":false,"checked_events":2,"broken_at_id":3,` +
+				`"reason":"event 3 does not link to the previous event, an event before it was removed or reordered"}`,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:                 "should return status 500 when unexpected error occurred",
+			auditEventStorageErr: errors.New("unexpected error"),
+			expectedStatusCode:   http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			server, mocks := newAuditTestServer(t, ctrl)
+			handler := http.HandlerFunc(server.VerifyAuditChainHandler)
+
+			mocks.auditEventStorage.EXPECT().ForEachOrderByIDAsc(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ context.Context, fn func(event model.AuditEvent) error) error {
+					for _, event := range tt.events {
+						if err := fn(event); err != nil {
+							return err
+						}
+					}
+					return tt.auditEventStorageErr
+				})
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/api/admin/audit-events/verify", nil)
+
+			handler.ServeHTTP(w, req)
+
+			resp := w.Result()
+			defer resp.Body.Close()
+			assertResponseMatchesSpec(t, req, resp)
+
+			assert.Equal(t, tt.expectedStatusCode, resp.StatusCode, "Response status code does not match expected status")
+			if tt.expectedBody != "" {
+				body, err := io.ReadAll(resp.Body)
+				require.NoError(t, err, "Error reading response body")
+				require.Equal(t, tt.expectedBody, string(body), "Response body does not match expected body")
+			}
+		})
+	}
+}
+
+type auditTestMocks struct {
+	userStorage       *MockUserStorage
+	totpStorage       *MockTotpStorage
+	withdrawnStorage  *MockWithdrawnStorage
+	auditEventStorage *MockAuditEventStorage
+}
+
+func newAuditTestServer(t *testing.T, ctrl *gomock.Controller) (*Server, auditTestMocks) {
+	validate, err := validator.GetValidator()
+	require.NoError(t, err, "Error init validator")
+	authToken := auth.GenerateAuthToken("secret")
+	cfg := &config.ServerConfig{}
+	logger, err := logger.Initialize("error")
+	require.NoError(t, err, "Error init logger")
+
+	userStorage := NewMockUserStorage(ctrl)
+	withdrawnStorage := NewMockWithdrawnStorage(ctrl)
+	totpStorage := NewMockTotpStorage(ctrl)
+	auditEventStorage := NewMockAuditEventStorage(ctrl)
+
+	userService := service.NewUserService(userStorage, logger)
+	totpService := service.NewTotpService(totpStorage, logger)
+	auditService := service.NewAuditService(auditEventStorage, logger)
+	authService := service.NewAuthService(userService, totpService, auditService, newTestPasswordHasher(),
+		authToken, logger)
+	withdrawnService := service.NewWithdrawnService(withdrawnStorage, auditService, logger)
+
+	server := NewServer(authService, userService, nil, withdrawnService, nil, totpService,
+		nil, nil, nil, nil, auditService, validate, authToken, cfg, logger)
+
+	return server, auditTestMocks{
+		userStorage:       userStorage,
+		totpStorage:       totpStorage,
+		withdrawnStorage:  withdrawnStorage,
+		auditEventStorage: auditEventStorage,
+	}
+}
+
+// newTestAuditService returns an audit service that accepts any event, for tests that don't check the audit trail.
+func newTestAuditService(ctrl *gomock.Controller, l *logger.ServerLogger) service.AuditService {
+	auditEventStorage := NewMockAuditEventStorage(ctrl)
+	auditEventStorage.EXPECT().Append(gomock.Any(), gomock.Any()).Return(model.AuditEvent{}, nil).AnyTimes()
+	return service.NewAuditService(auditEventStorage, l)
+}
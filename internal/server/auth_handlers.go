@@ -3,36 +3,30 @@ package server
 import (
 	"bytes"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"io"
 	"net/http"
 
-	er "github.com/Stern-Ritter/gophermart/internal/errors"
 	"github.com/Stern-Ritter/gophermart/internal/model"
+	"github.com/Stern-Ritter/gophermart/internal/problem"
 )
 
 func (s *Server) SignUpHandler(res http.ResponseWriter, req *http.Request) {
 	signUpRequest := model.SignUpRequest{}
 	err := decodeWithUnknownAndDuplicateFieldsCheck(req.Body, &signUpRequest)
 	if err != nil {
-		http.Error(res, "Error decode request JSON body", http.StatusBadRequest)
+		s.writeProblem(res, req, http.StatusBadRequest, problem.CodeInvalidRequest, "Error decode request JSON body")
 		return
 	}
 
 	if err := signUpRequest.Validate(s.Validate); err != nil {
-		http.Error(res, err.Error(), http.StatusBadRequest)
+		s.writeError(res, req, err)
 		return
 	}
 
 	tokenString, err := s.AuthService.SignUp(req.Context(), signUpRequest)
 	if err != nil {
-		var conflictError er.ConflictError
-		if errors.As(err, &conflictError) {
-			http.Error(res, conflictError.Error(), http.StatusConflict)
-			return
-		}
-		http.Error(res, err.Error(), http.StatusInternalServerError)
+		s.writeError(res, req, err)
 		return
 	}
 
@@ -44,23 +38,57 @@ func (s *Server) SignInHandler(res http.ResponseWriter, req *http.Request) {
 	signInRequest := model.SignInRequest{}
 	err := decodeWithUnknownAndDuplicateFieldsCheck(req.Body, &signInRequest)
 	if err != nil {
-		http.Error(res, "Error decode request JSON body", http.StatusBadRequest)
+		s.writeProblem(res, req, http.StatusBadRequest, problem.CodeInvalidRequest, "Error decode request JSON body")
 		return
 	}
 
 	if err := signInRequest.Validate(s.Validate); err != nil {
-		http.Error(res, err.Error(), http.StatusBadRequest)
+		s.writeError(res, req, err)
 		return
 	}
 
-	tokenString, err := s.AuthService.SignIn(req.Context(), signInRequest)
+	signInResult, err := s.AuthService.SignIn(req.Context(), signInRequest)
 	if err != nil {
-		var unauthorizedError er.UnauthorizedError
-		if errors.As(err, &unauthorizedError) {
-			http.Error(res, unauthorizedError.Error(), http.StatusUnauthorized)
+		s.writeError(res, req, err)
+		return
+	}
+
+	if signInResult.MfaRequired() {
+		body, err := json.Marshal(model.MfaChallengeDto{ChallengeToken: signInResult.ChallengeToken})
+		if err != nil {
+			s.writeError(res, req, err)
 			return
 		}
-		http.Error(res, err.Error(), http.StatusInternalServerError)
+
+		res.Header().Set("Content-Type", "application/json")
+		res.WriteHeader(http.StatusAccepted)
+		_, err = res.Write(body)
+		if err != nil {
+			s.writeError(res, req, err)
+		}
+		return
+	}
+
+	res.Header().Set("Authorization", signInResult.AuthToken)
+	res.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) SignInSecondFactorHandler(res http.ResponseWriter, req *http.Request) {
+	signInSecondFactorRequest := model.SignInSecondFactorRequest{}
+	err := decodeWithUnknownAndDuplicateFieldsCheck(req.Body, &signInSecondFactorRequest)
+	if err != nil {
+		s.writeProblem(res, req, http.StatusBadRequest, problem.CodeInvalidRequest, "Error decode request JSON body")
+		return
+	}
+
+	if err := signInSecondFactorRequest.Validate(s.Validate); err != nil {
+		s.writeError(res, req, err)
+		return
+	}
+
+	tokenString, err := s.AuthService.SignInWithSecondFactor(req.Context(), signInSecondFactorRequest)
+	if err != nil {
+		s.writeError(res, req, err)
 		return
 	}
 
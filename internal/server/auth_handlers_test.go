@@ -1,12 +1,16 @@
 package server
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/go-chi/jwtauth/v5"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/stretchr/testify/assert"
@@ -18,6 +22,7 @@ import (
 	"github.com/Stern-Ritter/gophermart/internal/config"
 	"github.com/Stern-Ritter/gophermart/internal/logger"
 	"github.com/Stern-Ritter/gophermart/internal/model"
+	"github.com/Stern-Ritter/gophermart/internal/problem"
 	"github.com/Stern-Ritter/gophermart/internal/service"
 	"github.com/Stern-Ritter/gophermart/internal/validator"
 )
@@ -30,69 +35,86 @@ func TestSignUpHandler(t *testing.T) {
 		userStorageErr            error
 		expectedStatusCode        int
 		expectAuthorizationHeader bool
+		expectedProblemCode       string
 	}{
 		{
 			name:                      "should return status 200 when user with this login not exists",
-			body:                      `{"login":"user42","password":"password"}`,
+			body:                      `{"login":"user42","password":"Secret-Passw0rd"}`,
 			useUserStorage:            true,
 			expectedStatusCode:        http.StatusOK,
 			expectAuthorizationHeader: true,
 		},
 		{
-			name:               "should return status 400 when request body is empty",
-			body:               "",
-			useUserStorage:     false,
-			expectedStatusCode: http.StatusBadRequest,
+			name:                "should return status 400 when request body is empty",
+			body:                "",
+			useUserStorage:      false,
+			expectedStatusCode:  http.StatusBadRequest,
+			expectedProblemCode: problem.CodeInvalidRequest,
 		},
 		{
-			name:               "should return status 400 when required login field is missing",
-			body:               `{"login1":"user42","password":"password"}`,
-			useUserStorage:     false,
-			expectedStatusCode: http.StatusBadRequest,
+			name:                "should return status 400 when required login field is missing",
+			body:                `{"login1":"user42","password":"password"}`,
+			useUserStorage:      false,
+			expectedStatusCode:  http.StatusBadRequest,
+			expectedProblemCode: problem.CodeInvalidRequest,
 		},
 		{
-			name:               "should return status 400 when login field length is less than 4 characters",
-			body:               `{"login":"usr","password":"password"}`,
-			useUserStorage:     false,
-			expectedStatusCode: http.StatusBadRequest,
+			name:                "should return status 400 when login field length is less than 4 characters",
+			body:                `{"login":"usr","password":"password"}`,
+			useUserStorage:      false,
+			expectedStatusCode:  http.StatusBadRequest,
+			expectedProblemCode: problem.CodeValidationFailed,
 		},
 		{
-			name:               "should return status 400 when login field length is more than 30 characters",
-			body:               `{"login":"user42user42user42user42user42user42","password":"password"}`,
-			useUserStorage:     false,
-			expectedStatusCode: http.StatusBadRequest,
+			name:                "should return status 400 when login field length is more than 30 characters",
+			body:                `{"login":"user42user42user42user42user42user42","password":"password"}`,
+			useUserStorage:      false,
+			expectedStatusCode:  http.StatusBadRequest,
+			expectedProblemCode: problem.CodeValidationFailed,
 		},
 		{
-			name:               "should return status 400 when required password field is missing",
-			body:               `{"login":"user42","password1":"password"}`,
-			useUserStorage:     false,
-			expectedStatusCode: http.StatusBadRequest,
+			name:                "should return status 400 when required password field is missing",
+			body:                `{"login":"user42","password1":"password"}`,
+			useUserStorage:      false,
+			expectedStatusCode:  http.StatusBadRequest,
+			expectedProblemCode: problem.CodeInvalidRequest,
 		},
 		{
-			name:               "should return status 400 when password field length is less than 8 characters",
-			body:               `{"login":"user42","password":"word"}`,
-			useUserStorage:     false,
-			expectedStatusCode: http.StatusBadRequest,
+			name:                "should return status 400 when password field length is less than 8 characters",
+			body:                `{"login":"user42","password":"word"}`,
+			useUserStorage:      false,
+			expectedStatusCode:  http.StatusBadRequest,
+			expectedProblemCode: problem.CodeValidationFailed,
 		},
 		{
-			name:               "should return status 400 when password field length is more than 256 characters",
-			body:               `{"login":"user","password":"passwordpasswordpasswordpasswordpasswordpasswordpasswordpasswordpasswordpasswordpasswordpasswordpasswordpasswordpasswordpasswordpasswordpasswordpasswordpasswordpasswordpasswordpasswordpasswordpasswordpasswordpasswordpasswordpasswordpasswordpasswordpasswordpassword"}`,
-			useUserStorage:     false,
-			expectedStatusCode: http.StatusBadRequest,
+			name:                "should return status 400 when password has not enough character classes",
+			body:                `{"login":"user42","password":"password"}`,
+			useUserStorage:      false,
+			expectedStatusCode:  http.StatusBadRequest,
+			expectedProblemCode: problem.CodeValidationFailed,
 		},
 		{
-			name:               "should return status 409 when user with this login already exists",
-			body:               `{"login":"user42","password":"password"}`,
-			useUserStorage:     true,
-			userStorageErr:     &pgconn.PgError{ConstraintName: "users_login_unique"},
-			expectedStatusCode: http.StatusConflict,
+			name:                "should return status 400 when password field length is more than 256 characters",
+			body:                `{"login":"user","password":"passwordpasswordpasswordpasswordpasswordpasswordpasswordpasswordpasswordpasswordpasswordpasswordpasswordpasswordpasswordpasswordpasswordpasswordpasswordpasswordpasswordpasswordpasswordpasswordpasswordpasswordpasswordpasswordpasswordpasswordpasswordpasswordpassword"}`,
+			useUserStorage:      false,
+			expectedStatusCode:  http.StatusBadRequest,
+			expectedProblemCode: problem.CodeValidationFailed,
 		},
 		{
-			name:               "should return status 500 when unexpected error occurred",
-			body:               `{"login":"user42","password":"password"}`,
-			useUserStorage:     true,
-			userStorageErr:     errors.New("unexpected error"),
-			expectedStatusCode: http.StatusInternalServerError,
+			name:                "should return status 409 when user with this login already exists",
+			body:                `{"login":"user42","password":"Secret-Passw0rd"}`,
+			useUserStorage:      true,
+			userStorageErr:      &pgconn.PgError{ConstraintName: "users_login_unique"},
+			expectedStatusCode:  http.StatusConflict,
+			expectedProblemCode: problem.CodeConflict,
+		},
+		{
+			name:                "should return status 500 when unexpected error occurred",
+			body:                `{"login":"user42","password":"Secret-Passw0rd"}`,
+			useUserStorage:      true,
+			userStorageErr:      errors.New("unexpected error"),
+			expectedStatusCode:  http.StatusInternalServerError,
+			expectedProblemCode: problem.CodeInternalError,
 		},
 	}
 
@@ -112,15 +134,26 @@ func TestSignUpHandler(t *testing.T) {
 			accrualStorage := NewMockAccrualStorage(ctrl)
 			withdrawnStorage := NewMockWithdrawnStorage(ctrl)
 			balanceStorage := NewMockBalanceStorage(ctrl)
+			totpStorage := NewMockTotpStorage(ctrl)
+			adjustmentStorage := NewMockAdjustmentStorage(ctrl)
+			apiKeyStorage := NewMockApiKeyStorage(ctrl)
+			accrualEventStorage := NewMockAccrualEventStorage(ctrl)
+			accrualEventListener := NewMockAccrualEventListener(ctrl)
 
 			userService := service.NewUserService(userStorage, logger)
-			authService := service.NewAuthService(userService, authToken, logger)
+			totpService := service.NewTotpService(totpStorage, logger)
+			auditService := newTestAuditService(ctrl, logger)
+			authService := service.NewAuthService(userService, totpService, auditService, newTestPasswordHasher(),
+				authToken, logger)
 			accrualService := service.NewAccrualService(accrualStorage, logger)
-			withdrawnService := service.NewWithdrawnService(withdrawnStorage, logger)
+			withdrawnService := service.NewWithdrawnService(withdrawnStorage, auditService, logger)
 			balanceService := service.NewBalanceService(balanceStorage, logger)
+			adjustmentService := service.NewAdjustmentService(adjustmentStorage, logger)
+			apiKeyService := service.NewApiKeyService(apiKeyStorage, userService, logger)
+			accrualEventService := service.NewAccrualEventService(accrualEventStorage, accrualEventListener, logger)
 
-			server := NewServer(authService, userService, accrualService, withdrawnService, balanceService, validate,
-				authToken, cfg, logger)
+			server := NewServer(authService, userService, accrualService, withdrawnService, balanceService, totpService,
+				adjustmentService, apiKeyService, accrualEventService, nil, auditService, validate, authToken, cfg, logger)
 
 			handler := http.HandlerFunc(server.SignUpHandler)
 			if tt.useUserStorage {
@@ -134,6 +167,7 @@ func TestSignUpHandler(t *testing.T) {
 
 			resp := w.Result()
 			defer resp.Body.Close()
+			assertResponseMatchesSpec(t, req, resp)
 
 			assert.Equal(t, tt.expectedStatusCode, resp.StatusCode, "Response status code does not match expected status")
 			if tt.expectAuthorizationHeader {
@@ -141,6 +175,13 @@ func TestSignUpHandler(t *testing.T) {
 			} else {
 				assert.Empty(t, resp.Header.Get("Authorization"), "Response header should not contain Authorization header")
 			}
+			if tt.expectedProblemCode != "" {
+				assert.Equal(t, problem.ContentType, resp.Header.Get("Content-Type"), "Response content type should be problem json")
+				var p problem.Problem
+				require.NoError(t, json.NewDecoder(resp.Body).Decode(&p), "Error decoding response body")
+				assert.Equal(t, tt.expectedProblemCode, p.Code, "Problem code does not match expected code")
+				assert.Equal(t, tt.expectedStatusCode, p.Status, "Problem status does not match response status")
+			}
 		})
 	}
 }
@@ -151,15 +192,67 @@ func TestSignInHandler(t *testing.T) {
 		body                      string
 		useUserStorage            bool
 		userStorageErr            error
+		userDisabled              bool
+		useTotpStorage            bool
+		totpStorageReturnedValue  model.Totp
+		totpStorageErr            error
 		expectedStatusCode        int
 		expectAuthorizationHeader bool
+		expectChallengeToken      bool
+		passwordHash              string
+		expectPasswordRehash      bool
+		updatePasswordErr         error
 	}{
 		{
 			name:                      "should return status 200 when user with this login exist and password is valid",
 			body:                      `{"login":"user42","password":"password"}`,
 			useUserStorage:            true,
+			useTotpStorage:            true,
+			totpStorageErr:            pgx.ErrNoRows,
 			expectedStatusCode:        http.StatusOK,
 			expectAuthorizationHeader: true,
+			expectPasswordRehash:      true,
+		},
+		{
+			name:                      "should not rehash password when it is hashed with current argon2id parameters",
+			body:                      `{"login":"user42","password":"password"}`,
+			useUserStorage:            true,
+			useTotpStorage:            true,
+			totpStorageErr:            pgx.ErrNoRows,
+			passwordHash:              mustHashTestPassword(t, "password"),
+			expectedStatusCode:        http.StatusOK,
+			expectAuthorizationHeader: true,
+		},
+		{
+			name:                      "should return status 200 when legacy password rehash failed",
+			body:                      `{"login":"user42","password":"password"}`,
+			useUserStorage:            true,
+			useTotpStorage:            true,
+			totpStorageErr:            pgx.ErrNoRows,
+			expectedStatusCode:        http.StatusOK,
+			expectAuthorizationHeader: true,
+			expectPasswordRehash:      true,
+			updatePasswordErr:         errors.New("unexpected error"),
+		},
+		{
+			name:                     "should return status 202 with challenge token when user has two-factor authentication enabled",
+			body:                     `{"login":"user42","password":"password"}`,
+			useUserStorage:           true,
+			useTotpStorage:           true,
+			totpStorageReturnedValue: model.Totp{UserID: 1, Enabled: true},
+			expectedStatusCode:       http.StatusAccepted,
+			expectChallengeToken:     true,
+			expectPasswordRehash:     true,
+		},
+		{
+			name:                      "should return status 200 when user has not confirmed two-factor authentication enrollment",
+			body:                      `{"login":"user42","password":"password"}`,
+			useUserStorage:            true,
+			useTotpStorage:            true,
+			totpStorageReturnedValue:  model.Totp{UserID: 1, Enabled: false},
+			expectedStatusCode:        http.StatusOK,
+			expectAuthorizationHeader: true,
+			expectPasswordRehash:      true,
 		},
 		{
 			name:               "should return status 401 when user with this login not exists",
@@ -174,6 +267,13 @@ func TestSignInHandler(t *testing.T) {
 			useUserStorage:     true,
 			expectedStatusCode: http.StatusUnauthorized,
 		},
+		{
+			name:               "should return status 401 when user is disabled",
+			body:               `{"login":"user42","password":"password"}`,
+			useUserStorage:     true,
+			userDisabled:       true,
+			expectedStatusCode: http.StatusUnauthorized,
+		},
 		{
 			name:               "should return status 400 when request body is empty",
 			body:               "",
@@ -241,23 +341,50 @@ func TestSignInHandler(t *testing.T) {
 			accrualStorage := NewMockAccrualStorage(ctrl)
 			withdrawnStorage := NewMockWithdrawnStorage(ctrl)
 			balanceStorage := NewMockBalanceStorage(ctrl)
+			totpStorage := NewMockTotpStorage(ctrl)
+			adjustmentStorage := NewMockAdjustmentStorage(ctrl)
+			apiKeyStorage := NewMockApiKeyStorage(ctrl)
+			accrualEventStorage := NewMockAccrualEventStorage(ctrl)
+			accrualEventListener := NewMockAccrualEventListener(ctrl)
 
 			userService := service.NewUserService(userStorage, logger)
-			authService := service.NewAuthService(userService, authToken, logger)
+			totpService := service.NewTotpService(totpStorage, logger)
+			auditService := newTestAuditService(ctrl, logger)
+			authService := service.NewAuthService(userService, totpService, auditService, newTestPasswordHasher(),
+				authToken, logger)
 			accrualService := service.NewAccrualService(accrualStorage, logger)
-			withdrawnService := service.NewWithdrawnService(withdrawnStorage, logger)
+			withdrawnService := service.NewWithdrawnService(withdrawnStorage, auditService, logger)
 			balanceService := service.NewBalanceService(balanceStorage, logger)
+			adjustmentService := service.NewAdjustmentService(adjustmentStorage, logger)
+			apiKeyService := service.NewApiKeyService(apiKeyStorage, userService, logger)
+			accrualEventService := service.NewAccrualEventService(accrualEventStorage, accrualEventListener, logger)
 
-			server := NewServer(authService, userService, accrualService, withdrawnService, balanceService, validate,
-				authToken, cfg, logger)
+			server := NewServer(authService, userService, accrualService, withdrawnService, balanceService, totpService,
+				adjustmentService, apiKeyService, accrualEventService, nil, auditService, validate, authToken, cfg, logger)
 
 			handler := http.HandlerFunc(server.SignInHandler)
 
-			hashedPassword, err := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.DefaultCost)
-			require.NoError(t, err, "Error hashing password")
+			passwordHash := tt.passwordHash
+			if passwordHash == "" {
+				hashedPassword, err := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.DefaultCost)
+				require.NoError(t, err, "Error hashing password")
+				passwordHash = string(hashedPassword)
+			}
 			if tt.useUserStorage {
 				userStorage.EXPECT().GetOneByLogin(gomock.Any(), gomock.Any()).
-					Return(model.User{ID: 1, Login: "user42", Password: string(hashedPassword)}, tt.userStorageErr)
+					Return(model.User{ID: 1, Login: "user42", Password: passwordHash, Disabled: tt.userDisabled},
+						tt.userStorageErr)
+			}
+			if tt.expectPasswordRehash {
+				userStorage.EXPECT().UpdatePassword(gomock.Any(), int64(1), gomock.Any()).
+					DoAndReturn(func(_ context.Context, _ int64, hash string) error {
+						assert.True(t, strings.HasPrefix(hash, "$argon2id$"), "Password should be rehashed with argon2id")
+						return tt.updatePasswordErr
+					})
+			}
+			if tt.useTotpStorage {
+				totpStorage.EXPECT().GetOneByUserID(gomock.Any(), int64(1)).
+					Return(tt.totpStorageReturnedValue, tt.totpStorageErr)
 			}
 
 			w := httptest.NewRecorder()
@@ -267,6 +394,7 @@ func TestSignInHandler(t *testing.T) {
 
 			resp := w.Result()
 			defer resp.Body.Close()
+			assertResponseMatchesSpec(t, req, resp)
 
 			assert.Equal(t, tt.expectedStatusCode, resp.StatusCode, "Response status code does not match expected status")
 			if tt.expectAuthorizationHeader {
@@ -274,6 +402,224 @@ func TestSignInHandler(t *testing.T) {
 			} else {
 				assert.Empty(t, resp.Header.Get("Authorization"), "Response header should not contain Authorization header")
 			}
+			if tt.expectChallengeToken {
+				challenge := model.MfaChallengeDto{}
+				err := json.NewDecoder(resp.Body).Decode(&challenge)
+				require.NoError(t, err, "Error decoding response body")
+				assert.NotEmpty(t, challenge.ChallengeToken, "Response body should contain challenge token")
+			}
 		})
 	}
 }
+
+func TestSignInSecondFactorHandler(t *testing.T) {
+	secret, err := auth.GenerateTotpSecret()
+	require.NoError(t, err, "Error generating totp secret")
+	validCode, err := auth.GenerateTotpCode(secret, auth.GetTotpStep(time.Now()))
+	require.NoError(t, err, "Error generating totp code")
+	expiredCode, err := auth.GenerateTotpCode(secret, auth.GetTotpStep(time.Now())-5)
+	require.NoError(t, err, "Error generating totp code")
+
+	tests := []struct {
+		name                      string
+		challengeClaims           map[string]interface{}
+		rawChallengeToken         string
+		code                      string
+		useUserStorage            bool
+		useTotpStorage            bool
+		failedAttempts            int64
+		useLastUsedStep           bool
+		lastUsedStepUpdated       bool
+		useRecoveryCode           bool
+		recoveryCodeUsed          bool
+		expectedStatusCode        int
+		expectAuthorizationHeader bool
+	}{
+		{
+			name:                      "should return status 200 when challenge token and totp code are valid",
+			challengeClaims:           map[string]interface{}{"mfa_login": "user42"},
+			code:                      validCode,
+			useUserStorage:            true,
+			useTotpStorage:            true,
+			useLastUsedStep:           true,
+			lastUsedStepUpdated:       true,
+			expectedStatusCode:        http.StatusOK,
+			expectAuthorizationHeader: true,
+		},
+		{
+			name:               "should return status 401 when too many codes were tried even if totp code is valid",
+			challengeClaims:    map[string]interface{}{"mfa_login": "user42"},
+			code:               validCode,
+			useUserStorage:     true,
+			useTotpStorage:     true,
+			failedAttempts:     6,
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:                      "should return status 200 when challenge token and recovery code are valid",
+			challengeClaims:           map[string]interface{}{"mfa_login": "user42"},
+			code:                      "abcde-fghij",
+			useUserStorage:            true,
+			useTotpStorage:            true,
+			useRecoveryCode:           true,
+			recoveryCodeUsed:          true,
+			expectedStatusCode:        http.StatusOK,
+			expectAuthorizationHeader: true,
+		},
+		{
+			name:                "should return status 401 when totp code has already been used",
+			challengeClaims:     map[string]interface{}{"mfa_login": "user42"},
+			code:                validCode,
+			useUserStorage:      true,
+			useTotpStorage:      true,
+			useLastUsedStep:     true,
+			lastUsedStepUpdated: false,
+			expectedStatusCode:  http.StatusUnauthorized,
+		},
+		{
+			name:               "should return status 401 when totp code is expired",
+			challengeClaims:    map[string]interface{}{"mfa_login": "user42"},
+			code:               expiredCode,
+			useUserStorage:     true,
+			useTotpStorage:     true,
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:               "should return status 401 when recovery code is unknown or already used",
+			challengeClaims:    map[string]interface{}{"mfa_login": "user42"},
+			code:               "abcde-fghij",
+			useUserStorage:     true,
+			useTotpStorage:     true,
+			useRecoveryCode:    true,
+			recoveryCodeUsed:   false,
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:               "should return status 401 when auth token is used instead of challenge token",
+			challengeClaims:    map[string]interface{}{"login": "user42"},
+			code:               validCode,
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:               "should return status 401 when challenge token is invalid",
+			rawChallengeToken:  "invalid",
+			code:               validCode,
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:               "should return status 400 when code field is missing",
+			challengeClaims:    map[string]interface{}{"mfa_login": "user42"},
+			expectedStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			validate, err := validator.GetValidator()
+			require.NoError(t, err, "Error init validator")
+			authToken := auth.GenerateAuthToken("secret")
+			cfg := &config.ServerConfig{}
+			logger, err := logger.Initialize("error")
+			require.NoError(t, err, "Error init logger")
+
+			userStorage := NewMockUserStorage(ctrl)
+			accrualStorage := NewMockAccrualStorage(ctrl)
+			withdrawnStorage := NewMockWithdrawnStorage(ctrl)
+			balanceStorage := NewMockBalanceStorage(ctrl)
+			totpStorage := NewMockTotpStorage(ctrl)
+			adjustmentStorage := NewMockAdjustmentStorage(ctrl)
+			apiKeyStorage := NewMockApiKeyStorage(ctrl)
+			accrualEventStorage := NewMockAccrualEventStorage(ctrl)
+			accrualEventListener := NewMockAccrualEventListener(ctrl)
+
+			userService := service.NewUserService(userStorage, logger)
+			totpService := service.NewTotpService(totpStorage, logger)
+			auditService := newTestAuditService(ctrl, logger)
+			authService := service.NewAuthService(userService, totpService, auditService, newTestPasswordHasher(),
+				authToken, logger)
+			accrualService := service.NewAccrualService(accrualStorage, logger)
+			withdrawnService := service.NewWithdrawnService(withdrawnStorage, auditService, logger)
+			balanceService := service.NewBalanceService(balanceStorage, logger)
+			adjustmentService := service.NewAdjustmentService(adjustmentStorage, logger)
+			apiKeyService := service.NewApiKeyService(apiKeyStorage, userService, logger)
+			accrualEventService := service.NewAccrualEventService(accrualEventStorage, accrualEventListener, logger)
+
+			server := NewServer(authService, userService, accrualService, withdrawnService, balanceService, totpService,
+				adjustmentService, apiKeyService, accrualEventService, nil, auditService, validate, authToken, cfg, logger)
+
+			handler := http.HandlerFunc(server.SignInSecondFactorHandler)
+
+			if tt.useUserStorage {
+				userStorage.EXPECT().GetOneByLogin(gomock.Any(), "user42").
+					Return(model.User{ID: 1, Login: "user42"}, nil)
+			}
+			if tt.useTotpStorage {
+				totpStorage.EXPECT().GetOneByUserID(gomock.Any(), int64(1)).
+					Return(model.Totp{UserID: 1, Secret: secret, Enabled: true}, nil)
+				failedAttempts := tt.failedAttempts
+				if failedAttempts == 0 {
+					failedAttempts = 1
+				}
+				totpStorage.EXPECT().IncrementFailedAttempts(gomock.Any(), int64(1), gomock.Any(), gomock.Any()).
+					Return(failedAttempts, nil)
+			}
+			if tt.expectedStatusCode == http.StatusOK {
+				totpStorage.EXPECT().ResetFailedAttempts(gomock.Any(), int64(1)).Return(nil)
+			}
+			if tt.useLastUsedStep {
+				totpStorage.EXPECT().UpdateLastUsedStep(gomock.Any(), int64(1), gomock.Any()).
+					Return(tt.lastUsedStepUpdated, nil)
+			}
+			if tt.useRecoveryCode {
+				totpStorage.EXPECT().UseRecoveryCode(gomock.Any(), int64(1), auth.GetRecoveryCodeHash(tt.code)).
+					Return(tt.recoveryCodeUsed, nil)
+			}
+
+			challengeToken := tt.rawChallengeToken
+			if tt.challengeClaims != nil {
+				jwtauth.SetExpiry(tt.challengeClaims, time.Now().Add(time.Minute*5))
+				_, challengeToken, err = authToken.Encode(tt.challengeClaims)
+				require.NoError(t, err, "Error encoding token")
+			}
+			body, err := json.Marshal(model.SignInSecondFactorRequest{ChallengeToken: challengeToken, Code: tt.code})
+			require.NoError(t, err, "Error encoding request body")
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/api/user/login/2fa", strings.NewReader(string(body)))
+
+			handler.ServeHTTP(w, req)
+
+			resp := w.Result()
+			defer resp.Body.Close()
+			assertResponseMatchesSpec(t, req, resp)
+
+			assert.Equal(t, tt.expectedStatusCode, resp.StatusCode, "Response status code does not match expected status")
+			if tt.expectAuthorizationHeader {
+				assert.NotEmpty(t, resp.Header.Get("Authorization"), "Response header should contain Authorization header")
+			} else {
+				assert.Empty(t, resp.Header.Get("Authorization"), "Response header should not contain Authorization header")
+			}
+		})
+	}
+}
+
+var testArgon2idParams = auth.Argon2idParams{
+	Memory:      1024,
+	Iterations:  1,
+	Parallelism: 1,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+func newTestPasswordHasher() auth.PasswordHasher {
+	return auth.NewPasswordHasher(auth.NewArgon2idHasher(testArgon2idParams), auth.NewBcryptHasher(bcrypt.DefaultCost))
+}
+
+func mustHashTestPassword(t *testing.T, password string) string {
+	hash, err := newTestPasswordHasher().Hash(password)
+	require.NoError(t, err, "Error hashing password")
+	return hash
+}
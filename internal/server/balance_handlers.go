@@ -10,27 +10,30 @@ import (
 func (s *Server) GetLoyaltyPointsBalanceHandler(res http.ResponseWriter, req *http.Request) {
 	currentUser, err := s.UserService.GetCurrentUser(req.Context())
 	if err != nil {
-		http.Error(res, err.Error(), http.StatusUnauthorized)
+		s.writeError(res, req, err)
 		return
 	}
 
 	balance, err := s.BalanceService.GetBalanceByUserID(req.Context(), currentUser.ID)
 	if err != nil {
-		http.Error(res, err.Error(), http.StatusInternalServerError)
+		s.writeError(res, req, err)
 		return
 	}
 
-	balanceDto := model.ToBalanceDto(balance)
+	var balanceDto any = model.ToBalanceDto(balance)
+	if getAPIVersion(req.Context()) == APIVersion2 {
+		balanceDto = model.ToBalanceV2Dto(balance)
+	}
 
 	body, err := json.Marshal(balanceDto)
 	if err != nil {
-		http.Error(res, "Error encoding response", http.StatusInternalServerError)
+		s.writeError(res, req, err)
 		return
 	}
 	res.Header().Set("Content-Type", "application/json")
 	_, err = res.Write(body)
 	if err != nil {
-		http.Error(res, "Error encoding response", http.StatusInternalServerError)
+		s.writeError(res, req, err)
 		return
 	}
 }
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/go-chi/jwtauth/v5"
+	"github.com/jackc/pgx/v5"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
@@ -38,6 +39,20 @@ func TestGetLoyaltyPointsBalanceHandler(t *testing.T) {
 			useBalanceStorage:  false,
 			expectedStatusCode: http.StatusUnauthorized,
 		},
+		{
+			name:               "should return status 401 when user does not exist",
+			isAuthorized:       true,
+			userUserStorage:    true,
+			userStorageErr:     pgx.ErrNoRows,
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:               "should return status 500 without error details when user storage fails",
+			isAuthorized:       true,
+			userUserStorage:    true,
+			userStorageErr:     errors.New("dial tcp 10.0.0.5:5432: connect: connection refused"),
+			expectedStatusCode: http.StatusInternalServerError,
+		},
 		{
 			name:                        "should return status 200 when user user exists",
 			isAuthorized:                true,
@@ -73,15 +88,26 @@ func TestGetLoyaltyPointsBalanceHandler(t *testing.T) {
 			accrualStorage := NewMockAccrualStorage(ctrl)
 			withdrawnStorage := NewMockWithdrawnStorage(ctrl)
 			balanceStorage := NewMockBalanceStorage(ctrl)
+			totpStorage := NewMockTotpStorage(ctrl)
+			adjustmentStorage := NewMockAdjustmentStorage(ctrl)
+			apiKeyStorage := NewMockApiKeyStorage(ctrl)
+			accrualEventStorage := NewMockAccrualEventStorage(ctrl)
+			accrualEventListener := NewMockAccrualEventListener(ctrl)
 
 			userService := service.NewUserService(userStorage, logger)
-			authService := service.NewAuthService(userService, authToken, logger)
+			totpService := service.NewTotpService(totpStorage, logger)
+			auditService := newTestAuditService(ctrl, logger)
+			authService := service.NewAuthService(userService, totpService, auditService, newTestPasswordHasher(),
+				authToken, logger)
 			accrualService := service.NewAccrualService(accrualStorage, logger)
-			withdrawnService := service.NewWithdrawnService(withdrawnStorage, logger)
+			withdrawnService := service.NewWithdrawnService(withdrawnStorage, auditService, logger)
 			balanceService := service.NewBalanceService(balanceStorage, logger)
+			adjustmentService := service.NewAdjustmentService(adjustmentStorage, logger)
+			apiKeyService := service.NewApiKeyService(apiKeyStorage, userService, logger)
+			accrualEventService := service.NewAccrualEventService(accrualEventStorage, accrualEventListener, logger)
 
-			server := NewServer(authService, userService, accrualService, withdrawnService, balanceService, validate,
-				authToken, cfg, logger)
+			server := NewServer(authService, userService, accrualService, withdrawnService, balanceService, totpService,
+				adjustmentService, apiKeyService, accrualEventService, nil, auditService, validate, authToken, cfg, logger)
 
 			handler := http.HandlerFunc(server.GetLoyaltyPointsBalanceHandler)
 
@@ -113,13 +139,17 @@ func TestGetLoyaltyPointsBalanceHandler(t *testing.T) {
 
 			resp := w.Result()
 			defer resp.Body.Close()
+			assertResponseMatchesSpec(t, req, resp)
 
 			assert.Equal(t, tt.expectedStatusCode, resp.StatusCode, "Response status code does not match expected status")
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err, "Error reading response body")
 			if tt.expectedBody != "" {
-				body, err := io.ReadAll(resp.Body)
-				require.NoError(t, err, "Error reading response body")
 				require.Equal(t, tt.expectedBody, string(body), "Response body does not match expected body")
 			}
+			if tt.userStorageErr != nil {
+				assert.NotContains(t, string(body), tt.userStorageErr.Error(), "Response body should not contain storage error")
+			}
 		})
 	}
 }
@@ -0,0 +1,229 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Stern-Ritter/gophermart/internal/model"
+	"github.com/Stern-Ritter/gophermart/internal/problem"
+	"github.com/Stern-Ritter/gophermart/internal/utils"
+)
+
+var (
+	historyCSVHeader    = []string{"record", "order", "status", "amount", "uploaded_at", "processed_at"}
+	historyJSONSections = []string{"orders", "withdrawals"}
+)
+
+type historyWriter interface {
+	WriteHeader(header model.HistoryExportHeaderDto) error
+	WriteAccrual(accrual model.Accrual) error
+	WriteWithdrawal(withdrawn model.Withdrawn) error
+	Close() error
+}
+
+func (s *Server) ExportUserHistoryHandler(res http.ResponseWriter, req *http.Request) {
+	currentUser, err := s.UserService.GetCurrentUser(req.Context())
+	if err != nil {
+		s.writeError(res, req, err)
+		return
+	}
+
+	format, err := model.ParseExportFormat(req.URL.Query().Get("format"))
+	if err != nil {
+		s.writeProblem(res, req, http.StatusBadRequest, problem.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	balance, err := s.BalanceService.GetBalanceByUserID(req.Context(), currentUser.ID)
+	if err != nil {
+		s.writeError(res, req, err)
+		return
+	}
+
+	exportedAt := time.Now().UTC()
+	fileName := fmt.Sprintf("gophermart-history-%d-%s.%s", currentUser.ID, exportedAt.Format("20060102T150405Z"), format)
+
+	var writer historyWriter
+	switch format {
+	case model.ExportFormatCSV:
+		res.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		writer = newCSVHistoryWriter(res)
+	default:
+		res.Header().Set("Content-Type", "application/json")
+		writer = newJSONHistoryWriter(res)
+	}
+	res.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, fileName))
+	res.Header().Set("Cache-Control", "no-store")
+
+	err = s.exportHistory(req.Context(), writer, model.HistoryExportHeaderDto{
+		Login:      currentUser.Login,
+		ExportedAt: model.Time{Time: exportedAt},
+		Balance:    model.ToBalanceDto(balance),
+	}, currentUser.ID)
+	if err != nil {
+		// The status line is already sent, so the client only sees a truncated file.
+		s.Logger.WithContext(req.Context()).Error("Error exporting user history",
+			zap.String("event", "export user history"), zap.Error(err))
+	}
+}
+
+func (s *Server) exportHistory(ctx context.Context, writer historyWriter, header model.HistoryExportHeaderDto,
+	userID int64) error {
+	if err := writer.WriteHeader(header); err != nil {
+		return err
+	}
+
+	err := s.AccrualService.ForEachAccrualByUserID(ctx, userID, writer.WriteAccrual)
+	if err != nil {
+		return err
+	}
+
+	err = s.WithdrawnService.ForEachWithdrawalByUserID(ctx, userID, writer.WriteWithdrawal)
+	if err != nil {
+		return err
+	}
+
+	return writer.Close()
+}
+
+type jsonHistoryWriter struct {
+	w       *bufio.Writer
+	section int
+	count   int
+}
+
+func newJSONHistoryWriter(w io.Writer) *jsonHistoryWriter {
+	return &jsonHistoryWriter{w: bufio.NewWriter(w), section: -1}
+}
+
+func (w *jsonHistoryWriter) WriteHeader(header model.HistoryExportHeaderDto) error {
+	body, err := json.Marshal(header)
+	if err != nil {
+		return err
+	}
+
+	// The header object is left open so that the sections can be appended to it.
+	_, err = w.w.Write(body[:len(body)-1])
+	return err
+}
+
+func (w *jsonHistoryWriter) WriteAccrual(accrual model.Accrual) error {
+	return w.writeItem(0, model.ToAccrualExportDto(accrual))
+}
+
+func (w *jsonHistoryWriter) WriteWithdrawal(withdrawn model.Withdrawn) error {
+	return w.writeItem(1, model.ToWithdrawnDto(withdrawn))
+}
+
+func (w *jsonHistoryWriter) Close() error {
+	if err := w.startSection(len(historyJSONSections) - 1); err != nil {
+		return err
+	}
+	if _, err := w.w.WriteString("]}"); err != nil {
+		return err
+	}
+
+	return w.w.Flush()
+}
+
+func (w *jsonHistoryWriter) writeItem(section int, item any) error {
+	if err := w.startSection(section); err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+	if w.count > 0 {
+		if err := w.w.WriteByte(','); err != nil {
+			return err
+		}
+	}
+	w.count++
+
+	_, err = w.w.Write(body)
+	return err
+}
+
+func (w *jsonHistoryWriter) startSection(section int) error {
+	for w.section < section {
+		prefix := "],"
+		if w.section < 0 {
+			prefix = ","
+		}
+		w.section++
+		w.count = 0
+
+		if _, err := fmt.Fprintf(w.w, "%s%q:[", prefix, historyJSONSections[w.section]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type csvHistoryWriter struct {
+	w *csv.Writer
+}
+
+func newCSVHistoryWriter(w io.Writer) *csvHistoryWriter {
+	return &csvHistoryWriter{w: csv.NewWriter(w)}
+}
+
+func (w *csvHistoryWriter) WriteHeader(header model.HistoryExportHeaderDto) error {
+	if err := w.w.Write(historyCSVHeader); err != nil {
+		return err
+	}
+	if err := w.w.Write([]string{"balance_current", "", "", formatAmount(header.Balance.CurrentPointsAmount), "", ""}); err != nil {
+		return err
+	}
+	return w.w.Write([]string{"balance_withdrawn", "", "", formatAmount(header.Balance.WithdrawnPointsAmount), "", ""})
+}
+
+func (w *csvHistoryWriter) WriteAccrual(accrual model.Accrual) error {
+	return w.w.Write([]string{
+		"order",
+		utils.FormatOrderNumber(accrual.OrderNumber),
+		string(accrual.Status),
+		formatAmount(accrual.PointsAmount),
+		formatTime(accrual.UploadedAt),
+		formatTime(accrual.ProcessedAt),
+	})
+}
+
+func (w *csvHistoryWriter) WriteWithdrawal(withdrawn model.Withdrawn) error {
+	return w.w.Write([]string{
+		"withdrawal",
+		utils.FormatOrderNumber(withdrawn.OrderNumber),
+		"",
+		formatAmount(withdrawn.PointsAmount),
+		"",
+		formatTime(withdrawn.ProcessedAt),
+	})
+}
+
+func (w *csvHistoryWriter) Close() error {
+	w.w.Flush()
+	return w.w.Error()
+}
+
+func formatAmount(amount float64) string {
+	return strconv.FormatFloat(amount, 'f', -1, 64)
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
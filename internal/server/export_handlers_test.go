@@ -0,0 +1,193 @@
+package server
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/Stern-Ritter/gophermart/internal/model"
+)
+
+func TestExportUserHistoryHandler(t *testing.T) {
+	uploadedAt := time.Date(2024, time.March, 1, 10, 0, 0, 0, time.UTC)
+	processedAt := time.Date(2024, time.March, 1, 10, 5, 0, 0, time.UTC)
+	accruals := []model.Accrual{
+		{UserID: 1, OrderNumber: 12345678903, Status: model.AccrualProcessed, PointsAmount: 500.5,
+			UploadedAt: uploadedAt, ProcessedAt: processedAt},
+		{UserID: 1, OrderNumber: 2377225624, Status: model.AccrualNew, UploadedAt: uploadedAt},
+	}
+	withdrawals := []model.Withdrawn{
+		{UserID: 1, OrderNumber: 79927398713, PointsAmount: 100, ProcessedAt: processedAt},
+	}
+
+	tests := []struct {
+		name                   string
+		url                    string
+		isAuthorized           bool
+		useBalanceStorage      bool
+		balanceStorageErr      error
+		useHistoryStorages     bool
+		accruals               []model.Accrual
+		withdrawals            []model.Withdrawn
+		expectedStatusCode     int
+		expectedContentType    string
+		expectedFileExtension  string
+		expectedOrdersCount    int
+		expectedWithdrawnCount int
+		expectedCSVRecords     [][]string
+	}{
+		{
+			name:               "should return status 401 when user is unauthorized",
+			url:                "/api/user/export",
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:               "should return status 400 when format is not supported",
+			url:                "/api/user/export?format=xml",
+			isAuthorized:       true,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "should return status 500 when balance is unavailable",
+			url:                "/api/user/export",
+			isAuthorized:       true,
+			useBalanceStorage:  true,
+			balanceStorageErr:  errors.New("unexpected error"),
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		{
+			name:                   "should export history as json by default",
+			url:                    "/api/user/export",
+			isAuthorized:           true,
+			useBalanceStorage:      true,
+			useHistoryStorages:     true,
+			accruals:               accruals,
+			withdrawals:            withdrawals,
+			expectedStatusCode:     http.StatusOK,
+			expectedContentType:    "application/json",
+			expectedFileExtension:  ".json",
+			expectedOrdersCount:    2,
+			expectedWithdrawnCount: 1,
+		},
+		{
+			name:                  "should export empty sections when user has no history",
+			url:                   "/api/user/export?format=json",
+			isAuthorized:          true,
+			useBalanceStorage:     true,
+			useHistoryStorages:    true,
+			expectedStatusCode:    http.StatusOK,
+			expectedContentType:   "application/json",
+			expectedFileExtension: ".json",
+		},
+		{
+			name:                  "should export history as csv",
+			url:                   "/api/user/export?format=csv",
+			isAuthorized:          true,
+			useBalanceStorage:     true,
+			useHistoryStorages:    true,
+			accruals:              accruals,
+			withdrawals:           withdrawals,
+			expectedStatusCode:    http.StatusOK,
+			expectedContentType:   "text/csv; charset=utf-8",
+			expectedFileExtension: ".csv",
+			expectedCSVRecords: [][]string{
+				{"record", "order", "status", "amount", "uploaded_at", "processed_at"},
+				{"balance_current", "", "", "400", "", ""},
+				{"balance_withdrawn", "", "", "300", "", ""},
+				{"order", "12345678903", "PROCESSED", "500.5", "2024-03-01T10:00:00Z", "2024-03-01T10:05:00Z"},
+				{"order", "2377225624", "NEW", "0", "2024-03-01T10:00:00Z", ""},
+				{"withdrawal", "79927398713", "", "100", "", "2024-03-01T10:05:00Z"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			server, mocks := newAdminTestServer(t, ctrl)
+			handler := http.HandlerFunc(server.ExportUserHistoryHandler)
+
+			req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			if tt.isAuthorized {
+				mocks.userStorage.EXPECT().GetOneByLogin(gomock.Any(), "user").
+					Return(model.User{ID: 1, Login: "user", Role: model.UserRoleUser}, nil)
+				req = withAuthorizedUser(t, server, req, "user")
+			}
+			if tt.useBalanceStorage {
+				mocks.balanceStorage.EXPECT().GetByUserID(gomock.Any(), int64(1)).
+					Return(model.Balance{UserID: 1, CurrentPointsAmount: 400, WithdrawnPointsAmount: 300}, tt.balanceStorageErr)
+			}
+			if tt.useHistoryStorages {
+				mocks.accrualStorage.EXPECT().ForEachByUserIDOrderByUploadedAtAsc(gomock.Any(), int64(1), gomock.Any()).
+					DoAndReturn(func(_ context.Context, _ int64, fn func(model.Accrual) error) error {
+						for _, accrual := range tt.accruals {
+							if err := fn(accrual); err != nil {
+								return err
+							}
+						}
+						return nil
+					})
+				mocks.withdrawnStorage.EXPECT().ForEachByUserIDOrderByProcessedAtAsc(gomock.Any(), int64(1), gomock.Any()).
+					DoAndReturn(func(_ context.Context, _ int64, fn func(model.Withdrawn) error) error {
+						for _, withdrawn := range tt.withdrawals {
+							if err := fn(withdrawn); err != nil {
+								return err
+							}
+						}
+						return nil
+					})
+			}
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			resp := w.Result()
+			defer resp.Body.Close()
+			assertResponseMatchesSpec(t, req, resp)
+
+			assert.Equal(t, tt.expectedStatusCode, resp.StatusCode, "Response status code does not match expected status")
+			if tt.expectedStatusCode != http.StatusOK {
+				return
+			}
+
+			assert.Equal(t, tt.expectedContentType, resp.Header.Get("Content-Type"), "Content type does not match expected type")
+			assert.Contains(t, resp.Header.Get("Content-Disposition"), "attachment; filename=\"gophermart-history-1-",
+				"Content disposition should contain file name")
+			assert.Contains(t, resp.Header.Get("Content-Disposition"), tt.expectedFileExtension+"\"",
+				"File name should have format extension")
+
+			if tt.expectedCSVRecords != nil {
+				records, err := csv.NewReader(resp.Body).ReadAll()
+				require.NoError(t, err, "Error reading csv response body")
+				assert.Equal(t, tt.expectedCSVRecords, records, "Csv records do not match expected records")
+				return
+			}
+
+			var export struct {
+				Login       string                   `json:"login"`
+				Balance     model.BalanceDto         `json:"balance"`
+				Orders      []model.AccrualExportDto `json:"orders"`
+				Withdrawals []model.WithdrawnDto     `json:"withdrawals"`
+			}
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&export), "Error decoding response body")
+			assert.Equal(t, "user", export.Login, "Exported login does not match expected login")
+			assert.Equal(t, model.BalanceDto{CurrentPointsAmount: 400, WithdrawnPointsAmount: 300}, export.Balance,
+				"Exported balance does not match expected balance")
+			assert.NotNil(t, export.Orders, "Orders section should be present")
+			assert.NotNil(t, export.Withdrawals, "Withdrawals section should be present")
+			assert.Len(t, export.Orders, tt.expectedOrdersCount, "Exported orders count does not match expected count")
+			assert.Len(t, export.Withdrawals, tt.expectedWithdrawnCount, "Exported withdrawals count does not match expected count")
+		})
+	}
+}
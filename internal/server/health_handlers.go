@@ -0,0 +1,38 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/Stern-Ritter/gophermart/internal/model"
+)
+
+func (s *Server) LivenessHandler(res http.ResponseWriter, req *http.Request) {
+	s.writeHealthReport(res, req, model.HealthReport{Status: model.HealthStatusOK})
+}
+
+func (s *Server) ReadinessHandler(res http.ResponseWriter, req *http.Request) {
+	s.writeHealthReport(res, req, s.HealthService.CheckReadiness(req.Context()))
+}
+
+func (s *Server) writeHealthReport(res http.ResponseWriter, req *http.Request, report model.HealthReport) {
+	body, err := json.Marshal(report)
+	if err != nil {
+		s.writeError(res, req, err)
+		return
+	}
+
+	status := http.StatusOK
+	if !report.IsOK() {
+		status = http.StatusServiceUnavailable
+	}
+
+	res.Header().Set("Content-Type", "application/json")
+	res.Header().Set("Cache-Control", "no-store")
+	res.WriteHeader(status)
+	_, err = res.Write(body)
+	if err != nil {
+		s.writeError(res, req, err)
+		return
+	}
+}
@@ -0,0 +1,195 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/Stern-Ritter/gophermart/internal/auth"
+	"github.com/Stern-Ritter/gophermart/internal/config"
+	"github.com/Stern-Ritter/gophermart/internal/logger"
+	"github.com/Stern-Ritter/gophermart/internal/model"
+	"github.com/Stern-Ritter/gophermart/internal/service"
+)
+
+type testHeartbeatSource struct {
+	lastHeartbeat time.Time
+}
+
+func (s testHeartbeatSource) LastHeartbeat() time.Time {
+	return s.lastHeartbeat
+}
+
+func TestLivenessHandler(t *testing.T) {
+	l, err := logger.Initialize("error")
+	require.NoError(t, err, "Error init logger")
+	server := NewServer(nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, auth.GenerateAuthToken("secret"),
+		&config.ServerConfig{}, l)
+
+	w := httptest.NewRecorder()
+	server.LivenessHandler(w, httptest.NewRequest(http.MethodGet, "/livez", nil))
+
+	resp := w.Result()
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "Response status code does not match expected status")
+	var report model.HealthReport
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&report), "Error decoding response body")
+	assert.Equal(t, model.HealthStatusOK, report.Status, "Liveness status does not match expected status")
+}
+
+func TestReadinessHandler(t *testing.T) {
+	accrualSystem := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.WriteHeader(http.StatusNotFound)
+	}))
+	defer accrualSystem.Close()
+
+	tests := []struct {
+		name               string
+		pingErr            error
+		migrationVersion   int64
+		migrationErr       error
+		lastHeartbeat      time.Time
+		accrualSystemURL   string
+		expectedStatusCode int
+		expectedComponents map[string]model.HealthStatus
+		expectedDetails    map[string]string
+	}{
+		{
+			name:               "should return status 200 when all components are ready",
+			migrationVersion:   8,
+			lastHeartbeat:      time.Now(),
+			expectedStatusCode: http.StatusOK,
+			expectedComponents: map[string]model.HealthStatus{
+				service.HealthComponentDatabase:   model.HealthStatusOK,
+				service.HealthComponentMigrations: model.HealthStatusOK,
+				service.HealthComponentScheduler:  model.HealthStatusOK,
+			},
+		},
+		{
+			name:               "should return status 503 when database is unreachable",
+			pingErr:            errors.New("dial tcp 10.0.0.5:5432: connection refused"),
+			migrationErr:       errors.New("dial tcp 10.0.0.5:5432: connection refused"),
+			lastHeartbeat:      time.Now(),
+			expectedStatusCode: http.StatusServiceUnavailable,
+			expectedComponents: map[string]model.HealthStatus{
+				service.HealthComponentDatabase:   model.HealthStatusFail,
+				service.HealthComponentMigrations: model.HealthStatusFail,
+				service.HealthComponentScheduler:  model.HealthStatusOK,
+			},
+			expectedDetails: map[string]string{
+				service.HealthComponentDatabase:   "database is not reachable",
+				service.HealthComponentMigrations: "schema version is not available",
+			},
+		},
+		{
+			name:               "should return status 503 when migrations are not applied",
+			migrationVersion:   7,
+			lastHeartbeat:      time.Now(),
+			expectedStatusCode: http.StatusServiceUnavailable,
+			expectedComponents: map[string]model.HealthStatus{
+				service.HealthComponentDatabase:   model.HealthStatusOK,
+				service.HealthComponentMigrations: model.HealthStatusFail,
+				service.HealthComponentScheduler:  model.HealthStatusOK,
+			},
+			expectedDetails: map[string]string{
+				service.HealthComponentMigrations: "version 7 is behind expected version 8",
+			},
+		},
+		{
+			name:               "should return status 503 when scheduler heartbeat is stale",
+			migrationVersion:   8,
+			lastHeartbeat:      time.Now().Add(-time.Hour),
+			expectedStatusCode: http.StatusServiceUnavailable,
+			expectedComponents: map[string]model.HealthStatus{
+				service.HealthComponentDatabase:   model.HealthStatusOK,
+				service.HealthComponentMigrations: model.HealthStatusOK,
+				service.HealthComponentScheduler:  model.HealthStatusFail,
+			},
+		},
+		{
+			name:               "should return status 503 when scheduler is not running",
+			migrationVersion:   8,
+			expectedStatusCode: http.StatusServiceUnavailable,
+			expectedComponents: map[string]model.HealthStatus{
+				service.HealthComponentDatabase:   model.HealthStatusOK,
+				service.HealthComponentMigrations: model.HealthStatusOK,
+				service.HealthComponentScheduler:  model.HealthStatusFail,
+			},
+		},
+		{
+			name:               "should check accrual system reachability when it is configured",
+			migrationVersion:   8,
+			lastHeartbeat:      time.Now(),
+			accrualSystemURL:   accrualSystem.URL,
+			expectedStatusCode: http.StatusOK,
+			expectedComponents: map[string]model.HealthStatus{
+				service.HealthComponentDatabase:      model.HealthStatusOK,
+				service.HealthComponentMigrations:    model.HealthStatusOK,
+				service.HealthComponentScheduler:     model.HealthStatusOK,
+				service.HealthComponentAccrualSystem: model.HealthStatusOK,
+			},
+		},
+		{
+			name:               "should return status 503 when accrual system is unreachable",
+			migrationVersion:   8,
+			lastHeartbeat:      time.Now(),
+			accrualSystemURL:   "http://127.0.0.1:1",
+			expectedStatusCode: http.StatusServiceUnavailable,
+			expectedComponents: map[string]model.HealthStatus{
+				service.HealthComponentDatabase:      model.HealthStatusOK,
+				service.HealthComponentMigrations:    model.HealthStatusOK,
+				service.HealthComponentScheduler:     model.HealthStatusOK,
+				service.HealthComponentAccrualSystem: model.HealthStatusFail,
+			},
+			expectedDetails: map[string]string{
+				service.HealthComponentAccrualSystem: "accrual system is not reachable",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			l, err := logger.Initialize("error")
+			require.NoError(t, err, "Error init logger")
+
+			healthStorage := NewMockHealthStorage(ctrl)
+			healthStorage.EXPECT().Ping(gomock.Any()).Return(tt.pingErr)
+			healthStorage.EXPECT().GetMigrationVersion(gomock.Any()).Return(tt.migrationVersion, tt.migrationErr)
+
+			healthService := service.NewHealthService(healthStorage, testHeartbeatSource{lastHeartbeat: tt.lastHeartbeat},
+				time.Minute, 8, tt.accrualSystemURL, l)
+			server := NewServer(nil, nil, nil, nil, nil, nil, nil, nil, nil, healthService, nil, nil,
+				auth.GenerateAuthToken("secret"), &config.ServerConfig{}, l)
+
+			w := httptest.NewRecorder()
+			server.ReadinessHandler(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+			resp := w.Result()
+			defer resp.Body.Close()
+
+			assert.Equal(t, tt.expectedStatusCode, resp.StatusCode, "Response status code does not match expected status")
+			var report model.HealthReport
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&report), "Error decoding response body")
+
+			components := make(map[string]model.HealthStatus, len(report.Components))
+			for name, component := range report.Components {
+				components[name] = component.Status
+			}
+			assert.Equal(t, tt.expectedComponents, components, "Component statuses do not match expected statuses")
+			for name, detail := range tt.expectedDetails {
+				assert.Equal(t, detail, report.Components[name].Detail, "Component detail does not match expected detail")
+			}
+		})
+	}
+}
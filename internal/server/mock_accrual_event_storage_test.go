@@ -0,0 +1,93 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./internal/storage/accrual_event_storage.go
+//
+// Generated by this command:
+//
+//	mockgen -source=./internal/storage/accrual_event_storage.go -destination ./internal/server/mock_accrual_event_storage_test.go -package server
+//
+
+// Package server is a generated GoMock package.
+package server
+
+import (
+	context "context"
+	reflect "reflect"
+
+	model "github.com/Stern-Ritter/gophermart/internal/model"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockAccrualEventStorage is a mock of AccrualEventStorage interface.
+type MockAccrualEventStorage struct {
+	ctrl     *gomock.Controller
+	recorder *MockAccrualEventStorageMockRecorder
+}
+
+// MockAccrualEventStorageMockRecorder is the mock recorder for MockAccrualEventStorage.
+type MockAccrualEventStorageMockRecorder struct {
+	mock *MockAccrualEventStorage
+}
+
+// NewMockAccrualEventStorage creates a new mock instance.
+func NewMockAccrualEventStorage(ctrl *gomock.Controller) *MockAccrualEventStorage {
+	mock := &MockAccrualEventStorage{ctrl: ctrl}
+	mock.recorder = &MockAccrualEventStorageMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAccrualEventStorage) EXPECT() *MockAccrualEventStorageMockRecorder {
+	return m.recorder
+}
+
+// GetAllByUserIDAfterIDOrderByIDAsc mocks base method.
+func (m *MockAccrualEventStorage) GetAllByUserIDAfterIDOrderByIDAsc(ctx context.Context, userID, afterID, limit int64) ([]model.AccrualEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAllByUserIDAfterIDOrderByIDAsc", ctx, userID, afterID, limit)
+	ret0, _ := ret[0].([]model.AccrualEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAllByUserIDAfterIDOrderByIDAsc indicates an expected call of GetAllByUserIDAfterIDOrderByIDAsc.
+func (mr *MockAccrualEventStorageMockRecorder) GetAllByUserIDAfterIDOrderByIDAsc(ctx, userID, afterID, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllByUserIDAfterIDOrderByIDAsc", reflect.TypeOf((*MockAccrualEventStorage)(nil).GetAllByUserIDAfterIDOrderByIDAsc), ctx, userID, afterID, limit)
+}
+
+// MockAccrualEventListener is a mock of AccrualEventListener interface.
+type MockAccrualEventListener struct {
+	ctrl     *gomock.Controller
+	recorder *MockAccrualEventListenerMockRecorder
+}
+
+// MockAccrualEventListenerMockRecorder is the mock recorder for MockAccrualEventListener.
+type MockAccrualEventListenerMockRecorder struct {
+	mock *MockAccrualEventListener
+}
+
+// NewMockAccrualEventListener creates a new mock instance.
+func NewMockAccrualEventListener(ctrl *gomock.Controller) *MockAccrualEventListener {
+	mock := &MockAccrualEventListener{ctrl: ctrl}
+	mock.recorder = &MockAccrualEventListenerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAccrualEventListener) EXPECT() *MockAccrualEventListenerMockRecorder {
+	return m.recorder
+}
+
+// Listen mocks base method.
+func (m *MockAccrualEventListener) Listen(ctx context.Context, handle func(model.AccrualEvent)) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Listen", ctx, handle)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Listen indicates an expected call of Listen.
+func (mr *MockAccrualEventListenerMockRecorder) Listen(ctx, handle any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Listen", reflect.TypeOf((*MockAccrualEventListener)(nil).Listen), ctx, handle)
+}
@@ -0,0 +1,71 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./internal/storage/adjustment_storage.go
+//
+// Generated by this command:
+//
+//	mockgen -source=./internal/storage/adjustment_storage.go -destination ./internal/server/mock_adjustment_storage_test.go -package server
+//
+
+// Package server is a generated GoMock package.
+package server
+
+import (
+	context "context"
+	reflect "reflect"
+
+	model "github.com/Stern-Ritter/gophermart/internal/model"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockAdjustmentStorage is a mock of AdjustmentStorage interface.
+type MockAdjustmentStorage struct {
+	ctrl     *gomock.Controller
+	recorder *MockAdjustmentStorageMockRecorder
+}
+
+// MockAdjustmentStorageMockRecorder is the mock recorder for MockAdjustmentStorage.
+type MockAdjustmentStorageMockRecorder struct {
+	mock *MockAdjustmentStorage
+}
+
+// NewMockAdjustmentStorage creates a new mock instance.
+func NewMockAdjustmentStorage(ctrl *gomock.Controller) *MockAdjustmentStorage {
+	mock := &MockAdjustmentStorage{ctrl: ctrl}
+	mock.recorder = &MockAdjustmentStorageMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAdjustmentStorage) EXPECT() *MockAdjustmentStorageMockRecorder {
+	return m.recorder
+}
+
+// GetAllByUserIDOrderByCreatedAtAsc mocks base method.
+func (m *MockAdjustmentStorage) GetAllByUserIDOrderByCreatedAtAsc(ctx context.Context, userID int64) ([]model.Adjustment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAllByUserIDOrderByCreatedAtAsc", ctx, userID)
+	ret0, _ := ret[0].([]model.Adjustment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAllByUserIDOrderByCreatedAtAsc indicates an expected call of GetAllByUserIDOrderByCreatedAtAsc.
+func (mr *MockAdjustmentStorageMockRecorder) GetAllByUserIDOrderByCreatedAtAsc(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllByUserIDOrderByCreatedAtAsc", reflect.TypeOf((*MockAdjustmentStorage)(nil).GetAllByUserIDOrderByCreatedAtAsc), ctx, userID)
+}
+
+// Save mocks base method.
+func (m *MockAdjustmentStorage) Save(ctx context.Context, adjustment model.Adjustment) (model.Adjustment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Save", ctx, adjustment)
+	ret0, _ := ret[0].(model.Adjustment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Save indicates an expected call of Save.
+func (mr *MockAdjustmentStorageMockRecorder) Save(ctx, adjustment any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockAdjustmentStorage)(nil).Save), ctx, adjustment)
+}
@@ -0,0 +1,101 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./internal/storage/api_key_storage.go
+//
+// Generated by this command:
+//
+//	mockgen -source=./internal/storage/api_key_storage.go -destination ./internal/server/mock_api_key_storage_test.go -package server
+//
+
+// Package server is a generated GoMock package.
+package server
+
+import (
+	context "context"
+	reflect "reflect"
+
+	model "github.com/Stern-Ritter/gophermart/internal/model"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockApiKeyStorage is a mock of ApiKeyStorage interface.
+type MockApiKeyStorage struct {
+	ctrl     *gomock.Controller
+	recorder *MockApiKeyStorageMockRecorder
+}
+
+// MockApiKeyStorageMockRecorder is the mock recorder for MockApiKeyStorage.
+type MockApiKeyStorageMockRecorder struct {
+	mock *MockApiKeyStorage
+}
+
+// NewMockApiKeyStorage creates a new mock instance.
+func NewMockApiKeyStorage(ctrl *gomock.Controller) *MockApiKeyStorage {
+	mock := &MockApiKeyStorage{ctrl: ctrl}
+	mock.recorder = &MockApiKeyStorageMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockApiKeyStorage) EXPECT() *MockApiKeyStorageMockRecorder {
+	return m.recorder
+}
+
+// GetAllActiveByUserIDOrderByCreatedAtAsc mocks base method.
+func (m *MockApiKeyStorage) GetAllActiveByUserIDOrderByCreatedAtAsc(ctx context.Context, userID int64) ([]model.ApiKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAllActiveByUserIDOrderByCreatedAtAsc", ctx, userID)
+	ret0, _ := ret[0].([]model.ApiKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAllActiveByUserIDOrderByCreatedAtAsc indicates an expected call of GetAllActiveByUserIDOrderByCreatedAtAsc.
+func (mr *MockApiKeyStorageMockRecorder) GetAllActiveByUserIDOrderByCreatedAtAsc(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllActiveByUserIDOrderByCreatedAtAsc", reflect.TypeOf((*MockApiKeyStorage)(nil).GetAllActiveByUserIDOrderByCreatedAtAsc), ctx, userID)
+}
+
+// GetOneActiveByKeyHash mocks base method.
+func (m *MockApiKeyStorage) GetOneActiveByKeyHash(ctx context.Context, keyHash string) (model.ApiKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOneActiveByKeyHash", ctx, keyHash)
+	ret0, _ := ret[0].(model.ApiKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOneActiveByKeyHash indicates an expected call of GetOneActiveByKeyHash.
+func (mr *MockApiKeyStorageMockRecorder) GetOneActiveByKeyHash(ctx, keyHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOneActiveByKeyHash", reflect.TypeOf((*MockApiKeyStorage)(nil).GetOneActiveByKeyHash), ctx, keyHash)
+}
+
+// Revoke mocks base method.
+func (m *MockApiKeyStorage) Revoke(ctx context.Context, userID, id int64) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Revoke", ctx, userID, id)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Revoke indicates an expected call of Revoke.
+func (mr *MockApiKeyStorageMockRecorder) Revoke(ctx, userID, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Revoke", reflect.TypeOf((*MockApiKeyStorage)(nil).Revoke), ctx, userID, id)
+}
+
+// Save mocks base method.
+func (m *MockApiKeyStorage) Save(ctx context.Context, apiKey model.ApiKey) (model.ApiKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Save", ctx, apiKey)
+	ret0, _ := ret[0].(model.ApiKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Save indicates an expected call of Save.
+func (mr *MockApiKeyStorageMockRecorder) Save(ctx, apiKey any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockApiKeyStorage)(nil).Save), ctx, apiKey)
+}
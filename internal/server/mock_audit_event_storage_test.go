@@ -0,0 +1,85 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./internal/storage/audit_event_storage.go
+//
+// Generated by this command:
+//
+//	mockgen -source=./internal/storage/audit_event_storage.go -destination ./internal/server/mock_audit_event_storage_test.go -package server
+//
+
+// Package server is a generated GoMock package.
+package server
+
+import (
+	context "context"
+	reflect "reflect"
+
+	model "github.com/Stern-Ritter/gophermart/internal/model"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockAuditEventStorage is a mock of AuditEventStorage interface.
+type MockAuditEventStorage struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuditEventStorageMockRecorder
+}
+
+// MockAuditEventStorageMockRecorder is the mock recorder for MockAuditEventStorage.
+type MockAuditEventStorageMockRecorder struct {
+	mock *MockAuditEventStorage
+}
+
+// NewMockAuditEventStorage creates a new mock instance.
+func NewMockAuditEventStorage(ctrl *gomock.Controller) *MockAuditEventStorage {
+	mock := &MockAuditEventStorage{ctrl: ctrl}
+	mock.recorder = &MockAuditEventStorageMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAuditEventStorage) EXPECT() *MockAuditEventStorageMockRecorder {
+	return m.recorder
+}
+
+// Append mocks base method.
+func (m *MockAuditEventStorage) Append(ctx context.Context, event model.AuditEvent) (model.AuditEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Append", ctx, event)
+	ret0, _ := ret[0].(model.AuditEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Append indicates an expected call of Append.
+func (mr *MockAuditEventStorageMockRecorder) Append(ctx, event any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Append", reflect.TypeOf((*MockAuditEventStorage)(nil).Append), ctx, event)
+}
+
+// ForEachOrderByIDAsc mocks base method.
+func (m *MockAuditEventStorage) ForEachOrderByIDAsc(ctx context.Context, fn func(model.AuditEvent) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ForEachOrderByIDAsc", ctx, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ForEachOrderByIDAsc indicates an expected call of ForEachOrderByIDAsc.
+func (mr *MockAuditEventStorageMockRecorder) ForEachOrderByIDAsc(ctx, fn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ForEachOrderByIDAsc", reflect.TypeOf((*MockAuditEventStorage)(nil).ForEachOrderByIDAsc), ctx, fn)
+}
+
+// GetAllByFilter mocks base method.
+func (m *MockAuditEventStorage) GetAllByFilter(ctx context.Context, filter model.AuditEventsFilter) ([]model.AuditEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAllByFilter", ctx, filter)
+	ret0, _ := ret[0].([]model.AuditEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAllByFilter indicates an expected call of GetAllByFilter.
+func (mr *MockAuditEventStorageMockRecorder) GetAllByFilter(ctx, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllByFilter", reflect.TypeOf((*MockAuditEventStorage)(nil).GetAllByFilter), ctx, filter)
+}
@@ -0,0 +1,69 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./internal/storage/health_storage.go
+//
+// Generated by this command:
+//
+//	mockgen -source=./internal/storage/health_storage.go -destination ./internal/server/mock_health_storage_test.go -package server
+//
+
+// Package server is a generated GoMock package.
+package server
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockHealthStorage is a mock of HealthStorage interface.
+type MockHealthStorage struct {
+	ctrl     *gomock.Controller
+	recorder *MockHealthStorageMockRecorder
+}
+
+// MockHealthStorageMockRecorder is the mock recorder for MockHealthStorage.
+type MockHealthStorageMockRecorder struct {
+	mock *MockHealthStorage
+}
+
+// NewMockHealthStorage creates a new mock instance.
+func NewMockHealthStorage(ctrl *gomock.Controller) *MockHealthStorage {
+	mock := &MockHealthStorage{ctrl: ctrl}
+	mock.recorder = &MockHealthStorageMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockHealthStorage) EXPECT() *MockHealthStorageMockRecorder {
+	return m.recorder
+}
+
+// GetMigrationVersion mocks base method.
+func (m *MockHealthStorage) GetMigrationVersion(ctx context.Context) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMigrationVersion", ctx)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMigrationVersion indicates an expected call of GetMigrationVersion.
+func (mr *MockHealthStorageMockRecorder) GetMigrationVersion(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMigrationVersion", reflect.TypeOf((*MockHealthStorage)(nil).GetMigrationVersion), ctx)
+}
+
+// Ping mocks base method.
+func (m *MockHealthStorage) Ping(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Ping", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Ping indicates an expected call of Ping.
+func (mr *MockHealthStorageMockRecorder) Ping(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ping", reflect.TypeOf((*MockHealthStorage)(nil).Ping), ctx)
+}
@@ -40,6 +40,50 @@ func (m *MockUserStorage) EXPECT() *MockUserStorageMockRecorder {
 	return m.recorder
 }
 
+// DisableByLogin mocks base method.
+func (m *MockUserStorage) DisableByLogin(ctx context.Context, login string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DisableByLogin", ctx, login)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DisableByLogin indicates an expected call of DisableByLogin.
+func (mr *MockUserStorageMockRecorder) DisableByLogin(ctx, login any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DisableByLogin", reflect.TypeOf((*MockUserStorage)(nil).DisableByLogin), ctx, login)
+}
+
+// GetAllByLoginContainingOrderByLogin mocks base method.
+func (m *MockUserStorage) GetAllByLoginContainingOrderByLogin(ctx context.Context, login string, limit int64) ([]model.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAllByLoginContainingOrderByLogin", ctx, login, limit)
+	ret0, _ := ret[0].([]model.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAllByLoginContainingOrderByLogin indicates an expected call of GetAllByLoginContainingOrderByLogin.
+func (mr *MockUserStorageMockRecorder) GetAllByLoginContainingOrderByLogin(ctx, login, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllByLoginContainingOrderByLogin", reflect.TypeOf((*MockUserStorage)(nil).GetAllByLoginContainingOrderByLogin), ctx, login, limit)
+}
+
+// GetOneByID mocks base method.
+func (m *MockUserStorage) GetOneByID(ctx context.Context, id int64) (model.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOneByID", ctx, id)
+	ret0, _ := ret[0].(model.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOneByID indicates an expected call of GetOneByID.
+func (mr *MockUserStorageMockRecorder) GetOneByID(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOneByID", reflect.TypeOf((*MockUserStorage)(nil).GetOneByID), ctx, id)
+}
+
 // GetOneByLogin mocks base method.
 func (m *MockUserStorage) GetOneByLogin(ctx context.Context, login string) (model.User, error) {
 	m.ctrl.T.Helper()
@@ -68,3 +112,17 @@ func (mr *MockUserStorageMockRecorder) Save(ctx, user any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockUserStorage)(nil).Save), ctx, user)
 }
+
+// UpdatePassword mocks base method.
+func (m *MockUserStorage) UpdatePassword(ctx context.Context, userID int64, passwordHash string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePassword", ctx, userID, passwordHash)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdatePassword indicates an expected call of UpdatePassword.
+func (mr *MockUserStorageMockRecorder) UpdatePassword(ctx, userID, passwordHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePassword", reflect.TypeOf((*MockUserStorage)(nil).UpdatePassword), ctx, userID, passwordHash)
+}
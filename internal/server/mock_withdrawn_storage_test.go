@@ -40,6 +40,50 @@ func (m *MockWithdrawnStorage) EXPECT() *MockWithdrawnStorageMockRecorder {
 	return m.recorder
 }
 
+// CountByFilter mocks base method.
+func (m *MockWithdrawnStorage) CountByFilter(ctx context.Context, filter model.WithdrawalsFilter) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountByFilter", ctx, filter)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountByFilter indicates an expected call of CountByFilter.
+func (mr *MockWithdrawnStorageMockRecorder) CountByFilter(ctx, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountByFilter", reflect.TypeOf((*MockWithdrawnStorage)(nil).CountByFilter), ctx, filter)
+}
+
+// ForEachByUserIDOrderByProcessedAtAsc mocks base method.
+func (m *MockWithdrawnStorage) ForEachByUserIDOrderByProcessedAtAsc(ctx context.Context, userID int64, fn func(model.Withdrawn) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ForEachByUserIDOrderByProcessedAtAsc", ctx, userID, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ForEachByUserIDOrderByProcessedAtAsc indicates an expected call of ForEachByUserIDOrderByProcessedAtAsc.
+func (mr *MockWithdrawnStorageMockRecorder) ForEachByUserIDOrderByProcessedAtAsc(ctx, userID, fn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ForEachByUserIDOrderByProcessedAtAsc", reflect.TypeOf((*MockWithdrawnStorage)(nil).ForEachByUserIDOrderByProcessedAtAsc), ctx, userID, fn)
+}
+
+// GetAllByFilter mocks base method.
+func (m *MockWithdrawnStorage) GetAllByFilter(ctx context.Context, filter model.WithdrawalsFilter) ([]model.Withdrawn, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAllByFilter", ctx, filter)
+	ret0, _ := ret[0].([]model.Withdrawn)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAllByFilter indicates an expected call of GetAllByFilter.
+func (mr *MockWithdrawnStorageMockRecorder) GetAllByFilter(ctx, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllByFilter", reflect.TypeOf((*MockWithdrawnStorage)(nil).GetAllByFilter), ctx, filter)
+}
+
 // GetAllByUserIDOrderByProcessedAtAsc mocks base method.
 func (m *MockWithdrawnStorage) GetAllByUserIDOrderByProcessedAtAsc(ctx context.Context, userID int64) ([]model.Withdrawn, error) {
 	m.ctrl.T.Helper()
@@ -0,0 +1,35 @@
+package server
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Stern-Ritter/gophermart/api"
+	"github.com/Stern-Ritter/gophermart/internal/openapi"
+)
+
+var (
+	specValidator     *openapi.Validator
+	specValidatorErr  error
+	specValidatorOnce sync.Once
+)
+
+func assertResponseMatchesSpec(t *testing.T, req *http.Request, resp *http.Response) {
+	specValidatorOnce.Do(func() {
+		specValidator, specValidatorErr = openapi.NewValidator(api.Spec)
+	})
+	require.NoError(t, specValidatorErr, "Error loading openapi spec")
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err, "Error reading response body")
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	err = specValidator.ValidateResponse(req, resp.StatusCode, resp.Header, body)
+	assert.NoError(t, err, "Response does not match openapi spec")
+}
@@ -0,0 +1,107 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Stern-Ritter/gophermart/internal/model"
+)
+
+const (
+	defaultPageLimit = 50
+	maxPageLimit     = 200
+)
+
+type pageParams struct {
+	After         *model.Cursor
+	SortDirection model.SortDirection
+	Limit         int64
+	From          time.Time
+	To            time.Time
+}
+
+func parsePageParams(req *http.Request, fromParam string, toParam string) (pageParams, error) {
+	query := req.URL.Query()
+	params := pageParams{}
+
+	limit, err := parseLimit(query.Get("limit"), defaultPageLimit, maxPageLimit)
+	if err != nil {
+		return pageParams{}, err
+	}
+	params.Limit = limit
+
+	if after := query.Get("after"); after != "" {
+		cursor, err := model.ParseCursor(after)
+		if err != nil {
+			return pageParams{}, err
+		}
+		params.After = &cursor
+	}
+
+	params.SortDirection, err = model.ParseSortDirection(query.Get("sort"))
+	if err != nil {
+		return pageParams{}, err
+	}
+
+	params.From, err = parseTimeParam(query.Get(fromParam), fromParam)
+	if err != nil {
+		return pageParams{}, err
+	}
+	params.To, err = parseTimeParam(query.Get(toParam), toParam)
+	if err != nil {
+		return pageParams{}, err
+	}
+	if !params.From.IsZero() && !params.To.IsZero() && !params.From.Before(params.To) {
+		return pageParams{}, fmt.Errorf("%s should be before %s", fromParam, toParam)
+	}
+
+	return params, nil
+}
+
+// isUnpaginatedRequest reports whether a v1 request asks for a list without limit and after, such requests get the
+// whole list as before pagination was added.
+func isUnpaginatedRequest(req *http.Request) bool {
+	query := req.URL.Query()
+	return getAPIVersion(req.Context()) != APIVersion2 && query.Get("limit") == "" && query.Get("after") == ""
+}
+
+func parseTimeParam(value string, name string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s should be a date and time in RFC 3339 format", name)
+	}
+	return t, nil
+}
+
+func parseListParam(req *http.Request, name string) []string {
+	values := make([]string, 0)
+	for _, param := range req.URL.Query()[name] {
+		for _, value := range strings.Split(param, ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				values = append(values, value)
+			}
+		}
+	}
+	return values
+}
+
+func setNextPageHeaders(res http.ResponseWriter, req *http.Request, next *model.Cursor, limit int64) {
+	if next == nil {
+		return
+	}
+
+	cursor := next.Encode()
+	query := req.URL.Query()
+	query.Set("after", cursor)
+	query.Set("limit", strconv.FormatInt(limit, 10))
+
+	res.Header().Add("Link", fmt.Sprintf("<%s?%s>; rel=\"next\"", req.URL.Path, query.Encode()))
+	res.Header().Set("X-Next-Cursor", cursor)
+}
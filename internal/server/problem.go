@@ -0,0 +1,23 @@
+package server
+
+import (
+	"net/http"
+
+	"go.uber.org/zap"
+
+	"github.com/Stern-Ritter/gophermart/internal/problem"
+)
+
+func (s *Server) writeError(res http.ResponseWriter, req *http.Request, err error) {
+	p := problem.FromError(err).WithTraceContext(req.Context())
+	if p.Status >= http.StatusInternalServerError {
+		s.Logger.WithContext(req.Context()).Error("Error processing request", zap.String("event", "request error"),
+			zap.String("uri", req.RequestURI), zap.String("method", req.Method),
+			zap.String("trace id", p.TraceID), zap.Error(err))
+	}
+	problem.Write(res, req, p)
+}
+
+func (s *Server) writeProblem(res http.ResponseWriter, req *http.Request, status int, code string, detail string) {
+	problem.Write(res, req, problem.New(status, code, detail))
+}
@@ -10,29 +10,43 @@ import (
 )
 
 type Server struct {
-	AuthService      service.AuthService
-	UserService      service.UserService
-	AccrualService   service.AccrualService
-	WithdrawnService service.WithdrawnService
-	BalanceService   service.BalanceService
-	Validate         *validator.Validate
-	AuthToken        *jwtauth.JWTAuth
-	Logger           *logger.ServerLogger
-	Config           *config.ServerConfig
+	AuthService         service.AuthService
+	UserService         service.UserService
+	AccrualService      service.AccrualService
+	WithdrawnService    service.WithdrawnService
+	BalanceService      service.BalanceService
+	TotpService         service.TotpService
+	AdjustmentService   service.AdjustmentService
+	ApiKeyService       service.ApiKeyService
+	AccrualEventService service.AccrualEventService
+	HealthService       service.HealthService
+	AuditService        service.AuditService
+	Validate            *validator.Validate
+	AuthToken           *jwtauth.JWTAuth
+	Logger              *logger.ServerLogger
+	Config              *config.ServerConfig
 }
 
 func NewServer(authService service.AuthService, userService service.UserService, accrualService service.AccrualService,
-	withdrawnService service.WithdrawnService, balanceService service.BalanceService, validate *validator.Validate,
-	authToken *jwtauth.JWTAuth, config *config.ServerConfig, logger *logger.ServerLogger) *Server {
+	withdrawnService service.WithdrawnService, balanceService service.BalanceService, totpService service.TotpService,
+	adjustmentService service.AdjustmentService, apiKeyService service.ApiKeyService,
+	accrualEventService service.AccrualEventService, healthService service.HealthService,
+	auditService service.AuditService, validate *validator.Validate, authToken *jwtauth.JWTAuth, config *config.ServerConfig, logger *logger.ServerLogger) *Server {
 	return &Server{
-		AuthService:      authService,
-		UserService:      userService,
-		AccrualService:   accrualService,
-		WithdrawnService: withdrawnService,
-		BalanceService:   balanceService,
-		Validate:         validate,
-		AuthToken:        authToken,
-		Logger:           logger,
-		Config:           config,
+		AuthService:         authService,
+		UserService:         userService,
+		AccrualService:      accrualService,
+		WithdrawnService:    withdrawnService,
+		BalanceService:      balanceService,
+		TotpService:         totpService,
+		AdjustmentService:   adjustmentService,
+		ApiKeyService:       apiKeyService,
+		AccrualEventService: accrualEventService,
+		HealthService:       healthService,
+		AuditService:        auditService,
+		Validate:            validate,
+		AuthToken:           authToken,
+		Logger:              logger,
+		Config:              config,
 	}
 }
@@ -0,0 +1,103 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/Stern-Ritter/gophermart/internal/model"
+	"github.com/Stern-Ritter/gophermart/internal/problem"
+)
+
+func (s *Server) EnrollTotpHandler(res http.ResponseWriter, req *http.Request) {
+	currentUser, err := s.UserService.GetCurrentUser(req.Context())
+	if err != nil {
+		s.writeError(res, req, err)
+		return
+	}
+
+	enrollment, err := s.TotpService.Enroll(req.Context(), currentUser)
+	if err != nil {
+		s.writeError(res, req, err)
+		return
+	}
+
+	enrollmentDto := model.ToTotpEnrollmentDto(enrollment)
+
+	body, err := json.Marshal(enrollmentDto)
+	if err != nil {
+		s.writeError(res, req, err)
+		return
+	}
+
+	res.Header().Set("Content-Type", "application/json")
+	_, err = res.Write(body)
+	if err != nil {
+		s.writeError(res, req, err)
+		return
+	}
+}
+
+func (s *Server) ConfirmTotpHandler(res http.ResponseWriter, req *http.Request) {
+	currentUser, err := s.UserService.GetCurrentUser(req.Context())
+	if err != nil {
+		s.writeError(res, req, err)
+		return
+	}
+
+	totpCodeRequest := model.TotpCodeRequest{}
+	err = decodeWithUnknownAndDuplicateFieldsCheck(req.Body, &totpCodeRequest)
+	if err != nil {
+		s.writeProblem(res, req, http.StatusBadRequest, problem.CodeInvalidRequest, "Error decode request JSON body")
+		return
+	}
+	if err := totpCodeRequest.Validate(s.Validate); err != nil {
+		s.writeError(res, req, err)
+		return
+	}
+
+	recoveryCodes, err := s.TotpService.Confirm(req.Context(), currentUser.ID, totpCodeRequest.Code)
+	if err != nil {
+		s.writeError(res, req, err)
+		return
+	}
+
+	body, err := json.Marshal(model.RecoveryCodesDto{RecoveryCodes: recoveryCodes})
+	if err != nil {
+		s.writeError(res, req, err)
+		return
+	}
+
+	res.Header().Set("Content-Type", "application/json")
+	_, err = res.Write(body)
+	if err != nil {
+		s.writeError(res, req, err)
+		return
+	}
+}
+
+func (s *Server) DisableTotpHandler(res http.ResponseWriter, req *http.Request) {
+	currentUser, err := s.UserService.GetCurrentUser(req.Context())
+	if err != nil {
+		s.writeError(res, req, err)
+		return
+	}
+
+	totpCodeRequest := model.TotpCodeRequest{}
+	err = decodeWithUnknownAndDuplicateFieldsCheck(req.Body, &totpCodeRequest)
+	if err != nil {
+		s.writeProblem(res, req, http.StatusBadRequest, problem.CodeInvalidRequest, "Error decode request JSON body")
+		return
+	}
+	if err := totpCodeRequest.Validate(s.Validate); err != nil {
+		s.writeError(res, req, err)
+		return
+	}
+
+	err = s.TotpService.Disable(req.Context(), currentUser.ID, totpCodeRequest.Code)
+	if err != nil {
+		s.writeError(res, req, err)
+		return
+	}
+
+	res.WriteHeader(http.StatusOK)
+}
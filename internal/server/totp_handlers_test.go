@@ -0,0 +1,387 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/jwtauth/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/Stern-Ritter/gophermart/internal/auth"
+	"github.com/Stern-Ritter/gophermart/internal/config"
+	"github.com/Stern-Ritter/gophermart/internal/logger"
+	"github.com/Stern-Ritter/gophermart/internal/model"
+	"github.com/Stern-Ritter/gophermart/internal/service"
+	"github.com/Stern-Ritter/gophermart/internal/validator"
+)
+
+func TestEnrollTotpHandler(t *testing.T) {
+	tests := []struct {
+		name                     string
+		isAuthorized             bool
+		useUserStorage           bool
+		useTotpStorage           bool
+		totpStorageReturnedValue model.Totp
+		totpStorageErr           error
+		useTotpStorageSave       bool
+		totpStorageSaveErr       error
+		expectedStatusCode       int
+	}{
+		{
+			name:               "should return status 401 when user is unauthorized",
+			isAuthorized:       false,
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:               "should return status 200 when user has not enrolled yet",
+			isAuthorized:       true,
+			useUserStorage:     true,
+			useTotpStorage:     true,
+			totpStorageErr:     pgx.ErrNoRows,
+			useTotpStorageSave: true,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:                     "should return status 409 when two-factor authentication is already enabled",
+			isAuthorized:             true,
+			useUserStorage:           true,
+			useTotpStorage:           true,
+			totpStorageReturnedValue: model.Totp{UserID: 1, Enabled: true},
+			expectedStatusCode:       http.StatusConflict,
+		},
+		{
+			name:               "should return status 500 when unexpected error occurred",
+			isAuthorized:       true,
+			useUserStorage:     true,
+			useTotpStorage:     true,
+			totpStorageErr:     pgx.ErrNoRows,
+			useTotpStorageSave: true,
+			totpStorageSaveErr: errors.New("unexpected error"),
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			validate, err := validator.GetValidator()
+			require.NoError(t, err, "Error init validator")
+			authToken := auth.GenerateAuthToken("secret")
+			cfg := &config.ServerConfig{}
+			logger, err := logger.Initialize("error")
+			require.NoError(t, err, "Error init logger")
+
+			userStorage := NewMockUserStorage(ctrl)
+			accrualStorage := NewMockAccrualStorage(ctrl)
+			withdrawnStorage := NewMockWithdrawnStorage(ctrl)
+			balanceStorage := NewMockBalanceStorage(ctrl)
+			totpStorage := NewMockTotpStorage(ctrl)
+			adjustmentStorage := NewMockAdjustmentStorage(ctrl)
+			apiKeyStorage := NewMockApiKeyStorage(ctrl)
+			accrualEventStorage := NewMockAccrualEventStorage(ctrl)
+			accrualEventListener := NewMockAccrualEventListener(ctrl)
+
+			userService := service.NewUserService(userStorage, logger)
+			totpService := service.NewTotpService(totpStorage, logger)
+			auditService := newTestAuditService(ctrl, logger)
+			authService := service.NewAuthService(userService, totpService, auditService, newTestPasswordHasher(),
+				authToken, logger)
+			accrualService := service.NewAccrualService(accrualStorage, logger)
+			withdrawnService := service.NewWithdrawnService(withdrawnStorage, auditService, logger)
+			balanceService := service.NewBalanceService(balanceStorage, logger)
+			adjustmentService := service.NewAdjustmentService(adjustmentStorage, logger)
+			apiKeyService := service.NewApiKeyService(apiKeyStorage, userService, logger)
+			accrualEventService := service.NewAccrualEventService(accrualEventStorage, accrualEventListener, logger)
+
+			server := NewServer(authService, userService, accrualService, withdrawnService, balanceService, totpService,
+				adjustmentService, apiKeyService, accrualEventService, nil, auditService, validate, authToken, cfg, logger)
+
+			handler := http.HandlerFunc(server.EnrollTotpHandler)
+
+			if tt.useUserStorage {
+				userStorage.EXPECT().GetOneByLogin(gomock.Any(), "user").
+					Return(model.User{ID: 1, Login: "user", Password: "password"}, nil)
+			}
+			if tt.useTotpStorage {
+				totpStorage.EXPECT().GetOneByUserID(gomock.Any(), int64(1)).
+					Return(tt.totpStorageReturnedValue, tt.totpStorageErr)
+			}
+			if tt.useTotpStorageSave {
+				totpStorage.EXPECT().Save(gomock.Any(), gomock.Any()).Return(tt.totpStorageSaveErr)
+			}
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/api/user/2fa/enroll", nil)
+			if tt.isAuthorized {
+				req = withAuthorizedUser(t, server, req, "user")
+			}
+
+			handler.ServeHTTP(w, req)
+
+			resp := w.Result()
+			defer resp.Body.Close()
+			assertResponseMatchesSpec(t, req, resp)
+
+			assert.Equal(t, tt.expectedStatusCode, resp.StatusCode, "Response status code does not match expected status")
+			if tt.expectedStatusCode == http.StatusOK {
+				enrollment := model.TotpEnrollmentDto{}
+				err := json.NewDecoder(resp.Body).Decode(&enrollment)
+				require.NoError(t, err, "Error decoding response body")
+				assert.NotEmpty(t, enrollment.Secret, "Response body should contain totp secret")
+				assert.True(t, strings.HasPrefix(enrollment.URI, "otpauth://totp/Gophermart:user?"),
+					"Response body should contain otpauth uri")
+			}
+		})
+	}
+}
+
+func TestConfirmTotpHandler(t *testing.T) {
+	secret, err := auth.GenerateTotpSecret()
+	require.NoError(t, err, "Error generating totp secret")
+	validCode, err := auth.GenerateTotpCode(secret, auth.GetTotpStep(time.Now()))
+	require.NoError(t, err, "Error generating totp code")
+	expiredCode, err := auth.GenerateTotpCode(secret, auth.GetTotpStep(time.Now())-5)
+	require.NoError(t, err, "Error generating totp code")
+
+	tests := []struct {
+		name                     string
+		body                     string
+		useTotpStorage           bool
+		totpStorageReturnedValue model.Totp
+		totpStorageErr           error
+		useLastUsedStep          bool
+		useEnable                bool
+		expectedStatusCode       int
+	}{
+		{
+			name:                     "should return status 200 with recovery codes when code is valid",
+			body:                     `{"code":"` + validCode + `"}`,
+			useTotpStorage:           true,
+			totpStorageReturnedValue: model.Totp{UserID: 1, Secret: secret},
+			useLastUsedStep:          true,
+			useEnable:                true,
+			expectedStatusCode:       http.StatusOK,
+		},
+		{
+			name:                     "should return status 401 when code is invalid",
+			body:                     `{"code":"` + expiredCode + `"}`,
+			useTotpStorage:           true,
+			totpStorageReturnedValue: model.Totp{UserID: 1, Secret: secret},
+			expectedStatusCode:       http.StatusUnauthorized,
+		},
+		{
+			name:               "should return status 409 when enrollment is not started",
+			body:               `{"code":"` + validCode + `"}`,
+			useTotpStorage:     true,
+			totpStorageErr:     pgx.ErrNoRows,
+			expectedStatusCode: http.StatusConflict,
+		},
+		{
+			name:                     "should return status 409 when two-factor authentication is already enabled",
+			body:                     `{"code":"` + validCode + `"}`,
+			useTotpStorage:           true,
+			totpStorageReturnedValue: model.Totp{UserID: 1, Secret: secret, Enabled: true},
+			expectedStatusCode:       http.StatusConflict,
+		},
+		{
+			name:               "should return status 400 when request body is empty",
+			body:               "",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			validate, err := validator.GetValidator()
+			require.NoError(t, err, "Error init validator")
+			authToken := auth.GenerateAuthToken("secret")
+			cfg := &config.ServerConfig{}
+			logger, err := logger.Initialize("error")
+			require.NoError(t, err, "Error init logger")
+
+			userStorage := NewMockUserStorage(ctrl)
+			accrualStorage := NewMockAccrualStorage(ctrl)
+			withdrawnStorage := NewMockWithdrawnStorage(ctrl)
+			balanceStorage := NewMockBalanceStorage(ctrl)
+			totpStorage := NewMockTotpStorage(ctrl)
+			adjustmentStorage := NewMockAdjustmentStorage(ctrl)
+			apiKeyStorage := NewMockApiKeyStorage(ctrl)
+			accrualEventStorage := NewMockAccrualEventStorage(ctrl)
+			accrualEventListener := NewMockAccrualEventListener(ctrl)
+
+			userService := service.NewUserService(userStorage, logger)
+			totpService := service.NewTotpService(totpStorage, logger)
+			auditService := newTestAuditService(ctrl, logger)
+			authService := service.NewAuthService(userService, totpService, auditService, newTestPasswordHasher(),
+				authToken, logger)
+			accrualService := service.NewAccrualService(accrualStorage, logger)
+			withdrawnService := service.NewWithdrawnService(withdrawnStorage, auditService, logger)
+			balanceService := service.NewBalanceService(balanceStorage, logger)
+			adjustmentService := service.NewAdjustmentService(adjustmentStorage, logger)
+			apiKeyService := service.NewApiKeyService(apiKeyStorage, userService, logger)
+			accrualEventService := service.NewAccrualEventService(accrualEventStorage, accrualEventListener, logger)
+
+			server := NewServer(authService, userService, accrualService, withdrawnService, balanceService, totpService,
+				adjustmentService, apiKeyService, accrualEventService, nil, auditService, validate, authToken, cfg, logger)
+
+			handler := http.HandlerFunc(server.ConfirmTotpHandler)
+
+			userStorage.EXPECT().GetOneByLogin(gomock.Any(), "user").
+				Return(model.User{ID: 1, Login: "user", Password: "password"}, nil)
+			if tt.useTotpStorage {
+				totpStorage.EXPECT().GetOneByUserID(gomock.Any(), int64(1)).
+					Return(tt.totpStorageReturnedValue, tt.totpStorageErr)
+			}
+			if tt.useLastUsedStep {
+				totpStorage.EXPECT().UpdateLastUsedStep(gomock.Any(), int64(1), gomock.Any()).Return(true, nil)
+			}
+			if tt.useEnable {
+				totpStorage.EXPECT().Enable(gomock.Any(), int64(1), gomock.Len(10)).Return(nil)
+			}
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/api/user/2fa/confirm", strings.NewReader(tt.body))
+			req = withAuthorizedUser(t, server, req, "user")
+
+			handler.ServeHTTP(w, req)
+
+			resp := w.Result()
+			defer resp.Body.Close()
+			assertResponseMatchesSpec(t, req, resp)
+
+			assert.Equal(t, tt.expectedStatusCode, resp.StatusCode, "Response status code does not match expected status")
+			if tt.expectedStatusCode == http.StatusOK {
+				recoveryCodes := model.RecoveryCodesDto{}
+				err := json.NewDecoder(resp.Body).Decode(&recoveryCodes)
+				require.NoError(t, err, "Error decoding response body")
+				assert.Len(t, recoveryCodes.RecoveryCodes, 10, "Response body should contain recovery codes")
+			}
+		})
+	}
+}
+
+func TestDisableTotpHandler(t *testing.T) {
+	tests := []struct {
+		name               string
+		body               string
+		useTotpStorage     bool
+		recoveryCodeUsed   bool
+		useDelete          bool
+		expectedStatusCode int
+	}{
+		{
+			name:               "should return status 200 when recovery code is valid",
+			body:               `{"code":"abcde-fghij"}`,
+			useTotpStorage:     true,
+			recoveryCodeUsed:   true,
+			useDelete:          true,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "should return status 401 when recovery code is invalid",
+			body:               `{"code":"abcde-fghij"}`,
+			useTotpStorage:     true,
+			recoveryCodeUsed:   false,
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:               "should return status 400 when code is too short",
+			body:               `{"code":"123"}`,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			validate, err := validator.GetValidator()
+			require.NoError(t, err, "Error init validator")
+			authToken := auth.GenerateAuthToken("secret")
+			cfg := &config.ServerConfig{}
+			logger, err := logger.Initialize("error")
+			require.NoError(t, err, "Error init logger")
+
+			userStorage := NewMockUserStorage(ctrl)
+			accrualStorage := NewMockAccrualStorage(ctrl)
+			withdrawnStorage := NewMockWithdrawnStorage(ctrl)
+			balanceStorage := NewMockBalanceStorage(ctrl)
+			totpStorage := NewMockTotpStorage(ctrl)
+			adjustmentStorage := NewMockAdjustmentStorage(ctrl)
+			apiKeyStorage := NewMockApiKeyStorage(ctrl)
+			accrualEventStorage := NewMockAccrualEventStorage(ctrl)
+			accrualEventListener := NewMockAccrualEventListener(ctrl)
+
+			userService := service.NewUserService(userStorage, logger)
+			totpService := service.NewTotpService(totpStorage, logger)
+			auditService := newTestAuditService(ctrl, logger)
+			authService := service.NewAuthService(userService, totpService, auditService, newTestPasswordHasher(),
+				authToken, logger)
+			accrualService := service.NewAccrualService(accrualStorage, logger)
+			withdrawnService := service.NewWithdrawnService(withdrawnStorage, auditService, logger)
+			balanceService := service.NewBalanceService(balanceStorage, logger)
+			adjustmentService := service.NewAdjustmentService(adjustmentStorage, logger)
+			apiKeyService := service.NewApiKeyService(apiKeyStorage, userService, logger)
+			accrualEventService := service.NewAccrualEventService(accrualEventStorage, accrualEventListener, logger)
+
+			server := NewServer(authService, userService, accrualService, withdrawnService, balanceService, totpService,
+				adjustmentService, apiKeyService, accrualEventService, nil, auditService, validate, authToken, cfg, logger)
+
+			handler := http.HandlerFunc(server.DisableTotpHandler)
+
+			userStorage.EXPECT().GetOneByLogin(gomock.Any(), "user").
+				Return(model.User{ID: 1, Login: "user", Password: "password"}, nil)
+			if tt.useTotpStorage {
+				totpStorage.EXPECT().GetOneByUserID(gomock.Any(), int64(1)).
+					Return(model.Totp{UserID: 1, Enabled: true}, nil)
+				totpStorage.EXPECT().IncrementFailedAttempts(gomock.Any(), int64(1), gomock.Any(), gomock.Any()).
+					Return(int64(1), nil)
+				totpStorage.EXPECT().UseRecoveryCode(gomock.Any(), int64(1), gomock.Any()).
+					Return(tt.recoveryCodeUsed, nil)
+			}
+			if tt.useDelete {
+				totpStorage.EXPECT().ResetFailedAttempts(gomock.Any(), int64(1)).Return(nil)
+				totpStorage.EXPECT().Delete(gomock.Any(), int64(1)).Return(nil)
+			}
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/api/user/2fa/disable", strings.NewReader(tt.body))
+			req = withAuthorizedUser(t, server, req, "user")
+
+			handler.ServeHTTP(w, req)
+
+			resp := w.Result()
+			defer resp.Body.Close()
+			assertResponseMatchesSpec(t, req, resp)
+
+			assert.Equal(t, tt.expectedStatusCode, resp.StatusCode, "Response status code does not match expected status")
+		})
+	}
+}
+
+func withAuthorizedUser(t *testing.T, server *Server, req *http.Request, login string) *http.Request {
+	claims := map[string]interface{}{"login": login}
+	jwtauth.SetExpiry(claims, time.Now().Add(time.Hour*24))
+	_, tokenString, err := server.AuthToken.Encode(claims)
+	require.NoError(t, err, "Error encoding token")
+	decodedClaims, err := server.AuthToken.Decode(tokenString)
+	require.NoError(t, err, "Error decoding token")
+
+	req.Header.Set("Authorization", tokenString)
+	ctx := jwtauth.NewContext(req.Context(), decodedClaims, nil)
+	return req.WithContext(ctx)
+}
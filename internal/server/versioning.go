@@ -0,0 +1,71 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type APIVersion int
+
+const (
+	APIVersion1 APIVersion = iota + 1
+	APIVersion2
+)
+
+type apiVersionContextKey struct{}
+
+type DeprecationPolicy struct {
+	DeprecatedAt time.Time
+	SunsetAt     time.Time
+}
+
+func (p DeprecationPolicy) IsZero() bool {
+	return p.DeprecatedAt.IsZero() && p.SunsetAt.IsZero()
+}
+
+func WithAPIVersion(version APIVersion) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(res http.ResponseWriter, req *http.Request) {
+			ctx := context.WithValue(req.Context(), apiVersionContextKey{}, version)
+			next.ServeHTTP(res, req.WithContext(ctx))
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// Deprecated marks responses of routes under prefix with Deprecation (RFC 9745) and Sunset (RFC 8594) headers
+// and links them to the same route under successorPrefix.
+func Deprecated(policy DeprecationPolicy, prefix string, successorPrefix string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if policy.IsZero() {
+			return next
+		}
+
+		fn := func(res http.ResponseWriter, req *http.Request) {
+			if !policy.DeprecatedAt.IsZero() {
+				res.Header().Set("Deprecation", fmt.Sprintf("@%d", policy.DeprecatedAt.Unix()))
+			}
+			if !policy.SunsetAt.IsZero() {
+				res.Header().Set("Sunset", policy.SunsetAt.UTC().Format(http.TimeFormat))
+			}
+			if path, ok := strings.CutPrefix(req.URL.Path, prefix); ok {
+				res.Header().Add("Link", fmt.Sprintf("<%s%s>; rel=\"successor-version\"", successorPrefix, path))
+			}
+
+			next.ServeHTTP(res, req)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+func getAPIVersion(ctx context.Context) APIVersion {
+	if version, ok := ctx.Value(apiVersionContextKey{}).(APIVersion); ok {
+		return version
+	}
+	return APIVersion1
+}
@@ -0,0 +1,171 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/Stern-Ritter/gophermart/internal/model"
+)
+
+func TestDeprecated(t *testing.T) {
+	deprecatedAt := time.Date(2024, time.June, 1, 0, 0, 0, 0, time.UTC)
+	sunsetAt := time.Date(2024, time.December, 31, 23, 59, 59, 0, time.UTC)
+
+	tests := []struct {
+		name                string
+		policy              DeprecationPolicy
+		url                 string
+		expectedDeprecation string
+		expectedSunset      string
+		expectedLink        string
+	}{
+		{
+			name: "should not set headers when policy is not configured",
+			url:  "/api/user/balance",
+		},
+		{
+			name:                "should set deprecation, sunset and successor link headers when policy is configured",
+			policy:              DeprecationPolicy{DeprecatedAt: deprecatedAt, SunsetAt: sunsetAt},
+			url:                 "/api/user/balance",
+			expectedDeprecation: "@1717200000",
+			expectedSunset:      "Tue, 31 Dec 2024 23:59:59 GMT",
+			expectedLink:        `</api/v2/user/balance>; rel="successor-version"`,
+		},
+		{
+			name:           "should set only sunset header when deprecation date is not configured",
+			policy:         DeprecationPolicy{SunsetAt: sunsetAt},
+			url:            "/api/user/orders/12345678903",
+			expectedSunset: "Tue, 31 Dec 2024 23:59:59 GMT",
+			expectedLink:   `</api/v2/user/orders/12345678903>; rel="successor-version"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})
+			handler := Deprecated(tt.policy, "/api", "/api/v2")(next)
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.url, nil))
+
+			resp := w.Result()
+			defer resp.Body.Close()
+
+			assert.Equal(t, tt.expectedDeprecation, resp.Header.Get("Deprecation"), "Deprecation header does not match expected value")
+			assert.Equal(t, tt.expectedSunset, resp.Header.Get("Sunset"), "Sunset header does not match expected value")
+			assert.Equal(t, tt.expectedLink, resp.Header.Get("Link"), "Link header does not match expected value")
+		})
+	}
+}
+
+func TestVersionedHandlers(t *testing.T) {
+	tests := []struct {
+		name               string
+		version            APIVersion
+		method             string
+		url                string
+		body               string
+		useBalanceStorage  bool
+		useSaveWithdrawn   bool
+		expectedWithdrawn  model.Withdrawn
+		expectedStatusCode int
+		expectedBody       string
+	}{
+		{
+			name:               "should return balance amounts as numbers in v1",
+			version:            APIVersion1,
+			method:             http.MethodGet,
+			url:                "/api/user/balance",
+			useBalanceStorage:  true,
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       `{"current":400.5,"withdrawn":300}`,
+		},
+		{
+			name:               "should return balance amounts as decimal strings in v2",
+			version:            APIVersion2,
+			method:             http.MethodGet,
+			url:                "/api/v2/user/balance",
+			useBalanceStorage:  true,
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       `{"current":"400.50","withdrawn":"300.00"}`,
+		},
+		{
+			name:               "should accept withdrawal sum as decimal string in v2",
+			version:            APIVersion2,
+			method:             http.MethodPost,
+			url:                "/api/v2/user/balance/withdraw",
+			body:               `{"order":"2377225624","sum":"751.25"}`,
+			useSaveWithdrawn:   true,
+			expectedWithdrawn:  model.Withdrawn{UserID: 1, OrderNumber: 2377225624, PointsAmount: 751.25},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "should return status 400 when withdrawal sum is a number in v2",
+			version:            APIVersion2,
+			method:             http.MethodPost,
+			url:                "/api/v2/user/balance/withdraw",
+			body:               `{"order":"2377225624","sum":751.25}`,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			server, mocks := newAdminTestServer(t, ctrl)
+
+			mocks.userStorage.EXPECT().GetOneByLogin(gomock.Any(), "user").
+				Return(model.User{ID: 1, Login: "user", Role: model.UserRoleUser}, nil)
+			if tt.useBalanceStorage {
+				mocks.balanceStorage.EXPECT().GetByUserID(gomock.Any(), int64(1)).
+					Return(model.Balance{UserID: 1, CurrentPointsAmount: 400.5, WithdrawnPointsAmount: 300}, nil)
+			}
+			if tt.useSaveWithdrawn {
+				mocks.withdrawnStorage.EXPECT().Save(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, withdrawn model.Withdrawn) error {
+						assert.Equal(t, tt.expectedWithdrawn.OrderNumber, withdrawn.OrderNumber, "Withdrawn order does not match expected order")
+						assert.Equal(t, tt.expectedWithdrawn.PointsAmount, withdrawn.PointsAmount, "Withdrawn sum does not match expected sum")
+						return nil
+					})
+			}
+
+			handler := http.HandlerFunc(server.GetLoyaltyPointsBalanceHandler)
+			if tt.method == http.MethodPost {
+				handler = server.WithdrawLoyaltyPointsHandler
+			}
+
+			req := httptest.NewRequest(tt.method, tt.url, strings.NewReader(tt.body))
+			if tt.body != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			req = withAuthorizedUser(t, server, req, "user")
+			w := httptest.NewRecorder()
+
+			WithAPIVersion(tt.version)(handler).ServeHTTP(w, req)
+
+			resp := w.Result()
+			defer resp.Body.Close()
+			assertResponseMatchesSpec(t, req, resp)
+
+			assert.Equal(t, tt.expectedStatusCode, resp.StatusCode, "Response status code does not match expected status")
+			if tt.expectedBody != "" {
+				body, err := io.ReadAll(resp.Body)
+				require.NoError(t, err, "Error reading response body")
+				assert.Equal(t, tt.expectedBody, string(body), "Response body does not match expected body")
+			}
+		})
+	}
+}
@@ -1,49 +1,42 @@
 package server
 
 import (
-	"bytes"
 	"encoding/json"
-	"errors"
-	"io"
+	"fmt"
 	"net/http"
+	"strconv"
 
-	er "github.com/Stern-Ritter/gophermart/internal/errors"
 	"github.com/Stern-Ritter/gophermart/internal/model"
+	"github.com/Stern-Ritter/gophermart/internal/problem"
 	"github.com/Stern-Ritter/gophermart/internal/utils"
 )
 
 func (s *Server) WithdrawLoyaltyPointsHandler(res http.ResponseWriter, req *http.Request) {
 	currentUser, err := s.UserService.GetCurrentUser(req.Context())
 	if err != nil {
-		http.Error(res, err.Error(), http.StatusUnauthorized)
+		s.writeError(res, req, err)
 		return
 	}
 
-	createWithdrawnDto, err := decodeCreateWithdrawnDto(req.Body)
+	createWithdrawnDto, err := decodeCreateWithdrawnDto(req)
 	if err != nil {
-		http.Error(res, "Error decode request JSON body", http.StatusBadRequest)
+		s.writeProblem(res, req, http.StatusBadRequest, problem.CodeInvalidRequest, "Error decode request JSON body")
 		return
 	}
 	if err := createWithdrawnDto.Validate(s.Validate); err != nil {
-		http.Error(res, err.Error(), http.StatusBadRequest)
+		s.writeError(res, req, err)
 		return
 	}
 	parsedOrderNumber, err := utils.ParseOrderNumber(createWithdrawnDto.OrderNumber)
 	if err != nil {
-		http.Error(res, err.Error(), http.StatusBadRequest)
+		s.writeProblem(res, req, http.StatusBadRequest, problem.CodeInvalidRequest, err.Error())
 		return
 	}
 
 	withdrawn := model.NewWithdrawn(currentUser.ID, parsedOrderNumber, createWithdrawnDto.PointsAmount)
 	err = s.WithdrawnService.CreateWithdrawn(req.Context(), withdrawn)
 	if err != nil {
-		var paymentRequiredError er.PaymentRequiredError
-		switch {
-		case errors.As(err, &paymentRequiredError):
-			http.Error(res, err.Error(), http.StatusPaymentRequired)
-			return
-		}
-		http.Error(res, err.Error(), http.StatusInternalServerError)
+		s.writeError(res, req, err)
 		return
 	}
 
@@ -53,44 +46,105 @@ func (s *Server) WithdrawLoyaltyPointsHandler(res http.ResponseWriter, req *http
 func (s *Server) FindAllWithdrawalsByUserHandler(res http.ResponseWriter, req *http.Request) {
 	currentUser, err := s.UserService.GetCurrentUser(req.Context())
 	if err != nil {
-		http.Error(res, err.Error(), http.StatusUnauthorized)
+		s.writeError(res, req, err)
 		return
 	}
 
-	withdrawals, err := s.WithdrawnService.GetAllWithdrawalsByUserID(req.Context(), currentUser.ID)
+	filter, err := parseWithdrawalsFilter(req, currentUser.ID)
 	if err != nil {
-		http.Error(res, err.Error(), http.StatusInternalServerError)
+		s.writeProblem(res, req, http.StatusBadRequest, problem.CodeInvalidRequest, err.Error())
 		return
 	}
-	if len(withdrawals) == 0 {
+
+	var page model.WithdrawalsPage
+	if isUnpaginatedRequest(req) {
+		filter.Limit = maxPageLimit
+		page, err = s.WithdrawnService.GetAllWithdrawalsPages(req.Context(), filter)
+	} else {
+		page, err = s.WithdrawnService.GetWithdrawalsPage(req.Context(), filter)
+	}
+	if err != nil {
+		s.writeError(res, req, err)
+		return
+	}
+	res.Header().Set("X-Total-Count", strconv.FormatInt(page.TotalCount, 10))
+	if len(page.Withdrawals) == 0 {
 		res.WriteHeader(http.StatusNoContent)
 		return
 	}
 
-	withdrawalsDto := model.ToWithdrawalsDto(withdrawals)
+	var withdrawalsDto any = model.ToWithdrawalsDto(page.Withdrawals)
+	if getAPIVersion(req.Context()) == APIVersion2 {
+		withdrawalsDto = model.ToWithdrawalsV2Dto(page.Withdrawals)
+	}
 
 	body, err := json.Marshal(withdrawalsDto)
 	if err != nil {
-		http.Error(res, "Error encoding response", http.StatusInternalServerError)
+		s.writeError(res, req, err)
 		return
 	}
 
+	setNextPageHeaders(res, req, page.NextCursor, filter.Limit)
 	res.Header().Set("Content-Type", "application/json")
 	_, err = res.Write(body)
 	if err != nil {
-		http.Error(res, "Error encoding response", http.StatusInternalServerError)
+		s.writeError(res, req, err)
 		return
 	}
 }
 
-func decodeCreateWithdrawnDto(source io.ReadCloser) (model.CreateWithdrawnDto, error) {
-	dto := model.CreateWithdrawnDto{}
-	var buf bytes.Buffer
-	_, err := buf.ReadFrom(source)
-	if err != nil {
+func decodeCreateWithdrawnDto(req *http.Request) (model.CreateWithdrawnDto, error) {
+	if getAPIVersion(req.Context()) != APIVersion2 {
+		dto := model.CreateWithdrawnDto{}
+		err := decodeWithUnknownAndDuplicateFieldsCheck(req.Body, &dto)
 		return dto, err
 	}
 
-	err = json.Unmarshal(buf.Bytes(), &dto)
-	return dto, err
+	dto := model.CreateWithdrawnV2Dto{}
+	if err := decodeWithUnknownAndDuplicateFieldsCheck(req.Body, &dto); err != nil {
+		return model.CreateWithdrawnDto{}, err
+	}
+	return dto.ToCreateWithdrawnDto(), nil
+}
+
+func parseWithdrawalsFilter(req *http.Request, userID int64) (model.WithdrawalsFilter, error) {
+	params, err := parsePageParams(req, "from", "to")
+	if err != nil {
+		return model.WithdrawalsFilter{}, err
+	}
+
+	minSum, err := parseSumParam(req.URL.Query().Get("min_sum"), "min_sum")
+	if err != nil {
+		return model.WithdrawalsFilter{}, err
+	}
+	maxSum, err := parseSumParam(req.URL.Query().Get("max_sum"), "max_sum")
+	if err != nil {
+		return model.WithdrawalsFilter{}, err
+	}
+	if minSum > 0 && maxSum > 0 && minSum > maxSum {
+		return model.WithdrawalsFilter{}, fmt.Errorf("min_sum should not be greater than max_sum")
+	}
+
+	return model.WithdrawalsFilter{
+		UserID:        userID,
+		ProcessedFrom: params.From,
+		ProcessedTo:   params.To,
+		MinSum:        minSum,
+		MaxSum:        maxSum,
+		After:         params.After,
+		SortDirection: params.SortDirection,
+		Limit:         params.Limit,
+	}, nil
+}
+
+func parseSumParam(value string, name string) (float64, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	sum, err := strconv.ParseFloat(value, 64)
+	if err != nil || sum <= 0 {
+		return 0, fmt.Errorf("%s should be a number greater than 0", name)
+	}
+	return sum, nil
 }
@@ -1,10 +1,12 @@
 package server
 
 import (
+	"encoding/json"
 	"errors"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -81,6 +83,14 @@ func TestWithdrawLoyaltyPointsHandler(t *testing.T) {
 			useWithdrawnStorage: false,
 			expectedStatusCode:  http.StatusBadRequest,
 		},
+		{
+			name:                "should return status 400 when request body contains unknown field",
+			body:                `{"order":"12345678903","sum":100,"comment":"gift"}`,
+			isAuthorized:        true,
+			useUserStorage:      true,
+			useWithdrawnStorage: false,
+			expectedStatusCode:  http.StatusBadRequest,
+		},
 		{
 			name:                "should return status 400 when sum is less or equal to 0",
 			body:                `{"order":"12345678903","sum":0}`,
@@ -126,15 +136,26 @@ func TestWithdrawLoyaltyPointsHandler(t *testing.T) {
 			accrualStorage := NewMockAccrualStorage(ctrl)
 			withdrawnStorage := NewMockWithdrawnStorage(ctrl)
 			balanceStorage := NewMockBalanceStorage(ctrl)
+			totpStorage := NewMockTotpStorage(ctrl)
+			adjustmentStorage := NewMockAdjustmentStorage(ctrl)
+			apiKeyStorage := NewMockApiKeyStorage(ctrl)
+			accrualEventStorage := NewMockAccrualEventStorage(ctrl)
+			accrualEventListener := NewMockAccrualEventListener(ctrl)
 
 			userService := service.NewUserService(userStorage, logger)
-			authService := service.NewAuthService(userService, authToken, logger)
+			totpService := service.NewTotpService(totpStorage, logger)
+			auditService := newTestAuditService(ctrl, logger)
+			authService := service.NewAuthService(userService, totpService, auditService, newTestPasswordHasher(),
+				authToken, logger)
 			accrualService := service.NewAccrualService(accrualStorage, logger)
-			withdrawnService := service.NewWithdrawnService(withdrawnStorage, logger)
+			withdrawnService := service.NewWithdrawnService(withdrawnStorage, auditService, logger)
 			balanceService := service.NewBalanceService(balanceStorage, logger)
+			adjustmentService := service.NewAdjustmentService(adjustmentStorage, logger)
+			apiKeyService := service.NewApiKeyService(apiKeyStorage, userService, logger)
+			accrualEventService := service.NewAccrualEventService(accrualEventStorage, accrualEventListener, logger)
 
-			server := NewServer(authService, userService, accrualService, withdrawnService, balanceService, validate,
-				authToken, cfg, logger)
+			server := NewServer(authService, userService, accrualService, withdrawnService, balanceService, totpService,
+				adjustmentService, apiKeyService, accrualEventService, nil, auditService, validate, authToken, cfg, logger)
 
 			handler := http.HandlerFunc(server.WithdrawLoyaltyPointsHandler)
 
@@ -166,6 +187,7 @@ func TestWithdrawLoyaltyPointsHandler(t *testing.T) {
 
 			resp := w.Result()
 			defer resp.Body.Close()
+			assertResponseMatchesSpec(t, req, resp)
 
 			assert.Equal(t, tt.expectedStatusCode, resp.StatusCode, "Response status code does not match expected status")
 		})
@@ -181,7 +203,13 @@ func TestFindAllWithdrawalsByUserHandler(t *testing.T) {
 		userStorageErr                error
 		withdrawnStorageReturnedValue []model.Withdrawn
 		withdrawnStorageErr           error
+		withdrawnStorageCount         int64
+		withdrawnStorageCountErr      error
+		query                         string
+		expectedFilter                *model.WithdrawalsFilter
 		expectedBody                  string
+		expectedNextCursor            string
+		expectedTotalCount            string
 		expectedStatusCode            int
 	}{
 		{
@@ -204,8 +232,10 @@ func TestFindAllWithdrawalsByUserHandler(t *testing.T) {
 					ProcessedAt:  time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
 				},
 			},
-			expectedBody:       `[{"order":"12345678903","sum":42,"processed_at":"2024-01-01T00:00:00Z"}]`,
-			expectedStatusCode: http.StatusOK,
+			withdrawnStorageCount: 1,
+			expectedBody:          `[{"order":"12345678903","sum":42,"processed_at":"2024-01-01T00:00:00Z"}]`,
+			expectedTotalCount:    "1",
+			expectedStatusCode:    http.StatusOK,
 		},
 		{
 			name:                          "should return status 204 when user did not make any withdrawn of points",
@@ -213,6 +243,7 @@ func TestFindAllWithdrawalsByUserHandler(t *testing.T) {
 			useUserStorage:                true,
 			useWithdrawnStorage:           true,
 			withdrawnStorageReturnedValue: make([]model.Withdrawn, 0),
+			expectedTotalCount:            "0",
 			expectedStatusCode:            http.StatusNoContent,
 		},
 		{
@@ -223,6 +254,81 @@ func TestFindAllWithdrawalsByUserHandler(t *testing.T) {
 			withdrawnStorageErr: errors.New("unexpected error"),
 			expectedStatusCode:  http.StatusInternalServerError,
 		},
+		{
+			name:                          "should return status 500 when counting withdrawals failed",
+			isAuthorized:                  true,
+			useUserStorage:                true,
+			useWithdrawnStorage:           true,
+			withdrawnStorageReturnedValue: make([]model.Withdrawn, 0),
+			withdrawnStorageCountErr:      errors.New("unexpected error"),
+			expectedStatusCode:            http.StatusInternalServerError,
+		},
+		{
+			name:                "should return next cursor and total count when there are more withdrawals than limit",
+			isAuthorized:        true,
+			useUserStorage:      true,
+			useWithdrawnStorage: true,
+			query:               "?limit=1",
+			expectedFilter:      &model.WithdrawalsFilter{UserID: 1, SortDirection: model.SortAsc, Limit: 2},
+			withdrawnStorageReturnedValue: []model.Withdrawn{
+				{OrderNumber: 1, PointsAmount: 10, ProcessedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+				{OrderNumber: 2, PointsAmount: 20, ProcessedAt: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+			},
+			withdrawnStorageCount: 5,
+			expectedBody:          `[{"order":"1","sum":10,"processed_at":"2024-01-01T00:00:00Z"}]`,
+			expectedNextCursor:    model.Cursor{Time: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), Key: 1}.Encode(),
+			expectedTotalCount:    "5",
+			expectedStatusCode:    http.StatusOK,
+		},
+		{
+			name:                "should pass filters and cursor to storage",
+			isAuthorized:        true,
+			useUserStorage:      true,
+			useWithdrawnStorage: true,
+			query: "?from=2024-01-01T00:00:00Z&to=2024-02-01T00:00:00Z&min_sum=10&max_sum=100.5" +
+				"&sort=desc&limit=10&after=" + testOrdersCursor.Encode(),
+			expectedFilter: &model.WithdrawalsFilter{
+				UserID:        1,
+				ProcessedFrom: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+				ProcessedTo:   time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+				MinSum:        10,
+				MaxSum:        100.5,
+				After:         &testOrdersCursor,
+				SortDirection: model.SortDesc,
+				Limit:         11,
+			},
+			withdrawnStorageReturnedValue: make([]model.Withdrawn, 0),
+			expectedTotalCount:            "0",
+			expectedStatusCode:            http.StatusNoContent,
+		},
+		{
+			name:               "should return status 400 when limit is invalid",
+			isAuthorized:       true,
+			useUserStorage:     true,
+			query:              "?limit=0",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "should return status 400 when from is not a date",
+			isAuthorized:       true,
+			useUserStorage:     true,
+			query:              "?from=yesterday",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "should return status 400 when min_sum is not a positive number",
+			isAuthorized:       true,
+			useUserStorage:     true,
+			query:              "?min_sum=-1",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "should return status 400 when min_sum is greater than max_sum",
+			isAuthorized:       true,
+			useUserStorage:     true,
+			query:              "?min_sum=100&max_sum=10",
+			expectedStatusCode: http.StatusBadRequest,
+		},
 	}
 
 	for _, tt := range tests {
@@ -241,15 +347,26 @@ func TestFindAllWithdrawalsByUserHandler(t *testing.T) {
 			accrualStorage := NewMockAccrualStorage(ctrl)
 			withdrawnStorage := NewMockWithdrawnStorage(ctrl)
 			balanceStorage := NewMockBalanceStorage(ctrl)
+			totpStorage := NewMockTotpStorage(ctrl)
+			adjustmentStorage := NewMockAdjustmentStorage(ctrl)
+			apiKeyStorage := NewMockApiKeyStorage(ctrl)
+			accrualEventStorage := NewMockAccrualEventStorage(ctrl)
+			accrualEventListener := NewMockAccrualEventListener(ctrl)
 
 			userService := service.NewUserService(userStorage, logger)
-			authService := service.NewAuthService(userService, authToken, logger)
+			totpService := service.NewTotpService(totpStorage, logger)
+			auditService := newTestAuditService(ctrl, logger)
+			authService := service.NewAuthService(userService, totpService, auditService, newTestPasswordHasher(),
+				authToken, logger)
 			accrualService := service.NewAccrualService(accrualStorage, logger)
-			withdrawnService := service.NewWithdrawnService(withdrawnStorage, logger)
+			withdrawnService := service.NewWithdrawnService(withdrawnStorage, auditService, logger)
 			balanceService := service.NewBalanceService(balanceStorage, logger)
+			adjustmentService := service.NewAdjustmentService(adjustmentStorage, logger)
+			apiKeyService := service.NewApiKeyService(apiKeyStorage, userService, logger)
+			accrualEventService := service.NewAccrualEventService(accrualEventStorage, accrualEventListener, logger)
 
-			server := NewServer(authService, userService, accrualService, withdrawnService, balanceService, validate,
-				authToken, cfg, logger)
+			server := NewServer(authService, userService, accrualService, withdrawnService, balanceService, totpService,
+				adjustmentService, apiKeyService, accrualEventService, nil, auditService, validate, authToken, cfg, logger)
 
 			handler := http.HandlerFunc(server.FindAllWithdrawalsByUserHandler)
 
@@ -258,8 +375,16 @@ func TestFindAllWithdrawalsByUserHandler(t *testing.T) {
 					Return(model.User{ID: 1, Login: "user", Password: "password"}, tt.userStorageErr)
 			}
 			if tt.useWithdrawnStorage {
-				withdrawnStorage.EXPECT().GetAllByUserIDOrderByProcessedAtAsc(gomock.Any(), int64(1)).
+				expectedFilter := model.WithdrawalsFilter{UserID: 1, SortDirection: model.SortAsc, Limit: maxPageLimit + 1}
+				if tt.expectedFilter != nil {
+					expectedFilter = *tt.expectedFilter
+				}
+				withdrawnStorage.EXPECT().GetAllByFilter(gomock.Any(), expectedFilter).
 					Return(tt.withdrawnStorageReturnedValue, tt.withdrawnStorageErr)
+				if tt.withdrawnStorageErr == nil {
+					withdrawnStorage.EXPECT().CountByFilter(gomock.Any(), expectedFilter).
+						Return(tt.withdrawnStorageCount, tt.withdrawnStorageCountErr)
+				}
 			}
 
 			claims := map[string]interface{}{"login": "user"}
@@ -269,7 +394,7 @@ func TestFindAllWithdrawalsByUserHandler(t *testing.T) {
 			decodedClaims, _ := server.AuthToken.Decode(tokenString)
 
 			w := httptest.NewRecorder()
-			req := httptest.NewRequest(http.MethodGet, "/api/user/withdrawals", nil)
+			req := httptest.NewRequest(http.MethodGet, "/api/user/withdrawals"+tt.query, nil)
 			req.Header.Set("Authorization", tokenString)
 			if tt.isAuthorized {
 				ctx := jwtauth.NewContext(req.Context(), decodedClaims, nil)
@@ -280,6 +405,7 @@ func TestFindAllWithdrawalsByUserHandler(t *testing.T) {
 
 			resp := w.Result()
 			defer resp.Body.Close()
+			assertResponseMatchesSpec(t, req, resp)
 
 			assert.Equal(t, tt.expectedStatusCode, resp.StatusCode, "Response status code does not match expected status")
 			if tt.expectedBody != "" {
@@ -287,6 +413,86 @@ func TestFindAllWithdrawalsByUserHandler(t *testing.T) {
 				require.NoError(t, err)
 				require.Equal(t, tt.expectedBody, string(body), "Response body does not match expected body")
 			}
+			assert.Equal(t, tt.expectedTotalCount, resp.Header.Get("X-Total-Count"), "Total count does not match expected")
+			assert.Equal(t, tt.expectedNextCursor, resp.Header.Get("X-Next-Cursor"), "Next cursor does not match expected")
+			if tt.expectedNextCursor != "" {
+				assert.Contains(t, resp.Header.Get("Link"), "after="+tt.expectedNextCursor, "Link header should contain next cursor")
+			}
+		})
+	}
+}
+
+func TestFindAllWithdrawalsByUserHandlerPagination(t *testing.T) {
+	firstPage := make([]model.Withdrawn, maxPageLimit+1)
+	for i := range firstPage {
+		firstPage[i] = model.Withdrawn{UserID: 1, OrderNumber: int64(i + 1), PointsAmount: 10,
+			ProcessedAt: time.Date(2024, 1, 1, 0, 0, i, 0, time.UTC)}
+	}
+	lastCursor := model.Cursor{Time: firstPage[maxPageLimit-1].ProcessedAt, Key: firstPage[maxPageLimit-1].OrderNumber}
+
+	tests := []struct {
+		name                string
+		version             APIVersion
+		expectedFilters     []model.WithdrawalsFilter
+		expectedWithdrawals int
+		expectedNextCursor  string
+	}{
+		{
+			name:    "should count withdrawals once when returning all pages of v1 request",
+			version: APIVersion1,
+			expectedFilters: []model.WithdrawalsFilter{
+				{UserID: 1, SortDirection: model.SortAsc, Limit: maxPageLimit + 1},
+				{UserID: 1, SortDirection: model.SortAsc, Limit: maxPageLimit + 1, After: &lastCursor},
+			},
+			expectedWithdrawals: maxPageLimit + 1,
+		},
+		{
+			name:    "should return first page of v2 request without limit",
+			version: APIVersion2,
+			expectedFilters: []model.WithdrawalsFilter{
+				{UserID: 1, SortDirection: model.SortAsc, Limit: defaultPageLimit + 1},
+			},
+			expectedWithdrawals: defaultPageLimit,
+			expectedNextCursor: model.Cursor{Time: firstPage[defaultPageLimit-1].ProcessedAt,
+				Key: firstPage[defaultPageLimit-1].OrderNumber}.Encode(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			server, mocks := newAdminTestServer(t, ctrl)
+			handler := WithAPIVersion(tt.version)(http.HandlerFunc(server.FindAllWithdrawalsByUserHandler))
+
+			mocks.userStorage.EXPECT().GetOneByLogin(gomock.Any(), "user").
+				Return(model.User{ID: 1, Login: "user", Password: "password"}, nil)
+			pages := [][]model.Withdrawn{firstPage[:tt.expectedFilters[0].Limit], firstPage[maxPageLimit:]}
+			calls := make([]any, len(tt.expectedFilters))
+			for i, filter := range tt.expectedFilters {
+				calls[i] = mocks.withdrawnStorage.EXPECT().GetAllByFilter(gomock.Any(), filter).Return(pages[i], nil)
+			}
+			gomock.InOrder(calls...)
+			mocks.withdrawnStorage.EXPECT().CountByFilter(gomock.Any(), tt.expectedFilters[0]).
+				Return(int64(len(firstPage)), nil).Times(1)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/api/user/withdrawals", nil)
+			req = withAuthorizedUser(t, server, req, "user")
+
+			handler.ServeHTTP(w, req)
+
+			resp := w.Result()
+			defer resp.Body.Close()
+
+			require.Equal(t, http.StatusOK, resp.StatusCode, "Response status code does not match expected status")
+			var withdrawals []json.RawMessage
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&withdrawals), "Error decoding response body")
+			assert.Len(t, withdrawals, tt.expectedWithdrawals, "Withdrawals count does not match expected count")
+			assert.Equal(t, strconv.Itoa(len(firstPage)), resp.Header.Get("X-Total-Count"),
+				"Total count does not match expected")
+			assert.Equal(t, tt.expectedNextCursor, resp.Header.Get("X-Next-Cursor"), "Next cursor does not match expected")
 		})
 	}
 }
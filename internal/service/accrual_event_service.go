@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Stern-Ritter/gophermart/internal/logger"
+	"github.com/Stern-Ritter/gophermart/internal/model"
+	"github.com/Stern-Ritter/gophermart/internal/storage"
+)
+
+const (
+	accrualEventsSubscriberBufferSize = 16
+	accrualEventsListenRetryInterval  = 5 * time.Second
+)
+
+type AccrualEventService interface {
+	Subscribe(userID int64) (<-chan model.AccrualEvent, func())
+	Publish(event model.AccrualEvent)
+	GetAllAccrualEventsByUserIDAfterID(ctx context.Context, userID int64, afterID int64,
+		limit int64) ([]model.AccrualEvent, error)
+	Listen(ctx context.Context)
+}
+
+type AccrualEventServiceImpl struct {
+	accrualEventStorage  storage.AccrualEventStorage
+	accrualEventListener storage.AccrualEventListener
+	subscribers          map[int64]map[chan model.AccrualEvent]struct{}
+	mu                   sync.Mutex
+	logger               *logger.ServerLogger
+}
+
+func NewAccrualEventService(accrualEventStorage storage.AccrualEventStorage,
+	accrualEventListener storage.AccrualEventListener, logger *logger.ServerLogger) AccrualEventService {
+	return &AccrualEventServiceImpl{
+		accrualEventStorage:  accrualEventStorage,
+		accrualEventListener: accrualEventListener,
+		subscribers:          make(map[int64]map[chan model.AccrualEvent]struct{}),
+		logger:               logger,
+	}
+}
+
+func (s *AccrualEventServiceImpl) Subscribe(userID int64) (<-chan model.AccrualEvent, func()) {
+	ch := make(chan model.AccrualEvent, accrualEventsSubscriberBufferSize)
+
+	s.mu.Lock()
+	if _, ok := s.subscribers[userID]; !ok {
+		s.subscribers[userID] = make(map[chan model.AccrualEvent]struct{})
+	}
+	s.subscribers[userID][ch] = struct{}{}
+	s.mu.Unlock()
+
+	unsubscribe := func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.removeSubscriber(userID, ch)
+	}
+
+	return ch, unsubscribe
+}
+
+// Publish never blocks: slow subscribers are disconnected and catch up on reconnect.
+func (s *AccrualEventServiceImpl) Publish(event model.AccrualEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for ch := range s.subscribers[event.UserID] {
+		select {
+		case ch <- event:
+		default:
+			s.logger.Warn("Accrual events subscriber is too slow, disconnecting",
+				zap.String("event", "publish accrual event"), zap.Int64("user id", event.UserID))
+			s.removeSubscriber(event.UserID, ch)
+		}
+	}
+}
+
+func (s *AccrualEventServiceImpl) GetAllAccrualEventsByUserIDAfterID(ctx context.Context, userID int64, afterID int64,
+	limit int64) ([]model.AccrualEvent, error) {
+	return s.accrualEventStorage.GetAllByUserIDAfterIDOrderByIDAsc(ctx, userID, afterID, limit)
+}
+
+// Notifications sent while the listener is reconnecting are lost, so subscribers are
+// disconnected to make clients catch up via Last-Event-ID.
+func (s *AccrualEventServiceImpl) Listen(ctx context.Context) {
+	for {
+		err := s.accrualEventListener.Listen(ctx, s.Publish)
+		if ctx.Err() != nil {
+			return
+		}
+
+		s.logger.Error("Error listening accrual events", zap.String("event", "listen accrual events"), zap.Error(err))
+		s.removeAllSubscribers()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(accrualEventsListenRetryInterval):
+		}
+	}
+}
+
+func (s *AccrualEventServiceImpl) removeSubscriber(userID int64, ch chan model.AccrualEvent) {
+	userSubscribers, ok := s.subscribers[userID]
+	if !ok {
+		return
+	}
+	if _, ok := userSubscribers[ch]; !ok {
+		return
+	}
+
+	delete(userSubscribers, ch)
+	close(ch)
+	if len(userSubscribers) == 0 {
+		delete(s.subscribers, userID)
+	}
+}
+
+func (s *AccrualEventServiceImpl) removeAllSubscribers() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for userID, userSubscribers := range s.subscribers {
+		for ch := range userSubscribers {
+			close(ch)
+		}
+		delete(s.subscribers, userID)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 
 	er "github.com/Stern-Ritter/gophermart/internal/errors"
@@ -14,10 +15,17 @@ import (
 
 type AccrualService interface {
 	CreateAccrual(ctx context.Context, accrual model.Accrual) error
+	CreateAccruals(ctx context.Context, accruals []model.Accrual) ([]model.AccrualBatchItemResult, error)
 	UpdateAccrual(ctx context.Context, accrual model.Accrual) error
 	UpdateAccruals(ctx context.Context, accruals []model.Accrual) error
 	GetAllAccrualsByUserID(ctx context.Context, userID int64) ([]model.Accrual, error)
+	ForEachAccrualByUserID(ctx context.Context, userID int64, fn func(accrual model.Accrual) error) error
+	GetAccrualsPage(ctx context.Context, filter model.AccrualsFilter) (model.AccrualsPage, error)
+	GetAccrualByOrderNumber(ctx context.Context, orderNumber int64) (model.Accrual, error)
+	GetUserAccrualByOrderNumber(ctx context.Context, userID int64, orderNumber int64) (model.Accrual, error)
 	GetAllNewAccrualsInProcessingWithLimit(ctx context.Context, limit int64) ([]model.Accrual, error)
+	CountAccrualsByStatus(ctx context.Context) (map[model.AccrualStatus]int64, error)
+	RequeueAccrual(ctx context.Context, orderNumber int64) error
 }
 
 type AccrualServiceImpl struct {
@@ -48,6 +56,43 @@ func (s *AccrualServiceImpl) CreateAccrual(ctx context.Context, accrual model.Ac
 	return err
 }
 
+func (s *AccrualServiceImpl) CreateAccruals(ctx context.Context,
+	accruals []model.Accrual) ([]model.AccrualBatchItemResult, error) {
+	uniqueAccruals := make([]model.Accrual, 0, len(accruals))
+	seen := make(map[int64]struct{}, len(accruals))
+	for _, accrual := range accruals {
+		if _, ok := seen[accrual.OrderNumber]; ok {
+			continue
+		}
+		seen[accrual.OrderNumber] = struct{}{}
+		uniqueAccruals = append(uniqueAccruals, accrual)
+	}
+
+	saved := make(map[int64]model.AccrualBatchItemStatus, len(uniqueAccruals))
+	if len(uniqueAccruals) > 0 {
+		savedResults, err := s.accrualStorage.SaveInBatch(ctx, uniqueAccruals)
+		if err != nil {
+			return nil, err
+		}
+		for _, result := range savedResults {
+			saved[result.OrderNumber] = result.Status
+		}
+	}
+
+	// A repeated number gets the status of its first occurrence, except that a number accepted by the first
+	// occurrence is already uploaded by the time of the next one.
+	results := make([]model.AccrualBatchItemResult, len(accruals))
+	for i, accrual := range accruals {
+		status := saved[accrual.OrderNumber]
+		results[i] = model.AccrualBatchItemResult{OrderNumber: accrual.OrderNumber, Status: status}
+		if status == model.AccrualBatchItemAccepted {
+			saved[accrual.OrderNumber] = model.AccrualBatchItemAlreadyUploaded
+		}
+	}
+
+	return results, nil
+}
+
 func (s *AccrualServiceImpl) UpdateAccrual(ctx context.Context, accrual model.Accrual) error {
 	return s.accrualStorage.Update(ctx, accrual)
 }
@@ -60,6 +105,75 @@ func (s *AccrualServiceImpl) GetAllAccrualsByUserID(ctx context.Context, userID
 	return s.accrualStorage.GetAllByUserIDOrderByUploadedAtAsc(ctx, userID)
 }
 
+func (s *AccrualServiceImpl) ForEachAccrualByUserID(ctx context.Context, userID int64,
+	fn func(accrual model.Accrual) error) error {
+	return s.accrualStorage.ForEachByUserIDOrderByUploadedAtAsc(ctx, userID, fn)
+}
+
+func (s *AccrualServiceImpl) GetAccrualsPage(ctx context.Context, filter model.AccrualsFilter) (model.AccrualsPage, error) {
+	limit := filter.Limit
+	filter.Limit = limit + 1
+
+	accruals, err := s.accrualStorage.GetAllByFilter(ctx, filter)
+	if err != nil {
+		return model.AccrualsPage{}, err
+	}
+
+	page := model.AccrualsPage{Accruals: accruals}
+	if int64(len(accruals)) > limit {
+		page.Accruals = accruals[:limit]
+		last := page.Accruals[limit-1]
+		page.NextCursor = &model.Cursor{Time: last.UploadedAt, Key: last.OrderNumber}
+	}
+
+	return page, nil
+}
+
+func (s *AccrualServiceImpl) GetAccrualByOrderNumber(ctx context.Context, orderNumber int64) (model.Accrual, error) {
+	accrual, err := s.accrualStorage.GetOneByOrderNumber(ctx, orderNumber)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return model.Accrual{}, er.NewNotFoundError("Order with this number not found", err)
+	}
+
+	return accrual, err
+}
+
+func (s *AccrualServiceImpl) GetUserAccrualByOrderNumber(ctx context.Context, userID int64,
+	orderNumber int64) (model.Accrual, error) {
+	accrual, err := s.GetAccrualByOrderNumber(ctx, orderNumber)
+	if err != nil {
+		return model.Accrual{}, err
+	}
+	if accrual.UserID != userID {
+		return model.Accrual{}, er.NewNotFoundError("Order with this number not found", nil)
+	}
+
+	return accrual, nil
+}
+
 func (s *AccrualServiceImpl) GetAllNewAccrualsInProcessingWithLimit(ctx context.Context, limit int64) ([]model.Accrual, error) {
 	return s.accrualStorage.GetAllUnprocessedWithLimit(ctx, limit)
 }
+
+func (s *AccrualServiceImpl) CountAccrualsByStatus(ctx context.Context) (map[model.AccrualStatus]int64, error) {
+	return s.accrualStorage.CountByStatus(ctx)
+}
+
+// RequeueAccrual makes the scheduler fetch the accrual of the order again. Processed orders can't be requeued,
+// their points may already be spent.
+func (s *AccrualServiceImpl) RequeueAccrual(ctx context.Context, orderNumber int64) error {
+	accrual, err := s.GetAccrualByOrderNumber(ctx, orderNumber)
+	if err != nil {
+		return err
+	}
+	if accrual.Status == model.AccrualProcessed {
+		return er.NewConflictError("Processed order can't be requeued", nil)
+	}
+
+	err = s.accrualStorage.Requeue(ctx, orderNumber)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return er.NewConflictError("Order was processed while requeueing", err)
+	}
+
+	return err
+}
@@ -0,0 +1,34 @@
+package service
+
+import (
+	"context"
+
+	"github.com/Stern-Ritter/gophermart/internal/logger"
+	"github.com/Stern-Ritter/gophermart/internal/model"
+	"github.com/Stern-Ritter/gophermart/internal/storage"
+)
+
+type AdjustmentService interface {
+	CreateAdjustment(ctx context.Context, adjustment model.Adjustment) (model.Adjustment, error)
+	GetAllAdjustmentsByUserID(ctx context.Context, userID int64) ([]model.Adjustment, error)
+}
+
+type AdjustmentServiceImpl struct {
+	adjustmentStorage storage.AdjustmentStorage
+	logger            *logger.ServerLogger
+}
+
+func NewAdjustmentService(adjustmentStorage storage.AdjustmentStorage, logger *logger.ServerLogger) AdjustmentService {
+	return &AdjustmentServiceImpl{
+		adjustmentStorage: adjustmentStorage,
+		logger:            logger,
+	}
+}
+
+func (s *AdjustmentServiceImpl) CreateAdjustment(ctx context.Context, adjustment model.Adjustment) (model.Adjustment, error) {
+	return s.adjustmentStorage.Save(ctx, adjustment)
+}
+
+func (s *AdjustmentServiceImpl) GetAllAdjustmentsByUserID(ctx context.Context, userID int64) ([]model.Adjustment, error) {
+	return s.adjustmentStorage.GetAllByUserIDOrderByCreatedAtAsc(ctx, userID)
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/Stern-Ritter/gophermart/internal/auth"
+	er "github.com/Stern-Ritter/gophermart/internal/errors"
+	"github.com/Stern-Ritter/gophermart/internal/logger"
+	"github.com/Stern-Ritter/gophermart/internal/model"
+	"github.com/Stern-Ritter/gophermart/internal/storage"
+)
+
+type ApiKeyService interface {
+	CreateApiKey(ctx context.Context, userID int64, name string, scopes []model.ApiKeyScope) (model.ApiKey, string, error)
+	GetAllApiKeysByUserID(ctx context.Context, userID int64) ([]model.ApiKey, error)
+	RevokeApiKey(ctx context.Context, userID int64, id int64) error
+	VerifyApiKey(ctx context.Context, key string) (model.User, model.ApiKey, error)
+}
+
+type ApiKeyServiceImpl struct {
+	apiKeyStorage storage.ApiKeyStorage
+	userService   UserService
+	logger        *logger.ServerLogger
+}
+
+func NewApiKeyService(apiKeyStorage storage.ApiKeyStorage, userService UserService,
+	logger *logger.ServerLogger) ApiKeyService {
+	return &ApiKeyServiceImpl{
+		apiKeyStorage: apiKeyStorage,
+		userService:   userService,
+		logger:        logger,
+	}
+}
+
+func (s *ApiKeyServiceImpl) CreateApiKey(ctx context.Context, userID int64, name string,
+	scopes []model.ApiKeyScope) (model.ApiKey, string, error) {
+	key, err := auth.GenerateApiKey()
+	if err != nil {
+		return model.ApiKey{}, "", err
+	}
+
+	apiKey := model.NewApiKey(userID, name, auth.GetApiKeyPrefix(key), auth.GetApiKeyHash(key), scopes)
+	apiKey, err = s.apiKeyStorage.Save(ctx, apiKey)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.ConstraintName == "user_api_keys_user_id_name_active_idx" {
+			return model.ApiKey{}, "", er.NewConflictError("Api key with this name already exists", err)
+		}
+		return model.ApiKey{}, "", err
+	}
+
+	return apiKey, key, nil
+}
+
+func (s *ApiKeyServiceImpl) GetAllApiKeysByUserID(ctx context.Context, userID int64) ([]model.ApiKey, error) {
+	return s.apiKeyStorage.GetAllActiveByUserIDOrderByCreatedAtAsc(ctx, userID)
+}
+
+func (s *ApiKeyServiceImpl) RevokeApiKey(ctx context.Context, userID int64, id int64) error {
+	revoked, err := s.apiKeyStorage.Revoke(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return er.NewNotFoundError("Api key not found", nil)
+	}
+
+	return nil
+}
+
+func (s *ApiKeyServiceImpl) VerifyApiKey(ctx context.Context, key string) (model.User, model.ApiKey, error) {
+	apiKey, err := s.apiKeyStorage.GetOneActiveByKeyHash(ctx, auth.GetApiKeyHash(key))
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return model.User{}, model.ApiKey{}, er.NewUnauthorizedError("Invalid api key", err)
+	case err != nil:
+		return model.User{}, model.ApiKey{}, err
+	}
+
+	user, err := s.userService.GetUserByID(ctx, apiKey.UserID)
+	if err != nil {
+		return model.User{}, model.ApiKey{}, err
+	}
+	if user.Disabled {
+		return model.User{}, model.ApiKey{}, er.NewUnauthorizedError("User is disabled", nil)
+	}
+
+	return user, apiKey, nil
+}
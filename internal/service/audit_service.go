@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/go-chi/jwtauth/v5"
+	"go.uber.org/zap"
+
+	"github.com/Stern-Ritter/gophermart/internal/audit"
+	"github.com/Stern-Ritter/gophermart/internal/auth"
+	"github.com/Stern-Ritter/gophermart/internal/logger"
+	"github.com/Stern-Ritter/gophermart/internal/model"
+	"github.com/Stern-Ritter/gophermart/internal/storage"
+)
+
+var errAuditChainBroken = errors.New("audit chain is broken")
+
+type AuditService interface {
+	Record(ctx context.Context, event model.AuditEvent)
+	GetAuditEventsPage(ctx context.Context, filter model.AuditEventsFilter) (model.AuditEventsPage, error)
+	VerifyChain(ctx context.Context) (model.AuditChainVerification, error)
+}
+
+type AuditServiceImpl struct {
+	auditEventStorage storage.AuditEventStorage
+	logger            *logger.ServerLogger
+}
+
+func NewAuditService(auditEventStorage storage.AuditEventStorage, logger *logger.ServerLogger) AuditService {
+	return &AuditServiceImpl{
+		auditEventStorage: auditEventStorage,
+		logger:            logger,
+	}
+}
+
+// Record appends the event with the client of the current request. Failing to write the audit trail doesn't
+// fail the audited action, the error is logged instead.
+func (s *AuditServiceImpl) Record(ctx context.Context, event model.AuditEvent) {
+	client := audit.ClientFromContext(ctx)
+	event.IP = client.IP
+	event.UserAgent = client.UserAgent
+
+	if _, err := s.auditEventStorage.Append(ctx, event); err != nil {
+		s.logger.WithContext(ctx).Error("Error recording audit event", zap.String("event", "record audit event"),
+			zap.String("action", string(event.Action)), zap.String("outcome", string(event.Outcome)), zap.Error(err))
+	}
+}
+
+func (s *AuditServiceImpl) GetAuditEventsPage(ctx context.Context, filter model.AuditEventsFilter) (model.AuditEventsPage, error) {
+	limit := filter.Limit
+	filter.Limit = limit + 1
+
+	events, err := s.auditEventStorage.GetAllByFilter(ctx, filter)
+	if err != nil {
+		return model.AuditEventsPage{}, err
+	}
+
+	page := model.AuditEventsPage{Events: events}
+	if int64(len(events)) > limit {
+		page.Events = events[:limit]
+		last := page.Events[limit-1]
+		page.NextCursor = &model.Cursor{Time: last.CreatedAt, Key: last.ID}
+	}
+
+	return page, nil
+}
+
+// VerifyChain walks the audit trail from the first event and checks that every event links to the hash of the
+// previous one and that its own hash matches its fields.
+func (s *AuditServiceImpl) VerifyChain(ctx context.Context) (model.AuditChainVerification, error) {
+	verification := model.AuditChainVerification{Valid: true}
+	expectedPrevHash := model.AuditGenesisHash
+
+	err := s.auditEventStorage.ForEachOrderByIDAsc(ctx, func(event model.AuditEvent) error {
+		verification.CheckedEvents++
+
+		switch {
+		case event.PrevHash != expectedPrevHash:
+			verification.Valid = false
+			verification.BrokenAtID = event.ID
+			verification.Reason = fmt.Sprintf("event %d does not link to the previous event, "+
+				"an event before it was removed or reordered", event.ID)
+		case event.ComputeHash() != event.Hash:
+			verification.Valid = false
+			verification.BrokenAtID = event.ID
+			verification.Reason = fmt.Sprintf("event %d hash does not match its content, the event was modified",
+				event.ID)
+		}
+
+		if !verification.Valid {
+			return errAuditChainBroken
+		}
+
+		expectedPrevHash = event.Hash
+		return nil
+	})
+	if err != nil && !errors.Is(err, errAuditChainBroken) {
+		return model.AuditChainVerification{}, err
+	}
+
+	return verification, nil
+}
+
+func auditOutcome(err error) model.AuditOutcome {
+	if err != nil {
+		return model.AuditOutcomeFailure
+	}
+	return model.AuditOutcomeSuccess
+}
+
+// auditActor returns the login of the authenticated user of the request, if any.
+func auditActor(ctx context.Context) string {
+	_, claims, err := jwtauth.FromContext(ctx)
+	if err != nil {
+		return ""
+	}
+	login, _ := claims[auth.LoginClaim].(string)
+	return login
+}
@@ -8,6 +8,8 @@ import (
 	"github.com/go-chi/jwtauth/v5"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+	"go.uber.org/zap"
 
 	"github.com/Stern-Ritter/gophermart/internal/auth"
 	er "github.com/Stern-Ritter/gophermart/internal/errors"
@@ -15,69 +17,213 @@ import (
 	"github.com/Stern-Ritter/gophermart/internal/model"
 )
 
+const (
+	authTokenTTL         = time.Hour * 24
+	mfaChallengeTokenTTL = time.Minute * 5
+	mfaChallengeClaim    = "mfa_login"
+)
+
 type AuthService interface {
 	SignUp(ctx context.Context, request model.SignUpRequest) (string, error)
-	SignIn(ctx context.Context, request model.SignInRequest) (string, error)
+	SignIn(ctx context.Context, request model.SignInRequest) (model.SignInResult, error)
+	SignInWithSecondFactor(ctx context.Context, request model.SignInSecondFactorRequest) (string, error)
+	CreateUser(ctx context.Context, request model.SignUpRequest, role model.UserRole) error
+	DisableUser(ctx context.Context, login string) error
 }
 
 type AuthServiceImpl struct {
-	userService UserService
-	authToken   *jwtauth.JWTAuth
-	logger      *logger.ServerLogger
+	userService    UserService
+	totpService    TotpService
+	auditService   AuditService
+	passwordHasher auth.PasswordHasher
+	authToken      *jwtauth.JWTAuth
+	logger         *logger.ServerLogger
 }
 
-func NewAuthService(userService UserService, authToken *jwtauth.JWTAuth, logger *logger.ServerLogger) AuthService {
+func NewAuthService(userService UserService, totpService TotpService, auditService AuditService,
+	passwordHasher auth.PasswordHasher, authToken *jwtauth.JWTAuth, logger *logger.ServerLogger) AuthService {
 	return &AuthServiceImpl{
-		userService: userService,
-		authToken:   authToken,
-		logger:      logger,
+		userService:    userService,
+		totpService:    totpService,
+		auditService:   auditService,
+		passwordHasher: passwordHasher,
+		authToken:      authToken,
+		logger:         logger,
 	}
 }
 
 func (s *AuthServiceImpl) SignUp(ctx context.Context, request model.SignUpRequest) (string, error) {
+	token, err := s.signUp(ctx, request)
+	s.auditService.Record(ctx, model.NewAuditEvent(0, request.Login, model.AuditActionSignUp, request.Login,
+		auditOutcome(err)))
+	return token, err
+}
+
+func (s *AuthServiceImpl) signUp(ctx context.Context, request model.SignUpRequest) (string, error) {
 	user := model.SignUpRequestToUser(request)
 
-	passwordHash, err := auth.GetPasswordHash(user.Password)
+	err := s.createUser(ctx, user)
 	if err != nil {
 		return "", err
 	}
+
+	return s.generateAuthToken(user)
+}
+
+// CreateUser creates a user with the given role without signing them in, it is used by operators.
+func (s *AuthServiceImpl) CreateUser(ctx context.Context, request model.SignUpRequest, role model.UserRole) error {
+	user := model.SignUpRequestToUser(request)
+	user.Role = role
+
+	err := s.createUser(ctx, user)
+	s.auditService.Record(ctx, model.NewAuditEvent(0, auditActor(ctx), model.AuditActionUserCreate, request.Login,
+		auditOutcome(err)))
+	return err
+}
+
+func (s *AuthServiceImpl) createUser(ctx context.Context, user model.User) error {
+	passwordHash, err := s.passwordHasher.Hash(user.Password)
+	if err != nil {
+		return err
+	}
 	user.Password = passwordHash
 
 	err = s.userService.CreateUser(ctx, user)
 	if err != nil {
 		var pgErr *pgconn.PgError
 		if errors.As(err, &pgErr) && pgErr.ConstraintName == "users_login_unique" {
-			return "", er.NewConflictError("User with this login already exists", err)
+			return er.NewConflictError("User with this login already exists", err)
 		}
 
-		return "", err
+		return err
 	}
 
-	claims := map[string]interface{}{"login": user.Login}
-	jwtauth.SetExpiry(claims, time.Now().Add(time.Hour*24))
-	_, tokenString, err := s.authToken.Encode(claims)
+	return nil
+}
+
+// DisableUser prevents the user from signing in. Sessions and api keys of the user are rejected as well.
+func (s *AuthServiceImpl) DisableUser(ctx context.Context, login string) error {
+	err := s.userService.DisableUser(ctx, login)
+	s.auditService.Record(ctx, model.NewAuditEvent(0, auditActor(ctx), model.AuditActionUserDisable, login,
+		auditOutcome(err)))
+	return err
+}
+
+func (s *AuthServiceImpl) SignIn(ctx context.Context, request model.SignInRequest) (model.SignInResult, error) {
+	result, err := s.signIn(ctx, request)
+	outcome := auditOutcome(err)
+	if err == nil && result.ChallengeToken != "" {
+		outcome = model.AuditOutcomeMfaRequired
+	}
+	s.auditService.Record(ctx, model.NewAuditEvent(0, request.Login, model.AuditActionSignIn, request.Login, outcome))
+	return result, err
+}
+
+func (s *AuthServiceImpl) signIn(ctx context.Context, request model.SignInRequest) (model.SignInResult, error) {
+	user, err := s.userService.GetUserByLogin(ctx, request.Login)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return model.SignInResult{}, er.NewUnauthorizedError("Invalid login or password", err)
+	case err != nil:
+		return model.SignInResult{}, err
+	}
+
+	if !s.passwordHasher.Verify(request.Password, user.Password) {
+		return model.SignInResult{}, er.NewUnauthorizedError("Invalid login or password", err)
+	}
+	if user.Disabled {
+		return model.SignInResult{}, er.NewUnauthorizedError("User is disabled", nil)
+	}
+	s.rehashPasswordIfNeeded(ctx, user, request.Password)
+
+	mfaEnabled, err := s.totpService.IsEnabled(ctx, user.ID)
 	if err != nil {
-		return "", err
+		return model.SignInResult{}, err
+	}
+	if mfaEnabled {
+		challengeToken, err := s.generateMfaChallengeToken(user.Login)
+		if err != nil {
+			return model.SignInResult{}, err
+		}
+		return model.SignInResult{ChallengeToken: challengeToken}, nil
 	}
 
-	return tokenString, nil
+	tokenString, err := s.generateAuthToken(user)
+	if err != nil {
+		return model.SignInResult{}, err
+	}
+
+	return model.SignInResult{AuthToken: tokenString}, nil
 }
 
-func (s *AuthServiceImpl) SignIn(ctx context.Context, request model.SignInRequest) (string, error) {
-	user, err := s.userService.GetUserByLogin(ctx, request.Login)
+func (s *AuthServiceImpl) SignInWithSecondFactor(ctx context.Context, request model.SignInSecondFactorRequest) (string, error) {
+	authToken, login, err := s.signInWithSecondFactor(ctx, request)
+	s.auditService.Record(ctx, model.NewAuditEvent(0, login, model.AuditActionSignInWithSecondFactor, login,
+		auditOutcome(err)))
+	return authToken, err
+}
+
+func (s *AuthServiceImpl) signInWithSecondFactor(ctx context.Context,
+	request model.SignInSecondFactorRequest) (string, string, error) {
+	token, err := s.authToken.Decode(request.ChallengeToken)
+	if err != nil || jwt.Validate(token, s.authToken.ValidateOptions()...) != nil {
+		return "", "", er.NewUnauthorizedError("Invalid or expired challenge token", err)
+	}
+
+	login, ok := token.PrivateClaims()[mfaChallengeClaim].(string)
+	if !ok {
+		return "", "", er.NewUnauthorizedError("Invalid or expired challenge token", nil)
+	}
+
+	user, err := s.userService.GetUserByLogin(ctx, login)
 	switch {
 	case errors.Is(err, pgx.ErrNoRows):
-		return "", er.NewUnauthorizedError("Invalid login or password", err)
+		return "", login, er.NewUnauthorizedError("Invalid or expired challenge token", err)
 	case err != nil:
-		return "", err
+		return "", login, err
+	}
+	if user.Disabled {
+		return "", login, er.NewUnauthorizedError("User is disabled", nil)
+	}
+
+	err = s.totpService.Verify(ctx, user.ID, request.Code)
+	if err != nil {
+		return "", login, err
+	}
+
+	authToken, err := s.generateAuthToken(user)
+	return authToken, login, err
+}
+
+func (s *AuthServiceImpl) rehashPasswordIfNeeded(ctx context.Context, user model.User, password string) {
+	if !s.passwordHasher.NeedsRehash(user.Password) {
+		return
+	}
+
+	passwordHash, err := s.passwordHasher.Hash(password)
+	if err == nil {
+		err = s.userService.UpdateUserPassword(ctx, user.ID, passwordHash)
+	}
+	if err != nil {
+		s.logger.WithContext(ctx).Warn("Failed to rehash user password", zap.String("event", "rehash password"),
+			zap.Int64("user id", user.ID), zap.Error(err))
 	}
+}
 
-	if !auth.CheckPasswordHash(request.Password, user.Password) {
-		return "", er.NewUnauthorizedError("Invalid login or password", err)
+func (s *AuthServiceImpl) generateAuthToken(user model.User) (string, error) {
+	claims := map[string]interface{}{auth.LoginClaim: user.Login}
+	jwtauth.SetExpiry(claims, time.Now().Add(authTokenTTL))
+	_, tokenString, err := s.authToken.Encode(claims)
+	if err != nil {
+		return "", err
 	}
 
-	claims := map[string]interface{}{"login": user.Login}
-	jwtauth.SetExpiry(claims, time.Now().Add(time.Hour*24))
+	return tokenString, nil
+}
+
+func (s *AuthServiceImpl) generateMfaChallengeToken(login string) (string, error) {
+	claims := map[string]interface{}{mfaChallengeClaim: login}
+	jwtauth.SetExpiry(claims, time.Now().Add(mfaChallengeTokenTTL))
 	_, tokenString, err := s.authToken.Encode(claims)
 	if err != nil {
 		return "", err
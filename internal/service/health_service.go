@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Stern-Ritter/gophermart/internal/logger"
+	"github.com/Stern-Ritter/gophermart/internal/model"
+	"github.com/Stern-Ritter/gophermart/internal/storage"
+)
+
+const (
+	readinessCheckTimeout = 2 * time.Second
+
+	HealthComponentDatabase      = "database"
+	HealthComponentMigrations    = "migrations"
+	HealthComponentScheduler     = "scheduler"
+	HealthComponentAccrualSystem = "accrual_system"
+)
+
+var errSchemaOutdated = errors.New("database schema is outdated")
+
+type HeartbeatSource interface {
+	LastHeartbeat() time.Time
+}
+
+type HealthService interface {
+	CheckReadiness(ctx context.Context) model.HealthReport
+	CheckSchema(ctx context.Context) error
+}
+
+type HealthServiceImpl struct {
+	healthStorage    storage.HealthStorage
+	heartbeatSource  HeartbeatSource
+	heartbeatMaxAge  time.Duration
+	migrationVersion int64
+	accrualSystemURL string
+	httpClient       *http.Client
+	logger           *logger.ServerLogger
+}
+
+// NewHealthService creates a readiness checker. The accrual system is checked only when accrualSystemURL is not empty.
+func NewHealthService(healthStorage storage.HealthStorage, heartbeatSource HeartbeatSource, heartbeatMaxAge time.Duration,
+	migrationVersion int64, accrualSystemURL string, logger *logger.ServerLogger) HealthService {
+	return &HealthServiceImpl{
+		healthStorage:    healthStorage,
+		heartbeatSource:  heartbeatSource,
+		heartbeatMaxAge:  heartbeatMaxAge,
+		migrationVersion: migrationVersion,
+		accrualSystemURL: accrualSystemURL,
+		httpClient:       &http.Client{Timeout: readinessCheckTimeout},
+		logger:           logger,
+	}
+}
+
+func (s *HealthServiceImpl) CheckReadiness(ctx context.Context) model.HealthReport {
+	ctx, cancel := context.WithTimeout(ctx, readinessCheckTimeout)
+	defer cancel()
+
+	components := map[string]model.ComponentHealth{
+		HealthComponentDatabase:   s.checkDatabase(ctx),
+		HealthComponentMigrations: s.checkMigrations(ctx),
+		HealthComponentScheduler:  s.checkScheduler(),
+	}
+	if s.accrualSystemURL != "" {
+		components[HealthComponentAccrualSystem] = s.checkAccrualSystem(ctx)
+	}
+
+	report := model.HealthReport{Status: model.HealthStatusOK, Components: components}
+	for name, component := range components {
+		if component.Status != model.HealthStatusOK {
+			report.Status = model.HealthStatusFail
+			s.logger.WithContext(ctx).Warn("Readiness check failed", zap.String("event", "readiness check"),
+				zap.String("component", name), zap.String("detail", component.Detail))
+		}
+	}
+	return report
+}
+
+func (s *HealthServiceImpl) checkDatabase(ctx context.Context) model.ComponentHealth {
+	if err := s.healthStorage.Ping(ctx); err != nil {
+		return s.failedCheck(ctx, HealthComponentDatabase, "database is not reachable", err)
+	}
+	return model.ComponentHealth{Status: model.HealthStatusOK}
+}
+
+// CheckSchema returns an error when the database schema is behind the migrations the server was built with.
+func (s *HealthServiceImpl) CheckSchema(ctx context.Context) error {
+	_, err := s.schemaVersion(ctx)
+	return err
+}
+
+func (s *HealthServiceImpl) checkMigrations(ctx context.Context) model.ComponentHealth {
+	version, err := s.schemaVersion(ctx)
+	if errors.Is(err, errSchemaOutdated) {
+		return failedComponent(fmt.Sprintf("version %d is behind expected version %d", version, s.migrationVersion))
+	}
+	if err != nil {
+		return s.failedCheck(ctx, HealthComponentMigrations, "schema version is not available", err)
+	}
+	return model.ComponentHealth{Status: model.HealthStatusOK, Detail: fmt.Sprintf("version %d", version)}
+}
+
+func (s *HealthServiceImpl) schemaVersion(ctx context.Context) (int64, error) {
+	version, err := s.healthStorage.GetMigrationVersion(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if version < s.migrationVersion {
+		return version, fmt.Errorf("%w: version %d is behind expected version %d",
+			errSchemaOutdated, version, s.migrationVersion)
+	}
+	return version, nil
+}
+
+func (s *HealthServiceImpl) checkScheduler() model.ComponentHealth {
+	lastHeartbeat := s.heartbeatSource.LastHeartbeat()
+	if lastHeartbeat.IsZero() {
+		return failedComponent("scheduler is not running")
+	}
+	if age := time.Since(lastHeartbeat); age > s.heartbeatMaxAge {
+		return failedComponent(fmt.Sprintf("last heartbeat %s ago", age.Round(time.Second)))
+	}
+	return model.ComponentHealth{Status: model.HealthStatusOK}
+}
+
+func (s *HealthServiceImpl) checkAccrualSystem(ctx context.Context) model.ComponentHealth {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.accrualSystemURL, nil)
+	if err != nil {
+		return s.failedCheck(ctx, HealthComponentAccrualSystem, "accrual system is not reachable", err)
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return s.failedCheck(ctx, HealthComponentAccrualSystem, "accrual system is not reachable", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return failedComponent(fmt.Sprintf("unexpected response status %d", resp.StatusCode))
+	}
+	return model.ComponentHealth{Status: model.HealthStatusOK}
+}
+
+// failedCheck logs the error of a failed check and reports the component with a fixed detail, the readiness report is
+// public and must not reveal addresses or driver messages.
+func (s *HealthServiceImpl) failedCheck(ctx context.Context, component string, detail string,
+	err error) model.ComponentHealth {
+	s.logger.WithContext(ctx).Error("Readiness check error", zap.String("event", "readiness check"),
+		zap.String("component", component), zap.Error(err))
+	return failedComponent(detail)
+}
+
+func failedComponent(detail string) model.ComponentHealth {
+	return model.ComponentHealth{Status: model.HealthStatusFail, Detail: detail}
+}
@@ -0,0 +1,186 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/Stern-Ritter/gophermart/internal/auth"
+	er "github.com/Stern-Ritter/gophermart/internal/errors"
+	"github.com/Stern-Ritter/gophermart/internal/logger"
+	"github.com/Stern-Ritter/gophermart/internal/model"
+	"github.com/Stern-Ritter/gophermart/internal/storage"
+)
+
+const (
+	totpIssuer         = "Gophermart"
+	recoveryCodesCount = 10
+
+	// maxTotpAttempts codes can be tried in a row, further attempts are rejected until no code has been tried for
+	// totpAttemptsWindow.
+	maxTotpAttempts    = 5
+	totpAttemptsWindow = time.Minute * 15
+)
+
+type TotpService interface {
+	Enroll(ctx context.Context, user model.User) (model.TotpEnrollment, error)
+	Confirm(ctx context.Context, userID int64, code string) ([]string, error)
+	Disable(ctx context.Context, userID int64, code string) error
+	IsEnabled(ctx context.Context, userID int64) (bool, error)
+	Verify(ctx context.Context, userID int64, code string) error
+}
+
+type TotpServiceImpl struct {
+	totpStorage storage.TotpStorage
+	logger      *logger.ServerLogger
+}
+
+func NewTotpService(totpStorage storage.TotpStorage, logger *logger.ServerLogger) TotpService {
+	return &TotpServiceImpl{
+		totpStorage: totpStorage,
+		logger:      logger,
+	}
+}
+
+func (s *TotpServiceImpl) Enroll(ctx context.Context, user model.User) (model.TotpEnrollment, error) {
+	enabled, err := s.IsEnabled(ctx, user.ID)
+	if err != nil {
+		return model.TotpEnrollment{}, err
+	}
+	if enabled {
+		return model.TotpEnrollment{}, er.NewConflictError("Two-factor authentication is already enabled", nil)
+	}
+
+	secret, err := auth.GenerateTotpSecret()
+	if err != nil {
+		return model.TotpEnrollment{}, err
+	}
+
+	err = s.totpStorage.Save(ctx, model.NewTotp(user.ID, secret))
+	if err != nil {
+		return model.TotpEnrollment{}, err
+	}
+
+	return model.TotpEnrollment{
+		Secret: secret,
+		URI:    auth.GetTotpURI(totpIssuer, user.Login, secret),
+	}, nil
+}
+
+func (s *TotpServiceImpl) Confirm(ctx context.Context, userID int64, code string) ([]string, error) {
+	totp, err := s.totpStorage.GetOneByUserID(ctx, userID)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return nil, er.NewConflictError("Two-factor authentication enrollment is not started", err)
+	case err != nil:
+		return nil, err
+	case totp.Enabled:
+		return nil, er.NewConflictError("Two-factor authentication is already enabled", nil)
+	}
+
+	err = s.verifyTotpCode(ctx, totp, code)
+	if err != nil {
+		return nil, err
+	}
+
+	recoveryCodes, err := auth.GenerateRecoveryCodes(recoveryCodesCount)
+	if err != nil {
+		return nil, err
+	}
+	recoveryCodeHashes := make([]string, len(recoveryCodes))
+	for i, recoveryCode := range recoveryCodes {
+		recoveryCodeHashes[i] = auth.GetRecoveryCodeHash(recoveryCode)
+	}
+
+	err = s.totpStorage.Enable(ctx, userID, recoveryCodeHashes)
+	if err != nil {
+		return nil, err
+	}
+
+	return recoveryCodes, nil
+}
+
+func (s *TotpServiceImpl) Disable(ctx context.Context, userID int64, code string) error {
+	err := s.Verify(ctx, userID, code)
+	if err != nil {
+		return err
+	}
+
+	return s.totpStorage.Delete(ctx, userID)
+}
+
+func (s *TotpServiceImpl) IsEnabled(ctx context.Context, userID int64) (bool, error) {
+	totp, err := s.totpStorage.GetOneByUserID(ctx, userID)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return false, nil
+	case err != nil:
+		return false, err
+	}
+
+	return totp.Enabled, nil
+}
+
+func (s *TotpServiceImpl) Verify(ctx context.Context, userID int64, code string) error {
+	totp, err := s.totpStorage.GetOneByUserID(ctx, userID)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return er.NewUnauthorizedError("Two-factor authentication is not enabled", err)
+	case err != nil:
+		return err
+	case !totp.Enabled:
+		return er.NewUnauthorizedError("Two-factor authentication is not enabled", nil)
+	}
+
+	// The attempt is counted before the code is checked, so that concurrent requests can't try more codes.
+	now := time.Now()
+	attempts, err := s.totpStorage.IncrementFailedAttempts(ctx, userID, now, now.Add(-totpAttemptsWindow))
+	if err != nil {
+		return err
+	}
+	if attempts > maxTotpAttempts {
+		return er.NewUnauthorizedError("Too many invalid two-factor authentication codes, try again later", nil)
+	}
+
+	err = s.verifyCode(ctx, totp, code)
+	if err != nil {
+		return err
+	}
+
+	return s.totpStorage.ResetFailedAttempts(ctx, userID)
+}
+
+func (s *TotpServiceImpl) verifyCode(ctx context.Context, totp model.Totp, code string) error {
+	if auth.IsTotpCode(code) {
+		return s.verifyTotpCode(ctx, totp, code)
+	}
+
+	used, err := s.totpStorage.UseRecoveryCode(ctx, totp.UserID, auth.GetRecoveryCodeHash(code))
+	if err != nil {
+		return err
+	}
+	if !used {
+		return er.NewUnauthorizedError("Invalid two-factor authentication code", nil)
+	}
+
+	return nil
+}
+
+func (s *TotpServiceImpl) verifyTotpCode(ctx context.Context, totp model.Totp, code string) error {
+	step, ok := auth.ValidateTotpCode(totp.Secret, code, time.Now())
+	if !ok {
+		return er.NewUnauthorizedError("Invalid two-factor authentication code", nil)
+	}
+
+	updated, err := s.totpStorage.UpdateLastUsedStep(ctx, totp.UserID, step)
+	if err != nil {
+		return err
+	}
+	if !updated {
+		return er.NewUnauthorizedError("Two-factor authentication code has already been used", nil)
+	}
+
+	return nil
+}
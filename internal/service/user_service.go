@@ -2,9 +2,12 @@ package service
 
 import (
 	"context"
+	"errors"
 
 	"github.com/go-chi/jwtauth/v5"
+	"github.com/jackc/pgx/v5"
 
+	"github.com/Stern-Ritter/gophermart/internal/auth"
 	er "github.com/Stern-Ritter/gophermart/internal/errors"
 	"github.com/Stern-Ritter/gophermart/internal/logger"
 	"github.com/Stern-Ritter/gophermart/internal/model"
@@ -15,6 +18,10 @@ type UserService interface {
 	CreateUser(ctx context.Context, user model.User) error
 	GetUserByLogin(ctx context.Context, login string) (model.User, error)
 	GetCurrentUser(ctx context.Context) (model.User, error)
+	GetUserByID(ctx context.Context, id int64) (model.User, error)
+	SearchUsersByLogin(ctx context.Context, login string, limit int64) ([]model.User, error)
+	UpdateUserPassword(ctx context.Context, userID int64, passwordHash string) error
+	DisableUser(ctx context.Context, login string) error
 }
 
 type UserServiceImpl struct {
@@ -43,11 +50,48 @@ func (s *UserServiceImpl) GetCurrentUser(ctx context.Context) (model.User, error
 		return model.User{}, er.NewUnauthorizedError("User is not authorized to access this resource", err)
 	}
 
-	if _, ok := claims["login"]; !ok {
+	if _, ok := claims[auth.LoginClaim]; !ok {
 		return model.User{}, er.NewUnauthorizedError("User is not authorized to access this resource", err)
 	}
 
-	login := claims["login"].(string)
+	login := claims[auth.LoginClaim].(string)
 	currentUser, err := s.userStorage.GetOneByLogin(ctx, login)
-	return currentUser, err
+	if errors.Is(err, pgx.ErrNoRows) {
+		return model.User{}, er.NewUnauthorizedError("User is not authorized to access this resource", err)
+	}
+	if err != nil {
+		return model.User{}, err
+	}
+	if currentUser.Disabled {
+		return model.User{}, er.NewUnauthorizedError("User is disabled", nil)
+	}
+
+	logger.SetUserID(ctx, currentUser.ID)
+	return currentUser, nil
+}
+
+func (s *UserServiceImpl) GetUserByID(ctx context.Context, id int64) (model.User, error) {
+	user, err := s.userStorage.GetOneByID(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return model.User{}, er.NewNotFoundError("User not found", err)
+	}
+
+	return user, err
+}
+
+func (s *UserServiceImpl) SearchUsersByLogin(ctx context.Context, login string, limit int64) ([]model.User, error) {
+	return s.userStorage.GetAllByLoginContainingOrderByLogin(ctx, login, limit)
+}
+
+func (s *UserServiceImpl) DisableUser(ctx context.Context, login string) error {
+	err := s.userStorage.DisableByLogin(ctx, login)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return er.NewNotFoundError("User not found", err)
+	}
+
+	return err
+}
+
+func (s *UserServiceImpl) UpdateUserPassword(ctx context.Context, userID int64, passwordHash string) error {
+	return s.userStorage.UpdatePassword(ctx, userID, passwordHash)
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/Stern-Ritter/gophermart/internal/logger"
+	"github.com/Stern-Ritter/gophermart/internal/metrics"
 	"github.com/Stern-Ritter/gophermart/internal/model"
 	"github.com/Stern-Ritter/gophermart/internal/storage"
 )
@@ -11,24 +12,106 @@ import (
 type WithdrawnService interface {
 	CreateWithdrawn(ctx context.Context, withdrawn model.Withdrawn) error
 	GetAllWithdrawalsByUserID(ctx context.Context, userID int64) ([]model.Withdrawn, error)
+	ForEachWithdrawalByUserID(ctx context.Context, userID int64, fn func(withdrawn model.Withdrawn) error) error
+	GetWithdrawalsPage(ctx context.Context, filter model.WithdrawalsFilter) (model.WithdrawalsPage, error)
+	GetAllWithdrawalsPages(ctx context.Context, filter model.WithdrawalsFilter) (model.WithdrawalsPage, error)
 }
 
 type WithdrawnServiceImpl struct {
 	withdrawnStorage storage.WithdrawnStorage
+	auditService     AuditService
 	logger           *logger.ServerLogger
 }
 
-func NewWithdrawnService(withdrawnStorage storage.WithdrawnStorage, logger *logger.ServerLogger) WithdrawnService {
+func NewWithdrawnService(withdrawnStorage storage.WithdrawnStorage, auditService AuditService,
+	logger *logger.ServerLogger) WithdrawnService {
 	return &WithdrawnServiceImpl{
 		withdrawnStorage: withdrawnStorage,
+		auditService:     auditService,
 		logger:           logger,
 	}
 }
 
 func (s *WithdrawnServiceImpl) CreateWithdrawn(ctx context.Context, withdrawn model.Withdrawn) error {
-	return s.withdrawnStorage.Save(ctx, withdrawn)
+	err := s.withdrawnStorage.Save(ctx, withdrawn)
+	s.auditService.Record(ctx, model.NewAuditEvent(withdrawn.UserID, auditActor(ctx), model.AuditActionWithdraw,
+		model.AuditOrderTarget(withdrawn.OrderNumber), auditOutcome(err)))
+	if err != nil {
+		return err
+	}
+
+	metrics.PointsWithdrawnTotal.Add(withdrawn.PointsAmount)
+	return nil
 }
 
 func (s *WithdrawnServiceImpl) GetAllWithdrawalsByUserID(ctx context.Context, userID int64) ([]model.Withdrawn, error) {
 	return s.withdrawnStorage.GetAllByUserIDOrderByProcessedAtAsc(ctx, userID)
 }
+
+func (s *WithdrawnServiceImpl) ForEachWithdrawalByUserID(ctx context.Context, userID int64,
+	fn func(withdrawn model.Withdrawn) error) error {
+	return s.withdrawnStorage.ForEachByUserIDOrderByProcessedAtAsc(ctx, userID, fn)
+}
+
+func (s *WithdrawnServiceImpl) GetWithdrawalsPage(ctx context.Context, filter model.WithdrawalsFilter) (model.WithdrawalsPage, error) {
+	page, err := s.getWithdrawalsPage(ctx, filter)
+	if err != nil {
+		return model.WithdrawalsPage{}, err
+	}
+
+	page.TotalCount, err = s.withdrawnStorage.CountByFilter(ctx, withLookahead(filter))
+	if err != nil {
+		return model.WithdrawalsPage{}, err
+	}
+
+	return page, nil
+}
+
+// GetAllWithdrawalsPages fetches every page of filter.Limit withdrawals and counts them once.
+func (s *WithdrawnServiceImpl) GetAllWithdrawalsPages(ctx context.Context,
+	filter model.WithdrawalsFilter) (model.WithdrawalsPage, error) {
+	all := model.WithdrawalsPage{}
+	for next := filter; ; {
+		page, err := s.getWithdrawalsPage(ctx, next)
+		if err != nil {
+			return model.WithdrawalsPage{}, err
+		}
+		all.Withdrawals = append(all.Withdrawals, page.Withdrawals...)
+		if page.NextCursor == nil {
+			break
+		}
+		next.After = page.NextCursor
+	}
+
+	totalCount, err := s.withdrawnStorage.CountByFilter(ctx, withLookahead(filter))
+	if err != nil {
+		return model.WithdrawalsPage{}, err
+	}
+	all.TotalCount = totalCount
+
+	return all, nil
+}
+
+func (s *WithdrawnServiceImpl) getWithdrawalsPage(ctx context.Context,
+	filter model.WithdrawalsFilter) (model.WithdrawalsPage, error) {
+	limit := filter.Limit
+	withdrawals, err := s.withdrawnStorage.GetAllByFilter(ctx, withLookahead(filter))
+	if err != nil {
+		return model.WithdrawalsPage{}, err
+	}
+
+	page := model.WithdrawalsPage{Withdrawals: withdrawals}
+	if int64(len(withdrawals)) > limit {
+		page.Withdrawals = withdrawals[:limit]
+		last := page.Withdrawals[limit-1]
+		page.NextCursor = &model.Cursor{Time: last.ProcessedAt, Key: last.OrderNumber}
+	}
+
+	return page, nil
+}
+
+// withLookahead asks the storage for one extra withdrawal to find out whether there is a next page.
+func withLookahead(filter model.WithdrawalsFilter) model.WithdrawalsFilter {
+	filter.Limit++
+	return filter
+}
@@ -0,0 +1,157 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+
+	"github.com/Stern-Ritter/gophermart/internal/logger"
+	"github.com/Stern-Ritter/gophermart/internal/model"
+)
+
+const accrualEventsChannel = "loyalty_points_accrual_events"
+
+type AccrualEventStorage interface {
+	GetAllByUserIDAfterIDOrderByIDAsc(ctx context.Context, userID int64, afterID int64,
+		limit int64) ([]model.AccrualEvent, error)
+}
+
+type AccrualEventListener interface {
+	Listen(ctx context.Context, handle func(event model.AccrualEvent)) error
+}
+
+type AccrualEventStorageImpl struct {
+	db     PgxIface
+	logger *logger.ServerLogger
+}
+
+func NewAccrualEventStorage(db PgxIface, logger *logger.ServerLogger) AccrualEventStorage {
+	return &AccrualEventStorageImpl{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (s *AccrualEventStorageImpl) GetAllByUserIDAfterIDOrderByIDAsc(ctx context.Context, userID int64, afterID int64,
+	limit int64) ([]model.AccrualEvent, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT
+		    id,
+		    user_id,
+		    order_number,
+		    status,
+		    amount,
+		    processed_at
+		FROM loyalty_points_accrual_events
+		WHERE 
+		    user_id = @userId AND
+		    id > @afterId
+		ORDER BY id
+		LIMIT @limit
+	`, pgx.NamedArgs{
+		"userId":  userID,
+		"afterId": afterID,
+		"limit":   limit,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := make([]model.AccrualEvent, 0)
+
+	for rows.Next() {
+		event := model.AccrualEvent{}
+		var processedAt sql.NullTime
+		if err := rows.Scan(&event.ID, &event.UserID, &event.OrderNumber, &event.Status, &event.PointsAmount,
+			&processedAt); err != nil {
+			return nil, err
+		}
+		if processedAt.Valid {
+			event.ProcessedAt = processedAt.Time
+		}
+
+		events = append(events, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+type accrualEventPayload struct {
+	ID           int64               `json:"id"`
+	UserID       int64               `json:"user_id"`
+	OrderNumber  int64               `json:"order_number"`
+	Status       model.AccrualStatus `json:"status"`
+	PointsAmount float64             `json:"amount"`
+	ProcessedAt  *time.Time          `json:"processed_at"`
+}
+
+type AccrualEventListenerImpl struct {
+	db     *pgxpool.Pool
+	logger *logger.ServerLogger
+}
+
+func NewAccrualEventListener(db *pgxpool.Pool, logger *logger.ServerLogger) AccrualEventListener {
+	return &AccrualEventListenerImpl{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (l *AccrualEventListenerImpl) Listen(ctx context.Context, handle func(event model.AccrualEvent)) error {
+	conn, err := l.db.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	_, err = conn.Exec(ctx, "LISTEN "+accrualEventsChannel)
+	if err != nil {
+		return err
+	}
+	defer conn.Exec(context.Background(), "UNLISTEN "+accrualEventsChannel) //nolint:errcheck
+
+	for {
+		notification, err := conn.Conn().WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+
+		event, err := decodeAccrualEventPayload(notification.Payload)
+		if err != nil {
+			l.logger.Error("Error decoding accrual event notification", zap.String("event", "listen accrual events"),
+				zap.String("payload", notification.Payload), zap.Error(err))
+			continue
+		}
+		handle(event)
+	}
+}
+
+func decodeAccrualEventPayload(payload string) (model.AccrualEvent, error) {
+	dto := accrualEventPayload{}
+	if err := json.Unmarshal([]byte(payload), &dto); err != nil {
+		return model.AccrualEvent{}, err
+	}
+
+	event := model.AccrualEvent{
+		ID:           dto.ID,
+		UserID:       dto.UserID,
+		OrderNumber:  dto.OrderNumber,
+		Status:       dto.Status,
+		PointsAmount: dto.PointsAmount,
+	}
+	if dto.ProcessedAt != nil {
+		event.ProcessedAt = *dto.ProcessedAt
+	}
+	return event, nil
+}
@@ -0,0 +1,90 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pashagolub/pgxmock/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Stern-Ritter/gophermart/internal/logger"
+	"github.com/Stern-Ritter/gophermart/internal/model"
+)
+
+func TestAccrualEventStorageGetAllByUserIDAfterIDOrderByIDAsc(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err, "Error init connection mock")
+	defer mock.Close()
+
+	l, err := logger.Initialize("error")
+	require.NoError(t, err, "Error init logger")
+
+	accrualEventStorage := NewAccrualEventStorage(mock, l)
+
+	processedAt := time.Date(2024, 1, 1, 0, 1, 0, 0, time.UTC)
+	rows := pgxmock.NewRows([]string{"id", "user_id", "order_number", "status", "amount", "processed_at"}).
+		AddRow(int64(5), int64(1), int64(12345678903), model.AccrualProcessing, float64(0), nil).
+		AddRow(int64(7), int64(1), int64(12345678903), model.AccrualProcessed, float64(42), processedAt)
+
+	mock.ExpectQuery("SELECT .* FROM loyalty_points_accrual_events WHERE user_id = @userId AND id > @afterId "+
+		"ORDER BY id LIMIT @limit").
+		WithArgs(int64(1), int64(4), int64(100)).
+		WillReturnRows(rows)
+
+	events, err := accrualEventStorage.GetAllByUserIDAfterIDOrderByIDAsc(context.Background(), 1, 4, 100)
+	assert.NoError(t, err, "Error getting accrual events")
+	assert.Equal(t, []model.AccrualEvent{
+		{ID: 5, UserID: 1, OrderNumber: 12345678903, Status: model.AccrualProcessing},
+		{ID: 7, UserID: 1, OrderNumber: 12345678903, Status: model.AccrualProcessed, PointsAmount: 42,
+			ProcessedAt: processedAt},
+	}, events, "Returned accrual events does not match expected")
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err, "The expected sql commands were not executed")
+}
+
+func TestDecodeAccrualEventPayload(t *testing.T) {
+	tests := []struct {
+		name          string
+		payload       string
+		expectedEvent model.AccrualEvent
+		expectedErr   bool
+	}{
+		{
+			name: "should decode notification payload of processed accrual",
+			payload: `{"id" : 7, "user_id" : 1, "order_number" : 12345678903, "status" : "PROCESSED", ` +
+				`"amount" : 42, "processed_at" : "2024-01-01T03:01:00+03:00"}`,
+			expectedEvent: model.AccrualEvent{ID: 7, UserID: 1, OrderNumber: 12345678903,
+				Status: model.AccrualProcessed, PointsAmount: 42,
+				ProcessedAt: time.Date(2024, 1, 1, 0, 1, 0, 0, time.UTC)},
+		},
+		{
+			name: "should decode notification payload without processed_at",
+			payload: `{"id" : 5, "user_id" : 1, "order_number" : 12345678903, "status" : "PROCESSING", ` +
+				`"amount" : 0, "processed_at" : null}`,
+			expectedEvent: model.AccrualEvent{ID: 5, UserID: 1, OrderNumber: 12345678903,
+				Status: model.AccrualProcessing},
+		},
+		{
+			name:        "should return error when payload is not json",
+			payload:     "event",
+			expectedErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event, err := decodeAccrualEventPayload(tt.payload)
+			if tt.expectedErr {
+				assert.Error(t, err, "Expected decoding error")
+				return
+			}
+			require.NoError(t, err, "Error decoding payload")
+			assert.True(t, tt.expectedEvent.ProcessedAt.Equal(event.ProcessedAt), "Processed at does not match expected")
+			event.ProcessedAt = tt.expectedEvent.ProcessedAt
+			assert.Equal(t, tt.expectedEvent, event, "Decoded event does not match expected")
+		})
+	}
+}
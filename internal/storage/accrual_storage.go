@@ -3,8 +3,11 @@ package storage
 import (
 	"context"
 	"database/sql"
+	"strings"
+	"time"
 
 	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
 
 	"github.com/Stern-Ritter/gophermart/internal/logger"
 	"github.com/Stern-Ritter/gophermart/internal/model"
@@ -12,10 +15,16 @@ import (
 
 type AccrualStorage interface {
 	Save(ctx context.Context, accrual model.Accrual) error
+	SaveInBatch(ctx context.Context, accruals []model.Accrual) ([]model.AccrualBatchItemResult, error)
 	Update(ctx context.Context, accrual model.Accrual) error
 	UpdateInBatch(ctx context.Context, accruals []model.Accrual) error
 	GetAllByUserIDOrderByUploadedAtAsc(ctx context.Context, userID int64) ([]model.Accrual, error)
+	ForEachByUserIDOrderByUploadedAtAsc(ctx context.Context, userID int64, fn func(accrual model.Accrual) error) error
+	GetAllByFilter(ctx context.Context, filter model.AccrualsFilter) ([]model.Accrual, error)
+	GetOneByOrderNumber(ctx context.Context, orderNumber int64) (model.Accrual, error)
 	GetAllUnprocessedWithLimit(ctx context.Context, limit int64) ([]model.Accrual, error)
+	CountByStatus(ctx context.Context) (map[model.AccrualStatus]int64, error)
+	Requeue(ctx context.Context, orderNumber int64) error
 }
 
 type AccrualStorageImpl struct {
@@ -30,8 +39,12 @@ func NewAccrualStorage(db PgxIface, logger *logger.ServerLogger) AccrualStorage
 	}
 }
 
-func (s *AccrualStorageImpl) Save(ctx context.Context, accrual model.Accrual) error {
-	_, err := s.db.Exec(ctx, `
+func (s *AccrualStorageImpl) Save(ctx context.Context, accrual model.Accrual) (err error) {
+	defer func() {
+		LogChange(ctx, s.logger, "save accrual", err, zap.Int64("order number", accrual.OrderNumber))
+	}()
+
+	_, err = s.db.Exec(ctx, `
 		INSERT INTO loyalty_points_accrual 
 		    (user_id, order_number, uploaded_at, status, amount)
 		VALUES (@userId, @orderNumber, @uploadedAt, @status, @amount)
@@ -46,8 +59,90 @@ func (s *AccrualStorageImpl) Save(ctx context.Context, accrual model.Accrual) er
 	return err
 }
 
-func (s *AccrualStorageImpl) Update(ctx context.Context, accrual model.Accrual) error {
-	_, err := s.db.Exec(ctx, `
+func (s *AccrualStorageImpl) SaveInBatch(ctx context.Context,
+	accruals []model.Accrual) (_ []model.AccrualBatchItemResult, err error) {
+	defer func() {
+		LogChange(ctx, s.logger, "save accruals batch", err, zap.Int("count", len(accruals)))
+	}()
+
+	userIDs := make([]int64, len(accruals))
+	orderNumbers := make([]int64, len(accruals))
+	uploadedAts := make([]time.Time, len(accruals))
+	statuses := make([]string, len(accruals))
+	for i, accrual := range accruals {
+		userIDs[i] = accrual.UserID
+		orderNumbers[i] = accrual.OrderNumber
+		uploadedAts[i] = accrual.UploadedAt
+		statuses[i] = string(accrual.Status)
+	}
+
+	rows, err := s.db.Query(ctx, `
+		WITH input AS (
+		    SELECT *
+		    FROM unnest(@userIds::BIGINT[], @orderNumbers::BIGINT[], @uploadedAts::TIMESTAMPTZ[],
+		        @statuses::ACCRUAL_STATUS[]) WITH ORDINALITY AS t(user_id, order_number, uploaded_at, status, position)
+		), inserted AS (
+		    INSERT INTO loyalty_points_accrual
+		        (user_id, order_number, uploaded_at, status)
+		    SELECT user_id, order_number, uploaded_at, status
+		    FROM input
+		    ON CONFLICT DO NOTHING
+		    RETURNING order_number
+		)
+		SELECT
+		    input.order_number,
+		    inserted.order_number IS NOT NULL,
+		    existing.user_id = input.user_id
+		FROM input
+		LEFT JOIN inserted ON inserted.order_number = input.order_number
+		LEFT JOIN loyalty_points_accrual existing ON existing.order_number = input.order_number
+		ORDER BY input.position
+	`, pgx.NamedArgs{
+		"userIds":      userIDs,
+		"orderNumbers": orderNumbers,
+		"uploadedAts":  uploadedAts,
+		"statuses":     statuses,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := make([]model.AccrualBatchItemResult, 0, len(accruals))
+
+	for rows.Next() {
+		result := model.AccrualBatchItemResult{}
+		var inserted bool
+		var uploadedBySameUser sql.NullBool
+		if err := rows.Scan(&result.OrderNumber, &inserted, &uploadedBySameUser); err != nil {
+			return nil, err
+		}
+
+		switch {
+		case inserted:
+			result.Status = model.AccrualBatchItemAccepted
+		case uploadedBySameUser.Valid && uploadedBySameUser.Bool:
+			result.Status = model.AccrualBatchItemAlreadyUploaded
+		default:
+			result.Status = model.AccrualBatchItemConflict
+		}
+		results = append(results, result)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+func (s *AccrualStorageImpl) Update(ctx context.Context, accrual model.Accrual) (err error) {
+	defer func() {
+		LogChange(ctx, s.logger, "update accrual", err, zap.Int64("order number", accrual.OrderNumber))
+	}()
+
+	_, err = s.db.Exec(ctx, `
 		UPDATE loyalty_points_accrual
 		SET processed_at = @processedAt, status = @status, amount = @amount
 		WHERE user_id = @userId AND order_number = @orderNumber
@@ -62,7 +157,11 @@ func (s *AccrualStorageImpl) Update(ctx context.Context, accrual model.Accrual)
 	return err
 }
 
-func (s *AccrualStorageImpl) UpdateInBatch(ctx context.Context, accruals []model.Accrual) error {
+func (s *AccrualStorageImpl) UpdateInBatch(ctx context.Context, accruals []model.Accrual) (err error) {
+	defer func() {
+		LogChange(ctx, s.logger, "update accruals batch", err, zap.Int("count", len(accruals)))
+	}()
+
 	tx, err := s.db.BeginTx(ctx, pgx.TxOptions{})
 	if err != nil {
 		return err
@@ -90,6 +189,21 @@ func (s *AccrualStorageImpl) UpdateInBatch(ctx context.Context, accruals []model
 }
 
 func (s *AccrualStorageImpl) GetAllByUserIDOrderByUploadedAtAsc(ctx context.Context, userID int64) ([]model.Accrual, error) {
+	accruals := make([]model.Accrual, 0)
+
+	err := s.ForEachByUserIDOrderByUploadedAtAsc(ctx, userID, func(accrual model.Accrual) error {
+		accruals = append(accruals, accrual)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return accruals, nil
+}
+
+func (s *AccrualStorageImpl) ForEachByUserIDOrderByUploadedAtAsc(ctx context.Context, userID int64,
+	fn func(accrual model.Accrual) error) error {
 	rows, err := s.db.Query(ctx, `
 		SELECT
 		    user_id,
@@ -106,6 +220,79 @@ func (s *AccrualStorageImpl) GetAllByUserIDOrderByUploadedAtAsc(ctx context.Cont
 		"userId": userID,
 	})
 
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		accrual := model.Accrual{}
+		var processedAt sql.NullTime
+		if err := rows.Scan(&accrual.UserID, &accrual.OrderNumber, &accrual.UploadedAt, &processedAt, &accrual.Status,
+			&accrual.PointsAmount); err != nil {
+			return err
+		}
+		if processedAt.Valid {
+			accrual.ProcessedAt = processedAt.Time
+		}
+
+		if err := fn(accrual); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+func (s *AccrualStorageImpl) GetAllByFilter(ctx context.Context, filter model.AccrualsFilter) ([]model.Accrual, error) {
+	query := strings.Builder{}
+	query.WriteString(`
+		SELECT
+		    user_id,
+			order_number,
+			uploaded_at,
+			processed_at,
+			status,
+			amount
+		FROM loyalty_points_accrual
+		WHERE 
+		    user_id = @userId`)
+	args := pgx.NamedArgs{
+		"userId": filter.UserID,
+		"limit":  filter.Limit,
+	}
+
+	if len(filter.Statuses) > 0 {
+		statuses := make([]string, len(filter.Statuses))
+		for i, status := range filter.Statuses {
+			statuses[i] = string(status)
+		}
+		query.WriteString(" AND\n\t\t    status = ANY(@statuses::accrual_status[])")
+		args["statuses"] = statuses
+	}
+	if !filter.UploadedFrom.IsZero() {
+		query.WriteString(" AND\n\t\t    uploaded_at >= @uploadedFrom")
+		args["uploadedFrom"] = filter.UploadedFrom
+	}
+	if !filter.UploadedTo.IsZero() {
+		query.WriteString(" AND\n\t\t    uploaded_at < @uploadedTo")
+		args["uploadedTo"] = filter.UploadedTo
+	}
+
+	direction := "ASC"
+	comparison := ">"
+	if filter.SortDirection == model.SortDesc {
+		direction = "DESC"
+		comparison = "<"
+	}
+	if filter.After != nil {
+		query.WriteString(" AND\n\t\t    (uploaded_at, order_number) " + comparison + " (@afterUploadedAt, @afterOrderNumber)")
+		args["afterUploadedAt"] = filter.After.Time
+		args["afterOrderNumber"] = filter.After.Key
+	}
+	query.WriteString("\n\t\tORDER BY uploaded_at " + direction + ", order_number " + direction + "\n\t\tLIMIT @limit\n\t")
+
+	rows, err := s.db.Query(ctx, query.String(), args)
 	if err != nil {
 		return nil, err
 	}
@@ -134,7 +321,41 @@ func (s *AccrualStorageImpl) GetAllByUserIDOrderByUploadedAtAsc(ctx context.Cont
 	return accruals, nil
 }
 
-func (s *AccrualStorageImpl) GetAllUnprocessedWithLimit(ctx context.Context, limit int64) ([]model.Accrual, error) {
+func (s *AccrualStorageImpl) GetOneByOrderNumber(ctx context.Context, orderNumber int64) (model.Accrual, error) {
+	row := s.db.QueryRow(ctx, `
+		SELECT
+		    user_id,
+			order_number,
+			uploaded_at,
+			processed_at,
+			status,
+			amount
+		FROM loyalty_points_accrual
+		WHERE
+		    order_number = @orderNumber
+	`, pgx.NamedArgs{
+		"orderNumber": orderNumber,
+	})
+
+	accrual := model.Accrual{}
+	var processedAt sql.NullTime
+	err := row.Scan(&accrual.UserID, &accrual.OrderNumber, &accrual.UploadedAt, &processedAt, &accrual.Status,
+		&accrual.PointsAmount)
+	if err != nil {
+		return model.Accrual{}, err
+	}
+	if processedAt.Valid {
+		accrual.ProcessedAt = processedAt.Time
+	}
+
+	return accrual, nil
+}
+
+func (s *AccrualStorageImpl) GetAllUnprocessedWithLimit(ctx context.Context, limit int64) (_ []model.Accrual, err error) {
+	defer func() {
+		LogChange(ctx, s.logger, "claim unprocessed accruals", err, zap.Int64("limit", limit))
+	}()
+
 	tx, err := s.db.BeginTx(ctx, pgx.TxOptions{})
 	if err != nil {
 		return nil, err
@@ -200,6 +421,54 @@ func (s *AccrualStorageImpl) GetAllUnprocessedWithLimit(ctx context.Context, lim
 	return accruals, nil
 }
 
+func (s *AccrualStorageImpl) CountByStatus(ctx context.Context) (map[model.AccrualStatus]int64, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT status, COUNT(*)
+		FROM loyalty_points_accrual
+		GROUP BY status
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[model.AccrualStatus]int64)
+	for rows.Next() {
+		var status model.AccrualStatus
+		var count int64
+		if err := rows.Scan(&status, &count); err != nil {
+			return nil, err
+		}
+		counts[status] = count
+	}
+
+	return counts, rows.Err()
+}
+
+// Requeue returns an unprocessed order to the NEW status and releases its processing lock, so that the scheduler
+// fetches its accrual again.
+func (s *AccrualStorageImpl) Requeue(ctx context.Context, orderNumber int64) (err error) {
+	defer func() {
+		LogChange(ctx, s.logger, "requeue accrual", err, zap.Int64("order number", orderNumber))
+	}()
+
+	tag, err := s.db.Exec(ctx, `
+		UPDATE loyalty_points_accrual
+		SET status = 'NEW', processing_lock = FALSE, processed_at = NULL, amount = 0
+		WHERE order_number = @orderNumber AND status <> 'PROCESSED'
+	`, pgx.NamedArgs{
+		"orderNumber": orderNumber,
+	})
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+
+	return nil
+}
+
 func updateStatusInBatch(ctx context.Context, tx pgx.Tx, status model.AccrualStatus, processingLock bool,
 	accruals []model.Accrual) error {
 	for _, accrual := range accruals {
@@ -6,6 +6,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/pashagolub/pgxmock/v3"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -223,6 +224,81 @@ func TestAccrualStorageGetAllByUserIDOrderByUploadedAtAsc(t *testing.T) {
 	assert.NoError(t, err, "The expected sql commands were not executed")
 }
 
+func TestAccrualStorageGetOneByOrderNumber(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err, "Error init connection mock")
+	defer mock.Close()
+
+	l, err := logger.Initialize("error")
+	require.NoError(t, err, "Error init logger")
+
+	accrualStorage := NewAccrualStorage(mock, l)
+
+	expectedAccrual := model.Accrual{
+		UserID:       1,
+		OrderNumber:  int64(12345678903),
+		UploadedAt:   time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		ProcessedAt:  time.Date(2024, 1, 1, 0, 1, 0, 0, time.UTC),
+		Status:       model.AccrualProcessed,
+		PointsAmount: 300,
+	}
+
+	rows := pgxmock.NewRows([]string{
+		"user_id",
+		"order_number",
+		"uploaded_at",
+		"processed_at",
+		"status",
+		"amount",
+	}).AddRow(
+		expectedAccrual.UserID,
+		expectedAccrual.OrderNumber,
+		expectedAccrual.UploadedAt,
+		expectedAccrual.ProcessedAt,
+		expectedAccrual.Status,
+		expectedAccrual.PointsAmount,
+	)
+
+	mock.ExpectQuery("SELECT .* FROM loyalty_points_accrual WHERE order_number = @orderNumber").
+		WithArgs(expectedAccrual.OrderNumber).
+		WillReturnRows(rows)
+
+	accrual, err := accrualStorage.GetOneByOrderNumber(context.Background(), expectedAccrual.OrderNumber)
+
+	assert.NoError(t, err, "Error getting accrual by order number")
+	assert.Equal(t, expectedAccrual, accrual, "Returned accrual does not match expected")
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err, "The expected sql commands were not executed")
+}
+
+func TestAccrualStorageCountByStatus(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err, "Error init connection mock")
+	defer mock.Close()
+
+	l, err := logger.Initialize("error")
+	require.NoError(t, err, "Error init logger")
+
+	accrualStorage := NewAccrualStorage(mock, l)
+
+	rows := pgxmock.NewRows([]string{"status", "count"}).
+		AddRow(model.AccrualNew, int64(3)).
+		AddRow(model.AccrualProcessed, int64(7))
+
+	mock.ExpectQuery("SELECT status, COUNT\\(\\*\\) FROM loyalty_points_accrual GROUP BY status").
+		WillReturnRows(rows)
+
+	counts, err := accrualStorage.CountByStatus(context.Background())
+
+	assert.NoError(t, err, "Error counting accruals by status")
+	assert.Equal(t, map[model.AccrualStatus]int64{model.AccrualNew: 3, model.AccrualProcessed: 7}, counts,
+		"Returned counts do not match expected")
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err, "The expected sql commands were not executed")
+}
+
 func TestAccrualStorageGetAllUnprocessedWithLimit(t *testing.T) {
 	mock, err := pgxmock.NewPool()
 	require.NoError(t, err, "Error init connection mock")
@@ -315,3 +391,152 @@ func TestAccrualStorageGetAllUnprocessedWithLimit(t *testing.T) {
 	err = mock.ExpectationsWereMet()
 	assert.NoError(t, err, "The expected sql commands were not executed")
 }
+
+func TestAccrualStorageGetAllByFilter(t *testing.T) {
+	uploadedAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	after := model.Cursor{Time: uploadedAt, Key: 12345678903}
+
+	tests := []struct {
+		name          string
+		filter        model.AccrualsFilter
+		expectedQuery string
+		expectedArgs  []interface{}
+	}{
+		{
+			name:   "should select accruals by user id ordered by uploaded_at asc",
+			filter: model.AccrualsFilter{UserID: 1, SortDirection: model.SortAsc, Limit: 51},
+			expectedQuery: "SELECT .* FROM loyalty_points_accrual WHERE user_id = @userId " +
+				"ORDER BY uploaded_at ASC, order_number ASC LIMIT @limit",
+			expectedArgs: []interface{}{int64(1), int64(51)},
+		},
+		{
+			name: "should apply filters and cursor when they are specified",
+			filter: model.AccrualsFilter{
+				UserID:        1,
+				Statuses:      []model.AccrualStatus{model.AccrualProcessed},
+				UploadedFrom:  uploadedAt,
+				UploadedTo:    uploadedAt.AddDate(0, 1, 0),
+				After:         &after,
+				SortDirection: model.SortDesc,
+				Limit:         11,
+			},
+			expectedQuery: "SELECT .* FROM loyalty_points_accrual WHERE user_id = @userId AND " +
+				"status = ANY\\(@statuses::accrual_status\\[\\]\\) AND uploaded_at >= @uploadedFrom AND " +
+				"uploaded_at < @uploadedTo AND \\(uploaded_at, order_number\\) < \\(@afterUploadedAt, @afterOrderNumber\\) " +
+				"ORDER BY uploaded_at DESC, order_number DESC LIMIT @limit",
+			expectedArgs: []interface{}{int64(1), []string{"PROCESSED"}, uploadedAt, uploadedAt.AddDate(0, 1, 0),
+				uploadedAt, int64(12345678903), int64(11)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock, err := pgxmock.NewPool()
+			require.NoError(t, err, "Error init connection mock")
+			defer mock.Close()
+
+			l, err := logger.Initialize("error")
+			require.NoError(t, err, "Error init logger")
+
+			accrualStorage := NewAccrualStorage(mock, l)
+
+			rows := pgxmock.NewRows([]string{"user_id", "order_number", "uploaded_at", "processed_at", "status", "amount"}).
+				AddRow(int64(1), int64(12345678903), uploadedAt, nil, model.AccrualNew, float64(0))
+
+			mock.ExpectQuery(tt.expectedQuery).
+				WithArgs(tt.expectedArgs...).
+				WillReturnRows(rows)
+
+			accruals, err := accrualStorage.GetAllByFilter(context.Background(), tt.filter)
+			assert.NoError(t, err, "Error getting accruals by filter")
+			assert.Equal(t, []model.Accrual{
+				{UserID: 1, OrderNumber: 12345678903, Status: model.AccrualNew, UploadedAt: uploadedAt},
+			}, accruals, "Returned accruals does not match expected")
+
+			err = mock.ExpectationsWereMet()
+			assert.NoError(t, err, "The expected sql commands were not executed")
+		})
+	}
+}
+
+func TestAccrualStorageSaveInBatch(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err, "Error init connection mock")
+	defer mock.Close()
+
+	l, err := logger.Initialize("error")
+	require.NoError(t, err, "Error init logger")
+
+	accrualStorage := NewAccrualStorage(mock, l)
+
+	uploadedAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	accruals := []model.Accrual{
+		{UserID: 1, OrderNumber: 12345678903, Status: model.AccrualNew, UploadedAt: uploadedAt},
+		{UserID: 1, OrderNumber: 2377225624, Status: model.AccrualNew, UploadedAt: uploadedAt},
+		{UserID: 1, OrderNumber: 4561261212345467, Status: model.AccrualNew, UploadedAt: uploadedAt},
+	}
+
+	rows := pgxmock.NewRows([]string{"order_number", "inserted", "uploaded_by_same_user"}).
+		AddRow(int64(12345678903), true, nil).
+		AddRow(int64(2377225624), false, true).
+		AddRow(int64(4561261212345467), false, false)
+
+	mock.ExpectQuery("WITH input AS \\(.*unnest.*\\), inserted AS \\(.*INSERT INTO loyalty_points_accrual.*"+
+		"ON CONFLICT DO NOTHING.*\\) SELECT .* ORDER BY input.position").
+		WithArgs([]int64{1, 1, 1}, []int64{12345678903, 2377225624, 4561261212345467},
+			[]time.Time{uploadedAt, uploadedAt, uploadedAt}, []string{"NEW", "NEW", "NEW"}).
+		WillReturnRows(rows)
+
+	results, err := accrualStorage.SaveInBatch(context.Background(), accruals)
+	assert.NoError(t, err, "Error saving accruals in batch")
+	assert.Equal(t, []model.AccrualBatchItemResult{
+		{OrderNumber: 12345678903, Status: model.AccrualBatchItemAccepted},
+		{OrderNumber: 2377225624, Status: model.AccrualBatchItemAlreadyUploaded},
+		{OrderNumber: 4561261212345467, Status: model.AccrualBatchItemConflict},
+	}, results, "Returned results does not match expected")
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err, "The expected sql commands were not executed")
+}
+
+func TestAccrualStorageRequeue(t *testing.T) {
+	tests := []struct {
+		name         string
+		rowsAffected int64
+		expectedErr  error
+	}{
+		{
+			name:         "should requeue accrual when order is not processed",
+			rowsAffected: 1,
+		},
+		{
+			name:         "should return no rows error when order not exists or is processed",
+			rowsAffected: 0,
+			expectedErr:  pgx.ErrNoRows,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock, err := pgxmock.NewPool()
+			require.NoError(t, err, "Error init connection mock")
+			defer mock.Close()
+
+			l, err := logger.Initialize("error")
+			require.NoError(t, err, "Error init logger")
+
+			accrualStorage := NewAccrualStorage(mock, l)
+
+			mock.ExpectExec("UPDATE loyalty_points_accrual SET status = 'NEW', processing_lock = FALSE, " +
+				"processed_at = NULL, amount = 0 WHERE order_number = .* AND status <> 'PROCESSED'").
+				WithArgs(int64(12345678903)).
+				WillReturnResult(pgxmock.NewResult("UPDATE", tt.rowsAffected))
+
+			err = accrualStorage.Requeue(context.Background(), 12345678903)
+			assert.ErrorIs(t, err, tt.expectedErr, "Error does not match expected error")
+
+			err = mock.ExpectationsWereMet()
+			assert.NoError(t, err, "The expected sql commands were not executed")
+		})
+	}
+}
@@ -0,0 +1,177 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+
+	er "github.com/Stern-Ritter/gophermart/internal/errors"
+	"github.com/Stern-Ritter/gophermart/internal/logger"
+	"github.com/Stern-Ritter/gophermart/internal/model"
+	"github.com/Stern-Ritter/gophermart/internal/utils"
+)
+
+type AdjustmentStorage interface {
+	Save(ctx context.Context, adjustment model.Adjustment) (model.Adjustment, error)
+	GetAllByUserIDOrderByCreatedAtAsc(ctx context.Context, userID int64) ([]model.Adjustment, error)
+}
+
+type AdjustmentStorageImpl struct {
+	db     PgxIface
+	logger *logger.ServerLogger
+}
+
+func NewAdjustmentStorage(db PgxIface, logger *logger.ServerLogger) AdjustmentStorage {
+	return &AdjustmentStorageImpl{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (s *AdjustmentStorageImpl) Save(ctx context.Context, adjustment model.Adjustment) (_ model.Adjustment, err error) {
+	defer func() {
+		LogChange(ctx, s.logger, "save adjustment", err, zap.Int64("user id", adjustment.UserID))
+	}()
+
+	tx, err := s.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return model.Adjustment{}, err
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck
+
+	if adjustment.PointsAmount < 0 {
+		if err := lockUserByID(ctx, tx, adjustment.UserID); err != nil {
+			return model.Adjustment{}, err
+		}
+		currentPoints, err := getCurrentPointsSumByUserID(ctx, tx, adjustment.UserID)
+		if err != nil {
+			return model.Adjustment{}, err
+		}
+		if utils.Float64Compare(currentPoints, -adjustment.PointsAmount) < 0 {
+			return model.Adjustment{}, er.NewConflictError("Not enough loyalty points to debit", nil)
+		}
+	}
+
+	row := tx.QueryRow(ctx, `
+		INSERT INTO loyalty_points_adjustment
+		    (user_id, admin_id, amount, reason_code, note, created_at)
+		VALUES (@userId, @adminId, @amount, @reasonCode, @note, @createdAt)
+		RETURNING id
+	`, pgx.NamedArgs{
+		"userId":     adjustment.UserID,
+		"adminId":    adjustment.AdminID,
+		"amount":     adjustment.PointsAmount,
+		"reasonCode": adjustment.ReasonCode,
+		"note":       adjustment.Note,
+		"createdAt":  adjustment.CreatedAt,
+	})
+
+	err = row.Scan(&adjustment.ID)
+	if err != nil {
+		return model.Adjustment{}, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return model.Adjustment{}, err
+	}
+
+	return adjustment, nil
+}
+
+func (s *AdjustmentStorageImpl) GetAllByUserIDOrderByCreatedAtAsc(ctx context.Context, userID int64) ([]model.Adjustment, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT
+		    id,
+		    user_id,
+		    admin_id,
+		    amount,
+		    reason_code,
+		    note,
+		    created_at
+		FROM loyalty_points_adjustment
+		WHERE
+		    user_id = @userId
+		ORDER BY created_at
+	`, pgx.NamedArgs{
+		"userId": userID,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	adjustments := make([]model.Adjustment, 0)
+
+	for rows.Next() {
+		adjustment := model.Adjustment{}
+		if err := rows.Scan(&adjustment.ID, &adjustment.UserID, &adjustment.AdminID, &adjustment.PointsAmount,
+			&adjustment.ReasonCode, &adjustment.Note, &adjustment.CreatedAt); err != nil {
+			return nil, err
+		}
+		adjustments = append(adjustments, adjustment)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return adjustments, nil
+}
+
+func getAdjustmentPointsSumByUserID(ctx context.Context, tx pgx.Tx, userID int64) (float64, error) {
+	var adjustmentPoints float64
+
+	row := tx.QueryRow(ctx, `
+		SELECT COALESCE(SUM(amount),0) as adjustment_points
+		FROM loyalty_points_adjustment
+		WHERE
+		    user_id = @userId
+	`, pgx.NamedArgs{
+		"userId": userID,
+	})
+
+	err := row.Scan(&adjustmentPoints)
+	if err != nil {
+		return 0, err
+	}
+
+	return adjustmentPoints, nil
+}
+
+// lockUserByID locks the user row until the end of the transaction, so that concurrent debits of the same user
+// check the balance one after another.
+func lockUserByID(ctx context.Context, tx pgx.Tx, userID int64) error {
+	var id int64
+
+	row := tx.QueryRow(ctx, `
+		SELECT id
+		FROM users
+		WHERE
+		    id = @userId
+		FOR UPDATE
+	`, pgx.NamedArgs{
+		"userId": userID,
+	})
+
+	return row.Scan(&id)
+}
+
+func getCurrentPointsSumByUserID(ctx context.Context, tx pgx.Tx, userID int64) (float64, error) {
+	accrualPoints, err := getAccrualPointsSumByUserID(ctx, tx, userID)
+	if err != nil {
+		return 0, err
+	}
+	withdrawnPoints, err := getWithdrawnPointsSumByUserID(ctx, tx, userID)
+	if err != nil {
+		return 0, err
+	}
+	adjustmentPoints, err := getAdjustmentPointsSumByUserID(ctx, tx, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	return accrualPoints - withdrawnPoints + adjustmentPoints, nil
+}
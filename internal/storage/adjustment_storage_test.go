@@ -0,0 +1,127 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pashagolub/pgxmock/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	er "github.com/Stern-Ritter/gophermart/internal/errors"
+	"github.com/Stern-Ritter/gophermart/internal/logger"
+	"github.com/Stern-Ritter/gophermart/internal/model"
+)
+
+func TestAdjustmentStorageSaveCredit(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err, "Error init connection mock")
+	defer mock.Close()
+
+	l, err := logger.Initialize("error")
+	require.NoError(t, err, "Error init logger")
+
+	adjustmentStorage := NewAdjustmentStorage(mock, l)
+
+	adjustment := model.Adjustment{
+		UserID:       2,
+		AdminID:      1,
+		PointsAmount: 50,
+		ReasonCode:   model.AdjustmentGoodwill,
+		Note:         "sorry",
+		CreatedAt:    time.Now(),
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO loyalty_points_adjustment .* RETURNING id").
+		WithArgs(adjustment.UserID, adjustment.AdminID, adjustment.PointsAmount, adjustment.ReasonCode,
+			adjustment.Note, adjustment.CreatedAt).
+		WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(int64(1)))
+	mock.ExpectCommit()
+
+	saved, err := adjustmentStorage.Save(context.Background(), adjustment)
+	assert.NoError(t, err, "Error saving adjustment")
+	assert.Equal(t, int64(1), saved.ID, "Returned adjustment id does not match expected")
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err, "The expected sql commands were not executed")
+}
+
+func TestAdjustmentStorageSaveDebitWhenLoyaltyPointNotEnough(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err, "Error init connection mock")
+	defer mock.Close()
+
+	l, err := logger.Initialize("error")
+	require.NoError(t, err, "Error init logger")
+
+	adjustmentStorage := NewAdjustmentStorage(mock, l)
+
+	adjustment := model.Adjustment{
+		UserID:       2,
+		AdminID:      1,
+		PointsAmount: -50.1,
+		ReasonCode:   model.AdjustmentFraudCorrection,
+		Note:         "duplicate order",
+		CreatedAt:    time.Now(),
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id FROM users WHERE id = .* FOR UPDATE").
+		WithArgs(adjustment.UserID).
+		WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(adjustment.UserID))
+	mock.ExpectQuery("SELECT COALESCE\\(SUM\\(amount\\),0\\) as accrual_points").
+		WithArgs(adjustment.UserID).
+		WillReturnRows(pgxmock.NewRows([]string{"accrual_points"}).AddRow(100.0))
+	mock.ExpectQuery("SELECT COALESCE\\(SUM\\(amount\\),0\\) as withdrawn_points").
+		WithArgs(adjustment.UserID).
+		WillReturnRows(pgxmock.NewRows([]string{"withdrawn_points"}).AddRow(60.0))
+	mock.ExpectQuery("SELECT COALESCE\\(SUM\\(amount\\),0\\) as adjustment_points").
+		WithArgs(adjustment.UserID).
+		WillReturnRows(pgxmock.NewRows([]string{"adjustment_points"}).AddRow(10.0))
+	mock.ExpectRollback()
+
+	_, err = adjustmentStorage.Save(context.Background(), adjustment)
+	var conflictError er.ConflictError
+	assert.True(t, errors.As(err, &conflictError), "Expected conflict error does not returned")
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err, "The expected sql commands were not executed")
+}
+
+func TestAdjustmentStorageGetAllByUserIDOrderByCreatedAtAsc(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err, "Error init connection mock")
+	defer mock.Close()
+
+	l, err := logger.Initialize("error")
+	require.NoError(t, err, "Error init logger")
+
+	adjustmentStorage := NewAdjustmentStorage(mock, l)
+
+	userID := int64(2)
+	adjustments := []model.Adjustment{
+		{ID: 1, UserID: userID, AdminID: 1, PointsAmount: 50, ReasonCode: model.AdjustmentGoodwill,
+			Note: "sorry", CreatedAt: time.Now()},
+		{ID: 2, UserID: userID, AdminID: 1, PointsAmount: -20, ReasonCode: model.AdjustmentDataCorrection,
+			Note: "typo", CreatedAt: time.Now()},
+	}
+
+	rows := pgxmock.NewRows([]string{"id", "user_id", "admin_id", "amount", "reason_code", "note", "created_at"})
+	for _, a := range adjustments {
+		rows.AddRow(a.ID, a.UserID, a.AdminID, a.PointsAmount, a.ReasonCode, a.Note, a.CreatedAt)
+	}
+
+	mock.ExpectQuery("SELECT .* FROM loyalty_points_adjustment WHERE user_id = .* ORDER BY created_at").
+		WithArgs(userID).
+		WillReturnRows(rows)
+
+	got, err := adjustmentStorage.GetAllByUserIDOrderByCreatedAtAsc(context.Background(), userID)
+	assert.NoError(t, err, "Error getting adjustments")
+	assert.Equal(t, adjustments, got, "Returned adjustments does not match expected")
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err, "The expected sql commands were not executed")
+}
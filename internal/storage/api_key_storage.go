@@ -0,0 +1,172 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+
+	"github.com/Stern-Ritter/gophermart/internal/logger"
+	"github.com/Stern-Ritter/gophermart/internal/model"
+)
+
+type ApiKeyStorage interface {
+	Save(ctx context.Context, apiKey model.ApiKey) (model.ApiKey, error)
+	GetOneActiveByKeyHash(ctx context.Context, keyHash string) (model.ApiKey, error)
+	GetAllActiveByUserIDOrderByCreatedAtAsc(ctx context.Context, userID int64) ([]model.ApiKey, error)
+	Revoke(ctx context.Context, userID int64, id int64) (bool, error)
+}
+
+type ApiKeyStorageImpl struct {
+	db     PgxIface
+	logger *logger.ServerLogger
+}
+
+func NewApiKeyStorage(db PgxIface, logger *logger.ServerLogger) ApiKeyStorage {
+	return &ApiKeyStorageImpl{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (s *ApiKeyStorageImpl) Save(ctx context.Context, apiKey model.ApiKey) (_ model.ApiKey, err error) {
+	defer func() {
+		LogChange(ctx, s.logger, "save api key", err, zap.Int64("user id", apiKey.UserID))
+	}()
+
+	row := s.db.QueryRow(ctx, `
+		INSERT INTO user_api_keys
+		    (user_id, name, prefix, key_hash, scopes, created_at)
+		VALUES (@userId, @name, @prefix, @keyHash, @scopes, @createdAt)
+		RETURNING id
+	`, pgx.NamedArgs{
+		"userId":    apiKey.UserID,
+		"name":      apiKey.Name,
+		"prefix":    apiKey.Prefix,
+		"keyHash":   apiKey.KeyHash,
+		"scopes":    scopesToStrings(apiKey.Scopes),
+		"createdAt": apiKey.CreatedAt,
+	})
+
+	err = row.Scan(&apiKey.ID)
+	if err != nil {
+		return model.ApiKey{}, err
+	}
+
+	return apiKey, nil
+}
+
+func (s *ApiKeyStorageImpl) GetOneActiveByKeyHash(ctx context.Context, keyHash string) (model.ApiKey, error) {
+	row := s.db.QueryRow(ctx, `
+		SELECT
+		    id,
+		    user_id,
+		    name,
+		    prefix,
+		    key_hash,
+		    scopes,
+		    created_at
+		FROM user_api_keys
+		WHERE
+		    key_hash = @keyHash AND
+		    revoked_at IS NULL
+	`, pgx.NamedArgs{
+		"keyHash": keyHash,
+	})
+
+	apiKey := model.ApiKey{}
+	var scopes []string
+	err := row.Scan(&apiKey.ID, &apiKey.UserID, &apiKey.Name, &apiKey.Prefix, &apiKey.KeyHash, &scopes,
+		&apiKey.CreatedAt)
+	if err != nil {
+		return model.ApiKey{}, err
+	}
+	apiKey.Scopes = stringsToScopes(scopes)
+
+	return apiKey, nil
+}
+
+func (s *ApiKeyStorageImpl) GetAllActiveByUserIDOrderByCreatedAtAsc(ctx context.Context, userID int64) ([]model.ApiKey, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT
+		    id,
+		    user_id,
+		    name,
+		    prefix,
+		    key_hash,
+		    scopes,
+		    created_at
+		FROM user_api_keys
+		WHERE
+		    user_id = @userId AND
+		    revoked_at IS NULL
+		ORDER BY created_at
+	`, pgx.NamedArgs{
+		"userId": userID,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	apiKeys := make([]model.ApiKey, 0)
+
+	for rows.Next() {
+		apiKey := model.ApiKey{}
+		var scopes []string
+		if err := rows.Scan(&apiKey.ID, &apiKey.UserID, &apiKey.Name, &apiKey.Prefix, &apiKey.KeyHash, &scopes,
+			&apiKey.CreatedAt); err != nil {
+			return nil, err
+		}
+		apiKey.Scopes = stringsToScopes(scopes)
+		apiKeys = append(apiKeys, apiKey)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return apiKeys, nil
+}
+
+func (s *ApiKeyStorageImpl) Revoke(ctx context.Context, userID int64, id int64) (_ bool, err error) {
+	defer func() {
+		LogChange(ctx, s.logger, "revoke api key", err, zap.Int64("user id", userID), zap.Int64("api key id", id))
+	}()
+
+	tag, err := s.db.Exec(ctx, `
+		UPDATE user_api_keys
+		SET revoked_at = @revokedAt
+		WHERE
+		    id = @id AND
+		    user_id = @userId AND
+		    revoked_at IS NULL
+	`, pgx.NamedArgs{
+		"revokedAt": time.Now(),
+		"id":        id,
+		"userId":    userID,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return tag.RowsAffected() == 1, nil
+}
+
+func scopesToStrings(scopes []model.ApiKeyScope) []string {
+	values := make([]string, len(scopes))
+	for i, scope := range scopes {
+		values[i] = string(scope)
+	}
+	return values
+}
+
+func stringsToScopes(values []string) []model.ApiKeyScope {
+	scopes := make([]model.ApiKeyScope, len(values))
+	for i, value := range values {
+		scopes[i] = model.ApiKeyScope(value)
+	}
+	return scopes
+}
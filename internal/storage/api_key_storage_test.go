@@ -0,0 +1,124 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pashagolub/pgxmock/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Stern-Ritter/gophermart/internal/logger"
+	"github.com/Stern-Ritter/gophermart/internal/model"
+)
+
+func TestApiKeyStorageSave(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err, "Error init connection mock")
+	defer mock.Close()
+
+	l, err := logger.Initialize("error")
+	require.NoError(t, err, "Error init logger")
+
+	apiKeyStorage := NewApiKeyStorage(mock, l)
+
+	apiKey := model.ApiKey{
+		UserID:    1,
+		Name:      "pos",
+		Prefix:    "gm_01234567",
+		KeyHash:   "hash",
+		Scopes:    []model.ApiKeyScope{model.ApiKeyScopeOrdersWrite},
+		CreatedAt: time.Now(),
+	}
+
+	mock.ExpectQuery("INSERT INTO user_api_keys .* RETURNING id").
+		WithArgs(apiKey.UserID, apiKey.Name, apiKey.Prefix, apiKey.KeyHash, []string{"orders:write"}, apiKey.CreatedAt).
+		WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(int64(1)))
+
+	saved, err := apiKeyStorage.Save(context.Background(), apiKey)
+	assert.NoError(t, err, "Error saving api key")
+	assert.Equal(t, int64(1), saved.ID, "Returned api key id does not match expected")
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err, "The expected sql commands were not executed")
+}
+
+func TestApiKeyStorageGetOneActiveByKeyHash(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err, "Error init connection mock")
+	defer mock.Close()
+
+	l, err := logger.Initialize("error")
+	require.NoError(t, err, "Error init logger")
+
+	apiKeyStorage := NewApiKeyStorage(mock, l)
+
+	expectedApiKey := model.ApiKey{
+		ID:        1,
+		UserID:    1,
+		Name:      "pos",
+		Prefix:    "gm_01234567",
+		KeyHash:   "hash",
+		Scopes:    []model.ApiKeyScope{model.ApiKeyScopeOrdersWrite, model.ApiKeyScopeBalanceRead},
+		CreatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	rows := mock.NewRows([]string{"id", "user_id", "name", "prefix", "key_hash", "scopes", "created_at"}).
+		AddRow(expectedApiKey.ID, expectedApiKey.UserID, expectedApiKey.Name, expectedApiKey.Prefix,
+			expectedApiKey.KeyHash, []string{"orders:write", "balance:read"}, expectedApiKey.CreatedAt)
+
+	mock.ExpectQuery("SELECT .* FROM user_api_keys WHERE key_hash = .* AND revoked_at IS NULL").
+		WithArgs(expectedApiKey.KeyHash).
+		WillReturnRows(rows)
+
+	apiKey, err := apiKeyStorage.GetOneActiveByKeyHash(context.Background(), expectedApiKey.KeyHash)
+	assert.NoError(t, err, "Error getting api key by hash")
+	assert.Equal(t, expectedApiKey, apiKey, "Returned api key does not match expected")
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err, "The expected sql commands were not executed")
+}
+
+func TestApiKeyStorageRevoke(t *testing.T) {
+	tests := []struct {
+		name         string
+		rowsAffected int64
+		want         bool
+	}{
+		{
+			name:         "should return true when active api key revoked",
+			rowsAffected: 1,
+			want:         true,
+		},
+		{
+			name:         "should return false when api key not found or already revoked",
+			rowsAffected: 0,
+			want:         false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock, err := pgxmock.NewPool()
+			require.NoError(t, err, "Error init connection mock")
+			defer mock.Close()
+
+			l, err := logger.Initialize("error")
+			require.NoError(t, err, "Error init logger")
+
+			apiKeyStorage := NewApiKeyStorage(mock, l)
+
+			mock.ExpectExec("UPDATE user_api_keys SET revoked_at = .* WHERE id = .* AND user_id = .* AND revoked_at IS NULL").
+				WithArgs(pgxmock.AnyArg(), int64(2), int64(1)).
+				WillReturnResult(pgxmock.NewResult("UPDATE", tt.rowsAffected))
+
+			got, err := apiKeyStorage.Revoke(context.Background(), 1, 2)
+			assert.NoError(t, err, "Error revoking api key")
+			assert.Equal(t, tt.want, got, "Returned value does not match expected")
+
+			err = mock.ExpectationsWereMet()
+			assert.NoError(t, err, "The expected sql commands were not executed")
+		})
+	}
+}
@@ -0,0 +1,220 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+
+	"github.com/Stern-Ritter/gophermart/internal/logger"
+	"github.com/Stern-Ritter/gophermart/internal/model"
+)
+
+type AuditEventStorage interface {
+	Append(ctx context.Context, event model.AuditEvent) (model.AuditEvent, error)
+	GetAllByFilter(ctx context.Context, filter model.AuditEventsFilter) ([]model.AuditEvent, error)
+	ForEachOrderByIDAsc(ctx context.Context, fn func(event model.AuditEvent) error) error
+}
+
+type AuditEventStorageImpl struct {
+	db     PgxIface
+	logger *logger.ServerLogger
+}
+
+func NewAuditEventStorage(db PgxIface, logger *logger.ServerLogger) AuditEventStorage {
+	return &AuditEventStorageImpl{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Append links the event to the last one in the chain. The table lock serializes appends, so that two
+// concurrent events can't both be chained to the same previous hash.
+func (s *AuditEventStorageImpl) Append(ctx context.Context, event model.AuditEvent) (_ model.AuditEvent, err error) {
+	defer func() {
+		LogChange(ctx, s.logger, "append audit event", err, zap.String("action", string(event.Action)))
+	}()
+
+	tx, err := s.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return model.AuditEvent{}, err
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck
+
+	_, err = tx.Exec(ctx, `LOCK TABLE audit_events IN SHARE ROW EXCLUSIVE MODE`)
+	if err != nil {
+		return model.AuditEvent{}, err
+	}
+
+	event.PrevHash = model.AuditGenesisHash
+	err = tx.QueryRow(ctx, `
+		SELECT hash
+		FROM audit_events
+		ORDER BY id DESC
+		LIMIT 1
+	`).Scan(&event.PrevHash)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return model.AuditEvent{}, err
+	}
+	event.Hash = event.ComputeHash()
+
+	actorID := sql.NullInt64{Int64: event.ActorID, Valid: event.ActorID != 0}
+
+	row := tx.QueryRow(ctx, `
+		INSERT INTO audit_events
+		    (actor_id, actor, action, target, ip, user_agent, outcome, created_at, prev_hash, hash)
+		VALUES (@actorId, @actor, @action, @target, @ip, @userAgent, @outcome, @createdAt, @prevHash, @hash)
+		RETURNING id
+	`, pgx.NamedArgs{
+		"actorId":   actorID,
+		"actor":     event.Actor,
+		"action":    event.Action,
+		"target":    event.Target,
+		"ip":        event.IP,
+		"userAgent": event.UserAgent,
+		"outcome":   event.Outcome,
+		"createdAt": event.CreatedAt,
+		"prevHash":  event.PrevHash,
+		"hash":      event.Hash,
+	})
+
+	err = row.Scan(&event.ID)
+	if err != nil {
+		return model.AuditEvent{}, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return model.AuditEvent{}, err
+	}
+
+	return event, nil
+}
+
+func (s *AuditEventStorageImpl) GetAllByFilter(ctx context.Context, filter model.AuditEventsFilter) ([]model.AuditEvent, error) {
+	query := strings.Builder{}
+	query.WriteString(`
+		SELECT
+		    id,
+		    actor_id,
+		    actor,
+		    action,
+		    target,
+		    ip,
+		    user_agent,
+		    outcome,
+		    created_at,
+		    prev_hash,
+		    hash
+		FROM audit_events
+		WHERE 
+		    TRUE`)
+	args := pgx.NamedArgs{
+		"limit": filter.Limit,
+	}
+
+	if filter.Actor != "" {
+		query.WriteString(" AND\n\t\t    actor = @actor")
+		args["actor"] = filter.Actor
+	}
+	if filter.Action != "" {
+		query.WriteString(" AND\n\t\t    action = @action")
+		args["action"] = filter.Action
+	}
+	if !filter.CreatedFrom.IsZero() {
+		query.WriteString(" AND\n\t\t    created_at >= @createdFrom")
+		args["createdFrom"] = filter.CreatedFrom
+	}
+	if !filter.CreatedTo.IsZero() {
+		query.WriteString(" AND\n\t\t    created_at < @createdTo")
+		args["createdTo"] = filter.CreatedTo
+	}
+
+	direction := "ASC"
+	comparison := ">"
+	if filter.SortDirection == model.SortDesc {
+		direction = "DESC"
+		comparison = "<"
+	}
+	if filter.After != nil {
+		query.WriteString(" AND\n\t\t    (created_at, id) " + comparison + " (@afterCreatedAt, @afterId)")
+		args["afterCreatedAt"] = filter.After.Time
+		args["afterId"] = filter.After.Key
+	}
+	query.WriteString("\n\t\tORDER BY created_at " + direction + ", id " + direction + "\n\t\tLIMIT @limit\n\t")
+
+	rows, err := s.db.Query(ctx, query.String(), args)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := make([]model.AuditEvent, 0)
+
+	for rows.Next() {
+		event, err := scanAuditEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+func (s *AuditEventStorageImpl) ForEachOrderByIDAsc(ctx context.Context, fn func(event model.AuditEvent) error) error {
+	rows, err := s.db.Query(ctx, `
+		SELECT
+		    id,
+		    actor_id,
+		    actor,
+		    action,
+		    target,
+		    ip,
+		    user_agent,
+		    outcome,
+		    created_at,
+		    prev_hash,
+		    hash
+		FROM audit_events
+		ORDER BY id
+	`)
+
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		event, err := scanAuditEvent(rows)
+		if err != nil {
+			return err
+		}
+
+		if err := fn(event); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+func scanAuditEvent(rows pgx.Rows) (model.AuditEvent, error) {
+	event := model.AuditEvent{}
+	var actorID sql.NullInt64
+	err := rows.Scan(&event.ID, &actorID, &event.Actor, &event.Action, &event.Target, &event.IP, &event.UserAgent,
+		&event.Outcome, &event.CreatedAt, &event.PrevHash, &event.Hash)
+	if err != nil {
+		return model.AuditEvent{}, err
+	}
+
+	event.ActorID = actorID.Int64
+	return event, nil
+}
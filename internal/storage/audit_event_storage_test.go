@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Stern-Ritter/gophermart/internal/logger"
+	"github.com/Stern-Ritter/gophermart/internal/model"
+)
+
+func TestAuditEventStorageAppend(t *testing.T) {
+	previousHash := "5d41402abc4b2a76b9719d911017c592ae3f0e1a9b8f4c2d7e6a5b4c3d2e1f00"
+
+	tests := []struct {
+		name             string
+		lastHash         string
+		expectedPrevHash string
+	}{
+		{
+			name:             "should chain first event to genesis hash",
+			expectedPrevHash: model.AuditGenesisHash,
+		},
+		{
+			name:             "should chain event to hash of last event",
+			lastHash:         previousHash,
+			expectedPrevHash: previousHash,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock, err := pgxmock.NewPool()
+			require.NoError(t, err, "Error init connection mock")
+			defer mock.Close()
+
+			l, err := logger.Initialize("error")
+			require.NoError(t, err, "Error init logger")
+
+			auditEventStorage := NewAuditEventStorage(mock, l)
+
+			event := model.AuditEvent{
+				Actor:     "user",
+				Action:    model.AuditActionSignIn,
+				Target:    "user",
+				IP:        "192.0.2.1",
+				UserAgent: "curl/8.0",
+				Outcome:   model.AuditOutcomeSuccess,
+				CreatedAt: time.Date(2024, time.June, 1, 12, 0, 0, 0, time.UTC),
+			}
+			expectedEvent := event
+			expectedEvent.PrevHash = tt.expectedPrevHash
+			expectedEvent.Hash = expectedEvent.ComputeHash()
+
+			mock.ExpectBegin()
+			mock.ExpectExec("LOCK TABLE audit_events IN SHARE ROW EXCLUSIVE MODE").
+				WillReturnResult(pgxmock.NewResult("LOCK TABLE", 0))
+			lastHashQuery := mock.ExpectQuery("SELECT hash FROM audit_events ORDER BY id DESC LIMIT 1")
+			if tt.lastHash != "" {
+				lastHashQuery.WillReturnRows(pgxmock.NewRows([]string{"hash"}).AddRow(tt.lastHash))
+			} else {
+				lastHashQuery.WillReturnError(pgx.ErrNoRows)
+			}
+			mock.ExpectQuery("INSERT INTO audit_events .* RETURNING id").
+				WithArgs(sql.NullInt64{}, event.Actor, event.Action, event.Target, event.IP, event.UserAgent, event.Outcome,
+					event.CreatedAt, expectedEvent.PrevHash, expectedEvent.Hash).
+				WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(int64(7)))
+			mock.ExpectCommit()
+
+			saved, err := auditEventStorage.Append(context.Background(), event)
+			require.NoError(t, err, "Error appending audit event")
+			assert.Equal(t, int64(7), saved.ID, "Returned audit event id does not match expected")
+			assert.Equal(t, expectedEvent.PrevHash, saved.PrevHash, "Audit event previous hash does not match expected")
+			assert.Equal(t, expectedEvent.Hash, saved.Hash, "Audit event hash does not match expected")
+
+			err = mock.ExpectationsWereMet()
+			assert.NoError(t, err, "The expected sql commands were not executed")
+		})
+	}
+}
@@ -40,6 +40,10 @@ func (s *BalanceStorageImpl) GetByUserID(ctx context.Context, userID int64) (mod
 	if err != nil {
 		return model.Balance{}, err
 	}
+	adjustmentPoints, err := getAdjustmentPointsSumByUserID(ctx, tx, userID)
+	if err != nil {
+		return model.Balance{}, err
+	}
 
 	err = tx.Commit(ctx)
 	if err != nil {
@@ -48,7 +52,7 @@ func (s *BalanceStorageImpl) GetByUserID(ctx context.Context, userID int64) (mod
 
 	balance := model.Balance{
 		UserID:                userID,
-		CurrentPointsAmount:   accrualPoints - withdrawnPoints,
+		CurrentPointsAmount:   accrualPoints - withdrawnPoints + adjustmentPoints,
 		WithdrawnPointsAmount: withdrawnPoints,
 	}
 
@@ -26,10 +26,11 @@ func TestBalanceStorageGetByUserID(t *testing.T) {
 	userID := int64(1)
 	accrualPoints := 100.0
 	withdrawnPoints := 50.0
+	adjustmentPoints := 25.0
 
 	expectedBalance := model.Balance{
 		UserID:                userID,
-		CurrentPointsAmount:   accrualPoints - withdrawnPoints,
+		CurrentPointsAmount:   accrualPoints - withdrawnPoints + adjustmentPoints,
 		WithdrawnPointsAmount: withdrawnPoints,
 	}
 
@@ -57,6 +58,17 @@ func TestBalanceStorageGetByUserID(t *testing.T) {
 			NewRows([]string{"withdrawn_points"}).
 			AddRow(withdrawnPoints))
 
+	mock.ExpectQuery(`
+		SELECT COALESCE\(SUM\(amount\),0\) as adjustment_points
+		FROM loyalty_points_adjustment
+		WHERE
+		    user_id = \@userId
+	`).
+		WithArgs(userID).
+		WillReturnRows(pgxmock.
+			NewRows([]string{"adjustment_points"}).
+			AddRow(adjustmentPoints))
+
 	mock.ExpectCommit()
 
 	balance, err := balanceStorage.GetByUserID(context.Background(), userID)
@@ -0,0 +1,53 @@
+package storage_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Stern-Ritter/gophermart/internal/logger"
+	"github.com/Stern-Ritter/gophermart/internal/storage"
+	"github.com/Stern-Ritter/gophermart/internal/storage/storagetest"
+	"github.com/Stern-Ritter/gophermart/migrations"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+const testDatabaseURLEnv = "TEST_DATABASE_URI"
+
+// TestConformance runs the shared storage suite against a real Postgres, the database is truncated before every test.
+func TestConformance(t *testing.T) {
+	databaseURL := os.Getenv(testDatabaseURLEnv)
+	if databaseURL == "" {
+		t.Skipf("%s is not set", testDatabaseURLEnv)
+	}
+
+	require.NoError(t, migrations.Migrate(databaseURL, "postgres", "pgx"), "Error migrate database")
+
+	db, err := pgxpool.New(context.Background(), databaseURL)
+	require.NoError(t, err, "Error connect to database")
+	defer db.Close()
+
+	l, err := logger.Initialize("error")
+	require.NoError(t, err, "Error init logger")
+
+	storagetest.Run(t, func(t *testing.T) storagetest.Storages {
+		_, err := db.Exec(context.Background(), `
+			TRUNCATE users, loyalty_points_accrual, loyalty_points_withdrawn, loyalty_points_adjustment,
+			    loyalty_points_accrual_events
+			RESTART IDENTITY CASCADE
+		`)
+		require.NoError(t, err, "Error truncate tables")
+
+		return storagetest.Storages{
+			Users:       storage.NewUserStorage(db, l),
+			Accruals:    storage.NewAccrualStorage(db, l),
+			Withdrawals: storage.NewWithdrawnStorage(db, l),
+			Balances:    storage.NewBalanceStorage(db, l),
+			Adjustments: storage.NewAdjustmentStorage(db, l),
+		}
+	})
+}
@@ -0,0 +1,42 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/Stern-Ritter/gophermart/internal/logger"
+)
+
+type HealthStorage interface {
+	Ping(ctx context.Context) error
+	GetMigrationVersion(ctx context.Context) (int64, error)
+}
+
+type HealthStorageImpl struct {
+	db     PgxIface
+	logger *logger.ServerLogger
+}
+
+func NewHealthStorage(db PgxIface, logger *logger.ServerLogger) HealthStorage {
+	return &HealthStorageImpl{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (s *HealthStorageImpl) Ping(ctx context.Context) error {
+	return s.db.Ping(ctx)
+}
+
+func (s *HealthStorageImpl) GetMigrationVersion(ctx context.Context) (int64, error) {
+	row := s.db.QueryRow(ctx, `
+		SELECT 
+			COALESCE(MAX(version_id), 0)
+		FROM goose_db_version
+		WHERE 
+		    is_applied
+	`)
+
+	var version int64
+	err := row.Scan(&version)
+	return version, err
+}
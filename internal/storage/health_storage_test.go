@@ -0,0 +1,77 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/pashagolub/pgxmock/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Stern-Ritter/gophermart/internal/logger"
+)
+
+func TestHealthStoragePing(t *testing.T) {
+	tests := []struct {
+		name        string
+		pingErr     error
+		expectedErr bool
+	}{
+		{
+			name: "should return nil when database is reachable",
+		},
+		{
+			name:        "should return error when database is unreachable",
+			pingErr:     errors.New("connection refused"),
+			expectedErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock, err := pgxmock.NewPool()
+			require.NoError(t, err, "Error init connection mock")
+			defer mock.Close()
+
+			l, err := logger.Initialize("error")
+			require.NoError(t, err, "Error init logger")
+
+			healthStorage := NewHealthStorage(mock, l)
+
+			mock.ExpectPing().WillReturnError(tt.pingErr)
+
+			err = healthStorage.Ping(context.Background())
+			if tt.expectedErr {
+				assert.Error(t, err, "Ping should return error")
+			} else {
+				assert.NoError(t, err, "Ping should not return error")
+			}
+			assert.NoError(t, mock.ExpectationsWereMet(), "The expected sql commands were not executed")
+		})
+	}
+}
+
+func TestHealthStorageGetMigrationVersion(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err, "Error init connection mock")
+	defer mock.Close()
+
+	l, err := logger.Initialize("error")
+	require.NoError(t, err, "Error init logger")
+
+	healthStorage := NewHealthStorage(mock, l)
+
+	mock.ExpectQuery(`
+		SELECT 
+			COALESCE\(MAX\(version_id\), 0\)
+		FROM goose_db_version
+		WHERE 
+		    is_applied
+	`).WillReturnRows(pgxmock.NewRows([]string{"version"}).AddRow(int64(8)))
+
+	version, err := healthStorage.GetMigrationVersion(context.Background())
+	require.NoError(t, err, "Error getting migration version")
+	assert.Equal(t, int64(8), version, "Migration version does not match expected version")
+	assert.NoError(t, mock.ExpectationsWereMet(), "The expected sql commands were not executed")
+}
@@ -0,0 +1,19 @@
+package storage
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+
+	"github.com/Stern-Ritter/gophermart/internal/logger"
+)
+
+// LogChange records the outcome of a state changing storage operation, correlated with the request that caused it.
+func LogChange(ctx context.Context, l *logger.ServerLogger, event string, err error, fields ...zap.Field) {
+	fields = append(fields, zap.String("event", event))
+	if err != nil {
+		l.WithContext(ctx).Debug("Storage operation failed", append(fields, zap.Error(err))...)
+		return
+	}
+	l.WithContext(ctx).Debug("Storage operation done", fields...)
+}
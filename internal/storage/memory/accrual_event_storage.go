@@ -0,0 +1,78 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/Stern-Ritter/gophermart/internal/logger"
+	"github.com/Stern-Ritter/gophermart/internal/model"
+	"github.com/Stern-Ritter/gophermart/internal/storage"
+)
+
+// accrualEventsBufferSize bounds the events waiting for a slow listener, further events are dropped like
+// notifications of a lost Postgres connection.
+const accrualEventsBufferSize = 256
+
+type AccrualEventStorageImpl struct {
+	db     *DB
+	logger *logger.ServerLogger
+}
+
+func NewAccrualEventStorage(db *DB, logger *logger.ServerLogger) storage.AccrualEventStorage {
+	return &AccrualEventStorageImpl{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (s *AccrualEventStorageImpl) GetAllByUserIDAfterIDOrderByIDAsc(ctx context.Context, userID int64, afterID int64,
+	limit int64) ([]model.AccrualEvent, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	events := make([]model.AccrualEvent, 0)
+	for _, event := range s.db.accrualEvents {
+		if int64(len(events)) >= limit {
+			break
+		}
+		if event.UserID == userID && event.ID > afterID {
+			events = append(events, event)
+		}
+	}
+
+	return events, nil
+}
+
+type AccrualEventListenerImpl struct {
+	db     *DB
+	logger *logger.ServerLogger
+}
+
+func NewAccrualEventListener(db *DB, logger *logger.ServerLogger) storage.AccrualEventListener {
+	return &AccrualEventListenerImpl{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (l *AccrualEventListenerImpl) Listen(ctx context.Context, handle func(event model.AccrualEvent)) error {
+	ch := make(chan model.AccrualEvent, accrualEventsBufferSize)
+
+	l.db.listenersMu.Lock()
+	l.db.listeners[ch] = struct{}{}
+	l.db.listenersMu.Unlock()
+
+	defer func() {
+		l.db.listenersMu.Lock()
+		delete(l.db.listeners, ch)
+		l.db.listenersMu.Unlock()
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case event := <-ch:
+			handle(event)
+		}
+	}
+}
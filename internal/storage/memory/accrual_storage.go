@@ -0,0 +1,282 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+
+	"github.com/Stern-Ritter/gophermart/internal/logger"
+	"github.com/Stern-Ritter/gophermart/internal/model"
+	"github.com/Stern-Ritter/gophermart/internal/storage"
+	"github.com/Stern-Ritter/gophermart/internal/utils"
+)
+
+type AccrualStorageImpl struct {
+	db     *DB
+	logger *logger.ServerLogger
+}
+
+func NewAccrualStorage(db *DB, logger *logger.ServerLogger) storage.AccrualStorage {
+	return &AccrualStorageImpl{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (s *AccrualStorageImpl) Save(ctx context.Context, accrual model.Accrual) (err error) {
+	defer func() {
+		storage.LogChange(ctx, s.logger, "save accrual", err, zap.Int64("order number", accrual.OrderNumber))
+	}()
+
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	return s.insert(accrual)
+}
+
+// insert checks the constraints of the accruals table in the order Postgres does, the caller holds the lock.
+func (s *AccrualStorageImpl) insert(accrual model.Accrual) error {
+	if i, ok := s.indexByOrderNumber(accrual.OrderNumber); ok {
+		if s.db.accruals[i].accrual.UserID == accrual.UserID {
+			return uniqueViolation("loyalty_points_accrual", "pk_loyalty_points_accrual")
+		}
+		return uniqueViolation("loyalty_points_accrual", "loyalty_points_accrual_order_number_unique")
+	}
+	if !s.db.userExists(accrual.UserID) {
+		return foreignKeyViolation("loyalty_points_accrual", "loyalty_points_accrual_to_users_fk")
+	}
+
+	accrual.ProcessedAt = time.Time{}
+	s.db.accruals = append(s.db.accruals, accrualRow{accrual: accrual})
+	return nil
+}
+
+func (s *AccrualStorageImpl) SaveInBatch(ctx context.Context,
+	accruals []model.Accrual) (_ []model.AccrualBatchItemResult, err error) {
+	defer func() {
+		storage.LogChange(ctx, s.logger, "save accruals batch", err, zap.Int("count", len(accruals)))
+	}()
+
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	for _, accrual := range accruals {
+		if _, ok := s.indexByOrderNumber(accrual.OrderNumber); !ok && !s.db.userExists(accrual.UserID) {
+			return nil, foreignKeyViolation("loyalty_points_accrual", "loyalty_points_accrual_to_users_fk")
+		}
+	}
+
+	results := make([]model.AccrualBatchItemResult, 0, len(accruals))
+	for _, accrual := range accruals {
+		result := model.AccrualBatchItemResult{OrderNumber: accrual.OrderNumber}
+
+		i, ok := s.indexByOrderNumber(accrual.OrderNumber)
+		switch {
+		case !ok:
+			accrual.PointsAmount = 0
+			_ = s.insert(accrual)
+			result.Status = model.AccrualBatchItemAccepted
+		case s.db.accruals[i].accrual.UserID == accrual.UserID:
+			result.Status = model.AccrualBatchItemAlreadyUploaded
+		default:
+			result.Status = model.AccrualBatchItemConflict
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+func (s *AccrualStorageImpl) Update(ctx context.Context, accrual model.Accrual) (err error) {
+	defer func() {
+		storage.LogChange(ctx, s.logger, "update accrual", err, zap.Int64("order number", accrual.OrderNumber))
+	}()
+
+	s.db.mu.Lock()
+	var events []model.AccrualEvent
+	if i, ok := s.indexByUserIDAndOrderNumber(accrual.UserID, accrual.OrderNumber); ok {
+		events = s.db.updateAccrual(i, accrual.Status, accrual.PointsAmount, accrual.ProcessedAt,
+			s.db.accruals[i].processingLock)
+	}
+	s.db.mu.Unlock()
+
+	s.db.publish(events)
+	return nil
+}
+
+func (s *AccrualStorageImpl) UpdateInBatch(ctx context.Context, accruals []model.Accrual) (err error) {
+	defer func() {
+		storage.LogChange(ctx, s.logger, "update accruals batch", err, zap.Int("count", len(accruals)))
+	}()
+
+	s.db.mu.Lock()
+	events := make([]model.AccrualEvent, 0)
+	for _, accrual := range accruals {
+		if i, ok := s.indexByUserIDAndOrderNumber(accrual.UserID, accrual.OrderNumber); ok {
+			events = append(events, s.db.updateAccrual(i, accrual.Status, accrual.PointsAmount, accrual.ProcessedAt,
+				false)...)
+		}
+	}
+	s.db.mu.Unlock()
+
+	s.db.publish(events)
+	return nil
+}
+
+func (s *AccrualStorageImpl) GetAllByUserIDOrderByUploadedAtAsc(ctx context.Context, userID int64) ([]model.Accrual, error) {
+	accruals := make([]model.Accrual, 0)
+
+	err := s.ForEachByUserIDOrderByUploadedAtAsc(ctx, userID, func(accrual model.Accrual) error {
+		accruals = append(accruals, accrual)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return accruals, nil
+}
+
+func (s *AccrualStorageImpl) ForEachByUserIDOrderByUploadedAtAsc(ctx context.Context, userID int64,
+	fn func(accrual model.Accrual) error) error {
+	s.db.mu.RLock()
+	accruals := make([]model.Accrual, 0)
+	for _, row := range s.db.accruals {
+		if row.accrual.UserID == userID {
+			accruals = append(accruals, row.accrual)
+		}
+	}
+	s.db.mu.RUnlock()
+
+	sort.SliceStable(accruals, func(i, j int) bool {
+		return accruals[i].UploadedAt.Before(accruals[j].UploadedAt)
+	})
+
+	for _, accrual := range accruals {
+		if err := fn(accrual); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *AccrualStorageImpl) GetAllByFilter(ctx context.Context, filter model.AccrualsFilter) ([]model.Accrual, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	statuses := make([]string, len(filter.Statuses))
+	for i, status := range filter.Statuses {
+		statuses[i] = string(status)
+	}
+
+	accruals := make([]model.Accrual, 0)
+	for _, row := range s.db.accruals {
+		accrual := row.accrual
+		if accrual.UserID != filter.UserID ||
+			len(statuses) > 0 && !utils.Contains(statuses, string(accrual.Status)) ||
+			!filter.UploadedFrom.IsZero() && accrual.UploadedAt.Before(filter.UploadedFrom) ||
+			!filter.UploadedTo.IsZero() && !accrual.UploadedAt.Before(filter.UploadedTo) {
+			continue
+		}
+		accruals = append(accruals, accrual)
+	}
+
+	return page(accruals, func(accrual model.Accrual) (time.Time, int64) {
+		return accrual.UploadedAt, accrual.OrderNumber
+	}, filter.After, filter.SortDirection, filter.Limit), nil
+}
+
+func (s *AccrualStorageImpl) GetOneByOrderNumber(ctx context.Context, orderNumber int64) (model.Accrual, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	i, ok := s.indexByOrderNumber(orderNumber)
+	if !ok {
+		return model.Accrual{}, pgx.ErrNoRows
+	}
+
+	return s.db.accruals[i].accrual, nil
+}
+
+func (s *AccrualStorageImpl) GetAllUnprocessedWithLimit(ctx context.Context, limit int64) (_ []model.Accrual, err error) {
+	defer func() {
+		storage.LogChange(ctx, s.logger, "claim unprocessed accruals", err, zap.Int64("limit", limit))
+	}()
+
+	s.db.mu.Lock()
+	indexes := make([]int, 0)
+	for i, row := range s.db.accruals {
+		if (row.accrual.Status == model.AccrualNew || row.accrual.Status == model.AccrualProcessing) &&
+			!row.processingLock {
+			indexes = append(indexes, i)
+		}
+	}
+	sort.SliceStable(indexes, func(i, j int) bool {
+		return s.db.accruals[indexes[i]].accrual.UploadedAt.Before(s.db.accruals[indexes[j]].accrual.UploadedAt)
+	})
+	indexes = indexes[:min(int64(len(indexes)), limit)]
+
+	accruals := make([]model.Accrual, 0, len(indexes))
+	events := make([]model.AccrualEvent, 0)
+	for _, i := range indexes {
+		row := s.db.accruals[i]
+		events = append(events, s.db.updateAccrual(i, model.AccrualProcessing, row.accrual.PointsAmount,
+			row.accrual.ProcessedAt, true)...)
+		accruals = append(accruals, s.db.accruals[i].accrual)
+	}
+	s.db.mu.Unlock()
+
+	s.db.publish(events)
+	return accruals, nil
+}
+
+func (s *AccrualStorageImpl) CountByStatus(ctx context.Context) (map[model.AccrualStatus]int64, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	counts := make(map[model.AccrualStatus]int64)
+	for _, row := range s.db.accruals {
+		counts[row.accrual.Status]++
+	}
+
+	return counts, nil
+}
+
+func (s *AccrualStorageImpl) Requeue(ctx context.Context, orderNumber int64) (err error) {
+	defer func() {
+		storage.LogChange(ctx, s.logger, "requeue accrual", err, zap.Int64("order number", orderNumber))
+	}()
+
+	s.db.mu.Lock()
+	i, ok := s.indexByOrderNumber(orderNumber)
+	if !ok || s.db.accruals[i].accrual.Status == model.AccrualProcessed {
+		s.db.mu.Unlock()
+		return pgx.ErrNoRows
+	}
+	events := s.db.updateAccrual(i, model.AccrualNew, 0, time.Time{}, false)
+	s.db.mu.Unlock()
+
+	s.db.publish(events)
+	return nil
+}
+
+func (s *AccrualStorageImpl) indexByOrderNumber(orderNumber int64) (int, bool) {
+	for i, row := range s.db.accruals {
+		if row.accrual.OrderNumber == orderNumber {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+func (s *AccrualStorageImpl) indexByUserIDAndOrderNumber(userID int64, orderNumber int64) (int, bool) {
+	i, ok := s.indexByOrderNumber(orderNumber)
+	if !ok || s.db.accruals[i].accrual.UserID != userID {
+		return 0, false
+	}
+	return i, true
+}
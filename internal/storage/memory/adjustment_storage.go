@@ -0,0 +1,70 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"go.uber.org/zap"
+
+	er "github.com/Stern-Ritter/gophermart/internal/errors"
+	"github.com/Stern-Ritter/gophermart/internal/logger"
+	"github.com/Stern-Ritter/gophermart/internal/model"
+	"github.com/Stern-Ritter/gophermart/internal/storage"
+	"github.com/Stern-Ritter/gophermart/internal/utils"
+)
+
+type AdjustmentStorageImpl struct {
+	db     *DB
+	logger *logger.ServerLogger
+}
+
+func NewAdjustmentStorage(db *DB, logger *logger.ServerLogger) storage.AdjustmentStorage {
+	return &AdjustmentStorageImpl{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (s *AdjustmentStorageImpl) Save(ctx context.Context, adjustment model.Adjustment) (_ model.Adjustment, err error) {
+	defer func() {
+		storage.LogChange(ctx, s.logger, "save adjustment", err, zap.Int64("user id", adjustment.UserID))
+	}()
+
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if adjustment.PointsAmount < 0 &&
+		utils.Float64Compare(s.db.currentPointsByUserID(adjustment.UserID), -adjustment.PointsAmount) < 0 {
+		return model.Adjustment{}, er.NewConflictError("Not enough loyalty points to debit", nil)
+	}
+	if !s.db.userExists(adjustment.UserID) {
+		return model.Adjustment{}, foreignKeyViolation("loyalty_points_adjustment",
+			"loyalty_points_adjustment_to_users_fk")
+	}
+	if !s.db.userExists(adjustment.AdminID) {
+		return model.Adjustment{}, foreignKeyViolation("loyalty_points_adjustment",
+			"loyalty_points_adjustment_admin_to_users_fk")
+	}
+
+	adjustment.ID = int64(len(s.db.adjustments) + 1)
+	s.db.adjustments = append(s.db.adjustments, adjustment)
+
+	return adjustment, nil
+}
+
+func (s *AdjustmentStorageImpl) GetAllByUserIDOrderByCreatedAtAsc(ctx context.Context, userID int64) ([]model.Adjustment, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	adjustments := make([]model.Adjustment, 0)
+	for _, adjustment := range s.db.adjustments {
+		if adjustment.UserID == userID {
+			adjustments = append(adjustments, adjustment)
+		}
+	}
+	sort.SliceStable(adjustments, func(i, j int) bool {
+		return adjustments[i].CreatedAt.Before(adjustments[j].CreatedAt)
+	})
+
+	return adjustments, nil
+}
@@ -0,0 +1,106 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+
+	"github.com/Stern-Ritter/gophermart/internal/logger"
+	"github.com/Stern-Ritter/gophermart/internal/model"
+	"github.com/Stern-Ritter/gophermart/internal/storage"
+)
+
+type ApiKeyStorageImpl struct {
+	db     *DB
+	logger *logger.ServerLogger
+}
+
+func NewApiKeyStorage(db *DB, logger *logger.ServerLogger) storage.ApiKeyStorage {
+	return &ApiKeyStorageImpl{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (s *ApiKeyStorageImpl) Save(ctx context.Context, apiKey model.ApiKey) (_ model.ApiKey, err error) {
+	defer func() {
+		storage.LogChange(ctx, s.logger, "save api key", err, zap.Int64("user id", apiKey.UserID))
+	}()
+
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	for _, row := range s.db.apiKeys {
+		if row.apiKey.KeyHash == apiKey.KeyHash {
+			return model.ApiKey{}, uniqueViolation("user_api_keys", "user_api_keys_key_hash_unique")
+		}
+		if row.apiKey.UserID == apiKey.UserID && row.apiKey.Name == apiKey.Name && row.revokedAt.IsZero() {
+			return model.ApiKey{}, uniqueViolation("user_api_keys", "user_api_keys_user_id_name_active_idx")
+		}
+	}
+	if !s.db.userExists(apiKey.UserID) {
+		return model.ApiKey{}, foreignKeyViolation("user_api_keys", "user_api_keys_to_users_fk")
+	}
+
+	apiKey.ID = int64(len(s.db.apiKeys) + 1)
+	apiKey.Scopes = append([]model.ApiKeyScope{}, apiKey.Scopes...)
+	s.db.apiKeys = append(s.db.apiKeys, apiKeyRow{apiKey: apiKey})
+
+	return apiKey, nil
+}
+
+func (s *ApiKeyStorageImpl) GetOneActiveByKeyHash(ctx context.Context, keyHash string) (model.ApiKey, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	for _, row := range s.db.apiKeys {
+		if row.apiKey.KeyHash == keyHash && row.revokedAt.IsZero() {
+			return copyApiKey(row.apiKey), nil
+		}
+	}
+
+	return model.ApiKey{}, pgx.ErrNoRows
+}
+
+func (s *ApiKeyStorageImpl) GetAllActiveByUserIDOrderByCreatedAtAsc(ctx context.Context, userID int64) ([]model.ApiKey, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	apiKeys := make([]model.ApiKey, 0)
+	for _, row := range s.db.apiKeys {
+		if row.apiKey.UserID == userID && row.revokedAt.IsZero() {
+			apiKeys = append(apiKeys, copyApiKey(row.apiKey))
+		}
+	}
+	sort.SliceStable(apiKeys, func(i, j int) bool {
+		return apiKeys[i].CreatedAt.Before(apiKeys[j].CreatedAt)
+	})
+
+	return apiKeys, nil
+}
+
+func (s *ApiKeyStorageImpl) Revoke(ctx context.Context, userID int64, id int64) (_ bool, err error) {
+	defer func() {
+		storage.LogChange(ctx, s.logger, "revoke api key", err, zap.Int64("user id", userID), zap.Int64("api key id", id))
+	}()
+
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	for i, row := range s.db.apiKeys {
+		if row.apiKey.ID == id && row.apiKey.UserID == userID && row.revokedAt.IsZero() {
+			s.db.apiKeys[i].revokedAt = time.Now()
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func copyApiKey(apiKey model.ApiKey) model.ApiKey {
+	apiKey.Scopes = append([]model.ApiKeyScope{}, apiKey.Scopes...)
+	return apiKey
+}
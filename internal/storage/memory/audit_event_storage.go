@@ -0,0 +1,77 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Stern-Ritter/gophermart/internal/logger"
+	"github.com/Stern-Ritter/gophermart/internal/model"
+	"github.com/Stern-Ritter/gophermart/internal/storage"
+)
+
+type AuditEventStorageImpl struct {
+	db     *DB
+	logger *logger.ServerLogger
+}
+
+func NewAuditEventStorage(db *DB, logger *logger.ServerLogger) storage.AuditEventStorage {
+	return &AuditEventStorageImpl{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (s *AuditEventStorageImpl) Append(ctx context.Context, event model.AuditEvent) (_ model.AuditEvent, err error) {
+	defer func() {
+		storage.LogChange(ctx, s.logger, "append audit event", err, zap.String("action", string(event.Action)))
+	}()
+
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	event.PrevHash = model.AuditGenesisHash
+	if len(s.db.auditEvents) > 0 {
+		event.PrevHash = s.db.auditEvents[len(s.db.auditEvents)-1].Hash
+	}
+	event.Hash = event.ComputeHash()
+	event.ID = int64(len(s.db.auditEvents) + 1)
+	s.db.auditEvents = append(s.db.auditEvents, event)
+
+	return event, nil
+}
+
+func (s *AuditEventStorageImpl) GetAllByFilter(ctx context.Context, filter model.AuditEventsFilter) ([]model.AuditEvent, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	events := make([]model.AuditEvent, 0)
+	for _, event := range s.db.auditEvents {
+		if filter.Actor != "" && event.Actor != filter.Actor ||
+			filter.Action != "" && event.Action != filter.Action ||
+			!filter.CreatedFrom.IsZero() && event.CreatedAt.Before(filter.CreatedFrom) ||
+			!filter.CreatedTo.IsZero() && !event.CreatedAt.Before(filter.CreatedTo) {
+			continue
+		}
+		events = append(events, event)
+	}
+
+	return page(events, func(event model.AuditEvent) (time.Time, int64) {
+		return event.CreatedAt, event.ID
+	}, filter.After, filter.SortDirection, filter.Limit), nil
+}
+
+func (s *AuditEventStorageImpl) ForEachOrderByIDAsc(ctx context.Context, fn func(event model.AuditEvent) error) error {
+	s.db.mu.RLock()
+	events := append([]model.AuditEvent{}, s.db.auditEvents...)
+	s.db.mu.RUnlock()
+
+	for _, event := range events {
+		if err := fn(event); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
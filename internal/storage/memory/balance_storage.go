@@ -0,0 +1,36 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/Stern-Ritter/gophermart/internal/logger"
+	"github.com/Stern-Ritter/gophermart/internal/model"
+	"github.com/Stern-Ritter/gophermart/internal/storage"
+)
+
+type BalanceStorageImpl struct {
+	db     *DB
+	logger *logger.ServerLogger
+}
+
+func NewBalanceStorage(db *DB, logger *logger.ServerLogger) storage.BalanceStorage {
+	return &BalanceStorageImpl{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (s *BalanceStorageImpl) GetByUserID(ctx context.Context, userID int64) (model.Balance, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	accrued, withdrawn, adjusted := s.db.pointsSumByUserID(userID)
+
+	balance := model.Balance{
+		UserID:                userID,
+		CurrentPointsAmount:   accrued - withdrawn + adjusted,
+		WithdrawnPointsAmount: withdrawn,
+	}
+
+	return balance, nil
+}
@@ -0,0 +1,26 @@
+package memory
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Stern-Ritter/gophermart/internal/logger"
+	"github.com/Stern-Ritter/gophermart/internal/storage/storagetest"
+)
+
+func TestConformance(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storagetest.Storages {
+		l, err := logger.Initialize("error")
+		require.NoError(t, err, "Error init logger")
+
+		db := NewDB()
+		return storagetest.Storages{
+			Users:       NewUserStorage(db, l),
+			Accruals:    NewAccrualStorage(db, l),
+			Withdrawals: NewWithdrawnStorage(db, l),
+			Balances:    NewBalanceStorage(db, l),
+			Adjustments: NewAdjustmentStorage(db, l),
+		}
+	})
+}
@@ -0,0 +1,201 @@
+package memory
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/Stern-Ritter/gophermart/internal/model"
+)
+
+const (
+	uniqueViolationCode     = "23505"
+	foreignKeyViolationCode = "23503"
+)
+
+// DB holds the tables of the in-memory storage. The storages built on it report missing rows and constraint
+// violations the way Postgres does, so that services handle both backends the same way. The data lives as long
+// as the process.
+type DB struct {
+	mu sync.RWMutex
+
+	users              []model.User
+	accruals           []accrualRow
+	withdrawals        []model.Withdrawn
+	adjustments        []model.Adjustment
+	totps              map[int64]model.Totp
+	totpFailedAttempts map[int64]totpFailedAttempts
+	recoveryCodes      map[int64]map[string]bool
+	apiKeys            []apiKeyRow
+	auditEvents        []model.AuditEvent
+	accrualEvents      []model.AccrualEvent
+
+	listenersMu sync.Mutex
+	listeners   map[chan model.AccrualEvent]struct{}
+}
+
+type accrualRow struct {
+	accrual        model.Accrual
+	processingLock bool
+}
+
+type totpFailedAttempts struct {
+	count         int64
+	lastAttemptAt time.Time
+}
+
+type apiKeyRow struct {
+	apiKey    model.ApiKey
+	revokedAt time.Time
+}
+
+func NewDB() *DB {
+	return &DB{
+		totps:              make(map[int64]model.Totp),
+		totpFailedAttempts: make(map[int64]totpFailedAttempts),
+		recoveryCodes:      make(map[int64]map[string]bool),
+		listeners:          make(map[chan model.AccrualEvent]struct{}),
+	}
+}
+
+func (db *DB) userExists(id int64) bool {
+	for _, user := range db.users {
+		if user.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// pointsSumByUserID returns the accrued, withdrawn and adjusted points of the user, the caller holds the lock.
+func (db *DB) pointsSumByUserID(userID int64) (accrued float64, withdrawn float64, adjusted float64) {
+	for _, row := range db.accruals {
+		if row.accrual.UserID == userID && row.accrual.Status == model.AccrualProcessed {
+			accrued += row.accrual.PointsAmount
+		}
+	}
+	for _, w := range db.withdrawals {
+		if w.UserID == userID {
+			withdrawn += w.PointsAmount
+		}
+	}
+	for _, adjustment := range db.adjustments {
+		if adjustment.UserID == userID {
+			adjusted += adjustment.PointsAmount
+		}
+	}
+	return accrued, withdrawn, adjusted
+}
+
+func (db *DB) currentPointsByUserID(userID int64) float64 {
+	accrued, withdrawn, adjusted := db.pointsSumByUserID(userID)
+	return accrued - withdrawn + adjusted
+}
+
+// updateAccrual changes the accrual row and records an accrual event when its status or amount changed, as the
+// Postgres trigger does. The caller holds the lock and publishes the returned events after releasing it.
+func (db *DB) updateAccrual(i int, status model.AccrualStatus, amount float64, processedAt time.Time,
+	processingLock bool) []model.AccrualEvent {
+	row := &db.accruals[i]
+	changed := row.accrual.Status != status || row.accrual.PointsAmount != amount
+
+	row.accrual.Status = status
+	row.accrual.PointsAmount = amount
+	row.accrual.ProcessedAt = processedAt
+	row.processingLock = processingLock
+
+	if !changed {
+		return nil
+	}
+	event := model.AccrualEvent{
+		ID:           int64(len(db.accrualEvents) + 1),
+		UserID:       row.accrual.UserID,
+		OrderNumber:  row.accrual.OrderNumber,
+		Status:       status,
+		PointsAmount: amount,
+		ProcessedAt:  processedAt,
+	}
+	db.accrualEvents = append(db.accrualEvents, event)
+	return []model.AccrualEvent{event}
+}
+
+func (db *DB) publish(events []model.AccrualEvent) {
+	db.listenersMu.Lock()
+	defer db.listenersMu.Unlock()
+
+	for _, event := range events {
+		for ch := range db.listeners {
+			select {
+			case ch <- event:
+			default:
+			}
+		}
+	}
+}
+
+func uniqueViolation(table string, constraint string) error {
+	return &pgconn.PgError{
+		Severity:       "ERROR",
+		Code:           uniqueViolationCode,
+		Message:        fmt.Sprintf("duplicate key value violates unique constraint %q", constraint),
+		TableName:      table,
+		ConstraintName: constraint,
+	}
+}
+
+func foreignKeyViolation(table string, constraint string) error {
+	return &pgconn.PgError{
+		Severity:       "ERROR",
+		Code:           foreignKeyViolationCode,
+		Message:        fmt.Sprintf("insert or update on table %q violates foreign key constraint %q", table, constraint),
+		TableName:      table,
+		ConstraintName: constraint,
+	}
+}
+
+// compareByTimeAndKey orders rows by time and then by key, like the (time, key) row comparison in Postgres.
+func compareByTimeAndKey(t1 time.Time, key1 int64, t2 time.Time, key2 int64) int {
+	switch {
+	case t1.Before(t2):
+		return -1
+	case t1.After(t2):
+		return 1
+	case key1 < key2:
+		return -1
+	case key1 > key2:
+		return 1
+	}
+	return 0
+}
+
+// page sorts the rows in the filter direction and returns at most limit rows after the cursor.
+func page[T any](rows []T, key func(row T) (time.Time, int64), after *model.Cursor, direction model.SortDirection,
+	limit int64) []T {
+	sign := 1
+	if direction == model.SortDesc {
+		sign = -1
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		ti, ki := key(rows[i])
+		tj, kj := key(rows[j])
+		return sign*compareByTimeAndKey(ti, ki, tj, kj) < 0
+	})
+
+	result := make([]T, 0)
+	for _, row := range rows {
+		if int64(len(result)) >= limit {
+			break
+		}
+		if after != nil {
+			t, k := key(row)
+			if sign*compareByTimeAndKey(t, k, after.Time, after.Key) <= 0 {
+				continue
+			}
+		}
+		result = append(result, row)
+	}
+	return result
+}
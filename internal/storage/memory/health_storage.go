@@ -0,0 +1,30 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/Stern-Ritter/gophermart/internal/logger"
+	"github.com/Stern-Ritter/gophermart/internal/storage"
+	"github.com/Stern-Ritter/gophermart/migrations"
+)
+
+type HealthStorageImpl struct {
+	db     *DB
+	logger *logger.ServerLogger
+}
+
+func NewHealthStorage(db *DB, logger *logger.ServerLogger) storage.HealthStorage {
+	return &HealthStorageImpl{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (s *HealthStorageImpl) Ping(ctx context.Context) error {
+	return nil
+}
+
+// GetMigrationVersion reports the latest migration version, the in-memory tables always match the current schema.
+func (s *HealthStorageImpl) GetMigrationVersion(ctx context.Context) (int64, error) {
+	return migrations.LatestVersion(migrations.DialectPostgres)
+}
@@ -0,0 +1,170 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+
+	"github.com/Stern-Ritter/gophermart/internal/logger"
+	"github.com/Stern-Ritter/gophermart/internal/model"
+	"github.com/Stern-Ritter/gophermart/internal/storage"
+)
+
+type TotpStorageImpl struct {
+	db     *DB
+	logger *logger.ServerLogger
+}
+
+func NewTotpStorage(db *DB, logger *logger.ServerLogger) storage.TotpStorage {
+	return &TotpStorageImpl{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Save stores a new secret unless the user has already enabled two-factor authentication.
+func (s *TotpStorageImpl) Save(ctx context.Context, totp model.Totp) (err error) {
+	defer func() {
+		storage.LogChange(ctx, s.logger, "save totp", err, zap.Int64("user id", totp.UserID))
+	}()
+
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if existing, ok := s.db.totps[totp.UserID]; ok && existing.Enabled {
+		return nil
+	}
+	if !s.db.userExists(totp.UserID) {
+		return foreignKeyViolation("user_totp", "user_totp_to_users_fk")
+	}
+
+	totp.Enabled = false
+	totp.LastUsedStep = 0
+	s.db.totps[totp.UserID] = totp
+	return nil
+}
+
+func (s *TotpStorageImpl) GetOneByUserID(ctx context.Context, userID int64) (model.Totp, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	totp, ok := s.db.totps[userID]
+	if !ok {
+		return model.Totp{}, pgx.ErrNoRows
+	}
+
+	return totp, nil
+}
+
+func (s *TotpStorageImpl) Enable(ctx context.Context, userID int64, recoveryCodeHashes []string) (err error) {
+	defer func() {
+		storage.LogChange(ctx, s.logger, "enable totp", err, zap.Int64("user id", userID))
+	}()
+
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if totp, ok := s.db.totps[userID]; ok {
+		totp.Enabled = true
+		s.db.totps[userID] = totp
+	}
+
+	codes := make(map[string]bool, len(recoveryCodeHashes))
+	for _, codeHash := range recoveryCodeHashes {
+		codes[codeHash] = false
+	}
+	s.db.recoveryCodes[userID] = codes
+
+	return nil
+}
+
+func (s *TotpStorageImpl) Delete(ctx context.Context, userID int64) (err error) {
+	defer func() {
+		storage.LogChange(ctx, s.logger, "delete totp", err, zap.Int64("user id", userID))
+	}()
+
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	delete(s.db.recoveryCodes, userID)
+	delete(s.db.totpFailedAttempts, userID)
+	delete(s.db.totps, userID)
+
+	return nil
+}
+
+func (s *TotpStorageImpl) UpdateLastUsedStep(ctx context.Context, userID int64, step int64) (_ bool, err error) {
+	defer func() {
+		storage.LogChange(ctx, s.logger, "update totp last used step", err, zap.Int64("user id", userID))
+	}()
+
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	totp, ok := s.db.totps[userID]
+	if !ok || totp.LastUsedStep >= step {
+		return false, nil
+	}
+	totp.LastUsedStep = step
+	s.db.totps[userID] = totp
+
+	return true, nil
+}
+
+func (s *TotpStorageImpl) UseRecoveryCode(ctx context.Context, userID int64, codeHash string) (_ bool, err error) {
+	defer func() {
+		storage.LogChange(ctx, s.logger, "use recovery code", err, zap.Int64("user id", userID))
+	}()
+
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	used, ok := s.db.recoveryCodes[userID][codeHash]
+	if !ok || used {
+		return false, nil
+	}
+	s.db.recoveryCodes[userID][codeHash] = true
+
+	return true, nil
+}
+
+// IncrementFailedAttempts counts a verification attempt and returns the number of attempts since the last successful
+// one. Attempts are forgotten when the previous one was made before windowStart.
+func (s *TotpStorageImpl) IncrementFailedAttempts(ctx context.Context, userID int64, attemptedAt time.Time,
+	windowStart time.Time) (_ int64, err error) {
+	defer func() {
+		storage.LogChange(ctx, s.logger, "increment totp failed attempts", err, zap.Int64("user id", userID))
+	}()
+
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.db.totps[userID]; !ok {
+		return 0, pgx.ErrNoRows
+	}
+
+	attempts := s.db.totpFailedAttempts[userID]
+	if attempts.lastAttemptAt.Before(windowStart) {
+		attempts.count = 0
+	}
+	attempts.count++
+	attempts.lastAttemptAt = attemptedAt
+	s.db.totpFailedAttempts[userID] = attempts
+
+	return attempts.count, nil
+}
+
+func (s *TotpStorageImpl) ResetFailedAttempts(ctx context.Context, userID int64) (err error) {
+	defer func() {
+		storage.LogChange(ctx, s.logger, "reset totp failed attempts", err, zap.Int64("user id", userID))
+	}()
+
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	delete(s.db.totpFailedAttempts, userID)
+
+	return nil
+}
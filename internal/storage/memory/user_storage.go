@@ -0,0 +1,129 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+
+	"github.com/Stern-Ritter/gophermart/internal/logger"
+	"github.com/Stern-Ritter/gophermart/internal/model"
+	"github.com/Stern-Ritter/gophermart/internal/storage"
+)
+
+type UserStorageImpl struct {
+	db     *DB
+	logger *logger.ServerLogger
+}
+
+func NewUserStorage(db *DB, logger *logger.ServerLogger) storage.UserStorage {
+	return &UserStorageImpl{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (s *UserStorageImpl) Save(ctx context.Context, user model.User) (err error) {
+	defer func() {
+		storage.LogChange(ctx, s.logger, "save user", err, zap.String("login", user.Login))
+	}()
+
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	for _, existing := range s.db.users {
+		if existing.Login == user.Login {
+			return uniqueViolation("users", "users_login_unique")
+		}
+	}
+
+	if user.Role == "" {
+		user.Role = model.UserRoleUser
+	}
+	user.ID = int64(len(s.db.users) + 1)
+	user.Disabled = false
+	s.db.users = append(s.db.users, user)
+
+	return nil
+}
+
+func (s *UserStorageImpl) GetOneByLogin(ctx context.Context, login string) (model.User, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	for _, user := range s.db.users {
+		if user.Login == login {
+			return user, nil
+		}
+	}
+
+	return model.User{}, pgx.ErrNoRows
+}
+
+func (s *UserStorageImpl) GetOneByID(ctx context.Context, id int64) (model.User, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	for _, user := range s.db.users {
+		if user.ID == id {
+			return user, nil
+		}
+	}
+
+	return model.User{}, pgx.ErrNoRows
+}
+
+func (s *UserStorageImpl) GetAllByLoginContainingOrderByLogin(ctx context.Context, login string,
+	limit int64) ([]model.User, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	users := make([]model.User, 0)
+	for _, user := range s.db.users {
+		if strings.Contains(strings.ToLower(user.Login), strings.ToLower(login)) {
+			users = append(users, user)
+		}
+	}
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].Login < users[j].Login
+	})
+
+	return users[:min(int64(len(users)), limit)], nil
+}
+
+func (s *UserStorageImpl) UpdatePassword(ctx context.Context, userID int64, passwordHash string) (err error) {
+	defer func() {
+		storage.LogChange(ctx, s.logger, "update user password", err, zap.Int64("user id", userID))
+	}()
+
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	for i := range s.db.users {
+		if s.db.users[i].ID == userID {
+			s.db.users[i].Password = passwordHash
+		}
+	}
+
+	return nil
+}
+
+func (s *UserStorageImpl) DisableByLogin(ctx context.Context, login string) (err error) {
+	defer func() {
+		storage.LogChange(ctx, s.logger, "disable user", err, zap.String("login", login))
+	}()
+
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	for i := range s.db.users {
+		if s.db.users[i].Login == login {
+			s.db.users[i].Disabled = true
+			return nil
+		}
+	}
+
+	return pgx.ErrNoRows
+}
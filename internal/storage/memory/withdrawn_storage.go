@@ -0,0 +1,125 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+
+	er "github.com/Stern-Ritter/gophermart/internal/errors"
+	"github.com/Stern-Ritter/gophermart/internal/logger"
+	"github.com/Stern-Ritter/gophermart/internal/model"
+	"github.com/Stern-Ritter/gophermart/internal/storage"
+	"github.com/Stern-Ritter/gophermart/internal/utils"
+)
+
+type WithdrawnStorageImpl struct {
+	db     *DB
+	logger *logger.ServerLogger
+}
+
+func NewWithdrawnStorage(db *DB, logger *logger.ServerLogger) storage.WithdrawnStorage {
+	return &WithdrawnStorageImpl{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (s *WithdrawnStorageImpl) Save(ctx context.Context, withdrawn model.Withdrawn) (err error) {
+	defer func() {
+		storage.LogChange(ctx, s.logger, "save withdrawn", err, zap.Int64("order number", withdrawn.OrderNumber))
+	}()
+
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if utils.Float64Compare(s.db.currentPointsByUserID(withdrawn.UserID), withdrawn.PointsAmount) < 0 {
+		return er.NewPaymentRequiredError("Not enough loyalty points to withdrawn", nil)
+	}
+
+	for _, existing := range s.db.withdrawals {
+		if existing.OrderNumber != withdrawn.OrderNumber {
+			continue
+		}
+		if existing.UserID == withdrawn.UserID {
+			return uniqueViolation("loyalty_points_withdrawn", "pk_loyalty_points_withdrawn")
+		}
+		return uniqueViolation("loyalty_points_withdrawn", "loyalty_points_withdrawn_order_number_unique")
+	}
+	if !s.db.userExists(withdrawn.UserID) {
+		return foreignKeyViolation("loyalty_points_withdrawn", "loyalty_points_withdrawn_to_users_fk")
+	}
+
+	s.db.withdrawals = append(s.db.withdrawals, withdrawn)
+	return nil
+}
+
+func (s *WithdrawnStorageImpl) GetAllByUserIDOrderByProcessedAtAsc(ctx context.Context, userID int64) ([]model.Withdrawn, error) {
+	withdrawals := make([]model.Withdrawn, 0)
+
+	err := s.ForEachByUserIDOrderByProcessedAtAsc(ctx, userID, func(withdrawn model.Withdrawn) error {
+		withdrawals = append(withdrawals, withdrawn)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return withdrawals, nil
+}
+
+func (s *WithdrawnStorageImpl) ForEachByUserIDOrderByProcessedAtAsc(ctx context.Context, userID int64,
+	fn func(withdrawn model.Withdrawn) error) error {
+	s.db.mu.RLock()
+	withdrawals := make([]model.Withdrawn, 0)
+	for _, withdrawn := range s.db.withdrawals {
+		if withdrawn.UserID == userID {
+			withdrawals = append(withdrawals, withdrawn)
+		}
+	}
+	s.db.mu.RUnlock()
+
+	sort.SliceStable(withdrawals, func(i, j int) bool {
+		return withdrawals[i].ProcessedAt.Before(withdrawals[j].ProcessedAt)
+	})
+
+	for _, withdrawn := range withdrawals {
+		if err := fn(withdrawn); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *WithdrawnStorageImpl) GetAllByFilter(ctx context.Context, filter model.WithdrawalsFilter) ([]model.Withdrawn, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	return page(s.filter(filter), func(withdrawn model.Withdrawn) (time.Time, int64) {
+		return withdrawn.ProcessedAt, withdrawn.OrderNumber
+	}, filter.After, filter.SortDirection, filter.Limit), nil
+}
+
+func (s *WithdrawnStorageImpl) CountByFilter(ctx context.Context, filter model.WithdrawalsFilter) (int64, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	return int64(len(s.filter(filter))), nil
+}
+
+func (s *WithdrawnStorageImpl) filter(filter model.WithdrawalsFilter) []model.Withdrawn {
+	withdrawals := make([]model.Withdrawn, 0)
+	for _, withdrawn := range s.db.withdrawals {
+		if withdrawn.UserID != filter.UserID ||
+			!filter.ProcessedFrom.IsZero() && withdrawn.ProcessedAt.Before(filter.ProcessedFrom) ||
+			!filter.ProcessedTo.IsZero() && !withdrawn.ProcessedAt.Before(filter.ProcessedTo) ||
+			filter.MinSum > 0 && withdrawn.PointsAmount < filter.MinSum ||
+			filter.MaxSum > 0 && withdrawn.PointsAmount > filter.MaxSum {
+			continue
+		}
+		withdrawals = append(withdrawals, withdrawn)
+	}
+	return withdrawals
+}
@@ -0,0 +1,162 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Stern-Ritter/gophermart/internal/logger"
+	"github.com/Stern-Ritter/gophermart/internal/model"
+	"github.com/Stern-Ritter/gophermart/internal/storage"
+)
+
+const (
+	accrualEventsPollInterval = time.Second
+	accrualEventsPollLimit    = 100
+)
+
+type AccrualEventStorageImpl struct {
+	db     *sql.DB
+	logger *logger.ServerLogger
+}
+
+func NewAccrualEventStorage(db *sql.DB, logger *logger.ServerLogger) storage.AccrualEventStorage {
+	return &AccrualEventStorageImpl{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (s *AccrualEventStorageImpl) GetAllByUserIDAfterIDOrderByIDAsc(ctx context.Context, userID int64, afterID int64,
+	limit int64) ([]model.AccrualEvent, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT
+		    id,
+		    user_id,
+		    order_number,
+		    status,
+		    amount,
+		    processed_at
+		FROM loyalty_points_accrual_events
+		WHERE 
+		    user_id = @userId AND
+		    id > @afterId
+		ORDER BY id
+		LIMIT @limit
+	`,
+		sql.Named("userId", userID),
+		sql.Named("afterId", afterID),
+		sql.Named("limit", limit),
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return scanAccrualEvents(rows)
+}
+
+// AccrualEventListenerImpl polls the events table, SQLite has no notifications that reach other connections.
+type AccrualEventListenerImpl struct {
+	db       *sql.DB
+	logger   *logger.ServerLogger
+	interval time.Duration
+}
+
+func NewAccrualEventListener(db *sql.DB, logger *logger.ServerLogger) storage.AccrualEventListener {
+	return &AccrualEventListenerImpl{
+		db:       db,
+		logger:   logger,
+		interval: accrualEventsPollInterval,
+	}
+}
+
+func (l *AccrualEventListenerImpl) Listen(ctx context.Context, handle func(event model.AccrualEvent)) error {
+	var lastID int64
+	err := l.db.QueryRowContext(ctx, `
+		SELECT
+		    COALESCE(MAX(id), 0)
+		FROM loyalty_points_accrual_events
+	`).Scan(&lastID)
+	if err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(l.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		events, err := l.getAllAfterID(ctx, lastID)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			l.logger.Error("Error polling accrual events", zap.String("event", "listen accrual events"),
+				zap.Error(err))
+			continue
+		}
+
+		for _, event := range events {
+			handle(event)
+			lastID = event.ID
+		}
+	}
+}
+
+func (l *AccrualEventListenerImpl) getAllAfterID(ctx context.Context, afterID int64) ([]model.AccrualEvent, error) {
+	rows, err := l.db.QueryContext(ctx, `
+		SELECT
+		    id,
+		    user_id,
+		    order_number,
+		    status,
+		    amount,
+		    processed_at
+		FROM loyalty_points_accrual_events
+		WHERE 
+		    id > @afterId
+		ORDER BY id
+		LIMIT @limit
+	`,
+		sql.Named("afterId", afterID),
+		sql.Named("limit", accrualEventsPollLimit),
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return scanAccrualEvents(rows)
+}
+
+func scanAccrualEvents(rows *sql.Rows) ([]model.AccrualEvent, error) {
+	defer rows.Close()
+
+	events := make([]model.AccrualEvent, 0)
+
+	for rows.Next() {
+		event := model.AccrualEvent{}
+		var processedAt sql.NullInt64
+		if err := rows.Scan(&event.ID, &event.UserID, &event.OrderNumber, &event.Status, &event.PointsAmount,
+			&processedAt); err != nil {
+			return nil, err
+		}
+		event.ProcessedAt = fromNullMicros(processedAt)
+
+		events = append(events, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
@@ -0,0 +1,68 @@
+//go:build cgo
+
+package sqlite
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Stern-Ritter/gophermart/internal/logger"
+	"github.com/Stern-Ritter/gophermart/internal/model"
+)
+
+func TestAccrualEventListener(t *testing.T) {
+	l, err := logger.Initialize("error")
+	require.NoError(t, err, "Error init logger")
+
+	db := openTestDB(t)
+	userID := saveTestUser(t, db, "user")
+	accrualStorage := NewAccrualStorage(db, l)
+	listener := &AccrualEventListenerImpl{db: db, logger: l, interval: 10 * time.Millisecond}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events := make(chan model.AccrualEvent, 100)
+	done := make(chan error, 1)
+	go func() {
+		done <- listener.Listen(ctx, func(event model.AccrualEvent) { events <- event })
+	}()
+
+	processedAt := time.Date(2024, time.June, 1, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, accrualStorage.Save(ctx, model.Accrual{UserID: userID, OrderNumber: 12345678903,
+		Status: model.AccrualNew, UploadedAt: processedAt}), "Error save accrual")
+
+	// The listener only reports events created after it started, so the amount is changed until one arrives.
+	var amount float64
+	var event model.AccrualEvent
+	require.Eventually(t, func() bool {
+		amount++
+		err := accrualStorage.UpdateInBatch(ctx, []model.Accrual{{UserID: userID, OrderNumber: 12345678903,
+			Status: model.AccrualProcessed, PointsAmount: amount, ProcessedAt: processedAt}})
+		if !assert.NoError(t, err, "Error update accrual") {
+			return false
+		}
+
+		select {
+		case event = <-events:
+			return true
+		case <-time.After(50 * time.Millisecond):
+			return false
+		}
+	}, 5*time.Second, 10*time.Millisecond, "Accrual event was not received")
+
+	assert.Equal(t, model.AccrualEvent{ID: event.ID, UserID: userID, OrderNumber: 12345678903,
+		Status: model.AccrualProcessed, PointsAmount: event.PointsAmount, ProcessedAt: processedAt}, event,
+		"Accrual event does not match expected accrual event")
+
+	stored, err := NewAccrualEventStorage(db, l).GetAllByUserIDAfterIDOrderByIDAsc(ctx, userID, 0, 10)
+	require.NoError(t, err, "Error get accrual events")
+	assert.Len(t, stored, int(amount), "Accrual events count does not match expected count")
+
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled, "Listener should stop when context is canceled")
+}
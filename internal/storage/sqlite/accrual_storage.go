@@ -0,0 +1,441 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/Stern-Ritter/gophermart/internal/logger"
+	"github.com/Stern-Ritter/gophermart/internal/model"
+	"github.com/Stern-Ritter/gophermart/internal/storage"
+)
+
+type AccrualStorageImpl struct {
+	db     *sql.DB
+	logger *logger.ServerLogger
+}
+
+func NewAccrualStorage(db *sql.DB, logger *logger.ServerLogger) storage.AccrualStorage {
+	return &AccrualStorageImpl{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (s *AccrualStorageImpl) Save(ctx context.Context, accrual model.Accrual) (err error) {
+	defer func() {
+		storage.LogChange(ctx, s.logger, "save accrual", err, zap.Int64("order number", accrual.OrderNumber))
+	}()
+
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO loyalty_points_accrual
+		    (user_id, order_number, uploaded_at, status, amount)
+		VALUES (@userId, @orderNumber, @uploadedAt, @status, @amount)
+	`,
+		sql.Named("userId", accrual.UserID),
+		sql.Named("orderNumber", accrual.OrderNumber),
+		sql.Named("uploadedAt", toMicros(accrual.UploadedAt)),
+		sql.Named("status", accrual.Status),
+		sql.Named("amount", accrual.PointsAmount),
+	)
+
+	return translateOrderError(ctx, s.db, "loyalty_points_accrual", accrual.UserID, accrual.OrderNumber, err)
+}
+
+func (s *AccrualStorageImpl) SaveInBatch(ctx context.Context,
+	accruals []model.Accrual) (_ []model.AccrualBatchItemResult, err error) {
+	defer func() {
+		storage.LogChange(ctx, s.logger, "save accruals batch", err, zap.Int("count", len(accruals)))
+	}()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	results := make([]model.AccrualBatchItemResult, 0, len(accruals))
+
+	for _, accrual := range accruals {
+		result := model.AccrualBatchItemResult{OrderNumber: accrual.OrderNumber}
+
+		inserted, err := tx.ExecContext(ctx, `
+			INSERT INTO loyalty_points_accrual
+			    (user_id, order_number, uploaded_at, status)
+			VALUES (@userId, @orderNumber, @uploadedAt, @status)
+			ON CONFLICT DO NOTHING
+		`,
+			sql.Named("userId", accrual.UserID),
+			sql.Named("orderNumber", accrual.OrderNumber),
+			sql.Named("uploadedAt", toMicros(accrual.UploadedAt)),
+			sql.Named("status", accrual.Status),
+		)
+		if err != nil {
+			return nil, translateError(err)
+		}
+		affected, err := inserted.RowsAffected()
+		if err != nil {
+			return nil, err
+		}
+
+		if affected > 0 {
+			result.Status = model.AccrualBatchItemAccepted
+		} else {
+			var ownerID int64
+			err = tx.QueryRowContext(ctx, `
+				SELECT user_id
+				FROM loyalty_points_accrual
+				WHERE order_number = @orderNumber
+			`, sql.Named("orderNumber", accrual.OrderNumber)).Scan(&ownerID)
+			if err != nil {
+				return nil, translateError(err)
+			}
+
+			result.Status = model.AccrualBatchItemConflict
+			if ownerID == accrual.UserID {
+				result.Status = model.AccrualBatchItemAlreadyUploaded
+			}
+		}
+		results = append(results, result)
+	}
+
+	return results, tx.Commit()
+}
+
+func (s *AccrualStorageImpl) Update(ctx context.Context, accrual model.Accrual) (err error) {
+	defer func() {
+		storage.LogChange(ctx, s.logger, "update accrual", err, zap.Int64("order number", accrual.OrderNumber))
+	}()
+
+	_, err = s.db.ExecContext(ctx, `
+		UPDATE loyalty_points_accrual
+		SET processed_at = @processedAt, status = @status, amount = @amount
+		WHERE user_id = @userId AND order_number = @orderNumber
+	`,
+		sql.Named("userId", accrual.UserID),
+		sql.Named("orderNumber", accrual.OrderNumber),
+		sql.Named("processedAt", toNullMicros(accrual.ProcessedAt)),
+		sql.Named("status", accrual.Status),
+		sql.Named("amount", accrual.PointsAmount),
+	)
+
+	return err
+}
+
+func (s *AccrualStorageImpl) UpdateInBatch(ctx context.Context, accruals []model.Accrual) (err error) {
+	defer func() {
+		storage.LogChange(ctx, s.logger, "update accruals batch", err, zap.Int("count", len(accruals)))
+	}()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	for _, accrual := range accruals {
+		_, err := tx.ExecContext(ctx, `
+			UPDATE loyalty_points_accrual
+			SET processed_at = @processedAt, status = @status, amount = @amount, processing_lock = FALSE
+			WHERE user_id = @userId AND order_number = @orderNumber
+		`,
+			sql.Named("userId", accrual.UserID),
+			sql.Named("orderNumber", accrual.OrderNumber),
+			sql.Named("processedAt", toNullMicros(accrual.ProcessedAt)),
+			sql.Named("status", accrual.Status),
+			sql.Named("amount", accrual.PointsAmount),
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (s *AccrualStorageImpl) GetAllByUserIDOrderByUploadedAtAsc(ctx context.Context, userID int64) ([]model.Accrual, error) {
+	accruals := make([]model.Accrual, 0)
+
+	err := s.ForEachByUserIDOrderByUploadedAtAsc(ctx, userID, func(accrual model.Accrual) error {
+		accruals = append(accruals, accrual)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return accruals, nil
+}
+
+func (s *AccrualStorageImpl) ForEachByUserIDOrderByUploadedAtAsc(ctx context.Context, userID int64,
+	fn func(accrual model.Accrual) error) error {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT
+		    user_id,
+			order_number,
+			uploaded_at,
+			processed_at,
+			status,
+			amount
+		FROM loyalty_points_accrual
+		WHERE
+		    user_id = @userId
+		ORDER BY uploaded_at
+	`, sql.Named("userId", userID))
+
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		accrual, err := scanAccrual(rows)
+		if err != nil {
+			return err
+		}
+
+		if err := fn(accrual); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+func (s *AccrualStorageImpl) GetAllByFilter(ctx context.Context, filter model.AccrualsFilter) ([]model.Accrual, error) {
+	query := strings.Builder{}
+	query.WriteString(`
+		SELECT
+		    user_id,
+			order_number,
+			uploaded_at,
+			processed_at,
+			status,
+			amount
+		FROM loyalty_points_accrual
+		WHERE
+		    user_id = @userId`)
+	args := []any{
+		sql.Named("userId", filter.UserID),
+		sql.Named("limit", filter.Limit),
+	}
+
+	if len(filter.Statuses) > 0 {
+		placeholders := make([]string, len(filter.Statuses))
+		for i, status := range filter.Statuses {
+			name := "status" + strconv.Itoa(i)
+			placeholders[i] = "@" + name
+			args = append(args, sql.Named(name, status))
+		}
+		query.WriteString(" AND\n\t\t    status IN (" + strings.Join(placeholders, ", ") + ")")
+	}
+	if !filter.UploadedFrom.IsZero() {
+		query.WriteString(" AND\n\t\t    uploaded_at >= @uploadedFrom")
+		args = append(args, sql.Named("uploadedFrom", toMicros(filter.UploadedFrom)))
+	}
+	if !filter.UploadedTo.IsZero() {
+		query.WriteString(" AND\n\t\t    uploaded_at < @uploadedTo")
+		args = append(args, sql.Named("uploadedTo", toMicros(filter.UploadedTo)))
+	}
+
+	direction := "ASC"
+	comparison := ">"
+	if filter.SortDirection == model.SortDesc {
+		direction = "DESC"
+		comparison = "<"
+	}
+	if filter.After != nil {
+		query.WriteString(" AND\n\t\t    (uploaded_at, order_number) " + comparison + " (@afterUploadedAt, @afterOrderNumber)")
+		args = append(args, sql.Named("afterUploadedAt", toMicros(filter.After.Time)),
+			sql.Named("afterOrderNumber", filter.After.Key))
+	}
+	query.WriteString("\n\t\tORDER BY uploaded_at " + direction + ", order_number " + direction + "\n\t\tLIMIT @limit\n\t")
+
+	rows, err := s.db.QueryContext(ctx, query.String(), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	accruals := make([]model.Accrual, 0)
+
+	for rows.Next() {
+		accrual, err := scanAccrual(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		accruals = append(accruals, accrual)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return accruals, nil
+}
+
+func (s *AccrualStorageImpl) GetOneByOrderNumber(ctx context.Context, orderNumber int64) (model.Accrual, error) {
+	row := s.db.QueryRowContext(ctx, `
+		SELECT
+		    user_id,
+			order_number,
+			uploaded_at,
+			processed_at,
+			status,
+			amount
+		FROM loyalty_points_accrual
+		WHERE
+		    order_number = @orderNumber
+	`, sql.Named("orderNumber", orderNumber))
+
+	accrual, err := scanAccrual(row)
+	return accrual, translateError(err)
+}
+
+func (s *AccrualStorageImpl) GetAllUnprocessedWithLimit(ctx context.Context, limit int64) (_ []model.Accrual, err error) {
+	defer func() {
+		storage.LogChange(ctx, s.logger, "claim unprocessed accruals", err, zap.Int64("limit", limit))
+	}()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT
+			user_id,
+			order_number,
+			uploaded_at,
+			processed_at,
+			status,
+			amount
+		FROM loyalty_points_accrual
+		WHERE status IN ('NEW', 'PROCESSING')
+		AND processing_lock = FALSE
+		ORDER BY uploaded_at
+		LIMIT @limit
+	`, sql.Named("limit", limit))
+
+	if err != nil {
+		return nil, err
+	}
+
+	accruals := make([]model.Accrual, 0)
+
+	for rows.Next() {
+		accrual, err := scanAccrual(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+
+		accruals = append(accruals, accrual)
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i, accrual := range accruals {
+		_, err := tx.ExecContext(ctx, `
+			UPDATE loyalty_points_accrual
+			SET status = @status, processing_lock = TRUE
+			WHERE user_id = @userId AND order_number = @orderNumber
+		`,
+			sql.Named("userId", accrual.UserID),
+			sql.Named("orderNumber", accrual.OrderNumber),
+			sql.Named("status", model.AccrualProcessing),
+		)
+		if err != nil {
+			return nil, err
+		}
+		accruals[i].Status = model.AccrualProcessing
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	return accruals, nil
+}
+
+func (s *AccrualStorageImpl) CountByStatus(ctx context.Context) (map[model.AccrualStatus]int64, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT status, COUNT(*)
+		FROM loyalty_points_accrual
+		GROUP BY status
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[model.AccrualStatus]int64)
+	for rows.Next() {
+		var status model.AccrualStatus
+		var count int64
+		if err := rows.Scan(&status, &count); err != nil {
+			return nil, err
+		}
+		counts[status] = count
+	}
+
+	return counts, rows.Err()
+}
+
+// Requeue returns an unprocessed order to the NEW status and releases its processing lock, so that the scheduler
+// fetches its accrual again.
+func (s *AccrualStorageImpl) Requeue(ctx context.Context, orderNumber int64) (err error) {
+	defer func() {
+		storage.LogChange(ctx, s.logger, "requeue accrual", err, zap.Int64("order number", orderNumber))
+	}()
+
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE loyalty_points_accrual
+		SET status = 'NEW', processing_lock = FALSE, processed_at = NULL, amount = 0
+		WHERE order_number = @orderNumber AND status <> 'PROCESSED'
+	`, sql.Named("orderNumber", orderNumber))
+	if err != nil {
+		return err
+	}
+
+	return requireAffected(result)
+}
+
+func scanAccrual(row scanner) (model.Accrual, error) {
+	accrual := model.Accrual{}
+	var uploadedAt int64
+	var processedAt sql.NullInt64
+	err := row.Scan(&accrual.UserID, &accrual.OrderNumber, &uploadedAt, &processedAt, &accrual.Status,
+		&accrual.PointsAmount)
+	if err != nil {
+		return model.Accrual{}, err
+	}
+	accrual.UploadedAt = fromMicros(uploadedAt)
+	accrual.ProcessedAt = fromNullMicros(processedAt)
+
+	return accrual, nil
+}
+
+func getPointsSumsByUserID(ctx context.Context, q querier, userID int64) (accrued float64, withdrawn float64,
+	adjusted float64, err error) {
+	err = q.QueryRowContext(ctx, `
+		SELECT
+		    (SELECT COALESCE(SUM(amount), 0) FROM loyalty_points_accrual
+		     WHERE user_id = @userId AND status = 'PROCESSED'),
+		    (SELECT COALESCE(SUM(amount), 0) FROM loyalty_points_withdrawn
+		     WHERE user_id = @userId),
+		    (SELECT COALESCE(SUM(amount), 0) FROM loyalty_points_adjustment
+		     WHERE user_id = @userId)
+	`, sql.Named("userId", userID)).Scan(&accrued, &withdrawn, &adjusted)
+
+	return accrued, withdrawn, adjusted, err
+}
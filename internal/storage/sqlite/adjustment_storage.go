@@ -0,0 +1,115 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+
+	"go.uber.org/zap"
+
+	er "github.com/Stern-Ritter/gophermart/internal/errors"
+	"github.com/Stern-Ritter/gophermart/internal/logger"
+	"github.com/Stern-Ritter/gophermart/internal/model"
+	"github.com/Stern-Ritter/gophermart/internal/storage"
+	"github.com/Stern-Ritter/gophermart/internal/utils"
+)
+
+type AdjustmentStorageImpl struct {
+	db     *sql.DB
+	logger *logger.ServerLogger
+}
+
+func NewAdjustmentStorage(db *sql.DB, logger *logger.ServerLogger) storage.AdjustmentStorage {
+	return &AdjustmentStorageImpl{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (s *AdjustmentStorageImpl) Save(ctx context.Context, adjustment model.Adjustment) (_ model.Adjustment, err error) {
+	defer func() {
+		storage.LogChange(ctx, s.logger, "save adjustment", err, zap.Int64("user id", adjustment.UserID))
+	}()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return model.Adjustment{}, err
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	if adjustment.PointsAmount < 0 {
+		accrued, withdrawn, adjusted, err := getPointsSumsByUserID(ctx, tx, adjustment.UserID)
+		if err != nil {
+			return model.Adjustment{}, err
+		}
+		if utils.Float64Compare(accrued-withdrawn+adjusted, -adjustment.PointsAmount) < 0 {
+			return model.Adjustment{}, er.NewConflictError("Not enough loyalty points to debit", nil)
+		}
+	}
+
+	row := tx.QueryRowContext(ctx, `
+		INSERT INTO loyalty_points_adjustment
+		    (user_id, admin_id, amount, reason_code, note, created_at)
+		VALUES (@userId, @adminId, @amount, @reasonCode, @note, @createdAt)
+		RETURNING id
+	`,
+		sql.Named("userId", adjustment.UserID),
+		sql.Named("adminId", adjustment.AdminID),
+		sql.Named("amount", adjustment.PointsAmount),
+		sql.Named("reasonCode", adjustment.ReasonCode),
+		sql.Named("note", adjustment.Note),
+		sql.Named("createdAt", toMicros(adjustment.CreatedAt)),
+	)
+
+	err = row.Scan(&adjustment.ID)
+	if err != nil {
+		return model.Adjustment{}, translateError(err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return model.Adjustment{}, err
+	}
+
+	return adjustment, nil
+}
+
+func (s *AdjustmentStorageImpl) GetAllByUserIDOrderByCreatedAtAsc(ctx context.Context, userID int64) ([]model.Adjustment, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT
+		    id,
+		    user_id,
+		    admin_id,
+		    amount,
+		    reason_code,
+		    note,
+		    created_at
+		FROM loyalty_points_adjustment
+		WHERE
+		    user_id = @userId
+		ORDER BY created_at
+	`, sql.Named("userId", userID))
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	adjustments := make([]model.Adjustment, 0)
+
+	for rows.Next() {
+		adjustment := model.Adjustment{}
+		var createdAt int64
+		if err := rows.Scan(&adjustment.ID, &adjustment.UserID, &adjustment.AdminID, &adjustment.PointsAmount,
+			&adjustment.ReasonCode, &adjustment.Note, &createdAt); err != nil {
+			return nil, err
+		}
+		adjustment.CreatedAt = fromMicros(createdAt)
+		adjustments = append(adjustments, adjustment)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return adjustments, nil
+}
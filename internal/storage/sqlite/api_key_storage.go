@@ -0,0 +1,159 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Stern-Ritter/gophermart/internal/logger"
+	"github.com/Stern-Ritter/gophermart/internal/model"
+	"github.com/Stern-Ritter/gophermart/internal/storage"
+)
+
+type ApiKeyStorageImpl struct {
+	db     *sql.DB
+	logger *logger.ServerLogger
+}
+
+func NewApiKeyStorage(db *sql.DB, logger *logger.ServerLogger) storage.ApiKeyStorage {
+	return &ApiKeyStorageImpl{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Save stores the scopes as a JSON array, SQLite has no array type.
+func (s *ApiKeyStorageImpl) Save(ctx context.Context, apiKey model.ApiKey) (_ model.ApiKey, err error) {
+	defer func() {
+		storage.LogChange(ctx, s.logger, "save api key", err, zap.Int64("user id", apiKey.UserID))
+	}()
+
+	scopes, err := json.Marshal(apiKey.Scopes)
+	if err != nil {
+		return model.ApiKey{}, err
+	}
+
+	row := s.db.QueryRowContext(ctx, `
+		INSERT INTO user_api_keys
+		    (user_id, name, prefix, key_hash, scopes, created_at)
+		VALUES (@userId, @name, @prefix, @keyHash, @scopes, @createdAt)
+		RETURNING id
+	`,
+		sql.Named("userId", apiKey.UserID),
+		sql.Named("name", apiKey.Name),
+		sql.Named("prefix", apiKey.Prefix),
+		sql.Named("keyHash", apiKey.KeyHash),
+		sql.Named("scopes", string(scopes)),
+		sql.Named("createdAt", toMicros(apiKey.CreatedAt)),
+	)
+
+	err = row.Scan(&apiKey.ID)
+	if err != nil {
+		return model.ApiKey{}, translateError(err)
+	}
+
+	return apiKey, nil
+}
+
+func (s *ApiKeyStorageImpl) GetOneActiveByKeyHash(ctx context.Context, keyHash string) (model.ApiKey, error) {
+	row := s.db.QueryRowContext(ctx, `
+		SELECT
+		    id,
+		    user_id,
+		    name,
+		    prefix,
+		    key_hash,
+		    scopes,
+		    created_at
+		FROM user_api_keys
+		WHERE
+		    key_hash = @keyHash AND
+		    revoked_at IS NULL
+	`, sql.Named("keyHash", keyHash))
+
+	apiKey, err := scanApiKey(row)
+	return apiKey, translateError(err)
+}
+
+func (s *ApiKeyStorageImpl) GetAllActiveByUserIDOrderByCreatedAtAsc(ctx context.Context, userID int64) ([]model.ApiKey, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT
+		    id,
+		    user_id,
+		    name,
+		    prefix,
+		    key_hash,
+		    scopes,
+		    created_at
+		FROM user_api_keys
+		WHERE
+		    user_id = @userId AND
+		    revoked_at IS NULL
+		ORDER BY created_at
+	`, sql.Named("userId", userID))
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	apiKeys := make([]model.ApiKey, 0)
+
+	for rows.Next() {
+		apiKey, err := scanApiKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		apiKeys = append(apiKeys, apiKey)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return apiKeys, nil
+}
+
+func (s *ApiKeyStorageImpl) Revoke(ctx context.Context, userID int64, id int64) (_ bool, err error) {
+	defer func() {
+		storage.LogChange(ctx, s.logger, "revoke api key", err, zap.Int64("user id", userID), zap.Int64("api key id", id))
+	}()
+
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE user_api_keys
+		SET revoked_at = @revokedAt
+		WHERE
+		    id = @id AND
+		    user_id = @userId AND
+		    revoked_at IS NULL
+	`,
+		sql.Named("revokedAt", toMicros(time.Now())),
+		sql.Named("id", id),
+		sql.Named("userId", userID),
+	)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	return affected == 1, err
+}
+
+func scanApiKey(row scanner) (model.ApiKey, error) {
+	apiKey := model.ApiKey{}
+	var scopes string
+	var createdAt int64
+	err := row.Scan(&apiKey.ID, &apiKey.UserID, &apiKey.Name, &apiKey.Prefix, &apiKey.KeyHash, &scopes, &createdAt)
+	if err != nil {
+		return model.ApiKey{}, err
+	}
+	if err := json.Unmarshal([]byte(scopes), &apiKey.Scopes); err != nil {
+		return model.ApiKey{}, err
+	}
+	apiKey.CreatedAt = fromMicros(createdAt)
+
+	return apiKey, nil
+}
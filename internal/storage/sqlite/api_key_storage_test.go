@@ -0,0 +1,58 @@
+//go:build cgo
+
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Stern-Ritter/gophermart/internal/logger"
+	"github.com/Stern-Ritter/gophermart/internal/model"
+)
+
+func TestApiKeyStorage(t *testing.T) {
+	ctx := context.Background()
+	l, err := logger.Initialize("error")
+	require.NoError(t, err, "Error init logger")
+
+	db := openTestDB(t)
+	userID := saveTestUser(t, db, "user")
+	apiKeyStorage := NewApiKeyStorage(db, l)
+
+	apiKey := model.ApiKey{
+		UserID:    userID,
+		Name:      "shop",
+		Prefix:    "gm_abcdef",
+		KeyHash:   "hash",
+		Scopes:    []model.ApiKeyScope{model.ApiKeyScopeOrdersRead, model.ApiKeyScopeBalanceRead},
+		CreatedAt: time.Date(2024, time.June, 1, 12, 0, 0, 0, time.UTC),
+	}
+	saved, err := apiKeyStorage.Save(ctx, apiKey)
+	require.NoError(t, err, "Error save api key")
+	apiKey.ID = saved.ID
+
+	got, err := apiKeyStorage.GetOneActiveByKeyHash(ctx, "hash")
+	require.NoError(t, err, "Error get api key")
+	assert.Equal(t, apiKey, got, "Api key does not match expected api key")
+
+	_, err = apiKeyStorage.Save(ctx, model.ApiKey{UserID: userID, Name: "shop", Prefix: "gm_123456",
+		KeyHash: "other hash", Scopes: apiKey.Scopes, CreatedAt: apiKey.CreatedAt})
+	var pgErr *pgconn.PgError
+	require.True(t, errors.As(err, &pgErr), "Error saving api key with the same name should be a constraint error")
+	assert.Equal(t, "user_api_keys_user_id_name_active_idx", pgErr.ConstraintName,
+		"Constraint name does not match expected constraint name")
+
+	revoked, err := apiKeyStorage.Revoke(ctx, userID, apiKey.ID)
+	require.NoError(t, err, "Error revoke api key")
+	assert.True(t, revoked, "Api key should be revoked")
+
+	apiKeys, err := apiKeyStorage.GetAllActiveByUserIDOrderByCreatedAtAsc(ctx, userID)
+	require.NoError(t, err, "Error get api keys")
+	assert.Empty(t, apiKeys, "Revoked api key should not be active")
+}
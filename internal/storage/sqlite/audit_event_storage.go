@@ -0,0 +1,209 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/Stern-Ritter/gophermart/internal/logger"
+	"github.com/Stern-Ritter/gophermart/internal/model"
+	"github.com/Stern-Ritter/gophermart/internal/storage"
+)
+
+type AuditEventStorageImpl struct {
+	db     *sql.DB
+	logger *logger.ServerLogger
+}
+
+func NewAuditEventStorage(db *sql.DB, logger *logger.ServerLogger) storage.AuditEventStorage {
+	return &AuditEventStorageImpl{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Append links the event to the last one in the chain. Transactions take the database write lock on begin, which
+// serializes appends like the table lock does in Postgres.
+func (s *AuditEventStorageImpl) Append(ctx context.Context, event model.AuditEvent) (_ model.AuditEvent, err error) {
+	defer func() {
+		storage.LogChange(ctx, s.logger, "append audit event", err, zap.String("action", string(event.Action)))
+	}()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return model.AuditEvent{}, err
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	event.PrevHash = model.AuditGenesisHash
+	err = tx.QueryRowContext(ctx, `
+		SELECT hash
+		FROM audit_events
+		ORDER BY id DESC
+		LIMIT 1
+	`).Scan(&event.PrevHash)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return model.AuditEvent{}, err
+	}
+	event.Hash = event.ComputeHash()
+
+	actorID := sql.NullInt64{Int64: event.ActorID, Valid: event.ActorID != 0}
+
+	row := tx.QueryRowContext(ctx, `
+		INSERT INTO audit_events
+		    (actor_id, actor, action, target, ip, user_agent, outcome, created_at, prev_hash, hash)
+		VALUES (@actorId, @actor, @action, @target, @ip, @userAgent, @outcome, @createdAt, @prevHash, @hash)
+		RETURNING id
+	`,
+		sql.Named("actorId", actorID),
+		sql.Named("actor", event.Actor),
+		sql.Named("action", event.Action),
+		sql.Named("target", event.Target),
+		sql.Named("ip", event.IP),
+		sql.Named("userAgent", event.UserAgent),
+		sql.Named("outcome", event.Outcome),
+		sql.Named("createdAt", toMicros(event.CreatedAt)),
+		sql.Named("prevHash", event.PrevHash),
+		sql.Named("hash", event.Hash),
+	)
+
+	err = row.Scan(&event.ID)
+	if err != nil {
+		return model.AuditEvent{}, translateError(err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return model.AuditEvent{}, err
+	}
+
+	return event, nil
+}
+
+func (s *AuditEventStorageImpl) GetAllByFilter(ctx context.Context, filter model.AuditEventsFilter) ([]model.AuditEvent, error) {
+	query := strings.Builder{}
+	query.WriteString(`
+		SELECT
+		    id,
+		    actor_id,
+		    actor,
+		    action,
+		    target,
+		    ip,
+		    user_agent,
+		    outcome,
+		    created_at,
+		    prev_hash,
+		    hash
+		FROM audit_events
+		WHERE 
+		    TRUE`)
+	args := []any{sql.Named("limit", filter.Limit)}
+
+	if filter.Actor != "" {
+		query.WriteString(" AND\n\t\t    actor = @actor")
+		args = append(args, sql.Named("actor", filter.Actor))
+	}
+	if filter.Action != "" {
+		query.WriteString(" AND\n\t\t    action = @action")
+		args = append(args, sql.Named("action", filter.Action))
+	}
+	if !filter.CreatedFrom.IsZero() {
+		query.WriteString(" AND\n\t\t    created_at >= @createdFrom")
+		args = append(args, sql.Named("createdFrom", toMicros(filter.CreatedFrom)))
+	}
+	if !filter.CreatedTo.IsZero() {
+		query.WriteString(" AND\n\t\t    created_at < @createdTo")
+		args = append(args, sql.Named("createdTo", toMicros(filter.CreatedTo)))
+	}
+
+	direction := "ASC"
+	comparison := ">"
+	if filter.SortDirection == model.SortDesc {
+		direction = "DESC"
+		comparison = "<"
+	}
+	if filter.After != nil {
+		query.WriteString(" AND\n\t\t    (created_at, id) " + comparison + " (@afterCreatedAt, @afterId)")
+		args = append(args, sql.Named("afterCreatedAt", toMicros(filter.After.Time)),
+			sql.Named("afterId", filter.After.Key))
+	}
+	query.WriteString("\n\t\tORDER BY created_at " + direction + ", id " + direction + "\n\t\tLIMIT @limit\n\t")
+
+	rows, err := s.db.QueryContext(ctx, query.String(), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := make([]model.AuditEvent, 0)
+
+	for rows.Next() {
+		event, err := scanAuditEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+func (s *AuditEventStorageImpl) ForEachOrderByIDAsc(ctx context.Context, fn func(event model.AuditEvent) error) error {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT
+		    id,
+		    actor_id,
+		    actor,
+		    action,
+		    target,
+		    ip,
+		    user_agent,
+		    outcome,
+		    created_at,
+		    prev_hash,
+		    hash
+		FROM audit_events
+		ORDER BY id
+	`)
+
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		event, err := scanAuditEvent(rows)
+		if err != nil {
+			return err
+		}
+
+		if err := fn(event); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+func scanAuditEvent(row scanner) (model.AuditEvent, error) {
+	event := model.AuditEvent{}
+	var actorID sql.NullInt64
+	var createdAt int64
+	err := row.Scan(&event.ID, &actorID, &event.Actor, &event.Action, &event.Target, &event.IP, &event.UserAgent,
+		&event.Outcome, &createdAt, &event.PrevHash, &event.Hash)
+	if err != nil {
+		return model.AuditEvent{}, err
+	}
+
+	event.ActorID = actorID.Int64
+	event.CreatedAt = fromMicros(createdAt)
+	return event, nil
+}
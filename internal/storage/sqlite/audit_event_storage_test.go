@@ -0,0 +1,56 @@
+//go:build cgo
+
+package sqlite
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Stern-Ritter/gophermart/internal/logger"
+	"github.com/Stern-Ritter/gophermart/internal/model"
+)
+
+func TestAuditEventStorage(t *testing.T) {
+	ctx := context.Background()
+	l, err := logger.Initialize("error")
+	require.NoError(t, err, "Error init logger")
+
+	db := openTestDB(t)
+	auditEventStorage := NewAuditEventStorage(db, l)
+
+	createdAt := time.Date(2024, time.June, 1, 12, 0, 0, 0, time.UTC)
+	appended := make([]model.AuditEvent, 0)
+	for i, action := range []model.AuditAction{model.AuditActionSignUp, model.AuditActionSignIn,
+		model.AuditActionWithdraw} {
+		event, err := auditEventStorage.Append(ctx, model.AuditEvent{Actor: "user", Action: action, Target: "user",
+			IP: "192.0.2.1", UserAgent: "curl/8.0", Outcome: model.AuditOutcomeSuccess,
+			CreatedAt: createdAt.Add(time.Duration(i) * time.Minute)})
+		require.NoError(t, err, "Error append audit event")
+		appended = append(appended, event)
+	}
+	assert.Equal(t, model.AuditGenesisHash, appended[0].PrevHash, "First event should be chained to genesis hash")
+	assert.Equal(t, appended[0].Hash, appended[1].PrevHash, "Event should be chained to hash of previous event")
+
+	chained := make([]model.AuditEvent, 0)
+	err = auditEventStorage.ForEachOrderByIDAsc(ctx, func(event model.AuditEvent) error {
+		chained = append(chained, event)
+		return nil
+	})
+	require.NoError(t, err, "Error iterate audit events")
+	assert.Equal(t, appended, chained, "Audit events do not match expected audit events")
+
+	page, err := auditEventStorage.GetAllByFilter(ctx, model.AuditEventsFilter{
+		SortDirection: model.SortDesc,
+		After:         &model.Cursor{Time: appended[2].CreatedAt, Key: appended[2].ID},
+		Limit:         1,
+	})
+	require.NoError(t, err, "Error get audit events")
+	assert.Equal(t, []model.AuditEvent{appended[1]}, page, "Audit events page does not match expected page")
+
+	_, err = db.ExecContext(ctx, `DELETE FROM audit_events`)
+	assert.Error(t, err, "Audit events should be append-only")
+}
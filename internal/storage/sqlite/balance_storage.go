@@ -0,0 +1,37 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/Stern-Ritter/gophermart/internal/logger"
+	"github.com/Stern-Ritter/gophermart/internal/model"
+	"github.com/Stern-Ritter/gophermart/internal/storage"
+)
+
+type BalanceStorageImpl struct {
+	db     *sql.DB
+	logger *logger.ServerLogger
+}
+
+func NewBalanceStorage(db *sql.DB, logger *logger.ServerLogger) storage.BalanceStorage {
+	return &BalanceStorageImpl{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (s *BalanceStorageImpl) GetByUserID(ctx context.Context, userID int64) (model.Balance, error) {
+	accrualPoints, withdrawnPoints, adjustmentPoints, err := getPointsSumsByUserID(ctx, s.db, userID)
+	if err != nil {
+		return model.Balance{}, err
+	}
+
+	balance := model.Balance{
+		UserID:                userID,
+		CurrentPointsAmount:   accrualPoints - withdrawnPoints + adjustmentPoints,
+		WithdrawnPointsAmount: withdrawnPoints,
+	}
+
+	return balance, nil
+}
@@ -0,0 +1,33 @@
+//go:build cgo
+
+package sqlite
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Stern-Ritter/gophermart/internal/logger"
+	"github.com/Stern-Ritter/gophermart/internal/storage/storagetest"
+)
+
+func TestConformance(t *testing.T) {
+	l, err := logger.Initialize("error")
+	require.NoError(t, err, "Error init logger")
+
+	storagetest.Run(t, func(t *testing.T) storagetest.Storages {
+		db := openTestDB(t)
+		return storagetest.Storages{
+			Users:       NewUserStorage(db, l),
+			Accruals:    NewAccrualStorage(db, l),
+			Withdrawals: NewWithdrawnStorage(db, l),
+			Balances:    NewBalanceStorage(db, l),
+			Adjustments: NewAdjustmentStorage(db, l),
+		}
+	})
+}
+
+func testDatabaseURL(t *testing.T) string {
+	return Scheme + "://" + filepath.Join(t.TempDir(), "gophermart.db")
+}
@@ -0,0 +1,37 @@
+//go:build cgo
+
+package sqlite
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/mattn/go-sqlite3"
+)
+
+func constraintError(err error) (*pgconn.PgError, bool) {
+	var sqliteErr sqlite3.Error
+	if !errors.As(err, &sqliteErr) || sqliteErr.Code != sqlite3.ErrConstraint {
+		return nil, false
+	}
+
+	switch sqliteErr.ExtendedCode {
+	case sqlite3.ErrConstraintUnique, sqlite3.ErrConstraintPrimaryKey:
+		columns := strings.TrimPrefix(sqliteErr.Error(), "UNIQUE constraint failed: ")
+		return &pgconn.PgError{
+			Severity:       "ERROR",
+			Code:           uniqueViolationCode,
+			Message:        sqliteErr.Error(),
+			ConstraintName: uniqueConstraints[columns],
+		}, true
+	case sqlite3.ErrConstraintForeignKey:
+		return &pgconn.PgError{
+			Severity: "ERROR",
+			Code:     foreignKeyViolationCode,
+			Message:  sqliteErr.Error(),
+		}, true
+	}
+
+	return nil, false
+}
@@ -0,0 +1,12 @@
+//go:build !cgo
+
+package sqlite
+
+import (
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// constraintError never matches without cgo: the driver is a stub that fails to open the database.
+func constraintError(err error) (*pgconn.PgError, bool) {
+	return nil, false
+}
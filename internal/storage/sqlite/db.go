@@ -0,0 +1,150 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const (
+	Scheme     = "sqlite"
+	DriverName = "sqlite3"
+
+	uniqueViolationCode     = "23505"
+	foreignKeyViolationCode = "23503"
+)
+
+// defaultParams enables foreign keys, which SQLite doesn't check by default, lets concurrent readers work while a
+// write is in progress and makes transactions take the write lock on begin, so that two transactions that read
+// before writing don't fail with "database is locked" when upgrading their locks.
+var defaultParams = map[string]string{
+	"_foreign_keys": "1",
+	"_journal_mode": "WAL",
+	"_busy_timeout": "5000",
+	"_txlock":       "immediate",
+	"_synchronous":  "NORMAL",
+}
+
+// uniqueConstraints maps the columns SQLite reports for a unique violation to the constraint names of the Postgres
+// schema, which services check to tell an order uploaded by another user from one uploaded by the same user.
+var uniqueConstraints = map[string]string{
+	"users.login": "users_login_unique",
+	"loyalty_points_accrual.user_id, loyalty_points_accrual.order_number":     "pk_loyalty_points_accrual",
+	"loyalty_points_accrual.order_number":                                     "loyalty_points_accrual_order_number_unique",
+	"loyalty_points_withdrawn.user_id, loyalty_points_withdrawn.order_number": "pk_loyalty_points_withdrawn",
+	"loyalty_points_withdrawn.order_number":                                   "loyalty_points_withdrawn_order_number_unique",
+	"user_api_keys.key_hash":                                                  "user_api_keys_key_hash_unique",
+	"user_api_keys.user_id, user_api_keys.name":                               "user_api_keys_user_id_name_active_idx",
+	"user_recovery_codes.user_id, user_recovery_codes.code_hash":              "pk_user_recovery_codes",
+	"audit_events.hash":                                                       "audit_events_hash_unique",
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// Open opens the database file of the sqlite:// URL, e.g. sqlite:///var/lib/gophermart/gophermart.db for an absolute
+// path or sqlite://gophermart.db for a path relative to the working directory.
+func Open(databaseURL string) (*sql.DB, error) {
+	dsn, err := DataSourceName(databaseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return sql.Open(DriverName, dsn)
+}
+
+// DataSourceName converts the sqlite:// URL to the DSN of the driver, adding the default connection parameters
+// that the URL doesn't set.
+func DataSourceName(databaseURL string) (string, error) {
+	rest, found := strings.CutPrefix(databaseURL, Scheme+"://")
+	if !found {
+		return "", fmt.Errorf("sqlite database url should start with %s://", Scheme)
+	}
+
+	path, rawQuery, _ := strings.Cut(rest, "?")
+	if path == "" {
+		return "", fmt.Errorf("sqlite database url should contain database file path")
+	}
+
+	params, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse sqlite database url parameters: %w", err)
+	}
+	for key, value := range defaultParams {
+		if !params.Has(key) {
+			params.Set(key, value)
+		}
+	}
+
+	return "file:" + path + "?" + params.Encode(), nil
+}
+
+// translateError reports missing rows and constraint violations the way the Postgres storages do, so that services
+// handle both backends the same way.
+func translateError(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return pgx.ErrNoRows
+	}
+	if pgErr, ok := constraintError(err); ok {
+		return pgErr
+	}
+
+	return err
+}
+
+// translateOrderError reports a second upload of an order by its owner as the primary key violation, like Postgres
+// does. SQLite checks the order number unique index first and reports it even when both constraints are violated.
+func translateOrderError(ctx context.Context, q querier, table string, userID int64, orderNumber int64, err error) error {
+	err = translateError(err)
+
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || pgErr.ConstraintName != table+"_order_number_unique" {
+		return err
+	}
+
+	var ownerID int64
+	row := q.QueryRowContext(ctx, `SELECT user_id FROM `+table+` WHERE order_number = @orderNumber`,
+		sql.Named("orderNumber", orderNumber))
+	if row.Scan(&ownerID) == nil && ownerID == userID {
+		pgErr.ConstraintName = "pk_" + table
+	}
+
+	return err
+}
+
+// Times are stored as Unix microseconds, the precision of Postgres timestamps, so that they compare and sort as
+// numbers.
+func toMicros(t time.Time) int64 {
+	return t.UnixMicro()
+}
+
+func toNullMicros(t time.Time) sql.NullInt64 {
+	return sql.NullInt64{Int64: t.UnixMicro(), Valid: !t.IsZero()}
+}
+
+func fromMicros(v int64) time.Time {
+	return time.UnixMicro(v).UTC()
+}
+
+func fromNullMicros(v sql.NullInt64) time.Time {
+	if !v.Valid {
+		return time.Time{}
+	}
+	return fromMicros(v.Int64)
+}
@@ -0,0 +1,85 @@
+//go:build cgo
+
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Stern-Ritter/gophermart/internal/logger"
+	"github.com/Stern-Ritter/gophermart/internal/model"
+	"github.com/Stern-Ritter/gophermart/migrations"
+)
+
+func openTestDB(t *testing.T) *sql.DB {
+	databaseURL := testDatabaseURL(t)
+	dsn, err := DataSourceName(databaseURL)
+	require.NoError(t, err, "Error build data source name")
+	require.NoError(t, migrations.Migrate(dsn, migrations.DialectSQLite, DriverName), "Error migrate database")
+
+	db, err := Open(databaseURL)
+	require.NoError(t, err, "Error open database")
+	t.Cleanup(func() { db.Close() })
+
+	return db
+}
+
+func TestDataSourceName(t *testing.T) {
+	tests := []struct {
+		name        string
+		databaseURL string
+		want        string
+		wantErr     bool
+	}{
+		{
+			name:        "should add default parameters to absolute path",
+			databaseURL: "sqlite:///var/lib/gophermart/gophermart.db",
+			want: "file:/var/lib/gophermart/gophermart.db?_busy_timeout=5000&_foreign_keys=1&_journal_mode=WAL" +
+				"&_synchronous=NORMAL&_txlock=immediate",
+		},
+		{
+			name:        "should keep parameters set in url",
+			databaseURL: "sqlite://gophermart.db?_busy_timeout=100&cache=shared",
+			want: "file:gophermart.db?_busy_timeout=100&_foreign_keys=1&_journal_mode=WAL&_synchronous=NORMAL" +
+				"&_txlock=immediate&cache=shared",
+		},
+		{
+			name:        "should return error for url without path",
+			databaseURL: "sqlite://",
+			wantErr:     true,
+		},
+		{
+			name:        "should return error for other scheme",
+			databaseURL: "postgres://localhost/gophermart",
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dsn, err := DataSourceName(tt.databaseURL)
+			if tt.wantErr {
+				assert.Error(t, err, "Error should be returned")
+				return
+			}
+			require.NoError(t, err, "Error build data source name")
+			assert.Equal(t, tt.want, dsn, "Data source name does not match expected")
+		})
+	}
+}
+
+func saveTestUser(t *testing.T, db *sql.DB, login string) int64 {
+	l, err := logger.Initialize("error")
+	require.NoError(t, err, "Error init logger")
+
+	users := NewUserStorage(db, l)
+	require.NoError(t, users.Save(context.Background(), model.User{Login: login, Password: "hash"}), "Error save user")
+
+	user, err := users.GetOneByLogin(context.Background(), login)
+	require.NoError(t, err, "Error get user")
+	return user.ID
+}
@@ -0,0 +1,39 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/Stern-Ritter/gophermart/internal/logger"
+	"github.com/Stern-Ritter/gophermart/internal/storage"
+)
+
+type HealthStorageImpl struct {
+	db     *sql.DB
+	logger *logger.ServerLogger
+}
+
+func NewHealthStorage(db *sql.DB, logger *logger.ServerLogger) storage.HealthStorage {
+	return &HealthStorageImpl{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (s *HealthStorageImpl) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+func (s *HealthStorageImpl) GetMigrationVersion(ctx context.Context) (int64, error) {
+	row := s.db.QueryRowContext(ctx, `
+		SELECT
+			COALESCE(MAX(version_id), 0)
+		FROM goose_db_version
+		WHERE
+		    is_applied
+	`)
+
+	var version int64
+	err := row.Scan(&version)
+	return version, err
+}
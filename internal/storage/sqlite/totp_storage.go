@@ -0,0 +1,237 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Stern-Ritter/gophermart/internal/logger"
+	"github.com/Stern-Ritter/gophermart/internal/model"
+	"github.com/Stern-Ritter/gophermart/internal/storage"
+)
+
+type TotpStorageImpl struct {
+	db     *sql.DB
+	logger *logger.ServerLogger
+}
+
+func NewTotpStorage(db *sql.DB, logger *logger.ServerLogger) storage.TotpStorage {
+	return &TotpStorageImpl{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (s *TotpStorageImpl) Save(ctx context.Context, totp model.Totp) (err error) {
+	defer func() {
+		storage.LogChange(ctx, s.logger, "save totp", err, zap.Int64("user id", totp.UserID))
+	}()
+
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO user_totp
+		    (user_id, secret, enabled, last_used_step, created_at)
+		VALUES (@userId, @secret, FALSE, 0, @createdAt)
+		ON CONFLICT (user_id) DO UPDATE
+		SET secret = excluded.secret, last_used_step = 0, created_at = excluded.created_at
+		WHERE user_totp.enabled = FALSE
+	`,
+		sql.Named("userId", totp.UserID),
+		sql.Named("secret", totp.Secret),
+		sql.Named("createdAt", toMicros(totp.CreatedAt)),
+	)
+
+	return translateError(err)
+}
+
+func (s *TotpStorageImpl) GetOneByUserID(ctx context.Context, userID int64) (model.Totp, error) {
+	row := s.db.QueryRowContext(ctx, `
+		SELECT
+		    user_id,
+		    secret,
+		    enabled,
+		    last_used_step,
+		    created_at
+		FROM user_totp
+		WHERE
+		    user_id = @userId
+	`, sql.Named("userId", userID))
+
+	totp := model.Totp{}
+	var createdAt int64
+	err := row.Scan(&totp.UserID, &totp.Secret, &totp.Enabled, &totp.LastUsedStep, &createdAt)
+	if err != nil {
+		return model.Totp{}, translateError(err)
+	}
+	totp.CreatedAt = fromMicros(createdAt)
+
+	return totp, nil
+}
+
+func (s *TotpStorageImpl) Enable(ctx context.Context, userID int64, recoveryCodeHashes []string) (err error) {
+	defer func() {
+		storage.LogChange(ctx, s.logger, "enable totp", err, zap.Int64("user id", userID))
+	}()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE user_totp
+		SET enabled = TRUE
+		WHERE user_id = @userId
+	`, sql.Named("userId", userID))
+	if err != nil {
+		return err
+	}
+
+	err = saveRecoveryCodes(ctx, tx, userID, recoveryCodeHashes)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *TotpStorageImpl) Delete(ctx context.Context, userID int64) (err error) {
+	defer func() {
+		storage.LogChange(ctx, s.logger, "delete totp", err, zap.Int64("user id", userID))
+	}()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	_, err = tx.ExecContext(ctx, `
+		DELETE FROM user_recovery_codes
+		WHERE user_id = @userId
+	`, sql.Named("userId", userID))
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		DELETE FROM user_totp
+		WHERE user_id = @userId
+	`, sql.Named("userId", userID))
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *TotpStorageImpl) UpdateLastUsedStep(ctx context.Context, userID int64, step int64) (_ bool, err error) {
+	defer func() {
+		storage.LogChange(ctx, s.logger, "update totp last used step", err, zap.Int64("user id", userID))
+	}()
+
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE user_totp
+		SET last_used_step = @step
+		WHERE user_id = @userId AND last_used_step < @step
+	`,
+		sql.Named("userId", userID),
+		sql.Named("step", step),
+	)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+func (s *TotpStorageImpl) UseRecoveryCode(ctx context.Context, userID int64, codeHash string) (_ bool, err error) {
+	defer func() {
+		storage.LogChange(ctx, s.logger, "use recovery code", err, zap.Int64("user id", userID))
+	}()
+
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE user_recovery_codes
+		SET used_at = @usedAt
+		WHERE user_id = @userId AND code_hash = @codeHash AND used_at IS NULL
+	`,
+		sql.Named("userId", userID),
+		sql.Named("codeHash", codeHash),
+		sql.Named("usedAt", toMicros(time.Now())),
+	)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+// IncrementFailedAttempts counts a verification attempt and returns the number of attempts since the last successful
+// one. Attempts are forgotten when the previous one was made before windowStart.
+func (s *TotpStorageImpl) IncrementFailedAttempts(ctx context.Context, userID int64, attemptedAt time.Time,
+	windowStart time.Time) (_ int64, err error) {
+	defer func() {
+		storage.LogChange(ctx, s.logger, "increment totp failed attempts", err, zap.Int64("user id", userID))
+	}()
+
+	row := s.db.QueryRowContext(ctx, `
+		UPDATE user_totp
+		SET
+		    failed_attempts = CASE WHEN last_failed_attempt_at >= @windowStart THEN failed_attempts + 1 ELSE 1 END,
+		    last_failed_attempt_at = @attemptedAt
+		WHERE user_id = @userId
+		RETURNING failed_attempts
+	`,
+		sql.Named("userId", userID),
+		sql.Named("attemptedAt", toMicros(attemptedAt)),
+		sql.Named("windowStart", toMicros(windowStart)),
+	)
+
+	var attempts int64
+	err = row.Scan(&attempts)
+	return attempts, translateError(err)
+}
+
+func (s *TotpStorageImpl) ResetFailedAttempts(ctx context.Context, userID int64) (err error) {
+	defer func() {
+		storage.LogChange(ctx, s.logger, "reset totp failed attempts", err, zap.Int64("user id", userID))
+	}()
+
+	_, err = s.db.ExecContext(ctx, `
+		UPDATE user_totp
+		SET failed_attempts = 0, last_failed_attempt_at = NULL
+		WHERE user_id = @userId
+	`, sql.Named("userId", userID))
+
+	return err
+}
+
+func saveRecoveryCodes(ctx context.Context, tx *sql.Tx, userID int64, recoveryCodeHashes []string) error {
+	_, err := tx.ExecContext(ctx, `
+		DELETE FROM user_recovery_codes
+		WHERE user_id = @userId
+	`, sql.Named("userId", userID))
+	if err != nil {
+		return err
+	}
+
+	for _, codeHash := range recoveryCodeHashes {
+		_, err := tx.ExecContext(ctx, `
+		INSERT INTO user_recovery_codes
+		    (user_id, code_hash)
+		VALUES (@userId, @codeHash)
+		`,
+			sql.Named("userId", userID),
+			sql.Named("codeHash", codeHash),
+		)
+		if err != nil {
+			return translateError(err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,66 @@
+//go:build cgo
+
+package sqlite
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Stern-Ritter/gophermart/internal/logger"
+	"github.com/Stern-Ritter/gophermart/internal/model"
+)
+
+func TestTotpStorage(t *testing.T) {
+	ctx := context.Background()
+	l, err := logger.Initialize("error")
+	require.NoError(t, err, "Error init logger")
+
+	db := openTestDB(t)
+	userID := saveTestUser(t, db, "user")
+	totpStorage := NewTotpStorage(db, l)
+
+	totp := model.Totp{UserID: userID, Secret: "secret", CreatedAt: time.Date(2024, time.June, 1, 12, 0, 0, 0, time.UTC)}
+	require.NoError(t, totpStorage.Save(ctx, totp), "Error save totp")
+	require.NoError(t, totpStorage.Enable(ctx, userID, []string{"code 1", "code 2"}), "Error enable totp")
+
+	require.NoError(t, totpStorage.Save(ctx, model.Totp{UserID: userID, Secret: "other secret",
+		CreatedAt: totp.CreatedAt}), "Error save totp")
+	got, err := totpStorage.GetOneByUserID(ctx, userID)
+	require.NoError(t, err, "Error get totp")
+	totp.Enabled = true
+	assert.Equal(t, totp, got, "Enabled totp should not be replaced")
+
+	updated, err := totpStorage.UpdateLastUsedStep(ctx, userID, 10)
+	require.NoError(t, err, "Error update last used step")
+	assert.True(t, updated, "Last used step should be updated")
+	updated, err = totpStorage.UpdateLastUsedStep(ctx, userID, 10)
+	require.NoError(t, err, "Error update last used step")
+	assert.False(t, updated, "Same step should not be used twice")
+
+	used, err := totpStorage.UseRecoveryCode(ctx, userID, "code 1")
+	require.NoError(t, err, "Error use recovery code")
+	assert.True(t, used, "Recovery code should be used")
+	used, err = totpStorage.UseRecoveryCode(ctx, userID, "code 1")
+	require.NoError(t, err, "Error use recovery code")
+	assert.False(t, used, "Recovery code should not be used twice")
+
+	attemptedAt := time.Now()
+	for want := int64(1); want <= 3; want++ {
+		attempts, err := totpStorage.IncrementFailedAttempts(ctx, userID, attemptedAt, attemptedAt.Add(-time.Minute))
+		require.NoError(t, err, "Error increment failed attempts")
+		assert.Equal(t, want, attempts, "Failed attempts count does not match expected")
+	}
+
+	attempts, err := totpStorage.IncrementFailedAttempts(ctx, userID, attemptedAt.Add(time.Hour), attemptedAt.Add(time.Minute))
+	require.NoError(t, err, "Error increment failed attempts")
+	assert.Equal(t, int64(1), attempts, "Attempts made before window start should be forgotten")
+
+	require.NoError(t, totpStorage.ResetFailedAttempts(ctx, userID), "Error reset failed attempts")
+	attempts, err = totpStorage.IncrementFailedAttempts(ctx, userID, attemptedAt, attemptedAt.Add(-time.Minute))
+	require.NoError(t, err, "Error increment failed attempts")
+	assert.Equal(t, int64(1), attempts, "Failed attempts should be reset")
+}
@@ -0,0 +1,183 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Stern-Ritter/gophermart/internal/logger"
+	"github.com/Stern-Ritter/gophermart/internal/model"
+	"github.com/Stern-Ritter/gophermart/internal/storage"
+)
+
+type UserStorageImpl struct {
+	db     *sql.DB
+	logger *logger.ServerLogger
+}
+
+func NewUserStorage(db *sql.DB, logger *logger.ServerLogger) storage.UserStorage {
+	return &UserStorageImpl{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (s *UserStorageImpl) Save(ctx context.Context, user model.User) (err error) {
+	defer func() {
+		storage.LogChange(ctx, s.logger, "save user", err, zap.String("login", user.Login))
+	}()
+
+	role := user.Role
+	if role == "" {
+		role = model.UserRoleUser
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO users
+		    (login, password, role)
+		VALUES
+		    (@login, @password, @role)
+	`,
+		sql.Named("login", user.Login),
+		sql.Named("password", user.Password),
+		sql.Named("role", role),
+	)
+
+	return translateError(err)
+}
+
+func (s *UserStorageImpl) GetOneByLogin(ctx context.Context, login string) (model.User, error) {
+	row := s.db.QueryRowContext(ctx, `
+		SELECT
+			id,
+			login,
+			password,
+			role,
+			disabled_at IS NOT NULL
+		FROM users
+		WHERE
+		    login = @login
+	`, sql.Named("login", login))
+
+	user := model.User{}
+	err := row.Scan(&user.ID, &user.Login, &user.Password, &user.Role, &user.Disabled)
+
+	return user, translateError(err)
+}
+
+func (s *UserStorageImpl) GetOneByID(ctx context.Context, id int64) (model.User, error) {
+	row := s.db.QueryRowContext(ctx, `
+		SELECT
+			id,
+			login,
+			password,
+			role,
+			disabled_at IS NOT NULL
+		FROM users
+		WHERE
+		    id = @id
+	`, sql.Named("id", id))
+
+	user := model.User{}
+	err := row.Scan(&user.ID, &user.Login, &user.Password, &user.Role, &user.Disabled)
+
+	return user, translateError(err)
+}
+
+// GetAllByLoginContainingOrderByLogin relies on LIKE in SQLite being case-insensitive for ASCII letters, like ILIKE.
+func (s *UserStorageImpl) GetAllByLoginContainingOrderByLogin(ctx context.Context, login string,
+	limit int64) ([]model.User, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT
+			id,
+			login,
+			password,
+			role,
+			disabled_at IS NOT NULL
+		FROM users
+		WHERE
+		    login LIKE @pattern ESCAPE '\'
+		ORDER BY login
+		LIMIT @limit
+	`,
+		sql.Named("pattern", "%"+escapeLikePattern(login)+"%"),
+		sql.Named("limit", limit),
+	)
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := make([]model.User, 0)
+
+	for rows.Next() {
+		user := model.User{}
+		if err := rows.Scan(&user.ID, &user.Login, &user.Password, &user.Role, &user.Disabled); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+func escapeLikePattern(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
+}
+
+func (s *UserStorageImpl) UpdatePassword(ctx context.Context, userID int64, passwordHash string) (err error) {
+	defer func() {
+		storage.LogChange(ctx, s.logger, "update user password", err, zap.Int64("user id", userID))
+	}()
+
+	_, err = s.db.ExecContext(ctx, `
+		UPDATE users
+		SET password = @password
+		WHERE id = @id
+	`,
+		sql.Named("password", passwordHash),
+		sql.Named("id", userID),
+	)
+
+	return err
+}
+
+func (s *UserStorageImpl) DisableByLogin(ctx context.Context, login string) (err error) {
+	defer func() {
+		storage.LogChange(ctx, s.logger, "disable user", err, zap.String("login", login))
+	}()
+
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE users
+		SET disabled_at = COALESCE(disabled_at, @disabledAt)
+		WHERE login = @login
+	`,
+		sql.Named("disabledAt", toMicros(time.Now())),
+		sql.Named("login", login),
+	)
+	if err != nil {
+		return err
+	}
+
+	return requireAffected(result)
+}
+
+// requireAffected returns the no rows error when the statement changed nothing.
+func requireAffected(result sql.Result) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return translateError(sql.ErrNoRows)
+	}
+	return nil
+}
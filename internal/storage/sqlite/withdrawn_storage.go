@@ -0,0 +1,218 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"go.uber.org/zap"
+
+	er "github.com/Stern-Ritter/gophermart/internal/errors"
+	"github.com/Stern-Ritter/gophermart/internal/logger"
+	"github.com/Stern-Ritter/gophermart/internal/model"
+	"github.com/Stern-Ritter/gophermart/internal/storage"
+	"github.com/Stern-Ritter/gophermart/internal/utils"
+)
+
+type WithdrawnStorageImpl struct {
+	db     *sql.DB
+	logger *logger.ServerLogger
+}
+
+func NewWithdrawnStorage(db *sql.DB, logger *logger.ServerLogger) storage.WithdrawnStorage {
+	return &WithdrawnStorageImpl{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Save checks the balance and inserts the withdrawal in one transaction, which takes the database write lock on
+// begin, so that concurrent withdrawals can't spend the same points.
+func (s *WithdrawnStorageImpl) Save(ctx context.Context, withdrawn model.Withdrawn) (err error) {
+	defer func() {
+		storage.LogChange(ctx, s.logger, "save withdrawn", err, zap.Int64("order number", withdrawn.OrderNumber))
+	}()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	accrued, withdrawnPoints, adjusted, err := getPointsSumsByUserID(ctx, tx, withdrawn.UserID)
+	if err != nil {
+		return err
+	}
+
+	if utils.Float64Compare(accrued-withdrawnPoints+adjusted, withdrawn.PointsAmount) < 0 {
+		return er.NewPaymentRequiredError("Not enough loyalty points to withdrawn", nil)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO loyalty_points_withdrawn
+		(user_id, order_number, processed_at, amount)
+		VALUES (@userId, @orderNumber, @processedAt, @amount)
+	`,
+		sql.Named("userId", withdrawn.UserID),
+		sql.Named("orderNumber", withdrawn.OrderNumber),
+		sql.Named("processedAt", toMicros(withdrawn.ProcessedAt)),
+		sql.Named("amount", withdrawn.PointsAmount),
+	)
+
+	if err != nil {
+		return translateOrderError(ctx, tx, "loyalty_points_withdrawn", withdrawn.UserID, withdrawn.OrderNumber, err)
+	}
+
+	return tx.Commit()
+}
+
+func (s *WithdrawnStorageImpl) GetAllByUserIDOrderByProcessedAtAsc(ctx context.Context, userID int64) ([]model.Withdrawn, error) {
+	withdrawals := make([]model.Withdrawn, 0)
+
+	err := s.ForEachByUserIDOrderByProcessedAtAsc(ctx, userID, func(withdrawn model.Withdrawn) error {
+		withdrawals = append(withdrawals, withdrawn)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return withdrawals, nil
+}
+
+func (s *WithdrawnStorageImpl) ForEachByUserIDOrderByProcessedAtAsc(ctx context.Context, userID int64,
+	fn func(withdrawn model.Withdrawn) error) error {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT
+		    user_id,
+		    order_number,
+		    processed_at,
+		    amount
+		FROM loyalty_points_withdrawn
+		WHERE
+		    user_id = @userId
+		ORDER BY processed_at
+	`, sql.Named("userId", userID))
+
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		withdrawn, err := scanWithdrawn(rows)
+		if err != nil {
+			return err
+		}
+
+		if err := fn(withdrawn); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+func (s *WithdrawnStorageImpl) GetAllByFilter(ctx context.Context, filter model.WithdrawalsFilter) ([]model.Withdrawn, error) {
+	query := strings.Builder{}
+	query.WriteString(`
+		SELECT
+		    user_id,
+		    order_number,
+		    processed_at,
+		    amount
+		FROM loyalty_points_withdrawn
+		WHERE
+		    user_id = @userId`)
+	args := writeWithdrawalsFilterConditions(&query, filter)
+	args = append(args, sql.Named("limit", filter.Limit))
+
+	direction := "ASC"
+	comparison := ">"
+	if filter.SortDirection == model.SortDesc {
+		direction = "DESC"
+		comparison = "<"
+	}
+	if filter.After != nil {
+		query.WriteString(" AND\n\t\t    (processed_at, order_number) " + comparison + " (@afterProcessedAt, @afterOrderNumber)")
+		args = append(args, sql.Named("afterProcessedAt", toMicros(filter.After.Time)),
+			sql.Named("afterOrderNumber", filter.After.Key))
+	}
+	query.WriteString("\n\t\tORDER BY processed_at " + direction + ", order_number " + direction + "\n\t\tLIMIT @limit\n\t")
+
+	rows, err := s.db.QueryContext(ctx, query.String(), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	withdrawals := make([]model.Withdrawn, 0)
+
+	for rows.Next() {
+		withdrawn, err := scanWithdrawn(rows)
+		if err != nil {
+			return nil, err
+		}
+		withdrawals = append(withdrawals, withdrawn)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return withdrawals, nil
+}
+
+func (s *WithdrawnStorageImpl) CountByFilter(ctx context.Context, filter model.WithdrawalsFilter) (int64, error) {
+	query := strings.Builder{}
+	query.WriteString(`
+		SELECT COUNT(*)
+		FROM loyalty_points_withdrawn
+		WHERE
+		    user_id = @userId`)
+	args := writeWithdrawalsFilterConditions(&query, filter)
+	query.WriteString("\n\t")
+
+	var count int64
+	err := s.db.QueryRowContext(ctx, query.String(), args...).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func writeWithdrawalsFilterConditions(query *strings.Builder, filter model.WithdrawalsFilter) []any {
+	args := []any{sql.Named("userId", filter.UserID)}
+
+	if !filter.ProcessedFrom.IsZero() {
+		query.WriteString(" AND\n\t\t    processed_at >= @processedFrom")
+		args = append(args, sql.Named("processedFrom", toMicros(filter.ProcessedFrom)))
+	}
+	if !filter.ProcessedTo.IsZero() {
+		query.WriteString(" AND\n\t\t    processed_at < @processedTo")
+		args = append(args, sql.Named("processedTo", toMicros(filter.ProcessedTo)))
+	}
+	if filter.MinSum > 0 {
+		query.WriteString(" AND\n\t\t    amount >= @minSum")
+		args = append(args, sql.Named("minSum", filter.MinSum))
+	}
+	if filter.MaxSum > 0 {
+		query.WriteString(" AND\n\t\t    amount <= @maxSum")
+		args = append(args, sql.Named("maxSum", filter.MaxSum))
+	}
+
+	return args
+}
+
+func scanWithdrawn(row scanner) (model.Withdrawn, error) {
+	withdrawn := model.Withdrawn{}
+	var processedAt int64
+	err := row.Scan(&withdrawn.UserID, &withdrawn.OrderNumber, &processedAt, &withdrawn.PointsAmount)
+	if err != nil {
+		return model.Withdrawn{}, err
+	}
+	withdrawn.ProcessedAt = fromMicros(processedAt)
+
+	return withdrawn, nil
+}
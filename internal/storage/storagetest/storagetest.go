@@ -0,0 +1,472 @@
+// Package storagetest contains the conformance suite shared by the storage backends. Every backend should report
+// missing rows and constraint violations the same way, so that services behave identically on top of them.
+package storagetest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	er "github.com/Stern-Ritter/gophermart/internal/errors"
+	"github.com/Stern-Ritter/gophermart/internal/model"
+	"github.com/Stern-Ritter/gophermart/internal/storage"
+)
+
+type Storages struct {
+	Users       storage.UserStorage
+	Accruals    storage.AccrualStorage
+	Withdrawals storage.WithdrawnStorage
+	Balances    storage.BalanceStorage
+	Adjustments storage.AdjustmentStorage
+}
+
+// Run runs the suite, newStorages should return storages on top of an empty database for every test.
+func Run(t *testing.T, newStorages func(t *testing.T) Storages) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, s Storages)
+	}{
+		{name: "should return saved user by login and id", run: testUserSaveAndGet},
+		{name: "should reject duplicate login", run: testUserLoginUnique},
+		{name: "should return no rows for missing user", run: testUserNotFound},
+		{name: "should find users by login part ordered by login", run: testUserSearch},
+		{name: "should disable user by login", run: testUserDisable},
+		{name: "should reject order uploaded twice by same or another user", run: testAccrualOwnership},
+		{name: "should report batch items by order ownership", run: testAccrualSaveInBatch},
+		{name: "should filter and paginate accruals", run: testAccrualFilter},
+		{name: "should claim unprocessed accruals once and requeue them", run: testAccrualClaimAndRequeue},
+		{name: "should count accruals by status", run: testAccrualCountByStatus},
+		{name: "should derive balance from accruals, withdrawals and adjustments", run: testBalance},
+		{name: "should reject withdrawal when points are not enough", run: testWithdrawnInsufficientFunds},
+		{name: "should reject withdrawal of used order number", run: testWithdrawnOrderUnique},
+		{name: "should filter, paginate and count withdrawals", run: testWithdrawnFilter},
+		{name: "should reject adjustment debiting more than balance", run: testAdjustmentDebit},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.run(t, newStorages(t))
+		})
+	}
+}
+
+var baseTime = time.Date(2024, time.March, 1, 10, 0, 0, 0, time.UTC)
+
+func saveUser(t *testing.T, s Storages, login string) model.User {
+	ctx := context.Background()
+	err := s.Users.Save(ctx, model.User{Login: login, Password: "hash"})
+	require.NoError(t, err, "Error save user")
+
+	user, err := s.Users.GetOneByLogin(ctx, login)
+	require.NoError(t, err, "Error get user")
+	return user
+}
+
+func saveProcessedAccrual(t *testing.T, s Storages, userID int64, orderNumber int64, amount float64) {
+	ctx := context.Background()
+	err := s.Accruals.Save(ctx, model.Accrual{UserID: userID, OrderNumber: orderNumber, Status: model.AccrualNew,
+		UploadedAt: baseTime})
+	require.NoError(t, err, "Error save accrual")
+
+	err = s.Accruals.UpdateInBatch(ctx, []model.Accrual{{UserID: userID, OrderNumber: orderNumber,
+		Status: model.AccrualProcessed, PointsAmount: amount, ProcessedAt: baseTime}})
+	require.NoError(t, err, "Error update accrual")
+}
+
+func constraintName(err error) string {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.ConstraintName
+	}
+	return ""
+}
+
+func orderNumbers(accruals []model.Accrual) []int64 {
+	numbers := make([]int64, len(accruals))
+	for i, accrual := range accruals {
+		numbers[i] = accrual.OrderNumber
+	}
+	return numbers
+}
+
+func withdrawnOrderNumbers(withdrawals []model.Withdrawn) []int64 {
+	numbers := make([]int64, len(withdrawals))
+	for i, withdrawn := range withdrawals {
+		numbers[i] = withdrawn.OrderNumber
+	}
+	return numbers
+}
+
+func testUserSaveAndGet(t *testing.T, s Storages) {
+	user := saveUser(t, s, "user")
+	assert.Equal(t, "user", user.Login, "Login does not match expected login")
+	assert.Equal(t, model.UserRoleUser, user.Role, "Role does not match expected default role")
+	assert.False(t, user.Disabled, "New user should not be disabled")
+
+	byID, err := s.Users.GetOneByID(context.Background(), user.ID)
+	require.NoError(t, err, "Error get user by id")
+	assert.Equal(t, user, byID, "User does not match expected user")
+}
+
+func testUserLoginUnique(t *testing.T, s Storages) {
+	saveUser(t, s, "user")
+
+	err := s.Users.Save(context.Background(), model.User{Login: "user", Password: "hash"})
+	assert.Equal(t, "users_login_unique", constraintName(err), "Error does not match expected unique violation")
+}
+
+func testUserNotFound(t *testing.T, s Storages) {
+	ctx := context.Background()
+
+	_, err := s.Users.GetOneByLogin(ctx, "missing")
+	assert.ErrorIs(t, err, pgx.ErrNoRows, "Error does not match expected no rows error")
+
+	_, err = s.Users.GetOneByID(ctx, 1000)
+	assert.ErrorIs(t, err, pgx.ErrNoRows, "Error does not match expected no rows error")
+
+	err = s.Users.DisableByLogin(ctx, "missing")
+	assert.ErrorIs(t, err, pgx.ErrNoRows, "Error does not match expected no rows error")
+}
+
+func testUserSearch(t *testing.T, s Storages) {
+	for _, login := range []string{"charlie", "alice", "bob", "alina"} {
+		saveUser(t, s, login)
+	}
+
+	users, err := s.Users.GetAllByLoginContainingOrderByLogin(context.Background(), "LI", 2)
+	require.NoError(t, err, "Error search users")
+
+	logins := make([]string, len(users))
+	for i, user := range users {
+		logins[i] = user.Login
+	}
+	assert.Equal(t, []string{"alice", "alina"}, logins, "Logins do not match expected logins")
+}
+
+func testUserDisable(t *testing.T, s Storages) {
+	ctx := context.Background()
+	saveUser(t, s, "user")
+
+	require.NoError(t, s.Users.DisableByLogin(ctx, "user"), "Error disable user")
+	require.NoError(t, s.Users.DisableByLogin(ctx, "user"), "Error disable disabled user")
+
+	user, err := s.Users.GetOneByLogin(ctx, "user")
+	require.NoError(t, err, "Error get user")
+	assert.True(t, user.Disabled, "User should be disabled")
+}
+
+func testAccrualOwnership(t *testing.T, s Storages) {
+	ctx := context.Background()
+	owner := saveUser(t, s, "owner")
+	other := saveUser(t, s, "other")
+
+	accrual := model.Accrual{UserID: owner.ID, OrderNumber: 12345678903, Status: model.AccrualNew, UploadedAt: baseTime}
+	require.NoError(t, s.Accruals.Save(ctx, accrual), "Error save accrual")
+
+	err := s.Accruals.Save(ctx, accrual)
+	assert.Equal(t, "pk_loyalty_points_accrual", constraintName(err),
+		"Error does not match expected primary key violation")
+
+	accrual.UserID = other.ID
+	err = s.Accruals.Save(ctx, accrual)
+	assert.Equal(t, "loyalty_points_accrual_order_number_unique", constraintName(err),
+		"Error does not match expected order number unique violation")
+
+	saved, err := s.Accruals.GetOneByOrderNumber(ctx, accrual.OrderNumber)
+	require.NoError(t, err, "Error get accrual")
+	assert.Equal(t, owner.ID, saved.UserID, "User id does not match expected owner id")
+	assert.Equal(t, model.AccrualNew, saved.Status, "Status does not match expected status")
+	assert.True(t, saved.UploadedAt.Equal(baseTime), "Uploaded at does not match expected time")
+	assert.True(t, saved.ProcessedAt.IsZero(), "Processed at should not be set")
+
+	_, err = s.Accruals.GetOneByOrderNumber(ctx, 79927398713)
+	assert.ErrorIs(t, err, pgx.ErrNoRows, "Error does not match expected no rows error")
+}
+
+func testAccrualSaveInBatch(t *testing.T, s Storages) {
+	ctx := context.Background()
+	owner := saveUser(t, s, "owner")
+	other := saveUser(t, s, "other")
+
+	require.NoError(t, s.Accruals.Save(ctx, model.Accrual{UserID: owner.ID, OrderNumber: 1, Status: model.AccrualNew,
+		UploadedAt: baseTime}), "Error save accrual")
+	require.NoError(t, s.Accruals.Save(ctx, model.Accrual{UserID: other.ID, OrderNumber: 2, Status: model.AccrualNew,
+		UploadedAt: baseTime}), "Error save accrual")
+
+	results, err := s.Accruals.SaveInBatch(ctx, []model.Accrual{
+		{UserID: owner.ID, OrderNumber: 3, Status: model.AccrualNew, UploadedAt: baseTime},
+		{UserID: owner.ID, OrderNumber: 1, Status: model.AccrualNew, UploadedAt: baseTime},
+		{UserID: owner.ID, OrderNumber: 2, Status: model.AccrualNew, UploadedAt: baseTime},
+	})
+	require.NoError(t, err, "Error save accruals batch")
+	assert.Equal(t, []model.AccrualBatchItemResult{
+		{OrderNumber: 3, Status: model.AccrualBatchItemAccepted},
+		{OrderNumber: 1, Status: model.AccrualBatchItemAlreadyUploaded},
+		{OrderNumber: 2, Status: model.AccrualBatchItemConflict},
+	}, results, "Batch results do not match expected results")
+
+	accruals, err := s.Accruals.GetAllByUserIDOrderByUploadedAtAsc(ctx, owner.ID)
+	require.NoError(t, err, "Error get accruals")
+	assert.ElementsMatch(t, []int64{1, 3}, orderNumbers(accruals), "Order numbers do not match expected")
+}
+
+func testAccrualFilter(t *testing.T, s Storages) {
+	ctx := context.Background()
+	user := saveUser(t, s, "user")
+	other := saveUser(t, s, "other")
+
+	for i := int64(1); i <= 4; i++ {
+		require.NoError(t, s.Accruals.Save(ctx, model.Accrual{UserID: user.ID, OrderNumber: i, Status: model.AccrualNew,
+			UploadedAt: baseTime.Add(time.Duration(i) * time.Hour)}), "Error save accrual")
+	}
+	require.NoError(t, s.Accruals.Save(ctx, model.Accrual{UserID: other.ID, OrderNumber: 5, Status: model.AccrualNew,
+		UploadedAt: baseTime}), "Error save accrual")
+	require.NoError(t, s.Accruals.UpdateInBatch(ctx, []model.Accrual{{UserID: user.ID, OrderNumber: 2,
+		Status: model.AccrualInvalid, ProcessedAt: baseTime}}), "Error update accrual")
+
+	tests := []struct {
+		name   string
+		filter model.AccrualsFilter
+		want   []int64
+	}{
+		{
+			name:   "should return user accruals in ascending order",
+			filter: model.AccrualsFilter{UserID: user.ID, SortDirection: model.SortAsc, Limit: 10},
+			want:   []int64{1, 2, 3, 4},
+		},
+		{
+			name:   "should return user accruals in descending order with limit",
+			filter: model.AccrualsFilter{UserID: user.ID, SortDirection: model.SortDesc, Limit: 2},
+			want:   []int64{4, 3},
+		},
+		{
+			name: "should return accruals after cursor",
+			filter: model.AccrualsFilter{UserID: user.ID, SortDirection: model.SortAsc, Limit: 10,
+				After: &model.Cursor{Time: baseTime.Add(2 * time.Hour), Key: 2}},
+			want: []int64{3, 4},
+		},
+		{
+			name: "should return accruals with statuses uploaded in range",
+			filter: model.AccrualsFilter{UserID: user.ID, SortDirection: model.SortAsc, Limit: 10,
+				Statuses:     []model.AccrualStatus{model.AccrualNew, model.AccrualInvalid},
+				UploadedFrom: baseTime.Add(2 * time.Hour), UploadedTo: baseTime.Add(4 * time.Hour)},
+			want: []int64{2, 3},
+		},
+		{
+			name: "should return accruals with status",
+			filter: model.AccrualsFilter{UserID: user.ID, SortDirection: model.SortAsc, Limit: 10,
+				Statuses: []model.AccrualStatus{model.AccrualInvalid}},
+			want: []int64{2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			accruals, err := s.Accruals.GetAllByFilter(ctx, tt.filter)
+			require.NoError(t, err, "Error get accruals by filter")
+			assert.Equal(t, tt.want, orderNumbers(accruals), "Order numbers do not match expected")
+		})
+	}
+}
+
+func testAccrualClaimAndRequeue(t *testing.T, s Storages) {
+	ctx := context.Background()
+	user := saveUser(t, s, "user")
+
+	for i := int64(1); i <= 3; i++ {
+		require.NoError(t, s.Accruals.Save(ctx, model.Accrual{UserID: user.ID, OrderNumber: i, Status: model.AccrualNew,
+			UploadedAt: baseTime.Add(time.Duration(i) * time.Hour)}), "Error save accrual")
+	}
+
+	claimed, err := s.Accruals.GetAllUnprocessedWithLimit(ctx, 2)
+	require.NoError(t, err, "Error claim accruals")
+	assert.Equal(t, []int64{1, 2}, orderNumbers(claimed), "Claimed order numbers do not match expected")
+	for _, accrual := range claimed {
+		assert.Equal(t, model.AccrualProcessing, accrual.Status, "Claimed accrual status does not match expected")
+	}
+
+	claimed, err = s.Accruals.GetAllUnprocessedWithLimit(ctx, 10)
+	require.NoError(t, err, "Error claim accruals")
+	assert.Equal(t, []int64{3}, orderNumbers(claimed), "Claimed order numbers do not match expected")
+
+	require.NoError(t, s.Accruals.UpdateInBatch(ctx, []model.Accrual{
+		{UserID: user.ID, OrderNumber: 1, Status: model.AccrualProcessed, PointsAmount: 10, ProcessedAt: baseTime},
+		{UserID: user.ID, OrderNumber: 2, Status: model.AccrualProcessing},
+	}), "Error update accruals")
+
+	claimed, err = s.Accruals.GetAllUnprocessedWithLimit(ctx, 10)
+	require.NoError(t, err, "Error claim accruals")
+	assert.Equal(t, []int64{2}, orderNumbers(claimed), "Released order numbers do not match expected")
+
+	err = s.Accruals.Requeue(ctx, 1)
+	assert.ErrorIs(t, err, pgx.ErrNoRows, "Requeue of processed order should return no rows error")
+
+	require.NoError(t, s.Accruals.Requeue(ctx, 3), "Error requeue accrual")
+	requeued, err := s.Accruals.GetOneByOrderNumber(ctx, 3)
+	require.NoError(t, err, "Error get accrual")
+	assert.Equal(t, model.AccrualNew, requeued.Status, "Requeued accrual status does not match expected")
+
+	claimed, err = s.Accruals.GetAllUnprocessedWithLimit(ctx, 10)
+	require.NoError(t, err, "Error claim accruals")
+	assert.Equal(t, []int64{3}, orderNumbers(claimed), "Requeued order numbers do not match expected")
+}
+
+func testAccrualCountByStatus(t *testing.T, s Storages) {
+	ctx := context.Background()
+	user := saveUser(t, s, "user")
+
+	saveProcessedAccrual(t, s, user.ID, 1, 10)
+	for i := int64(2); i <= 3; i++ {
+		require.NoError(t, s.Accruals.Save(ctx, model.Accrual{UserID: user.ID, OrderNumber: i, Status: model.AccrualNew,
+			UploadedAt: baseTime}), "Error save accrual")
+	}
+
+	counts, err := s.Accruals.CountByStatus(ctx)
+	require.NoError(t, err, "Error count accruals")
+	assert.Equal(t, int64(1), counts[model.AccrualProcessed], "Processed count does not match expected")
+	assert.Equal(t, int64(2), counts[model.AccrualNew], "New count does not match expected")
+	assert.Zero(t, counts[model.AccrualInvalid], "Invalid count does not match expected")
+}
+
+func testBalance(t *testing.T, s Storages) {
+	ctx := context.Background()
+	user := saveUser(t, s, "user")
+	admin := saveUser(t, s, "admin")
+
+	saveProcessedAccrual(t, s, user.ID, 1, 100)
+	require.NoError(t, s.Accruals.Save(ctx, model.Accrual{UserID: user.ID, OrderNumber: 2, Status: model.AccrualNew,
+		UploadedAt: baseTime}), "Error save accrual")
+	require.NoError(t, s.Withdrawals.Save(ctx, model.Withdrawn{UserID: user.ID, OrderNumber: 3, PointsAmount: 30,
+		ProcessedAt: baseTime}), "Error save withdrawn")
+	_, err := s.Adjustments.Save(ctx, model.Adjustment{UserID: user.ID, AdminID: admin.ID, PointsAmount: 5.5,
+		ReasonCode: model.AdjustmentGoodwill, CreatedAt: baseTime})
+	require.NoError(t, err, "Error save adjustment")
+
+	balance, err := s.Balances.GetByUserID(ctx, user.ID)
+	require.NoError(t, err, "Error get balance")
+	assert.InDelta(t, 75.5, balance.CurrentPointsAmount, 1e-9, "Current points do not match expected")
+	assert.InDelta(t, 30.0, balance.WithdrawnPointsAmount, 1e-9, "Withdrawn points do not match expected")
+
+	balance, err = s.Balances.GetByUserID(ctx, admin.ID)
+	require.NoError(t, err, "Error get balance")
+	assert.Zero(t, balance.CurrentPointsAmount, "Current points of user without operations should be zero")
+}
+
+func testWithdrawnInsufficientFunds(t *testing.T, s Storages) {
+	ctx := context.Background()
+	user := saveUser(t, s, "user")
+	saveProcessedAccrual(t, s, user.ID, 1, 50)
+
+	err := s.Withdrawals.Save(ctx, model.Withdrawn{UserID: user.ID, OrderNumber: 2, PointsAmount: 50.01,
+		ProcessedAt: baseTime})
+	var paymentRequiredErr er.PaymentRequiredError
+	assert.ErrorAs(t, err, &paymentRequiredErr, "Error does not match expected payment required error")
+
+	require.NoError(t, s.Withdrawals.Save(ctx, model.Withdrawn{UserID: user.ID, OrderNumber: 2, PointsAmount: 50,
+		ProcessedAt: baseTime}), "Error save withdrawn")
+
+	withdrawals, err := s.Withdrawals.GetAllByUserIDOrderByProcessedAtAsc(ctx, user.ID)
+	require.NoError(t, err, "Error get withdrawals")
+	assert.Equal(t, []int64{2}, withdrawnOrderNumbers(withdrawals), "Order numbers do not match expected")
+}
+
+func testWithdrawnOrderUnique(t *testing.T, s Storages) {
+	ctx := context.Background()
+	user := saveUser(t, s, "user")
+	other := saveUser(t, s, "other")
+	saveProcessedAccrual(t, s, user.ID, 1, 100)
+	saveProcessedAccrual(t, s, other.ID, 2, 100)
+
+	withdrawn := model.Withdrawn{UserID: user.ID, OrderNumber: 3, PointsAmount: 10, ProcessedAt: baseTime}
+	require.NoError(t, s.Withdrawals.Save(ctx, withdrawn), "Error save withdrawn")
+
+	err := s.Withdrawals.Save(ctx, withdrawn)
+	assert.Equal(t, "pk_loyalty_points_withdrawn", constraintName(err),
+		"Error does not match expected primary key violation")
+
+	withdrawn.UserID = other.ID
+	err = s.Withdrawals.Save(ctx, withdrawn)
+	assert.Equal(t, "loyalty_points_withdrawn_order_number_unique", constraintName(err),
+		"Error does not match expected order number unique violation")
+}
+
+func testWithdrawnFilter(t *testing.T, s Storages) {
+	ctx := context.Background()
+	user := saveUser(t, s, "user")
+	saveProcessedAccrual(t, s, user.ID, 1, 1000)
+
+	for i := int64(2); i <= 5; i++ {
+		require.NoError(t, s.Withdrawals.Save(ctx, model.Withdrawn{UserID: user.ID, OrderNumber: i,
+			PointsAmount: float64(i * 10), ProcessedAt: baseTime.Add(time.Duration(i) * time.Hour)}),
+			"Error save withdrawn")
+	}
+
+	tests := []struct {
+		name      string
+		filter    model.WithdrawalsFilter
+		want      []int64
+		wantCount int64
+	}{
+		{
+			name:      "should return withdrawals in descending order with limit",
+			filter:    model.WithdrawalsFilter{UserID: user.ID, SortDirection: model.SortDesc, Limit: 2},
+			want:      []int64{5, 4},
+			wantCount: 4,
+		},
+		{
+			name: "should return withdrawals after cursor",
+			filter: model.WithdrawalsFilter{UserID: user.ID, SortDirection: model.SortDesc, Limit: 10,
+				After: &model.Cursor{Time: baseTime.Add(4 * time.Hour), Key: 4}},
+			want:      []int64{3, 2},
+			wantCount: 4,
+		},
+		{
+			name: "should return withdrawals in sum and time range",
+			filter: model.WithdrawalsFilter{UserID: user.ID, SortDirection: model.SortAsc, Limit: 10,
+				MinSum: 30, MaxSum: 50, ProcessedFrom: baseTime, ProcessedTo: baseTime.Add(5 * time.Hour)},
+			want:      []int64{3, 4},
+			wantCount: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withdrawals, err := s.Withdrawals.GetAllByFilter(ctx, tt.filter)
+			require.NoError(t, err, "Error get withdrawals by filter")
+			assert.Equal(t, tt.want, withdrawnOrderNumbers(withdrawals), "Order numbers do not match expected")
+
+			count, err := s.Withdrawals.CountByFilter(ctx, tt.filter)
+			require.NoError(t, err, "Error count withdrawals by filter")
+			assert.Equal(t, tt.wantCount, count, "Count does not match expected")
+		})
+	}
+}
+
+func testAdjustmentDebit(t *testing.T, s Storages) {
+	ctx := context.Background()
+	user := saveUser(t, s, "user")
+	admin := saveUser(t, s, "admin")
+	saveProcessedAccrual(t, s, user.ID, 1, 10)
+
+	_, err := s.Adjustments.Save(ctx, model.Adjustment{UserID: user.ID, AdminID: admin.ID, PointsAmount: -10.5,
+		ReasonCode: model.AdjustmentFraudCorrection, CreatedAt: baseTime})
+	var conflictErr er.ConflictError
+	assert.ErrorAs(t, err, &conflictErr, "Error does not match expected conflict error")
+
+	saved, err := s.Adjustments.Save(ctx, model.Adjustment{UserID: user.ID, AdminID: admin.ID, PointsAmount: -10,
+		ReasonCode: model.AdjustmentFraudCorrection, Note: "chargeback", CreatedAt: baseTime})
+	require.NoError(t, err, "Error save adjustment")
+	assert.NotZero(t, saved.ID, "Adjustment id should be assigned")
+
+	adjustments, err := s.Adjustments.GetAllByUserIDOrderByCreatedAtAsc(ctx, user.ID)
+	require.NoError(t, err, "Error get adjustments")
+	require.Len(t, adjustments, 1, "Adjustments count does not match expected")
+	assert.Equal(t, saved.ID, adjustments[0].ID, "Adjustment id does not match expected")
+	assert.Equal(t, "chargeback", adjustments[0].Note, "Adjustment note does not match expected")
+}
@@ -0,0 +1,252 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+
+	"github.com/Stern-Ritter/gophermart/internal/logger"
+	"github.com/Stern-Ritter/gophermart/internal/model"
+)
+
+type TotpStorage interface {
+	Save(ctx context.Context, totp model.Totp) error
+	GetOneByUserID(ctx context.Context, userID int64) (model.Totp, error)
+	Enable(ctx context.Context, userID int64, recoveryCodeHashes []string) error
+	Delete(ctx context.Context, userID int64) error
+	UpdateLastUsedStep(ctx context.Context, userID int64, step int64) (bool, error)
+	UseRecoveryCode(ctx context.Context, userID int64, codeHash string) (bool, error)
+	IncrementFailedAttempts(ctx context.Context, userID int64, attemptedAt time.Time, windowStart time.Time) (int64, error)
+	ResetFailedAttempts(ctx context.Context, userID int64) error
+}
+
+type TotpStorageImpl struct {
+	db     PgxIface
+	logger *logger.ServerLogger
+}
+
+func NewTotpStorage(db PgxIface, logger *logger.ServerLogger) TotpStorage {
+	return &TotpStorageImpl{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (s *TotpStorageImpl) Save(ctx context.Context, totp model.Totp) (err error) {
+	defer func() {
+		LogChange(ctx, s.logger, "save totp", err, zap.Int64("user id", totp.UserID))
+	}()
+
+	_, err = s.db.Exec(ctx, `
+		INSERT INTO user_totp
+		    (user_id, secret, enabled, last_used_step, created_at)
+		VALUES (@userId, @secret, FALSE, 0, @createdAt)
+		ON CONFLICT (user_id) DO UPDATE
+		SET secret = EXCLUDED.secret, last_used_step = 0, created_at = EXCLUDED.created_at
+		WHERE user_totp.enabled = FALSE
+	`, pgx.NamedArgs{
+		"userId":    totp.UserID,
+		"secret":    totp.Secret,
+		"createdAt": totp.CreatedAt,
+	})
+
+	return err
+}
+
+func (s *TotpStorageImpl) GetOneByUserID(ctx context.Context, userID int64) (model.Totp, error) {
+	row := s.db.QueryRow(ctx, `
+		SELECT
+		    user_id,
+		    secret,
+		    enabled,
+		    last_used_step,
+		    created_at
+		FROM user_totp
+		WHERE
+		    user_id = @userId
+	`, pgx.NamedArgs{
+		"userId": userID,
+	})
+
+	totp := model.Totp{}
+	err := row.Scan(&totp.UserID, &totp.Secret, &totp.Enabled, &totp.LastUsedStep, &totp.CreatedAt)
+
+	return totp, err
+}
+
+func (s *TotpStorageImpl) Enable(ctx context.Context, userID int64, recoveryCodeHashes []string) (err error) {
+	defer func() {
+		LogChange(ctx, s.logger, "enable totp", err, zap.Int64("user id", userID))
+	}()
+
+	tx, err := s.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck
+
+	_, err = tx.Exec(ctx, `
+		UPDATE user_totp
+		SET enabled = TRUE
+		WHERE user_id = @userId
+	`, pgx.NamedArgs{
+		"userId": userID,
+	})
+	if err != nil {
+		return err
+	}
+
+	err = saveRecoveryCodes(ctx, tx, userID, recoveryCodeHashes)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (s *TotpStorageImpl) Delete(ctx context.Context, userID int64) (err error) {
+	defer func() {
+		LogChange(ctx, s.logger, "delete totp", err, zap.Int64("user id", userID))
+	}()
+
+	tx, err := s.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck
+
+	_, err = tx.Exec(ctx, `
+		DELETE FROM user_recovery_codes
+		WHERE user_id = @userId
+	`, pgx.NamedArgs{
+		"userId": userID,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `
+		DELETE FROM user_totp
+		WHERE user_id = @userId
+	`, pgx.NamedArgs{
+		"userId": userID,
+	})
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (s *TotpStorageImpl) UpdateLastUsedStep(ctx context.Context, userID int64, step int64) (_ bool, err error) {
+	defer func() {
+		LogChange(ctx, s.logger, "update totp last used step", err, zap.Int64("user id", userID))
+	}()
+
+	tag, err := s.db.Exec(ctx, `
+		UPDATE user_totp
+		SET last_used_step = @step
+		WHERE user_id = @userId AND last_used_step < @step
+	`, pgx.NamedArgs{
+		"userId": userID,
+		"step":   step,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
+
+func (s *TotpStorageImpl) UseRecoveryCode(ctx context.Context, userID int64, codeHash string) (_ bool, err error) {
+	defer func() {
+		LogChange(ctx, s.logger, "use recovery code", err, zap.Int64("user id", userID))
+	}()
+
+	tag, err := s.db.Exec(ctx, `
+		UPDATE user_recovery_codes
+		SET used_at = @usedAt
+		WHERE user_id = @userId AND code_hash = @codeHash AND used_at IS NULL
+	`, pgx.NamedArgs{
+		"userId":   userID,
+		"codeHash": codeHash,
+		"usedAt":   time.Now(),
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
+
+// IncrementFailedAttempts counts a verification attempt and returns the number of attempts since the last successful
+// one. Attempts are forgotten when the previous one was made before windowStart.
+func (s *TotpStorageImpl) IncrementFailedAttempts(ctx context.Context, userID int64, attemptedAt time.Time,
+	windowStart time.Time) (_ int64, err error) {
+	defer func() {
+		LogChange(ctx, s.logger, "increment totp failed attempts", err, zap.Int64("user id", userID))
+	}()
+
+	row := s.db.QueryRow(ctx, `
+		UPDATE user_totp
+		SET
+		    failed_attempts = CASE WHEN last_failed_attempt_at >= @windowStart THEN failed_attempts + 1 ELSE 1 END,
+		    last_failed_attempt_at = @attemptedAt
+		WHERE user_id = @userId
+		RETURNING failed_attempts
+	`, pgx.NamedArgs{
+		"userId":      userID,
+		"attemptedAt": attemptedAt,
+		"windowStart": windowStart,
+	})
+
+	var attempts int64
+	err = row.Scan(&attempts)
+	return attempts, err
+}
+
+func (s *TotpStorageImpl) ResetFailedAttempts(ctx context.Context, userID int64) (err error) {
+	defer func() {
+		LogChange(ctx, s.logger, "reset totp failed attempts", err, zap.Int64("user id", userID))
+	}()
+
+	_, err = s.db.Exec(ctx, `
+		UPDATE user_totp
+		SET failed_attempts = 0, last_failed_attempt_at = NULL
+		WHERE user_id = @userId
+	`, pgx.NamedArgs{
+		"userId": userID,
+	})
+
+	return err
+}
+
+func saveRecoveryCodes(ctx context.Context, tx pgx.Tx, userID int64, recoveryCodeHashes []string) error {
+	_, err := tx.Exec(ctx, `
+		DELETE FROM user_recovery_codes
+		WHERE user_id = @userId
+	`, pgx.NamedArgs{
+		"userId": userID,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, codeHash := range recoveryCodeHashes {
+		_, err := tx.Exec(ctx, `
+		INSERT INTO user_recovery_codes
+		    (user_id, code_hash)
+		VALUES (@userId, @codeHash)
+		`, pgx.NamedArgs{
+			"userId":   userID,
+			"codeHash": codeHash,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,220 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pashagolub/pgxmock/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Stern-Ritter/gophermart/internal/logger"
+	"github.com/Stern-Ritter/gophermart/internal/model"
+)
+
+func TestTotpStorageSave(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err, "Error init connection mock")
+	defer mock.Close()
+
+	l, err := logger.Initialize("error")
+	require.NoError(t, err, "Error init logger")
+
+	totpStorage := NewTotpStorage(mock, l)
+
+	totp := model.Totp{
+		UserID:    1,
+		Secret:    "JBSWY3DPEHPK3PXP",
+		CreatedAt: time.Now(),
+	}
+
+	mock.ExpectExec("INSERT INTO user_totp .* ON CONFLICT \\(user_id\\) DO UPDATE .* WHERE user_totp.enabled = FALSE").
+		WithArgs(totp.UserID, totp.Secret, totp.CreatedAt).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+	err = totpStorage.Save(context.Background(), totp)
+	assert.NoError(t, err, "Error saving totp")
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err, "The expected sql commands were not executed")
+}
+
+func TestTotpStorageGetOneByUserID(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err, "Error init connection mock")
+	defer mock.Close()
+
+	l, err := logger.Initialize("error")
+	require.NoError(t, err, "Error init logger")
+
+	totpStorage := NewTotpStorage(mock, l)
+
+	expectedTotp := model.Totp{
+		UserID:       1,
+		Secret:       "JBSWY3DPEHPK3PXP",
+		Enabled:      true,
+		LastUsedStep: 42,
+		CreatedAt:    time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	rows := mock.NewRows([]string{"user_id", "secret", "enabled", "last_used_step", "created_at"}).
+		AddRow(expectedTotp.UserID, expectedTotp.Secret, expectedTotp.Enabled, expectedTotp.LastUsedStep,
+			expectedTotp.CreatedAt)
+
+	mock.ExpectQuery("SELECT user_id, secret, enabled, last_used_step, created_at FROM user_totp WHERE user_id =").
+		WithArgs(expectedTotp.UserID).
+		WillReturnRows(rows)
+
+	totp, err := totpStorage.GetOneByUserID(context.Background(), expectedTotp.UserID)
+
+	assert.NoError(t, err, "Error getting totp by user id")
+	assert.Equal(t, expectedTotp, totp, "Returned totp does not match expected")
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err, "The expected sql commands were not executed")
+}
+
+func TestTotpStorageEnable(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err, "Error init connection mock")
+	defer mock.Close()
+
+	l, err := logger.Initialize("error")
+	require.NoError(t, err, "Error init logger")
+
+	totpStorage := NewTotpStorage(mock, l)
+
+	userID := int64(1)
+	recoveryCodeHashes := []string{"hash1", "hash2"}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE user_totp SET enabled = TRUE WHERE user_id =").
+		WithArgs(userID).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectExec("DELETE FROM user_recovery_codes WHERE user_id =").
+		WithArgs(userID).
+		WillReturnResult(pgxmock.NewResult("DELETE", 0))
+	for _, codeHash := range recoveryCodeHashes {
+		mock.ExpectExec("INSERT INTO user_recovery_codes").
+			WithArgs(userID, codeHash).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	}
+	mock.ExpectCommit()
+
+	err = totpStorage.Enable(context.Background(), userID, recoveryCodeHashes)
+	assert.NoError(t, err, "Error enabling totp")
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err, "The expected sql commands were not executed")
+}
+
+func TestTotpStorageUpdateLastUsedStep(t *testing.T) {
+	tests := []struct {
+		name         string
+		rowsAffected int64
+		want         bool
+	}{
+		{
+			name:         "should return true when time step is greater than last used",
+			rowsAffected: 1,
+			want:         true,
+		},
+		{
+			name:         "should return false when time step has already been used",
+			rowsAffected: 0,
+			want:         false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock, err := pgxmock.NewPool()
+			require.NoError(t, err, "Error init connection mock")
+			defer mock.Close()
+
+			l, err := logger.Initialize("error")
+			require.NoError(t, err, "Error init logger")
+
+			totpStorage := NewTotpStorage(mock, l)
+
+			mock.ExpectExec("UPDATE user_totp SET last_used_step = .* WHERE user_id = .* AND last_used_step <").
+				WithArgs(int64(42), int64(1)).
+				WillReturnResult(pgxmock.NewResult("UPDATE", tt.rowsAffected))
+
+			got, err := totpStorage.UpdateLastUsedStep(context.Background(), 1, 42)
+			assert.NoError(t, err, "Error updating last used step")
+			assert.Equal(t, tt.want, got, "Returned value does not match expected")
+
+			err = mock.ExpectationsWereMet()
+			assert.NoError(t, err, "The expected sql commands were not executed")
+		})
+	}
+}
+
+func TestTotpStorageUseRecoveryCode(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err, "Error init connection mock")
+	defer mock.Close()
+
+	l, err := logger.Initialize("error")
+	require.NoError(t, err, "Error init logger")
+
+	totpStorage := NewTotpStorage(mock, l)
+
+	mock.ExpectExec("UPDATE user_recovery_codes SET used_at = .* WHERE user_id = .* AND code_hash = .* AND used_at IS NULL").
+		WithArgs(pgxmock.AnyArg(), int64(1), "hash").
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+	used, err := totpStorage.UseRecoveryCode(context.Background(), 1, "hash")
+	assert.NoError(t, err, "Error using recovery code")
+	assert.True(t, used, "Recovery code should be marked as used")
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err, "The expected sql commands were not executed")
+}
+
+func TestTotpStorageIncrementFailedAttempts(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err, "Error init connection mock")
+	defer mock.Close()
+
+	l, err := logger.Initialize("error")
+	require.NoError(t, err, "Error init logger")
+
+	totpStorage := NewTotpStorage(mock, l)
+
+	attemptedAt := time.Date(2024, time.June, 1, 12, 0, 0, 0, time.UTC)
+	windowStart := attemptedAt.Add(-time.Minute * 15)
+	mock.ExpectQuery("UPDATE user_totp SET failed_attempts = CASE .* RETURNING failed_attempts").
+		WithArgs(windowStart, attemptedAt, int64(1)).
+		WillReturnRows(pgxmock.NewRows([]string{"failed_attempts"}).AddRow(int64(3)))
+
+	attempts, err := totpStorage.IncrementFailedAttempts(context.Background(), 1, attemptedAt, windowStart)
+	assert.NoError(t, err, "Error incrementing failed attempts")
+	assert.Equal(t, int64(3), attempts, "Failed attempts count does not match expected")
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err, "The expected sql commands were not executed")
+}
+
+func TestTotpStorageResetFailedAttempts(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err, "Error init connection mock")
+	defer mock.Close()
+
+	l, err := logger.Initialize("error")
+	require.NoError(t, err, "Error init logger")
+
+	totpStorage := NewTotpStorage(mock, l)
+
+	mock.ExpectExec("UPDATE user_totp SET failed_attempts = 0, last_failed_attempt_at = NULL WHERE user_id =").
+		WithArgs(int64(1)).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+	err = totpStorage.ResetFailedAttempts(context.Background(), 1)
+	assert.NoError(t, err, "Error resetting failed attempts")
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err, "The expected sql commands were not executed")
+}
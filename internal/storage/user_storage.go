@@ -2,8 +2,10 @@ package storage
 
 import (
 	"context"
+	"strings"
 
 	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
 
 	"github.com/Stern-Ritter/gophermart/internal/logger"
 	"github.com/Stern-Ritter/gophermart/internal/model"
@@ -12,6 +14,10 @@ import (
 type UserStorage interface {
 	Save(ctx context.Context, user model.User) error
 	GetOneByLogin(ctx context.Context, login string) (model.User, error)
+	GetOneByID(ctx context.Context, id int64) (model.User, error)
+	GetAllByLoginContainingOrderByLogin(ctx context.Context, login string, limit int64) ([]model.User, error)
+	UpdatePassword(ctx context.Context, userID int64, passwordHash string) error
+	DisableByLogin(ctx context.Context, login string) error
 }
 
 type UserStorageImpl struct {
@@ -26,15 +32,25 @@ func NewUserStorage(db PgxIface, logger *logger.ServerLogger) UserStorage {
 	}
 }
 
-func (s *UserStorageImpl) Save(ctx context.Context, user model.User) error {
-	_, err := s.db.Exec(ctx, `
+func (s *UserStorageImpl) Save(ctx context.Context, user model.User) (err error) {
+	defer func() {
+		LogChange(ctx, s.logger, "save user", err, zap.String("login", user.Login))
+	}()
+
+	role := user.Role
+	if role == "" {
+		role = model.UserRoleUser
+	}
+
+	_, err = s.db.Exec(ctx, `
 		INSERT INTO users 
-		    (login, password)
+		    (login, password, role)
 		VALUES 
-		    (@login, @password)
+		    (@login, @password, @role)
 	`, pgx.NamedArgs{
 		"login":    user.Login,
 		"password": user.Password,
+		"role":     role,
 	})
 
 	return err
@@ -45,7 +61,9 @@ func (s *UserStorageImpl) GetOneByLogin(ctx context.Context, login string) (mode
 		SELECT 
 			id,
 			login,
-			password
+			password,
+			role,
+			disabled_at IS NOT NULL
 		FROM users
 		WHERE 
 		    login = @login
@@ -54,7 +72,113 @@ func (s *UserStorageImpl) GetOneByLogin(ctx context.Context, login string) (mode
 	})
 
 	user := model.User{}
-	err := row.Scan(&user.ID, &user.Login, &user.Password)
+	err := row.Scan(&user.ID, &user.Login, &user.Password, &user.Role, &user.Disabled)
+
+	return user, err
+}
+
+func (s *UserStorageImpl) GetOneByID(ctx context.Context, id int64) (model.User, error) {
+	row := s.db.QueryRow(ctx, `
+		SELECT
+			id,
+			login,
+			password,
+			role,
+			disabled_at IS NOT NULL
+		FROM users
+		WHERE
+		    id = @id
+	`, pgx.NamedArgs{
+		"id": id,
+	})
+
+	user := model.User{}
+	err := row.Scan(&user.ID, &user.Login, &user.Password, &user.Role, &user.Disabled)
 
 	return user, err
 }
+
+func (s *UserStorageImpl) GetAllByLoginContainingOrderByLogin(ctx context.Context, login string,
+	limit int64) ([]model.User, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT
+			id,
+			login,
+			password,
+			role,
+			disabled_at IS NOT NULL
+		FROM users
+		WHERE
+		    login ILIKE @pattern
+		ORDER BY login
+		LIMIT @limit
+	`, pgx.NamedArgs{
+		"pattern": "%" + escapeLikePattern(login) + "%",
+		"limit":   limit,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := make([]model.User, 0)
+
+	for rows.Next() {
+		user := model.User{}
+		if err := rows.Scan(&user.ID, &user.Login, &user.Password, &user.Role, &user.Disabled); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+func escapeLikePattern(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
+}
+
+func (s *UserStorageImpl) UpdatePassword(ctx context.Context, userID int64, passwordHash string) (err error) {
+	defer func() {
+		LogChange(ctx, s.logger, "update user password", err, zap.Int64("user id", userID))
+	}()
+
+	_, err = s.db.Exec(ctx, `
+		UPDATE users
+		SET password = @password
+		WHERE id = @id
+	`, pgx.NamedArgs{
+		"password": passwordHash,
+		"id":       userID,
+	})
+
+	return err
+}
+
+// DisableByLogin marks the user as disabled, disabling an already disabled user keeps the original time.
+func (s *UserStorageImpl) DisableByLogin(ctx context.Context, login string) (err error) {
+	defer func() {
+		LogChange(ctx, s.logger, "disable user", err, zap.String("login", login))
+	}()
+
+	tag, err := s.db.Exec(ctx, `
+		UPDATE users
+		SET disabled_at = COALESCE(disabled_at, NOW())
+		WHERE login = @login
+	`, pgx.NamedArgs{
+		"login": login,
+	})
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"testing"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/pashagolub/pgxmock/v3"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -28,7 +29,7 @@ func TestUserStorageSave(t *testing.T) {
 	}
 
 	mock.ExpectExec("INSERT INTO users").
-		WithArgs(user.Login, user.Password).
+		WithArgs(user.Login, user.Password, model.UserRoleUser).
 		WillReturnResult(pgxmock.NewResult("INSERT", 1))
 
 	err = userStorage.Save(context.Background(), user)
@@ -52,12 +53,13 @@ func TestUserStorageGetOneByLogin(t *testing.T) {
 		ID:       1,
 		Login:    "testUser",
 		Password: "secretPassword",
+		Role:     model.UserRoleUser,
 	}
 
-	rows := mock.NewRows([]string{"id", "login", "password"}).
-		AddRow(expectedUser.ID, expectedUser.Login, expectedUser.Password)
+	rows := mock.NewRows([]string{"id", "login", "password", "role", "disabled"}).
+		AddRow(expectedUser.ID, expectedUser.Login, expectedUser.Password, expectedUser.Role, expectedUser.Disabled)
 
-	mock.ExpectQuery("SELECT id, login, password FROM users WHERE login =").
+	mock.ExpectQuery("SELECT id, login, password, role, disabled_at IS NOT NULL FROM users WHERE login =").
 		WithArgs("testUser").
 		WillReturnRows(rows)
 
@@ -69,3 +71,131 @@ func TestUserStorageGetOneByLogin(t *testing.T) {
 	err = mock.ExpectationsWereMet()
 	assert.NoError(t, err, "The expected sql commands were not executed")
 }
+
+func TestUserStorageGetOneByID(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err, "Error init connection mock")
+	defer mock.Close()
+
+	l, err := logger.Initialize("error")
+	require.NoError(t, err, "Error init logger")
+
+	userStorage := NewUserStorage(mock, l)
+
+	expectedUser := model.User{
+		ID:       1,
+		Login:    "testUser",
+		Password: "secretPassword",
+		Role:     model.UserRoleAdmin,
+	}
+
+	rows := mock.NewRows([]string{"id", "login", "password", "role", "disabled"}).
+		AddRow(expectedUser.ID, expectedUser.Login, expectedUser.Password, expectedUser.Role, expectedUser.Disabled)
+
+	mock.ExpectQuery("SELECT id, login, password, role, disabled_at IS NOT NULL FROM users WHERE id =").
+		WithArgs(expectedUser.ID).
+		WillReturnRows(rows)
+
+	user, err := userStorage.GetOneByID(context.Background(), expectedUser.ID)
+
+	assert.NoError(t, err, "Error getting user by id")
+	assert.Equal(t, expectedUser, user, "Returned user does not match expected")
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err, "The expected sql commands were not executed")
+}
+
+func TestUserStorageGetAllByLoginContainingOrderByLogin(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err, "Error init connection mock")
+	defer mock.Close()
+
+	l, err := logger.Initialize("error")
+	require.NoError(t, err, "Error init logger")
+
+	userStorage := NewUserStorage(mock, l)
+
+	expectedUsers := []model.User{
+		{ID: 1, Login: "test_user", Password: "secretPassword", Role: model.UserRoleUser},
+		{ID: 2, Login: "test_user2", Password: "secretPassword", Role: model.UserRoleAdmin, Disabled: true},
+	}
+
+	rows := mock.NewRows([]string{"id", "login", "password", "role", "disabled"})
+	for _, user := range expectedUsers {
+		rows.AddRow(user.ID, user.Login, user.Password, user.Role, user.Disabled)
+	}
+
+	mock.ExpectQuery("SELECT id, login, password, role, disabled_at IS NOT NULL FROM users WHERE login ILIKE .* ORDER BY login LIMIT").
+		WithArgs(`%test\_user%`, int64(20)).
+		WillReturnRows(rows)
+
+	users, err := userStorage.GetAllByLoginContainingOrderByLogin(context.Background(), "test_user", 20)
+
+	assert.NoError(t, err, "Error searching users by login")
+	assert.Equal(t, expectedUsers, users, "Returned users do not match expected")
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err, "The expected sql commands were not executed")
+}
+
+func TestUserStorageUpdatePassword(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err, "Error init connection mock")
+	defer mock.Close()
+
+	l, err := logger.Initialize("error")
+	require.NoError(t, err, "Error init logger")
+
+	userStorage := NewUserStorage(mock, l)
+
+	mock.ExpectExec("UPDATE users SET password = .* WHERE id =").
+		WithArgs("$argon2id$hash", int64(1)).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+	err = userStorage.UpdatePassword(context.Background(), 1, "$argon2id$hash")
+	assert.NoError(t, err, "Error updating user password")
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err, "The expected sql commands were not executed")
+}
+
+func TestUserStorageDisableByLogin(t *testing.T) {
+	tests := []struct {
+		name         string
+		rowsAffected int64
+		expectedErr  error
+	}{
+		{
+			name:         "should disable user when user exists",
+			rowsAffected: 1,
+		},
+		{
+			name:         "should return no rows error when user not exists",
+			rowsAffected: 0,
+			expectedErr:  pgx.ErrNoRows,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock, err := pgxmock.NewPool()
+			require.NoError(t, err, "Error init connection mock")
+			defer mock.Close()
+
+			l, err := logger.Initialize("error")
+			require.NoError(t, err, "Error init logger")
+
+			userStorage := NewUserStorage(mock, l)
+
+			mock.ExpectExec("UPDATE users SET disabled_at = COALESCE\\(disabled_at, NOW\\(\\)\\) WHERE login =").
+				WithArgs("test_user").
+				WillReturnResult(pgxmock.NewResult("UPDATE", tt.rowsAffected))
+
+			err = userStorage.DisableByLogin(context.Background(), "test_user")
+			assert.ErrorIs(t, err, tt.expectedErr, "Error does not match expected error")
+
+			err = mock.ExpectationsWereMet()
+			assert.NoError(t, err, "The expected sql commands were not executed")
+		})
+	}
+}
@@ -2,8 +2,10 @@ package storage
 
 import (
 	"context"
+	"strings"
 
 	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
 
 	er "github.com/Stern-Ritter/gophermart/internal/errors"
 	"github.com/Stern-Ritter/gophermart/internal/logger"
@@ -14,6 +16,9 @@ import (
 type WithdrawnStorage interface {
 	Save(ctx context.Context, withdrawn model.Withdrawn) error
 	GetAllByUserIDOrderByProcessedAtAsc(ctx context.Context, userID int64) ([]model.Withdrawn, error)
+	ForEachByUserIDOrderByProcessedAtAsc(ctx context.Context, userID int64, fn func(withdrawn model.Withdrawn) error) error
+	GetAllByFilter(ctx context.Context, filter model.WithdrawalsFilter) ([]model.Withdrawn, error)
+	CountByFilter(ctx context.Context, filter model.WithdrawalsFilter) (int64, error)
 }
 
 type WithdrawnStorageImpl struct {
@@ -28,28 +33,31 @@ func NewWithdrawnStorage(db PgxIface, logger *logger.ServerLogger) WithdrawnStor
 	}
 }
 
-func (s *WithdrawnStorageImpl) Save(ctx context.Context, withdrawn model.Withdrawn) error {
+func (s *WithdrawnStorageImpl) Save(ctx context.Context, withdrawn model.Withdrawn) (err error) {
+	defer func() {
+		LogChange(ctx, s.logger, "save withdrawn", err, zap.Int64("order number", withdrawn.OrderNumber))
+	}()
+
 	tx, err := s.db.BeginTx(ctx, pgx.TxOptions{})
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback(ctx) //nolint:errcheck
 
-	accrualPoints, err := getAccrualPointsSumByUserID(ctx, tx, withdrawn.UserID)
-	if err != nil {
+	if err = lockUserByID(ctx, tx, withdrawn.UserID); err != nil {
 		return err
 	}
-	withdrawnPoints, err := getWithdrawnPointsSumByUserID(ctx, tx, withdrawn.UserID)
+
+	currentPoints, err := getCurrentPointsSumByUserID(ctx, tx, withdrawn.UserID)
 	if err != nil {
 		return err
 	}
 
-	currentPoints := accrualPoints - withdrawnPoints
 	if utils.Float64Compare(currentPoints, withdrawn.PointsAmount) < 0 {
 		return er.NewPaymentRequiredError("Not enough loyalty points to withdrawn", nil)
 	}
 
-	_, err = s.db.Exec(ctx, `
+	_, err = tx.Exec(ctx, `
 		INSERT INTO loyalty_points_withdrawn
 		(user_id, order_number, processed_at, amount) 
 		VALUES (@userId, @orderNumber, @processedAt, @amount)		
@@ -68,6 +76,21 @@ func (s *WithdrawnStorageImpl) Save(ctx context.Context, withdrawn model.Withdra
 }
 
 func (s *WithdrawnStorageImpl) GetAllByUserIDOrderByProcessedAtAsc(ctx context.Context, userID int64) ([]model.Withdrawn, error) {
+	withdrawals := make([]model.Withdrawn, 0)
+
+	err := s.ForEachByUserIDOrderByProcessedAtAsc(ctx, userID, func(withdrawn model.Withdrawn) error {
+		withdrawals = append(withdrawals, withdrawn)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return withdrawals, nil
+}
+
+func (s *WithdrawnStorageImpl) ForEachByUserIDOrderByProcessedAtAsc(ctx context.Context, userID int64,
+	fn func(withdrawn model.Withdrawn) error) error {
 	rows, err := s.db.Query(ctx, `
 		SELECT
 		    user_id, 
@@ -82,6 +105,56 @@ func (s *WithdrawnStorageImpl) GetAllByUserIDOrderByProcessedAtAsc(ctx context.C
 		"userId": userID,
 	})
 
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		withdrawn := model.Withdrawn{}
+		if err := rows.Scan(&withdrawn.UserID, &withdrawn.OrderNumber, &withdrawn.ProcessedAt,
+			&withdrawn.PointsAmount); err != nil {
+			return err
+		}
+
+		if err := fn(withdrawn); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+func (s *WithdrawnStorageImpl) GetAllByFilter(ctx context.Context, filter model.WithdrawalsFilter) ([]model.Withdrawn, error) {
+	query := strings.Builder{}
+	query.WriteString(`
+		SELECT
+		    user_id, 
+		    order_number, 
+		    processed_at, 
+		    amount
+		FROM loyalty_points_withdrawn
+		WHERE 
+		    user_id = @userId`)
+	args := pgx.NamedArgs{
+		"limit": filter.Limit,
+	}
+	writeWithdrawalsFilterConditions(&query, args, filter)
+
+	direction := "ASC"
+	comparison := ">"
+	if filter.SortDirection == model.SortDesc {
+		direction = "DESC"
+		comparison = "<"
+	}
+	if filter.After != nil {
+		query.WriteString(" AND\n\t\t    (processed_at, order_number) " + comparison + " (@afterProcessedAt, @afterOrderNumber)")
+		args["afterProcessedAt"] = filter.After.Time
+		args["afterOrderNumber"] = filter.After.Key
+	}
+	query.WriteString("\n\t\tORDER BY processed_at " + direction + ", order_number " + direction + "\n\t\tLIMIT @limit\n\t")
+
+	rows, err := s.db.Query(ctx, query.String(), args)
 	if err != nil {
 		return nil, err
 	}
@@ -105,6 +178,47 @@ func (s *WithdrawnStorageImpl) GetAllByUserIDOrderByProcessedAtAsc(ctx context.C
 	return withdrawals, nil
 }
 
+func (s *WithdrawnStorageImpl) CountByFilter(ctx context.Context, filter model.WithdrawalsFilter) (int64, error) {
+	query := strings.Builder{}
+	query.WriteString(`
+		SELECT COUNT(*)
+		FROM loyalty_points_withdrawn
+		WHERE 
+		    user_id = @userId`)
+	args := pgx.NamedArgs{}
+	writeWithdrawalsFilterConditions(&query, args, filter)
+	query.WriteString("\n\t")
+
+	var count int64
+	err := s.db.QueryRow(ctx, query.String(), args).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func writeWithdrawalsFilterConditions(query *strings.Builder, args pgx.NamedArgs, filter model.WithdrawalsFilter) {
+	args["userId"] = filter.UserID
+
+	if !filter.ProcessedFrom.IsZero() {
+		query.WriteString(" AND\n\t\t    processed_at >= @processedFrom")
+		args["processedFrom"] = filter.ProcessedFrom
+	}
+	if !filter.ProcessedTo.IsZero() {
+		query.WriteString(" AND\n\t\t    processed_at < @processedTo")
+		args["processedTo"] = filter.ProcessedTo
+	}
+	if filter.MinSum > 0 {
+		query.WriteString(" AND\n\t\t    amount >= @minSum")
+		args["minSum"] = filter.MinSum
+	}
+	if filter.MaxSum > 0 {
+		query.WriteString(" AND\n\t\t    amount <= @maxSum")
+		args["maxSum"] = filter.MaxSum
+	}
+}
+
 func getWithdrawnPointsSumByUserID(ctx context.Context, tx pgx.Tx, userID int64) (float64, error) {
 	var withdrawnPoints float64
 
@@ -26,6 +26,7 @@ func TestWithdrawnStorageSaveWhenLoyaltyPointEnough(t *testing.T) {
 	userID := int64(1)
 	accrualPoints := 100.0
 	withdrawnPoints := 50.0
+	adjustmentPoints := 0.0
 
 	withdrawn := model.Withdrawn{
 		UserID:       1,
@@ -36,6 +37,18 @@ func TestWithdrawnStorageSaveWhenLoyaltyPointEnough(t *testing.T) {
 
 	mock.ExpectBegin()
 
+	mock.ExpectQuery(`
+		SELECT id
+		FROM users
+		WHERE
+		    id = \@userId
+		FOR UPDATE
+	`).
+		WithArgs(userID).
+		WillReturnRows(pgxmock.
+			NewRows([]string{"id"}).
+			AddRow(userID))
+
 	mock.ExpectQuery(`
 		SELECT COALESCE\(SUM\(amount\),0\) as accrual_points
 		FROM loyalty_points_accrual
@@ -59,6 +72,17 @@ func TestWithdrawnStorageSaveWhenLoyaltyPointEnough(t *testing.T) {
 			NewRows([]string{"withdrawn_points"}).
 			AddRow(withdrawnPoints))
 
+	mock.ExpectQuery(`
+		SELECT COALESCE\(SUM\(amount\),0\) as adjustment_points
+		FROM loyalty_points_adjustment
+		WHERE
+		    user_id = \@userId
+	`).
+		WithArgs(userID).
+		WillReturnRows(pgxmock.
+			NewRows([]string{"adjustment_points"}).
+			AddRow(adjustmentPoints))
+
 	mock.ExpectExec(`
 		INSERT INTO loyalty_points_withdrawn
 		\(user_id, order_number, processed_at, amount\)
@@ -93,6 +117,7 @@ func TestWithdrawnStorageSaveWhenLoyaltyPointNotEnough(t *testing.T) {
 	userID := int64(1)
 	accrualPoints := 100.0
 	withdrawnPoints := 50.0
+	adjustmentPoints := 0.0
 
 	withdrawn := model.Withdrawn{
 		UserID:       1,
@@ -103,6 +128,18 @@ func TestWithdrawnStorageSaveWhenLoyaltyPointNotEnough(t *testing.T) {
 
 	mock.ExpectBegin()
 
+	mock.ExpectQuery(`
+		SELECT id
+		FROM users
+		WHERE
+		    id = \@userId
+		FOR UPDATE
+	`).
+		WithArgs(userID).
+		WillReturnRows(pgxmock.
+			NewRows([]string{"id"}).
+			AddRow(userID))
+
 	mock.ExpectQuery(`
 		SELECT COALESCE\(SUM\(amount\),0\) as accrual_points
 		FROM loyalty_points_accrual
@@ -126,6 +163,17 @@ func TestWithdrawnStorageSaveWhenLoyaltyPointNotEnough(t *testing.T) {
 			NewRows([]string{"withdrawn_points"}).
 			AddRow(withdrawnPoints))
 
+	mock.ExpectQuery(`
+		SELECT COALESCE\(SUM\(amount\),0\) as adjustment_points
+		FROM loyalty_points_adjustment
+		WHERE
+		    user_id = \@userId
+	`).
+		WithArgs(userID).
+		WillReturnRows(pgxmock.
+			NewRows([]string{"adjustment_points"}).
+			AddRow(adjustmentPoints))
+
 	mock.ExpectRollback()
 
 	err = withdrawnStorage.Save(context.Background(), withdrawn)
@@ -206,3 +254,96 @@ func TestWithdrawnStorage_GetAllByUserIDOrderByProcessedAtAsc(t *testing.T) {
 	err = mock.ExpectationsWereMet()
 	assert.NoError(t, err, "The expected sql commands were not executed")
 }
+
+func TestWithdrawnStorage_GetAllByFilter(t *testing.T) {
+	processedAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	after := model.Cursor{Time: processedAt, Key: 12345678903}
+
+	tests := []struct {
+		name          string
+		filter        model.WithdrawalsFilter
+		expectedQuery string
+		expectedArgs  []interface{}
+	}{
+		{
+			name:   "should select withdrawals by user id ordered by processed_at asc",
+			filter: model.WithdrawalsFilter{UserID: 1, SortDirection: model.SortAsc, Limit: 51},
+			expectedQuery: "SELECT .* FROM loyalty_points_withdrawn WHERE user_id = @userId " +
+				"ORDER BY processed_at ASC, order_number ASC LIMIT @limit",
+			expectedArgs: []interface{}{int64(1), int64(51)},
+		},
+		{
+			name: "should apply filters and cursor when they are specified",
+			filter: model.WithdrawalsFilter{
+				UserID:        1,
+				ProcessedFrom: processedAt,
+				ProcessedTo:   processedAt.AddDate(0, 1, 0),
+				MinSum:        10,
+				MaxSum:        100,
+				After:         &after,
+				SortDirection: model.SortDesc,
+				Limit:         11,
+			},
+			expectedQuery: "SELECT .* FROM loyalty_points_withdrawn WHERE user_id = @userId AND " +
+				"processed_at >= @processedFrom AND processed_at < @processedTo AND amount >= @minSum AND " +
+				"amount <= @maxSum AND \\(processed_at, order_number\\) < \\(@afterProcessedAt, @afterOrderNumber\\) " +
+				"ORDER BY processed_at DESC, order_number DESC LIMIT @limit",
+			expectedArgs: []interface{}{int64(1), processedAt, processedAt.AddDate(0, 1, 0), float64(10), float64(100),
+				processedAt, int64(12345678903), int64(11)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock, err := pgxmock.NewPool()
+			require.NoError(t, err, "Error init connection mock")
+			defer mock.Close()
+
+			l, err := logger.Initialize("error")
+			require.NoError(t, err, "Error init logger")
+
+			withdrawnStorage := NewWithdrawnStorage(mock, l)
+
+			rows := pgxmock.NewRows([]string{"user_id", "order_number", "processed_at", "amount"}).
+				AddRow(int64(1), int64(12345678903), processedAt, float64(42))
+
+			mock.ExpectQuery(tt.expectedQuery).
+				WithArgs(tt.expectedArgs...).
+				WillReturnRows(rows)
+
+			withdrawals, err := withdrawnStorage.GetAllByFilter(context.Background(), tt.filter)
+			assert.NoError(t, err, "Error getting withdrawals by filter")
+			assert.Equal(t, []model.Withdrawn{
+				{UserID: 1, OrderNumber: 12345678903, PointsAmount: 42, ProcessedAt: processedAt},
+			}, withdrawals, "Returned withdrawals does not match expected")
+
+			err = mock.ExpectationsWereMet()
+			assert.NoError(t, err, "The expected sql commands were not executed")
+		})
+	}
+}
+
+func TestWithdrawnStorage_CountByFilter(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err, "Error init connection mock")
+	defer mock.Close()
+
+	l, err := logger.Initialize("error")
+	require.NoError(t, err, "Error init logger")
+
+	withdrawnStorage := NewWithdrawnStorage(mock, l)
+
+	after := model.Cursor{Time: time.Now(), Key: 12345678903}
+	filter := model.WithdrawalsFilter{UserID: 1, MinSum: 10, After: &after, SortDirection: model.SortAsc, Limit: 51}
+
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM loyalty_points_withdrawn WHERE user_id = @userId AND amount >= @minSum$").
+		WithArgs(int64(1), float64(10)).
+		WillReturnRows(pgxmock.NewRows([]string{"count"}).AddRow(int64(3)))
+
+	count, err := withdrawnStorage.CountByFilter(context.Background(), filter)
+	assert.NoError(t, err, "Error counting withdrawals by filter")
+	assert.Equal(t, int64(3), count, "Returned count does not match expected")
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err, "The expected sql commands were not executed")
+}
@@ -0,0 +1,62 @@
+package tracing
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.25.0"
+)
+
+func TestMiddleware(t *testing.T) {
+	tests := []struct {
+		name         string
+		url          string
+		expectedName string
+	}{
+		{
+			name:         "should name span after route pattern instead of path",
+			url:          "/orders/12345678903",
+			expectedName: "GET /orders/{number}",
+		},
+		{
+			name:         "should name span after method when route is unknown",
+			url:          "/unknown/12345678903",
+			expectedName: "GET",
+		},
+	}
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	defer otel.SetTracerProvider(previous)
+
+	r := chi.NewRouter()
+	r.Use(Middleware)
+	r.Get("/orders/{number}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := len(recorder.Ended())
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.url, nil))
+
+			spans := recorder.Ended()
+			require.Len(t, spans, before+1, "Middleware should record one span per request")
+			assert.Equal(t, tt.expectedName, spans[before].Name(), "Span name does not match expected name")
+		})
+	}
+
+	spans := recorder.Ended()
+	assert.Contains(t, spans[0].Attributes(), semconv.HTTPRoute("/orders/{number}"), "Span should have route attribute")
+}
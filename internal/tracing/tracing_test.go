@@ -0,0 +1,50 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Stern-Ritter/gophermart/internal/config"
+)
+
+func TestInit(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      config.TracingConfig
+		expectedErr bool
+	}{
+		{
+			name:   "should not fail when exporter is not configured",
+			config: config.TracingConfig{},
+		},
+		{
+			name:   "should init stdout exporter",
+			config: config.TracingConfig{Exporter: ExporterStdout},
+		},
+		{
+			name:   "should init otlp exporter",
+			config: config.TracingConfig{Exporter: ExporterOTLP, OtlpEndpoint: "http://localhost:4318"},
+		},
+		{
+			name:        "should return error when exporter is unknown",
+			config:      config.TracingConfig{Exporter: "jaeger"},
+			expectedErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shutdown, err := Init(context.Background(), tt.config)
+			if tt.expectedErr {
+				assert.Error(t, err, "Init should return error")
+				return
+			}
+
+			require.NoError(t, err, "Error init tracing")
+			assert.NoError(t, shutdown(context.Background()), "Error shutdown tracing")
+		})
+	}
+}
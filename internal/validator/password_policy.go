@@ -0,0 +1,98 @@
+package validator
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+
+	"github.com/go-playground/validator/v10"
+)
+
+const passwordPolicyTag = "password_policy"
+
+var DefaultPasswordPolicy = NewPasswordPolicy(3, nil)
+
+type PasswordPolicy struct {
+	minCharClasses    int
+	breachedPasswords map[string]struct{}
+}
+
+func NewPasswordPolicy(minCharClasses int, breachedPasswords []string) PasswordPolicy {
+	breached := make(map[string]struct{}, len(breachedPasswords))
+	for _, password := range breachedPasswords {
+		breached[strings.ToLower(password)] = struct{}{}
+	}
+
+	return PasswordPolicy{
+		minCharClasses:    minCharClasses,
+		breachedPasswords: breached,
+	}
+}
+
+func (p PasswordPolicy) Check(password string) error {
+	if classes := countCharClasses(password); classes < p.minCharClasses {
+		return fmt.Errorf("password should contain at least %d of character classes: "+
+			"lowercase letters, uppercase letters, digits, special characters", p.minCharClasses)
+	}
+	if _, ok := p.breachedPasswords[strings.ToLower(password)]; ok {
+		return fmt.Errorf("password is found in the list of breached passwords")
+	}
+
+	return nil
+}
+
+func RegisterPasswordPolicy(validate *validator.Validate, policy PasswordPolicy) error {
+	return validate.RegisterValidation(passwordPolicyTag, func(fl validator.FieldLevel) bool {
+		return policy.Check(fl.Field().String()) == nil
+	})
+}
+
+func LoadBreachedPasswords(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	passwords := make([]string, 0)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		passwords = append(passwords, line)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return passwords, nil
+}
+
+func countCharClasses(password string) int {
+	var hasLower, hasUpper, hasDigit, hasSpecial bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		default:
+			hasSpecial = true
+		}
+	}
+
+	classes := 0
+	for _, has := range []bool{hasLower, hasUpper, hasDigit, hasSpecial} {
+		if has {
+			classes++
+		}
+	}
+	return classes
+}
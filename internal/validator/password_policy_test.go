@@ -0,0 +1,65 @@
+package validator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPasswordPolicyCheck(t *testing.T) {
+	policy := NewPasswordPolicy(3, []string{"Passw0rd!"})
+
+	tests := []struct {
+		name     string
+		password string
+		wantErr  bool
+	}{
+		{
+			name:     "should accept password with enough character classes",
+			password: "Secret-Passw0rd",
+			wantErr:  false,
+		},
+		{
+			name:     "should accept password with non-ascii letters",
+			password: "Пароль-секрет",
+			wantErr:  false,
+		},
+		{
+			name:     "should reject password with not enough character classes",
+			password: "password42",
+			wantErr:  true,
+		},
+		{
+			name:     "should reject breached password regardless of case",
+			password: "passw0RD!",
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.Check(tt.password)
+			if tt.wantErr {
+				assert.Error(t, err, "Expected password policy error")
+			} else {
+				assert.NoError(t, err, "Unexpected password policy error")
+			}
+		})
+	}
+}
+
+func TestLoadBreachedPasswords(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	err := os.WriteFile(path, []byte("# top passwords\nPassw0rd!\n\n  Qwerty-123  \n"), 0o600)
+	require.NoError(t, err, "Error writing breached passwords file")
+
+	passwords, err := LoadBreachedPasswords(path)
+	require.NoError(t, err, "Error loading breached passwords")
+	assert.Equal(t, []string{"Passw0rd!", "Qwerty-123"}, passwords, "Loaded passwords does not match expected")
+
+	_, err = LoadBreachedPasswords(filepath.Join(t.TempDir(), "missing.txt"))
+	assert.Error(t, err, "Expected error when file does not exist")
+}
@@ -4,9 +4,11 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"strings"
 
 	"github.com/go-playground/validator/v10"
 
+	er "github.com/Stern-Ritter/gophermart/internal/errors"
 	"github.com/Stern-Ritter/gophermart/internal/utils"
 )
 
@@ -16,6 +18,11 @@ func GetValidator() (*validator.Validate, error) {
 		value := fl.Field().String()
 		return utils.ValidateOrderNumber(value) == nil
 	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = RegisterPasswordPolicy(validate, DefaultPasswordPolicy)
 	return validate, err
 }
 
@@ -32,18 +39,38 @@ func Validate[T interface{}](obj interface{}, validate *validator.Validate) (err
 
 	if err := validate.Struct(o); err != nil {
 		errorValid := err.(validator.ValidationErrors)
+		fields := make([]er.FieldError, 0, len(errorValid))
+		var joinedErr error
 		for _, e := range errorValid {
 			errMsg := errorTagFunc(obj, e.Field(), errMsgTag)
-			if errMsg != nil {
-				errs = errors.Join(errs, fmt.Errorf("%w", errMsg))
-			} else {
-				errs = errors.Join(errs, fmt.Errorf("%w", e))
+			if errMsg == nil {
+				errMsg = e
 			}
+			joinedErr = errors.Join(joinedErr, fmt.Errorf("%w", errMsg))
+			fields = append(fields, er.FieldError{Field: jsonFieldName(obj, e.Field()), Message: errMsg.Error()})
 		}
+		errs = er.NewValidationError(joinedErr.Error(), fields, joinedErr)
 	}
 	return
 }
 
+func jsonFieldName(obj any, fieldName string) string {
+	name, index, _ := strings.Cut(fieldName, "[")
+	field, ok := reflect.TypeOf(obj).FieldByName(name)
+	if !ok {
+		return fieldName
+	}
+
+	jsonName, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if jsonName == "" || jsonName == "-" {
+		return fieldName
+	}
+	if index != "" {
+		return jsonName + "[" + index
+	}
+	return jsonName
+}
+
 func errorTagFunc(obj any, fieldName string, tagName string) error {
 	val := reflect.ValueOf(obj)
 	for i := 0; i < val.NumField(); i++ {
@@ -0,0 +1,36 @@
+package validator
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	er "github.com/Stern-Ritter/gophermart/internal/errors"
+)
+
+type testValidatedDto struct {
+	Name   string   `json:"name" validate:"required" msg:"Name is required"`
+	Scopes []string `json:"scopes" validate:"dive,oneof=read write"`
+	Count  int      `validate:"gt=0"`
+}
+
+func TestValidate(t *testing.T) {
+	validate, err := GetValidator()
+	require.NoError(t, err, "Error init validator")
+
+	err = Validate[testValidatedDto](testValidatedDto{Scopes: []string{"read", "delete"}}, validate)
+
+	var validationError er.ValidationError
+	require.True(t, errors.As(err, &validationError), "Validation error expected")
+	assert.Equal(t, []er.FieldError{
+		{Field: "name", Message: "Name is required"},
+		{Field: "scopes[1]", Message: "Key: 'testValidatedDto.Scopes[1]' Error:Field validation for 'Scopes[1]' failed on the 'oneof' tag"},
+		{Field: "Count", Message: "Key: 'testValidatedDto.Count' Error:Field validation for 'Count' failed on the 'gt' tag"},
+	}, validationError.Fields(), "Field errors do not match expected")
+	assert.Contains(t, err.Error(), "Name is required", "Error message should contain custom field message")
+
+	err = Validate[testValidatedDto](testValidatedDto{Name: "name", Count: 1}, validate)
+	assert.NoError(t, err, "Valid dto should pass validation")
+}
@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS user_totp (
+    user_id BIGINT NOT NULL,
+    secret VARCHAR(64) NOT NULL,
+    enabled BOOL NOT NULL DEFAULT FALSE,
+    last_used_step BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    CONSTRAINT pk_user_totp PRIMARY KEY(user_id),
+    CONSTRAINT user_totp_to_users_fk
+    FOREIGN KEY(user_id) REFERENCES users(id)
+);
+
+CREATE TABLE IF NOT EXISTS user_recovery_codes (
+    user_id BIGINT NOT NULL,
+    code_hash VARCHAR(64) NOT NULL,
+    used_at TIMESTAMP WITH TIME ZONE,
+    CONSTRAINT pk_user_recovery_codes PRIMARY KEY(user_id, code_hash),
+    CONSTRAINT user_recovery_codes_to_users_fk
+    FOREIGN KEY(user_id) REFERENCES users(id)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE user_recovery_codes;
+DROP TABLE user_totp;
+-- +goose StatementEnd
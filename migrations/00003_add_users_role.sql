@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TYPE user_role AS ENUM ('USER', 'ADMIN');
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS role USER_ROLE NOT NULL DEFAULT 'USER';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE users DROP COLUMN role;
+DROP TYPE user_role;
+-- +goose StatementEnd
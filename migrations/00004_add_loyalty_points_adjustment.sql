@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TYPE adjustment_reason_code AS ENUM ('GOODWILL', 'COMPENSATION', 'FRAUD_CORRECTION', 'DATA_CORRECTION');
+
+CREATE TABLE IF NOT EXISTS loyalty_points_adjustment (
+    id BIGSERIAL,
+    user_id BIGINT NOT NULL,
+    admin_id BIGINT NOT NULL,
+    amount DOUBLE PRECISION NOT NULL,
+    reason_code ADJUSTMENT_REASON_CODE NOT NULL,
+    note TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    CONSTRAINT pk_loyalty_points_adjustment PRIMARY KEY(id),
+    CONSTRAINT loyalty_points_adjustment_to_users_fk
+    FOREIGN KEY(user_id) REFERENCES users(id),
+    CONSTRAINT loyalty_points_adjustment_admin_to_users_fk
+    FOREIGN KEY(admin_id) REFERENCES users(id),
+    CONSTRAINT loyalty_points_adjustment_amount_non_zero CHECK(amount <> 0)
+);
+
+CREATE INDEX IF NOT EXISTS loyalty_points_adjustment_user_id_idx ON loyalty_points_adjustment(user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE loyalty_points_adjustment;
+DROP TYPE adjustment_reason_code;
+-- +goose StatementEnd
@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS user_api_keys (
+    id BIGSERIAL,
+    user_id BIGINT NOT NULL,
+    name VARCHAR(64) NOT NULL,
+    prefix VARCHAR(16) NOT NULL,
+    key_hash VARCHAR(64) NOT NULL,
+    scopes TEXT[] NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    revoked_at TIMESTAMP WITH TIME ZONE,
+    CONSTRAINT pk_user_api_keys PRIMARY KEY(id),
+    CONSTRAINT user_api_keys_key_hash_unique UNIQUE(key_hash),
+    CONSTRAINT user_api_keys_to_users_fk
+    FOREIGN KEY(user_id) REFERENCES users(id)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS user_api_keys_user_id_name_active_idx
+    ON user_api_keys(user_id, name) WHERE revoked_at IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE user_api_keys;
+-- +goose StatementEnd
@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS loyalty_points_accrual_user_id_uploaded_at_idx
+    ON loyalty_points_accrual(user_id, uploaded_at, order_number);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX loyalty_points_accrual_user_id_uploaded_at_idx;
+-- +goose StatementEnd
@@ -0,0 +1,52 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS loyalty_points_accrual_events (
+    id BIGSERIAL,
+    user_id BIGINT NOT NULL,
+    order_number BIGINT NOT NULL,
+    status ACCRUAL_STATUS NOT NULL,
+    amount DOUBLE PRECISION NOT NULL DEFAULT 0,
+    processed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CONSTRAINT pk_loyalty_points_accrual_events PRIMARY KEY(id),
+    CONSTRAINT loyalty_points_accrual_events_to_users_fk
+    FOREIGN KEY(user_id) REFERENCES users(id)
+);
+
+CREATE INDEX IF NOT EXISTS loyalty_points_accrual_events_user_id_id_idx
+    ON loyalty_points_accrual_events(user_id, id);
+
+CREATE OR REPLACE FUNCTION loyalty_points_accrual_status_changed() RETURNS TRIGGER AS $$
+DECLARE
+    event_id BIGINT;
+BEGIN
+    INSERT INTO loyalty_points_accrual_events (user_id, order_number, status, amount, processed_at)
+    VALUES (NEW.user_id, NEW.order_number, NEW.status, NEW.amount, NEW.processed_at)
+    RETURNING id INTO event_id;
+
+    PERFORM pg_notify('loyalty_points_accrual_events', json_build_object(
+        'id', event_id,
+        'user_id', NEW.user_id,
+        'order_number', NEW.order_number,
+        'status', NEW.status,
+        'amount', NEW.amount,
+        'processed_at', NEW.processed_at
+    )::TEXT);
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER loyalty_points_accrual_status_changed_trigger
+    AFTER UPDATE ON loyalty_points_accrual
+    FOR EACH ROW
+    WHEN (OLD.status IS DISTINCT FROM NEW.status OR OLD.amount IS DISTINCT FROM NEW.amount)
+    EXECUTE FUNCTION loyalty_points_accrual_status_changed();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER loyalty_points_accrual_status_changed_trigger ON loyalty_points_accrual;
+DROP FUNCTION loyalty_points_accrual_status_changed();
+DROP TABLE loyalty_points_accrual_events;
+-- +goose StatementEnd
@@ -0,0 +1,39 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS audit_events (
+    id BIGSERIAL,
+    actor_id BIGINT,
+    actor VARCHAR(255) NOT NULL,
+    action VARCHAR(255) NOT NULL,
+    target VARCHAR(255) NOT NULL,
+    ip VARCHAR(64) NOT NULL,
+    user_agent VARCHAR(512) NOT NULL,
+    outcome VARCHAR(16) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    prev_hash CHAR(64) NOT NULL,
+    hash CHAR(64) NOT NULL,
+    CONSTRAINT pk_audit_events PRIMARY KEY(id),
+    CONSTRAINT audit_events_hash_unique UNIQUE(hash)
+);
+
+CREATE INDEX IF NOT EXISTS audit_events_created_at_id_idx
+    ON audit_events(created_at, id);
+
+CREATE OR REPLACE FUNCTION audit_events_immutable() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_events_immutable_trigger
+    BEFORE UPDATE OR DELETE ON audit_events
+    FOR EACH ROW
+    EXECUTE FUNCTION audit_events_immutable();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER audit_events_immutable_trigger ON audit_events;
+DROP FUNCTION audit_events_immutable();
+DROP TABLE audit_events;
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE user_totp ADD COLUMN IF NOT EXISTS failed_attempts INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE user_totp ADD COLUMN IF NOT EXISTS last_failed_attempt_at TIMESTAMP WITH TIME ZONE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE user_totp DROP COLUMN last_failed_attempt_at;
+ALTER TABLE user_totp DROP COLUMN failed_attempts;
+-- +goose StatementEnd
@@ -1,33 +1,106 @@
 package migrations
 
 import (
+	"context"
+	"database/sql"
 	"embed"
 	"fmt"
+	"io/fs"
+	"path"
 
 	"github.com/pressly/goose/v3"
 )
 
-//go:embed "*.sql"
+// Migrations holds the Postgres migrations in the root and the SQLite migrations in the sqlite directory.
+//
+//go:embed "*.sql" "sqlite/*.sql"
 var Migrations embed.FS
 
+const (
+	DialectPostgres = "postgres"
+	DialectSQLite   = "sqlite3"
+)
+
+// lockID identifies the Postgres advisory lock that serializes migrations of replicas started at the same time.
+const lockID int64 = 7_305_218_404_117_561_031
+
 func Migrate(databaseURL string, dialect string, driverName string) error {
+	return Run(databaseURL, dialect, driverName, "up")
+}
+
+// Run executes the goose command, e.g. up, down, status or redo, against the embedded migrations.
+func Run(databaseURL string, dialect string, driverName string, command string) (err error) {
 	goose.SetBaseFS(Migrations)
 	if err := goose.SetDialect(dialect); err != nil {
 		return fmt.Errorf("goose failed to set %s dialect: %w", dialect, err)
 	}
 
-	db, err := goose.OpenDBWithDriver(driverName, databaseURL)
+	db, err := sql.Open(driverName, databaseURL)
 	if err != nil {
 		return fmt.Errorf("goose failed to open database connection: %w", err)
 	}
+	defer func() {
+		if closeErr := db.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("goose failed to close database connection: %w", closeErr)
+		}
+	}()
 
-	if err := goose.Up(db, "."); err != nil {
-		return fmt.Errorf("goose failed to up migrations: %w", err)
+	ctx := context.Background()
+	if dialect == DialectPostgres && command != "status" {
+		unlock, err := lock(ctx, db)
+		if err != nil {
+			return err
+		}
+		defer unlock()
 	}
 
-	if err := db.Close(); err != nil {
-		return fmt.Errorf("goose failed to close database connection: %w", err)
+	if err := goose.RunContext(ctx, command, db, dir(dialect)); err != nil {
+		return fmt.Errorf("goose failed to %s migrations: %w", command, err)
 	}
 
 	return nil
 }
+
+// lock takes the migrations advisory lock, waiting for other replicas to finish their migrations. The lock is held
+// by a dedicated connection and released with the returned function.
+func lock(ctx context.Context, db *sql.DB) (func(), error) {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get connection for migrations lock: %w", err)
+	}
+
+	if _, err = conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", lockID); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to take migrations lock: %w", err)
+	}
+
+	return func() {
+		_, _ = conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", lockID)
+		conn.Close()
+	}, nil
+}
+
+// LatestVersion returns the version of the newest embedded migration of the dialect.
+func LatestVersion(dialect string) (int64, error) {
+	files, err := fs.Glob(Migrations, path.Join(dir(dialect), "*.sql"))
+	if err != nil {
+		return 0, fmt.Errorf("failed to list migrations: %w", err)
+	}
+
+	var latest int64
+	for _, file := range files {
+		version, err := goose.NumericComponent(file)
+		if err != nil {
+			return 0, fmt.Errorf("failed to parse migration %s version: %w", file, err)
+		}
+		latest = max(latest, version)
+	}
+	return latest, nil
+}
+
+func dir(dialect string) string {
+	if dialect == DialectSQLite {
+		return "sqlite"
+	}
+	return "."
+}
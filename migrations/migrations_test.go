@@ -0,0 +1,117 @@
+package migrations
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/pressly/goose/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+// testDatabaseURLEnv names the variable with the URL of a disposable Postgres database for the tests that apply
+// migrations, the tests are skipped when it is not set.
+const testDatabaseURLEnv = "TEST_DATABASE_URI"
+
+func TestMigrationsHaveDownSection(t *testing.T) {
+	files, err := fs.Glob(Migrations, "*.sql")
+	require.NoError(t, err, "Error listing migrations")
+	require.NotEmpty(t, files, "Migrations should be embedded")
+	sqliteFiles, err := fs.Glob(Migrations, "sqlite/*.sql")
+	require.NoError(t, err, "Error listing migrations")
+	require.NotEmpty(t, sqliteFiles, "SQLite migrations should be embedded")
+	files = append(files, sqliteFiles...)
+
+	for _, file := range files {
+		t.Run(file, func(t *testing.T) {
+			content, err := fs.ReadFile(Migrations, file)
+			require.NoError(t, err, "Error reading migration")
+
+			_, down, found := strings.Cut(string(content), "-- +goose Down")
+			require.True(t, found, "Migration should have down section")
+
+			statements := 0
+			for _, line := range strings.Split(down, "\n") {
+				line = strings.TrimSpace(line)
+				if line != "" && !strings.HasPrefix(line, "--") {
+					statements++
+				}
+			}
+			assert.Positive(t, statements, "Migration down section should not be empty")
+		})
+	}
+}
+
+func TestMigrationsDown(t *testing.T) {
+	databaseURL := testDatabaseURL(t)
+
+	latest, err := LatestVersion(DialectPostgres)
+	require.NoError(t, err, "Error getting latest migration version")
+
+	require.NoError(t, Migrate(databaseURL, "postgres", "pgx"), "Error applying migrations")
+	assert.Equal(t, latest, dbVersion(t, databaseURL), "Schema version does not match latest migration version")
+
+	for version := latest; version > 0; version-- {
+		require.NoError(t, Run(databaseURL, "postgres", "pgx", "down"), "Error reverting migration %d", version)
+		assert.Equal(t, version-1, dbVersion(t, databaseURL), "Schema version does not match expected version")
+	}
+
+	require.NoError(t, Migrate(databaseURL, "postgres", "pgx"), "Error applying migrations after reverting them")
+	assert.Equal(t, latest, dbVersion(t, databaseURL), "Schema version does not match latest migration version")
+
+	require.NoError(t, Run(databaseURL, "postgres", "pgx", "redo"), "Error redoing latest migration")
+	assert.Equal(t, latest, dbVersion(t, databaseURL), "Schema version does not match latest migration version")
+}
+
+func TestLock(t *testing.T) {
+	databaseURL := testDatabaseURL(t)
+	ctx := context.Background()
+
+	db, err := goose.OpenDBWithDriver("pgx", databaseURL)
+	require.NoError(t, err, "Error opening database")
+	defer db.Close()
+	other, err := goose.OpenDBWithDriver("pgx", databaseURL)
+	require.NoError(t, err, "Error opening database")
+	defer other.Close()
+	conn, err := other.Conn(ctx)
+	require.NoError(t, err, "Error getting connection")
+	defer conn.Close()
+
+	unlock, err := lock(ctx, db)
+	require.NoError(t, err, "Error taking migrations lock")
+
+	var locked bool
+	err = conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", lockID).Scan(&locked)
+	require.NoError(t, err, "Error checking migrations lock")
+	assert.False(t, locked, "Migrations lock should not be available while it is held")
+
+	unlock()
+	err = conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", lockID).Scan(&locked)
+	require.NoError(t, err, "Error checking migrations lock")
+	assert.True(t, locked, "Migrations lock should be available after it is released")
+	_, err = conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", lockID)
+	require.NoError(t, err, "Error releasing migrations lock")
+}
+
+func testDatabaseURL(t *testing.T) string {
+	databaseURL := os.Getenv(testDatabaseURLEnv)
+	if databaseURL == "" {
+		t.Skipf("%s is not set", testDatabaseURLEnv)
+	}
+	return databaseURL
+}
+
+func dbVersion(t *testing.T, databaseURL string) int64 {
+	db, err := goose.OpenDBWithDriver("pgx", databaseURL)
+	require.NoError(t, err, "Error opening database")
+	defer db.Close()
+
+	version, err := goose.GetDBVersion(db)
+	require.NoError(t, err, "Error getting schema version")
+	return version
+}
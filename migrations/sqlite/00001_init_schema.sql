@@ -0,0 +1,172 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS users (
+    id INTEGER NOT NULL,
+    login VARCHAR(30) NOT NULL,
+    password VARCHAR(256) NOT NULL,
+    role TEXT NOT NULL DEFAULT 'USER' CHECK(role IN ('USER', 'ADMIN')),
+    disabled_at INTEGER,
+    CONSTRAINT pk_users PRIMARY KEY(id),
+    CONSTRAINT users_login_unique UNIQUE(login)
+);
+
+CREATE TABLE IF NOT EXISTS loyalty_points_accrual (
+    user_id INTEGER NOT NULL,
+    order_number INTEGER NOT NULL,
+    uploaded_at INTEGER NOT NULL,
+    processed_at INTEGER,
+    status TEXT NOT NULL CHECK(status IN ('NEW', 'PROCESSING', 'INVALID', 'PROCESSED')),
+    amount REAL NOT NULL DEFAULT 0,
+    processing_lock BOOLEAN NOT NULL DEFAULT FALSE,
+    CONSTRAINT pk_loyalty_points_accrual PRIMARY KEY(user_id, order_number),
+    CONSTRAINT loyalty_points_accrual_to_users_fk
+    FOREIGN KEY(user_id) REFERENCES users(id),
+    CONSTRAINT loyalty_points_accrual_order_number_unique UNIQUE(order_number)
+);
+
+CREATE INDEX IF NOT EXISTS loyalty_points_accrual_user_id_uploaded_at_idx
+    ON loyalty_points_accrual(user_id, uploaded_at, order_number);
+
+CREATE TABLE IF NOT EXISTS loyalty_points_withdrawn (
+    user_id INTEGER NOT NULL,
+    order_number INTEGER NOT NULL,
+    processed_at INTEGER NOT NULL,
+    amount REAL NOT NULL DEFAULT 0,
+    CONSTRAINT pk_loyalty_points_withdrawn PRIMARY KEY(user_id, order_number),
+    CONSTRAINT loyalty_points_withdrawn_to_users_fk
+    FOREIGN KEY(user_id) REFERENCES users(id),
+    CONSTRAINT loyalty_points_withdrawn_order_number_unique UNIQUE(order_number)
+);
+
+CREATE INDEX IF NOT EXISTS loyalty_points_withdrawn_user_id_processed_at_idx
+    ON loyalty_points_withdrawn(user_id, processed_at, order_number);
+
+CREATE TABLE IF NOT EXISTS user_totp (
+    user_id INTEGER NOT NULL,
+    secret VARCHAR(64) NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    last_used_step INTEGER NOT NULL DEFAULT 0,
+    created_at INTEGER NOT NULL,
+    CONSTRAINT pk_user_totp PRIMARY KEY(user_id),
+    CONSTRAINT user_totp_to_users_fk
+    FOREIGN KEY(user_id) REFERENCES users(id)
+);
+
+CREATE TABLE IF NOT EXISTS user_recovery_codes (
+    user_id INTEGER NOT NULL,
+    code_hash VARCHAR(64) NOT NULL,
+    used_at INTEGER,
+    CONSTRAINT pk_user_recovery_codes PRIMARY KEY(user_id, code_hash),
+    CONSTRAINT user_recovery_codes_to_users_fk
+    FOREIGN KEY(user_id) REFERENCES users(id)
+);
+
+CREATE TABLE IF NOT EXISTS loyalty_points_adjustment (
+    id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    admin_id INTEGER NOT NULL,
+    amount REAL NOT NULL,
+    reason_code TEXT NOT NULL
+        CHECK(reason_code IN ('GOODWILL', 'COMPENSATION', 'FRAUD_CORRECTION', 'DATA_CORRECTION')),
+    note TEXT NOT NULL,
+    created_at INTEGER NOT NULL,
+    CONSTRAINT pk_loyalty_points_adjustment PRIMARY KEY(id),
+    CONSTRAINT loyalty_points_adjustment_to_users_fk
+    FOREIGN KEY(user_id) REFERENCES users(id),
+    CONSTRAINT loyalty_points_adjustment_admin_to_users_fk
+    FOREIGN KEY(admin_id) REFERENCES users(id),
+    CONSTRAINT loyalty_points_adjustment_amount_non_zero CHECK(amount <> 0)
+);
+
+CREATE INDEX IF NOT EXISTS loyalty_points_adjustment_user_id_idx ON loyalty_points_adjustment(user_id);
+
+CREATE TABLE IF NOT EXISTS user_api_keys (
+    id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    name VARCHAR(64) NOT NULL,
+    prefix VARCHAR(16) NOT NULL,
+    key_hash VARCHAR(64) NOT NULL,
+    scopes TEXT NOT NULL,
+    created_at INTEGER NOT NULL,
+    revoked_at INTEGER,
+    CONSTRAINT pk_user_api_keys PRIMARY KEY(id),
+    CONSTRAINT user_api_keys_key_hash_unique UNIQUE(key_hash),
+    CONSTRAINT user_api_keys_to_users_fk
+    FOREIGN KEY(user_id) REFERENCES users(id)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS user_api_keys_user_id_name_active_idx
+    ON user_api_keys(user_id, name) WHERE revoked_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS loyalty_points_accrual_events (
+    id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    order_number INTEGER NOT NULL,
+    status TEXT NOT NULL,
+    amount REAL NOT NULL DEFAULT 0,
+    processed_at INTEGER,
+    created_at INTEGER NOT NULL DEFAULT (CAST((julianday('now') - 2440587.5) * 86400000000 AS INTEGER)),
+    CONSTRAINT pk_loyalty_points_accrual_events PRIMARY KEY(id),
+    CONSTRAINT loyalty_points_accrual_events_to_users_fk
+    FOREIGN KEY(user_id) REFERENCES users(id)
+);
+
+CREATE INDEX IF NOT EXISTS loyalty_points_accrual_events_user_id_id_idx
+    ON loyalty_points_accrual_events(user_id, id);
+
+CREATE TRIGGER IF NOT EXISTS loyalty_points_accrual_status_changed_trigger
+    AFTER UPDATE ON loyalty_points_accrual
+    FOR EACH ROW
+    WHEN OLD.status IS NOT NEW.status OR OLD.amount IS NOT NEW.amount
+BEGIN
+    INSERT INTO loyalty_points_accrual_events (user_id, order_number, status, amount, processed_at)
+    VALUES (NEW.user_id, NEW.order_number, NEW.status, NEW.amount, NEW.processed_at);
+END;
+
+CREATE TABLE IF NOT EXISTS audit_events (
+    id INTEGER NOT NULL,
+    actor_id INTEGER,
+    actor VARCHAR(255) NOT NULL,
+    action VARCHAR(255) NOT NULL,
+    target VARCHAR(255) NOT NULL,
+    ip VARCHAR(64) NOT NULL,
+    user_agent VARCHAR(512) NOT NULL,
+    outcome VARCHAR(16) NOT NULL,
+    created_at INTEGER NOT NULL,
+    prev_hash CHAR(64) NOT NULL,
+    hash CHAR(64) NOT NULL,
+    CONSTRAINT pk_audit_events PRIMARY KEY(id),
+    CONSTRAINT audit_events_hash_unique UNIQUE(hash)
+);
+
+CREATE INDEX IF NOT EXISTS audit_events_created_at_id_idx
+    ON audit_events(created_at, id);
+
+CREATE TRIGGER IF NOT EXISTS audit_events_immutable_update_trigger
+    BEFORE UPDATE ON audit_events
+BEGIN
+    SELECT RAISE(ABORT, 'audit_events is append-only');
+END;
+
+CREATE TRIGGER IF NOT EXISTS audit_events_immutable_delete_trigger
+    BEFORE DELETE ON audit_events
+BEGIN
+    SELECT RAISE(ABORT, 'audit_events is append-only');
+END;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER audit_events_immutable_delete_trigger;
+DROP TRIGGER audit_events_immutable_update_trigger;
+DROP TABLE audit_events;
+DROP TRIGGER loyalty_points_accrual_status_changed_trigger;
+DROP TABLE loyalty_points_accrual_events;
+DROP TABLE user_api_keys;
+DROP TABLE loyalty_points_adjustment;
+DROP TABLE user_recovery_codes;
+DROP TABLE user_totp;
+DROP TABLE loyalty_points_withdrawn;
+DROP TABLE loyalty_points_accrual;
+DROP TABLE users;
+-- +goose StatementEnd
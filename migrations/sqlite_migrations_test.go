@@ -0,0 +1,47 @@
+//go:build cgo
+
+package migrations
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/pressly/goose/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestSQLiteMigrationsDown(t *testing.T) {
+	dsn := "file:" + filepath.Join(t.TempDir(), "gophermart.db") + "?_foreign_keys=1"
+
+	latest, err := LatestVersion(DialectSQLite)
+	require.NoError(t, err, "Error getting latest migration version")
+	require.Positive(t, latest, "SQLite migrations should be embedded")
+
+	require.NoError(t, Migrate(dsn, DialectSQLite, "sqlite3"), "Error applying migrations")
+	assert.Equal(t, latest, sqliteDBVersion(t, dsn), "Schema version does not match latest migration version")
+
+	for version := latest; version > 0; version-- {
+		require.NoError(t, Run(dsn, DialectSQLite, "sqlite3", "down"), "Error reverting migration %d", version)
+		assert.Equal(t, version-1, sqliteDBVersion(t, dsn), "Schema version does not match expected version")
+	}
+
+	require.NoError(t, Migrate(dsn, DialectSQLite, "sqlite3"), "Error applying migrations after reverting them")
+	assert.Equal(t, latest, sqliteDBVersion(t, dsn), "Schema version does not match latest migration version")
+
+	require.NoError(t, Run(dsn, DialectSQLite, "sqlite3", "redo"), "Error redoing latest migration")
+	assert.Equal(t, latest, sqliteDBVersion(t, dsn), "Schema version does not match latest migration version")
+}
+
+func sqliteDBVersion(t *testing.T, dsn string) int64 {
+	db, err := sql.Open("sqlite3", dsn)
+	require.NoError(t, err, "Error opening database")
+	defer db.Close()
+
+	version, err := goose.GetDBVersion(db)
+	require.NoError(t, err, "Error getting schema version")
+	return version
+}